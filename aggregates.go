@@ -0,0 +1,59 @@
+package excel_stream
+
+import (
+	"strconv"
+)
+
+// ColumnAggregate sums up the cells that have been written to a column so far.
+type ColumnAggregate struct {
+	// Count is the number of cells that are not empty, including formula cells.
+	Count int
+	// Numbers is the number of cells written as numbers, in number columns or by TypeInference. Sum, Min and Max are
+	// their sum, smallest and largest values, and are 0 until there is a number. Dates are not counted as numbers.
+	Numbers int
+	Sum     float64
+	Min     float64
+	Max     float64
+	// Errors is the number of cells written as errors, like the #NUM! written by ErrorForNonFiniteNumbers.
+	Errors int
+}
+
+// Aggregates returns the aggregates of each column of the named sheet, for the rows written to it so far. The header
+// row, subtotal rows and the totals row are not included. Sheets that have not been started have empty aggregates.
+func (sf *StreamFile) Aggregates(sheetName string) ([]ColumnAggregate, error) {
+	if err := sf.acquire(); err != nil {
+		return nil, err
+	}
+	defer sf.release()
+	sheetArrayIndex := sf.sheetIndexByName(sheetName)
+	if sheetArrayIndex == -1 {
+		return nil, UnknownSheetError
+	}
+	return append([]ColumnAggregate(nil), sf.columns[sheetArrayIndex].aggregates...), nil
+}
+
+// add counts a cell that was written with the value.
+func (a *ColumnAggregate) add(kind cellKind, value string) {
+	if value == "" && kind != formulaCell {
+		return
+	}
+	a.Count++
+	switch kind {
+	case numberCell:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			a.Errors++
+			return
+		}
+		if a.Numbers == 0 || number < a.Min {
+			a.Min = number
+		}
+		if a.Numbers == 0 || number > a.Max {
+			a.Max = number
+		}
+		a.Sum += number
+		a.Numbers++
+	case errorCell:
+		a.Errors++
+	}
+}
@@ -0,0 +1,66 @@
+package excel_stream
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestColumnAggregateAdd(t *testing.T) {
+	var aggregate ColumnAggregate
+	aggregate.add(numberCell, "2.5")
+	aggregate.add(numberCell, "")
+	aggregate.add(numberCell, "-4")
+	aggregate.add(numberCell, "10")
+	aggregate.add(errorCell, "#NUM!")
+	aggregate.add(formulaCell, "")
+	expected := ColumnAggregate{Count: 5, Numbers: 3, Sum: 8.5, Min: -4, Max: 10, Errors: 1}
+	if aggregate != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, aggregate)
+	}
+
+	var text ColumnAggregate
+	text.add(textCell, "Taco")
+	text.add(textCell, "")
+	if expected := (ColumnAggregate{Count: 1}); text != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, text)
+	}
+
+	var positive ColumnAggregate
+	positive.add(numberCell, "3")
+	if positive.Min != 3 || positive.Max != 3 {
+		t.Fatalf("Expected the first number to be the min and max, got %+v", positive)
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheetWithColumns("Sheet1", []ColumnDef{
+		{Name: "Name"},
+		{Name: "Amount", Type: NumberColumn},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco", "1.5"}, {"Burrito", "3"}, {"", ""}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	aggregates, err := excelStream.Aggregates("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ColumnAggregate{{Count: 2}, {Count: 2, Numbers: 2, Sum: 4.5, Min: 1.5, Max: 3}}
+	if len(aggregates) != len(expected) || aggregates[0] != expected[0] || aggregates[1] != expected[1] {
+		t.Fatalf("Expected %+v, got %+v", expected, aggregates)
+	}
+	if _, err := excelStream.Aggregates("Missing"); err != UnknownSheetError {
+		t.Fatalf("Expected %v, got %v", UnknownSheetError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
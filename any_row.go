@@ -0,0 +1,76 @@
+package excel_stream
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var UnsupportedRowValueError = errors.New("Row value must be a string, number, boolean, time.Time, Cell, nil or fmt.Stringer")
+
+// WriteRowAny will write a row to the current sheet from values of any of the types a row usually comes in, giving
+// each cell the type of its value. Strings and fmt.Stringers are written like the cells of WriteRow, numbers as
+// numbers, booleans as booleans and time.Times as dates like DateValue. Cells are written as they are, and nil values
+// are NULL, which are written as the NullValue of their column. Other values return a CellError wrapping
+// UnsupportedRowValueError. It works like WriteRowCells in every other way.
+func (sf *StreamFile) WriteRowAny(values []interface{}) error {
+	return sf.writeValueRow(values, anyValueCell)
+}
+
+// writeValueRow writes a row of values, using valueCell to make the cell of each value that is not nil. Nil values are
+// NULL.
+func (sf *StreamFile) writeValueRow(values []interface{}, valueCell func(interface{}) (Cell, error)) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	return sf.writeValues(values, valueCell)
+}
+
+// writeValues is writeValueRow for callers that have already acquired the StreamFile.
+func (sf *StreamFile) writeValues(values []interface{}, valueCell func(interface{}) (Cell, error)) error {
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	if sf.currentSheet == nil {
+		return NoCurrentSheetError
+	}
+	cells := make([]string, len(values))
+	typed := make([]Cell, len(values))
+	nulls := make([]bool, len(values))
+	for colIndex, value := range values {
+		if value == nil {
+			nulls[colIndex] = true
+			continue
+		}
+		cell, err := valueCell(value)
+		if err != nil {
+			sheetName := sf.xlsxFile.Sheets[sf.currentSheet.index-1].Name
+			return &CellError{Sheet: sheetName, Row: sf.currentSheet.rowCount + 1, Column: colIndex, Err: err}
+		}
+		cells[colIndex] = cell.Value
+		typed[colIndex] = cell
+	}
+	return sf.writeRow(cells, RowOptions{Nulls: nulls, cells: typed})
+}
+
+// anyValueCell returns the cell for a value of a row written with WriteRowAny.
+func anyValueCell(value interface{}) (Cell, error) {
+	switch v := value.(type) {
+	case Cell:
+		return v, nil
+	case time.Time:
+		return DateValue(v), nil
+	case bool:
+		return BoolValue(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		// The text of the number keeps all of its digits, which a float64 would not for large integers.
+		text, _ := mapValueString(v)
+		return Cell{Value: text, Type: NumberCell}, nil
+	}
+	text, err := mapValueString(value)
+	if err != nil {
+		return Cell{}, UnsupportedRowValueError
+	}
+	return Cell{Value: text}, nil
+}
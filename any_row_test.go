@@ -0,0 +1,77 @@
+package excel_stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnyValueCell(t *testing.T) {
+	date := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		testName      string
+		value         interface{}
+		expected      Cell
+		expectedError error
+	}{
+		{testName: "String", value: "Taco", expected: Cell{Value: "Taco"}},
+		{testName: "Int", value: 12, expected: Cell{Value: "12", Type: NumberCell}},
+		{testName: "Large int", value: int64(9007199254740993), expected: Cell{Value: "9007199254740993", Type: NumberCell}},
+		{testName: "Float", value: 1.5, expected: Cell{Value: "1.5", Type: NumberCell}},
+		{testName: "JSON number", value: json.Number("2.25"), expected: Cell{Value: "2.25", Type: NumberCell}},
+		{testName: "Bool", value: true, expected: BoolValue(true)},
+		{testName: "Date", value: date, expected: DateValue(date)},
+		{testName: "Cell", value: PercentValue(0.5), expected: PercentValue(0.5)},
+		{testName: "Stringer", value: time.Second, expected: Cell{Value: "1s"}},
+		{testName: "Array", value: []interface{}{1, 2}, expectedError: UnsupportedRowValueError},
+	}
+	for _, testCase := range testCases {
+		actual, err := anyValueCell(testCase.value)
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if actual != testCase.expected {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestWriteRowAny(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	columns := []ColumnDef{
+		{Name: "Name", Null: NullValue{Text: "N/A"}},
+		{Name: "Count"},
+		{Name: "Spicy"},
+		{Name: "Ordered"},
+	}
+	if err := file.AddSheetWithColumns("Sheet1", columns); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	if err := excelStream.WriteRowAny([]interface{}{nil, 3, true, date}); err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRowAny([]interface{}{"Taco", []int{1}, false, date})
+	if cellErr, ok := err.(*CellError); !ok || cellErr.Column != 1 || cellErr.Err != UnsupportedRowValueError {
+		t.Fatalf("Expected a CellError for column 1 wrapping %v, got %v", UnsupportedRowValueError, err)
+	}
+	if err := excelStream.WriteRowAny([]interface{}{"Taco", 3}); err != WrongNumberOfRowsError {
+		t.Fatalf("Expected %v, got %v", WrongNumberOfRowsError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	expected := `<row r="2"><c r="A2" t="inlineStr"><is><t>N/A</t></is></c><c r="B2"><v>3</v></c>` +
+		`<c r="C2" t="b"><v>1</v></c><c r="D2"`
+	if !strings.Contains(sheetXML, expected) {
+		t.Fatalf("Expected %s in %s", expected, sheetXML)
+	}
+}
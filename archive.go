@@ -0,0 +1,46 @@
+package excel_stream
+
+import (
+	"archive/zip"
+	"io"
+)
+
+// ArchiveWriter packages the parts of an XLSX file. *zip.Writer implements it, and is what NewStreamFileBuilder uses.
+// Other implementations, such as an encrypted zip or a tar file for tests, can be used with
+// NewStreamFileBuilderForArchive.
+type ArchiveWriter interface {
+	// CreateHeader starts a new part. The part's contents are written to the returned writer until the next part is
+	// started or the archive is closed. Sheets are created with the Store method, so that their rows can be streamed.
+	CreateHeader(header *zip.FileHeader) (io.Writer, error)
+	// Flush sends everything written so far on to the archive's output.
+	Flush() error
+	// Close finishes the archive. It does not need to close the archive's output.
+	Close() error
+}
+
+// NewStreamFileBuilderForArchive creates a StreamFileBuilder that writes the parts of the file to the archive instead
+// of to a zip file. Since the archive's output can not be seen, the byte limit of SetQuota and SetRateLimiter count
+// the bytes written to the parts, before the archive compresses them.
+func NewStreamFileBuilderForArchive(archive ArchiveWriter) *StreamFileBuilder {
+	sb := NewStreamFileBuilder(io.Discard)
+	sb.zipWriter = archive
+	sb.countParts = true
+	return sb
+}
+
+// partCounter adds the bytes written to a part to the count of the output, for archives whose output can not be
+// counted. Like the countingWriter, it records the first error the part fails with, and fails every write after it.
+type partCounter struct {
+	writer io.Writer
+	output *countingWriter
+}
+
+func (w *partCounter) Write(p []byte) (int, error) {
+	if w.output.err != nil {
+		return 0, w.output.err
+	}
+	n, err := w.writer.Write(p)
+	w.output.count += int64(n)
+	w.output.err = err
+	return n, err
+}
@@ -0,0 +1,84 @@
+package excel_stream
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+)
+
+// memoryArchive keeps each part in memory, in the order they were created.
+type memoryArchive struct {
+	names   []string
+	parts   map[string]*bytes.Buffer
+	flushes int
+	closed  bool
+}
+
+func (a *memoryArchive) CreateHeader(header *zip.FileHeader) (io.Writer, error) {
+	if a.parts == nil {
+		a.parts = map[string]*bytes.Buffer{}
+	}
+	part := &bytes.Buffer{}
+	a.names = append(a.names, header.Name)
+	a.parts[header.Name] = part
+	return part, nil
+}
+
+func (a *memoryArchive) Flush() error {
+	a.flushes++
+	return nil
+}
+
+func (a *memoryArchive) Close() error {
+	a.closed = true
+	return nil
+}
+
+func TestPartCounter(t *testing.T) {
+	output := &countingWriter{writer: io.Discard}
+	var part bytes.Buffer
+	writer := &partCounter{writer: &part, output: output}
+	writer.Write([]byte("<row/>"))
+	if output.count != 6 || part.String() != "<row/>" {
+		t.Fatalf("Expected 6 bytes to be counted, got %d and %q", output.count, part.String())
+	}
+	writer = &partCounter{writer: &failingWriter{}, output: output}
+	if _, err := writer.Write([]byte("<row/>")); err != failedWriteError || output.err != failedWriteError {
+		t.Fatalf("Expected the output to fail with %v, got %v, %v", failedWriteError, err, output.err)
+	}
+	writer = &partCounter{writer: &part, output: output}
+	if _, err := writer.Write([]byte("<row/>")); err != failedWriteError || part.String() != "<row/>" {
+		t.Fatalf("Expected writes after the failure to fail with %v, got %v and %q", failedWriteError, err,
+			part.String())
+	}
+}
+
+func TestNewStreamFileBuilderForArchive(t *testing.T) {
+	archive := &memoryArchive{}
+	file := NewStreamFileBuilderForArchive(archive)
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !archive.closed || archive.flushes == 0 {
+		t.Fatalf("Expected the archive to be flushed and closed, got %d flushes and closed %v", archive.flushes,
+			archive.closed)
+	}
+	sheet, ok := archive.parts["xl/worksheets/sheet1.xml"]
+	if !ok || !bytes.Contains(sheet.Bytes(), []byte(`<t>Taco</t>`)) {
+		t.Fatalf("Expected the row in the sheet part, got parts %v", archive.names)
+	}
+	if excelStream.output.count == 0 {
+		t.Fatal("Expected the bytes written to the parts to be counted")
+	}
+}
@@ -0,0 +1,83 @@
+package excel_stream
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// defaultFileMode is the permission given to new XLSX files written to a path.
+const defaultFileMode = 0644
+
+// atomicFile is an XLSX file being written to a path. The data goes to a temporary file in the same directory, which
+// only replaces the file at the path once it is complete. This way a crash or failed export never leaves a truncated
+// XLSX file that looks like a finished one.
+type atomicFile struct {
+	file *os.File
+	path string
+}
+
+// createAtomicFile creates the temporary file that will become the file at the path.
+func createAtomicFile(path string) (*atomicFile, error) {
+	// The temporary file must be in the same directory, since renaming is only atomic within a file system.
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{file: file, path: path}, nil
+}
+
+func (f *atomicFile) Write(p []byte) (int, error) {
+	return f.file.Write(p)
+}
+
+// commit makes sure all of the data has reached the disk, and then moves the temporary file to the path. If anything
+// fails before the move, the temporary file is removed and the file at the path is left as it was.
+func (f *atomicFile) commit() error {
+	if err := f.file.Sync(); err != nil {
+		f.abort()
+		return err
+	}
+	// Temporary files are only readable by their owner, so give the file the same permissions as the file it is
+	// replacing, or the usual permissions for a new file.
+	mode := os.FileMode(defaultFileMode)
+	if info, err := os.Stat(f.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := f.file.Chmod(mode); err != nil {
+		f.abort()
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		os.Remove(f.file.Name())
+		return err
+	}
+	if err := os.Rename(f.file.Name(), f.path); err != nil {
+		os.Remove(f.file.Name())
+		return err
+	}
+	// The rename is only on the disk once the directory is synced, so a crash before then could still leave the
+	// previous file at the path.
+	return syncDirectory(filepath.Dir(f.path))
+}
+
+// syncDirectory makes sure the changes to the entries of the directory have reached the disk.
+func syncDirectory(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	syncErr := dir.Sync()
+	if err := dir.Close(); err != nil && syncErr == nil {
+		return err
+	}
+	return syncErr
+}
+
+// abort closes and removes the temporary file, leaving the file at the path as it was.
+func (f *atomicFile) abort() error {
+	closeErr := f.file.Close()
+	if err := os.Remove(f.file.Name()); err != nil {
+		return err
+	}
+	return closeErr
+}
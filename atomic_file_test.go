@@ -0,0 +1,141 @@
+package excel_stream
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathOutputIsReplacedOnClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "excel_stream_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Workbook.xlsx")
+	if err := ioutil.WriteFile(path, []byte("previous export"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := NewStreamFileBuilderForPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123"}); err != nil {
+		t.Fatal(err)
+	}
+	// Until the file is closed, the previous file should be untouched.
+	if contents, err := ioutil.ReadFile(path); err != nil || string(contents) != "previous export" {
+		t.Fatalf("Expected the previous file to be untouched before Close, got %q %v", contents, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, workbookData := readXLSXFile(t, path, nil, 0, true)
+	if len(workbookData) != 1 || len(workbookData[0]) != 2 {
+		t.Fatalf("Expected one sheet with two rows, got %v", workbookData)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected the replaced file to keep its permissions, got %v", info.Mode().Perm())
+	}
+	assertDirectoryContains(t, dir, "Workbook.xlsx")
+}
+
+func TestPathOutputAbort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "excel_stream_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file, err := NewStreamFileBuilderForPath(filepath.Join(dir, "Workbook.xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != StreamFileClosedError {
+		t.Fatalf("Expected StreamFileClosedError, got %v", err)
+	}
+	assertDirectoryContains(t, dir)
+
+	file, err = NewStreamFileBuilderForPath(filepath.Join(dir, "Workbook.xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	assertDirectoryContains(t, dir)
+}
+
+// assertDirectoryContains fails the test unless the directory contains exactly the named files.
+func assertDirectoryContains(t *testing.T, dir string, names ...string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actualNames []string
+	for _, file := range files {
+		actualNames = append(actualNames, file.Name())
+	}
+	if len(actualNames) != len(names) {
+		t.Fatalf("Expected directory to contain %v, got %v", names, actualNames)
+	}
+	for i := range names {
+		if actualNames[i] != names[i] {
+			t.Fatalf("Expected directory to contain %v, got %v", names, actualNames)
+		}
+	}
+}
+
+func TestAtomicFileCommit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "excel_stream_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Workbook.xlsx")
+
+	file, err := createAtomicFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte("export")); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if contents, err := ioutil.ReadFile(path); err != nil || string(contents) != "export" {
+		t.Fatalf("Expected the committed file, got %q %v", contents, err)
+	}
+	assertDirectoryContains(t, dir, "Workbook.xlsx")
+
+	if err := syncDirectory(filepath.Join(dir, "Missing")); !os.IsNotExist(err) {
+		t.Fatalf("Expected a missing directory to fail, got %v", err)
+	}
+}
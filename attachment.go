@@ -0,0 +1,224 @@
+package excel_stream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+const (
+	embeddingsPathPrefix      = "xl/embeddings/oleObject"
+	oleObjectContentType      = "application/vnd.openxmlformats-officedocument.oleObject"
+	oleObjectRelationshipType = relationshipsNamespace + "/oleObject"
+	markupCompatibilityNS     = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+	// ole10NativeStream is the stream of an OLE package object that holds the embedded file.
+	ole10NativeStream = "\x01Ole10Native"
+	// maxOLEStreamSize is the largest stream that can be stored in a version 3 compound file.
+	maxOLEStreamSize = 1<<31 - 1
+	// defaultColumnPixels and defaultRowPixels are the size of a cell with the default column width and row height,
+	// which is what the corners of attachments are worked out with.
+	defaultColumnPixels = 64
+	defaultRowPixels    = 20
+)
+
+// packageClassID is the class ID of OLE package objects, {0003000C-0000-0000-C000-000000000046}, in the byte order it
+// is stored in.
+var packageClassID = [16]byte{0x0C, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}
+
+var (
+	InvalidAttachmentNameError = errors.New("Attachment file name must not be empty or contain a null character")
+	AttachmentTooLargeError    = errors.New("Attachment is too large to embed")
+)
+
+// Attachment describes a file embedded in a sheet, such as the source document behind a row of an audit export.
+type Attachment struct {
+	// FileName is the name the file is opened with, which is also its label. Its extension picks the program that opens
+	// it. Names are stored in the system's ANSI code page, so names that are not ASCII may be shown differently.
+	FileName string
+	// Icon is the picture drawn in the sheet for the attachment, in IconFormat. If it is nil, a plain page is drawn.
+	Icon       io.Reader
+	IconFormat ImageFormat
+}
+
+// attachmentObject is an attachment on a sheet. Its oleObject element is written with the end of the sheet, once the
+// ID of its VML shape is known.
+type attachmentObject struct {
+	// shapeIndex is the index of the attachment's shape in the sheet's VML drawing.
+	shapeIndex int
+	// objectID and iconID are the IDs of the sheet's relationships to the embedded file and to its icon.
+	objectID string
+	iconID   string
+	anchor   CellAnchor
+}
+
+// AddAttachment embeds the file read from r in the named sheet as an OLE package object. Excel draws its icon at the
+// anchor, and opens the file with the program for its extension when the icon is double clicked. If the anchor's width
+// and height are 0, the icon is shown at its own size. The sheet must be the current sheet or one that has not been
+// started yet.
+// The file and the icon are read immediately and held in memory until Close, when they are written to the file.
+func (sf *StreamFile) AddAttachment(sheetName string, anchor CellAnchor, attachment Attachment, r io.Reader) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	if err := anchor.validate(); err != nil {
+		return err
+	}
+	if attachment.FileName == "" || strings.ContainsRune(attachment.FileName, 0) {
+		return InvalidAttachmentNameError
+	}
+	icon, iconFormat := defaultAttachmentIcon(), PNG
+	if attachment.Icon != nil {
+		if _, _, err := attachment.IconFormat.extension(); err != nil {
+			return err
+		}
+		if icon, err = ioutil.ReadAll(attachment.Icon); err != nil {
+			return err
+		}
+		iconFormat = attachment.IconFormat
+	}
+	if anchor.Width == 0 {
+		config, _, err := image.DecodeConfig(bytes.NewReader(icon))
+		if err != nil {
+			return err
+		}
+		anchor.Width = config.Width
+		anchor.Height = config.Height
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	native := ole10Native(attachment.FileName, data)
+	if int64(len(native)) > maxOLEStreamSize {
+		return AttachmentTooLargeError
+	}
+
+	mediaName, err := sf.addMedia(icon, iconFormat)
+	if err != nil {
+		return err
+	}
+	sf.attachmentCount++
+	objectName := "oleObject" + strconv.Itoa(sf.attachmentCount) + ".bin"
+	sf.addPart(embeddingsPathPrefix+strconv.Itoa(sf.attachmentCount)+".bin", compoundFile(packageClassID,
+		[]cfbStream{{name: ole10NativeStream, data: native}}))
+	// Other parts, like printer settings, also end in .bin, so the content type is set for the part alone.
+	sf.contentTypes.addOverride(embeddingsPathPrefix+strconv.Itoa(sf.attachmentCount)+".bin", oleObjectContentType)
+
+	// Excel draws the icon from the VML shape, and newer versions also read it from the oleObject element.
+	vml := sf.vml(sheetArrayIndex)
+	vml.addShapeType("_x0000_t75", headerFooterImageShapeType)
+	vmlIconID := vml.addRelationship(imageRelationshipType, "../media/"+mediaName)
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	extras.attachments = append(extras.attachments, attachmentObject{
+		shapeIndex: len(vml.shapes),
+		objectID:   sf.addSheetRelationship(sheetArrayIndex, oleObjectRelationshipType, "../embeddings/"+objectName),
+		iconID:     sf.addSheetRelationship(sheetArrayIndex, imageRelationshipType, "../media/"+mediaName),
+		anchor:     anchor,
+	})
+	toColumn, columnOffset, toRow, rowOffset := anchor.corner()
+	vmlAnchor := strconv.Itoa(anchor.Column) + ", 0, " + strconv.Itoa(anchor.Row-1) + ", 0, " + strconv.Itoa(toColumn) +
+		", " + strconv.Itoa(columnOffset) + ", " + strconv.Itoa(toRow) + ", " + strconv.Itoa(rowOffset)
+	// VML sizes are in points, and there are 0.75 points in a pixel.
+	width := strconv.FormatFloat(float64(anchor.Width)*0.75, 'f', -1, 64)
+	height := strconv.FormatFloat(float64(anchor.Height)*0.75, 'f', -1, 64)
+	vml.addShape("", ` type="#_x0000_t75" style="position:absolute;margin-left:0;margin-top:0;width:`+width+`pt;`+
+		`height:`+height+`pt;z-index:`+strconv.Itoa(len(vml.shapes)+1)+`" o:insetmode="auto">`+
+		`<v:imagedata`+xmlAttribute("o:relid", vmlIconID)+xmlAttribute("o:title", "")+`/>`+
+		`<x:ClientData ObjectType="Pict"><x:SizeWithCells/><x:Anchor>`+vmlAnchor+`</x:Anchor><x:CF>Pict</x:CF>`+
+		`<x:AutoPict/></x:ClientData></v:shape>`)
+	return nil
+}
+
+// corner returns the cell the bottom right corner of the anchored object is in, with rows starting at 0, and the
+// offsets of the corner into the cell in pixels. Cells are taken to have the default column width and row height.
+func (a CellAnchor) corner() (int, int, int, int) {
+	return a.Column + a.Width/defaultColumnPixels, a.Width % defaultColumnPixels,
+		a.Row - 1 + a.Height/defaultRowPixels, a.Height % defaultRowPixels
+}
+
+// renderAttachments returns the oleObjects element for the attachments on a sheet, whose VML drawing starts at
+// firstShapeID. Versions of Excel before 2010 read the fallback, which has no anchor.
+func renderAttachments(attachments []attachmentObject, firstShapeID int) string {
+	var builder strings.Builder
+	builder.WriteString(`<oleObjects xmlns:r="` + relationshipsNamespace + `" xmlns:xdr="` + spreadsheetDrawingNS + `">`)
+	for _, attachment := range attachments {
+		object := `<oleObject progId="Package" dvAspect="DVASPECT_ICON" shapeId="` +
+			strconv.Itoa(firstShapeID+attachment.shapeIndex) + `" r:id="` + attachment.objectID + `"`
+		toColumn, columnOffset, toRow, rowOffset := attachment.anchor.corner()
+		builder.WriteString(`<mc:AlternateContent xmlns:mc="` + markupCompatibilityNS + `" xmlns:x14="` + x14Namespace +
+			`"><mc:Choice Requires="x14">` + object + `><objectPr defaultSize="0" autoPict="0" r:id="` +
+			attachment.iconID + `"><anchor moveWithCells="1"><from>` +
+			markerXML(attachment.anchor.Column, 0, attachment.anchor.Row-1, 0) + `</from><to>` +
+			markerXML(toColumn, columnOffset, toRow, rowOffset) + `</to></anchor></objectPr></oleObject></mc:Choice>` +
+			`<mc:Fallback>` + object + `/></mc:Fallback></mc:AlternateContent>`)
+	}
+	builder.WriteString(`</oleObjects>`)
+	return builder.String()
+}
+
+// markerXML returns the XML for a corner of an anchor, with the row starting at 0 and the offsets in pixels.
+func markerXML(column, columnOffset, row, rowOffset int) string {
+	return `<xdr:col>` + strconv.Itoa(column) + `</xdr:col><xdr:colOff>` + strconv.Itoa(columnOffset*emusPerPixel) +
+		`</xdr:colOff><xdr:row>` + strconv.Itoa(row) + `</xdr:row><xdr:rowOff>` + strconv.Itoa(rowOffset*emusPerPixel) +
+		`</xdr:rowOff>`
+}
+
+// ole10Native returns the stream that holds a file in an OLE package object. It starts with the size of the rest of
+// the stream, followed by the label, the source path, the command that opens it and the file's data. The name is used
+// for all three.
+func ole10Native(fileName string, data []byte) []byte {
+	var stream bytes.Buffer
+	binary.Write(&stream, binary.LittleEndian, uint16(2))
+	stream.WriteString(fileName + "\x00")
+	stream.WriteString(fileName + "\x00")
+	// The package is embedded rather than linked.
+	binary.Write(&stream, binary.LittleEndian, uint32(0x00030000))
+	binary.Write(&stream, binary.LittleEndian, uint32(len(fileName)+1))
+	stream.WriteString(fileName + "\x00")
+	binary.Write(&stream, binary.LittleEndian, uint32(len(data)))
+	stream.Write(data)
+	size := make([]byte, 4, 4+stream.Len())
+	binary.LittleEndian.PutUint32(size, uint32(stream.Len()))
+	return append(size, stream.Bytes()...)
+}
+
+// defaultAttachmentIcon returns a PNG of a plain page with lines of text, which is drawn for attachments that are not
+// given an icon.
+func defaultAttachmentIcon() []byte {
+	const width, height = 32, 40
+	icon := image.NewRGBA(image.Rect(0, 0, width, height))
+	border := color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF}
+	text := color.RGBA{R: 0xB0, G: 0xB0, B: 0xB0, A: 0xFF}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			switch {
+			case x == 0 || y == 0 || x == width-1 || y == height-1:
+				icon.Set(x, y, border)
+			case y >= 8 && y < height-6 && y%4 == 0 && x >= 6 && x < width-6:
+				icon.Set(x, y, text)
+			default:
+				icon.Set(x, y, color.White)
+			}
+		}
+	}
+	var buffer bytes.Buffer
+	// Encoding to memory can not fail.
+	png.Encode(&buffer, icon)
+	return buffer.Bytes()
+}
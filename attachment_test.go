@@ -0,0 +1,116 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAddAttachment(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Evidence"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123", ""}); err != nil {
+		t.Fatal(err)
+	}
+	control := FormControl{Column: 0, Row: 3, Label: "Approved"}
+	if err := excelStream.AddFormControl("Sheet1", control); err != nil {
+		t.Fatal(err)
+	}
+	pdf := []byte("%PDF-1.4 evidence")
+	attachment := Attachment{FileName: "evidence <1>.pdf"}
+	if err := excelStream.AddAttachment("Sheet1", CellAnchor{Column: 1, Row: 2}, attachment, bytes.NewReader(pdf)); err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.AddAttachment("Sheet1", CellAnchor{Column: 1, Row: 3}, Attachment{}, bytes.NewReader(pdf))
+	if err != InvalidAttachmentNameError {
+		t.Fatalf("Expected InvalidAttachmentNameError, got %v", err)
+	}
+	err = excelStream.AddAttachment("Sheet1", CellAnchor{Column: 1, Row: 3, Width: 10}, attachment, bytes.NewReader(pdf))
+	if err != InvalidAnchorSizeError {
+		t.Fatalf("Expected InvalidAnchorSizeError, got %v", err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	classID, streams := readCompoundFile(t, []byte(readZipPart(t, buffer.Bytes(), "xl/embeddings/oleObject1.bin")))
+	if classID != packageClassID || len(streams) != 1 || streams[0].name != ole10NativeStream ||
+		!bytes.Equal(streams[0].data, ole10Native(attachment.FileName, pdf)) {
+		t.Fatalf("Expected the file in a package object, got %x %v", classID, streams)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	expectedParts := []string{
+		`<oleObject progId="Package" dvAspect="DVASPECT_ICON" shapeId="1026" r:id="rId2">`,
+		`<objectPr defaultSize="0" autoPict="0" r:id="rId3">`,
+		`<from><xdr:col>1</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>1</xdr:row><xdr:rowOff>0</xdr:rowOff></from>`,
+		`<to><xdr:col>1</xdr:col><xdr:colOff>304800</xdr:colOff><xdr:row>3</xdr:row><xdr:rowOff>0</xdr:rowOff></to>`,
+	}
+	for _, expectedPart := range expectedParts {
+		if !strings.Contains(sheetXML, expectedPart) {
+			t.Fatalf("Expected %s in %s", expectedPart, sheetXML)
+		}
+	}
+	rels := readZipPart(t, buffer.Bytes(), "xl/worksheets/_rels/sheet1.xml.rels")
+	if !strings.Contains(rels, `Target="../embeddings/oleObject1.bin"`) || !strings.Contains(rels, `Target="../media/image1.png"`) {
+		t.Fatalf("Expected relationships to the object and its icon: %s", rels)
+	}
+	vmlXML := readZipPart(t, buffer.Bytes(), "xl/drawings/vmlDrawing1.vml")
+	if !strings.Contains(vmlXML, `<v:shape id="_x0000_s1026" type="#_x0000_t75"`) ||
+		!strings.Contains(vmlXML, `<x:Anchor>1, 0, 1, 0, 1, 32, 3, 0</x:Anchor><x:CF>Pict</x:CF>`) {
+		t.Fatalf("Expected a picture shape for the icon: %s", vmlXML)
+	}
+	contentTypes := readZipPart(t, buffer.Bytes(), "[Content_Types].xml")
+	if !strings.Contains(contentTypes, `PartName="/xl/embeddings/oleObject1.bin" ContentType="`+oleObjectContentType+`"`) {
+		t.Fatalf("Expected a content type for the object: %s", contentTypes)
+	}
+}
+
+func TestFirstVMLShapeID(t *testing.T) {
+	sf := &StreamFile{sheetExtras: make([]sheetExtras, 3)}
+	sf.sheetExtras[0].vml = &sheetVML{name: "vmlDrawing1", shapes: make([]vmlShape, vmlShapeIDBlockSize)}
+	sf.sheetExtras[0].headerFooterVML = &sheetVML{name: "vmlDrawingHF1", shapes: make([]vmlShape, 1)}
+	sf.sheetExtras[2].vml = &sheetVML{name: "vmlDrawing3", shapes: make([]vmlShape, 1)}
+	if id := sf.firstVMLShapeID(2); id != 4*vmlShapeIDBlockSize+1 {
+		t.Fatalf("Expected %d, got %d", 4*vmlShapeIDBlockSize+1, id)
+	}
+	sf.addVMLParts()
+	if vmlXML := string(sf.parts[2].data); !strings.Contains(vmlXML, `id="_x0000_s4097"`) {
+		t.Fatalf("Expected the drawing to start at the same ID: %s", vmlXML)
+	}
+}
+
+func TestAttachmentCorner(t *testing.T) {
+	testCases := []struct {
+		anchor   CellAnchor
+		expected [4]int
+	}{
+		{anchor: CellAnchor{Column: 0, Row: 1, Width: 32, Height: 40}, expected: [4]int{0, 32, 2, 0}},
+		{anchor: CellAnchor{Column: 2, Row: 5, Width: 100, Height: 15}, expected: [4]int{3, 36, 4, 15}},
+	}
+	for _, testCase := range testCases {
+		column, columnOffset, row, rowOffset := testCase.anchor.corner()
+		if actual := [4]int{column, columnOffset, row, rowOffset}; actual != testCase.expected {
+			t.Fatalf("%v: Expected %v, got %v", testCase.anchor, testCase.expected, actual)
+		}
+	}
+}
+
+func TestOLE10Native(t *testing.T) {
+	stream := ole10Native("evidence.pdf", []byte("%PDF"))
+	expected := []byte("\x39\x00\x00\x00\x02\x00evidence.pdf\x00evidence.pdf\x00\x00\x00\x03\x00\x0D\x00\x00\x00" +
+		"evidence.pdf\x00\x04\x00\x00\x00%PDF")
+	if !bytes.Equal(stream, expected) {
+		t.Fatalf("Expected %q, got %q", expected, stream)
+	}
+}
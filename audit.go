@@ -0,0 +1,118 @@
+package excel_stream
+
+import (
+	"errors"
+	"time"
+)
+
+// auditTimeFormat is the number format of the Written At audit column.
+const auditTimeFormat = "yyyy-mm-dd hh:mm:ss"
+
+var InvalidAuditColumnsError = errors.New("Audit columns must include at least one column, and can only be added once to a sheet with headers")
+
+// AuditColumns are columns added to the end of every row of a sheet, for exports that have to show when and where each
+// row came from. Rows are written without them, and the library fills them in.
+type AuditColumns struct {
+	// WrittenAt adds a "Written At" column with the date and time each row was written, as it reads in the time zone
+	// of the time Now returns.
+	WrittenAt bool
+	// Sequence adds a "Sequence" column that numbers the rows of the sheet from 1.
+	Sequence bool
+	// SourceID adds a "Source ID" column with the text it returns for each row. It is given the row's sequence number
+	// and cells.
+	SourceID func(sequence int, cells []string) string
+	// Now returns the time for WrittenAt. If it is nil, time.Now is used.
+	Now func() time.Time
+}
+
+// sheetAudit holds the audit columns of a sheet, and the sequence number of the last row written to it.
+type sheetAudit struct {
+	AuditColumns
+	sequence int
+}
+
+// SetAuditColumns adds the audit columns to the end of the named sheet, which must already have been added with
+// headers. Rows written to the sheet leave out the audit columns, and WriteRowFromMap does not take them as keys. Rows
+// can not be encoded by a RowEncoder for the sheet, since it does not fill them in.
+func (sb *StreamFileBuilder) SetAuditColumns(sheetName string, audit AuditColumns) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	headers := audit.headers()
+	for i, sheet := range sb.xlsxFile.Sheets {
+		if sheet.Name != sheetName {
+			continue
+		}
+		if len(headers) == 0 || sb.audits[i] != nil || len(sheet.Rows) == 0 || len(sheet.Cols) == 0 {
+			return InvalidAuditColumnsError
+		}
+		if len(sheet.Cols)+len(headers) > maxColumns {
+			return ColumnOutOfRangeError
+		}
+		// The audit headers look like the header before them, such as the bold headers of report sheets.
+		row := sheet.Rows[0]
+		style := row.Cells[len(row.Cells)-1].GetStyle()
+		for _, header := range headers {
+			cell := row.AddCell()
+			cell.SetString(header)
+			cell.SetStyle(style)
+		}
+		sb.audits[i] = &sheetAudit{AuditColumns: audit}
+		return nil
+	}
+	return UnknownSheetError
+}
+
+// headers returns the headers of the audit columns.
+func (a *AuditColumns) headers() []string {
+	var headers []string
+	if a.WrittenAt {
+		headers = append(headers, "Written At")
+	}
+	if a.Sequence {
+		headers = append(headers, "Sequence")
+	}
+	if a.SourceID != nil {
+		headers = append(headers, "Source ID")
+	}
+	return headers
+}
+
+// auditColumnCount returns the number of audit columns of the sheet.
+func (sf *StreamFile) auditColumnCount(sheetArrayIndex int) int {
+	if sheetArrayIndex >= len(sf.audits) || sf.audits[sheetArrayIndex] == nil {
+		return 0
+	}
+	return len(sf.audits[sheetArrayIndex].headers())
+}
+
+// addCells returns the cells of a row and its options with the audit cells added to the end.
+func (a *sheetAudit) addCells(cells []string, options RowOptions) ([]string, RowOptions) {
+	sequence := a.sequence + 1
+	var audit []Cell
+	if a.WrittenAt {
+		now := time.Now
+		if a.Now != nil {
+			now = a.Now
+		}
+		writtenAt := DateValue(now())
+		writtenAt.Format = auditTimeFormat
+		audit = append(audit, writtenAt)
+	}
+	if a.Sequence {
+		audit = append(audit, NumberValue(float64(sequence)))
+	}
+	if a.SourceID != nil {
+		audit = append(audit, TextValue(a.SourceID(sequence, cells)))
+	}
+	given := len(cells)
+	cells, options = padOptionalColumns(cells, options, given+len(audit))
+	if options.cells == nil {
+		options.cells = make([]Cell, len(cells))
+	}
+	for i, cell := range audit {
+		cells[given+i] = cell.Value
+		options.cells[given+i] = cell
+	}
+	return cells, options
+}
@@ -0,0 +1,92 @@
+package excel_stream
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditAddCells(t *testing.T) {
+	writtenAt := time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)
+	audit := &sheetAudit{
+		AuditColumns: AuditColumns{
+			WrittenAt: true,
+			Sequence:  true,
+			SourceID: func(sequence int, cells []string) string {
+				return cells[0] + "-" + strconv.Itoa(sequence)
+			},
+			Now: func() time.Time { return writtenAt },
+		},
+		sequence: 4,
+	}
+	if headers := audit.headers(); !reflect.DeepEqual(headers, []string{"Written At", "Sequence", "Source ID"}) {
+		t.Fatalf("Expected all of the audit headers, got %q", headers)
+	}
+	cells, options := audit.addCells([]string{"Taco", "1"}, RowOptions{Phonetics: []string{"タコ", ""}})
+	if !reflect.DeepEqual(cells, []string{"Taco", "1", "2024-01-31T12:00:00", "5", "Taco-5"}) {
+		t.Fatalf("Expected the audit cells after the row's cells, got %q", cells)
+	}
+	if len(options.Phonetics) != 5 || options.cells[0].Type != ColumnCell || options.cells[2].Type != DateCell ||
+		options.cells[2].Format != auditTimeFormat || options.cells[3].Type != NumberCell ||
+		options.cells[4].Type != TextCell {
+		t.Fatalf("Expected typed audit cells, got %+v", options)
+	}
+}
+
+func TestSetAuditColumns(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetAuditColumns("Sheet1", AuditColumns{}); err != InvalidAuditColumnsError {
+		t.Fatalf("Expected %v, got %v", InvalidAuditColumnsError, err)
+	}
+	if err := file.SetAuditColumns("Sheet2", AuditColumns{Sequence: true}); err != InvalidAuditColumnsError {
+		t.Fatalf("Expected %v, got %v", InvalidAuditColumnsError, err)
+	}
+	if err := file.SetAuditColumns("Sheet3", AuditColumns{Sequence: true}); err != UnknownSheetError {
+		t.Fatalf("Expected %v, got %v", UnknownSheetError, err)
+	}
+	if err := file.SetAuditColumns("Sheet1", AuditColumns{Sequence: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetAuditColumns("Sheet1", AuditColumns{Sequence: true}); err != InvalidAuditColumnsError {
+		t.Fatalf("Expected %v, got %v", InvalidAuditColumnsError, err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowFromMap(map[string]interface{}{"Name": "Salsa"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowFromMap(map[string]interface{}{"Sequence": 3}); err == nil {
+		t.Fatal("Expected the audit column to not be a key")
+	}
+	if err := excelStream.WriteRow([]string{"Nachos", "3"}); err != WrongNumberOfRowsError {
+		t.Fatalf("Expected %v, got %v", WrongNumberOfRowsError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sharedStrings := readZipPart(t, buffer.Bytes(), "xl/sharedStrings.xml"); !strings.Contains(sharedStrings,
+		`<t>Sequence</t>`) {
+		t.Fatalf("Expected the audit header: %s", sharedStrings)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{`<c r="B2"><v>1</v></c>`, `<c r="B3"><v>2</v></c>`} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
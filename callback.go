@@ -0,0 +1,36 @@
+package excel_stream
+
+import (
+	"errors"
+	"fmt"
+)
+
+var CallbackPanicError = errors.New("A callback given to the StreamFile panicked")
+
+// callbackPanic returns the error for a callback that panicked with the value.
+func callbackPanic(callback string, value interface{}) error {
+	return fmt.Errorf("%w: %s: %v", CallbackPanicError, callback, value)
+}
+
+// recoverCallback is deferred around calls to the callbacks given to the StreamFile, such as hooks, the rate limiter
+// and plugins. If the callback panics, err is set to an error wrapping CallbackPanicError, and the StreamFile is
+// poisoned.
+func (sf *StreamFile) recoverCallback(callback string, err *error) {
+	if value := recover(); value != nil {
+		*err = sf.poison(callbackPanic(callback, value))
+	}
+}
+
+// poison records the error as the output's, so that nothing more is written once the file can not be finished, such as
+// after a callback panicked in the middle of writing something or flushing the output failed. Every write after it
+// returns the error from outputError, which is also what poison returns. An error the output already failed with is
+// kept.
+func (sf *StreamFile) poison(err error) error {
+	if sf.output == nil {
+		return err
+	}
+	if sf.output.err == nil {
+		sf.output.err = err
+	}
+	return sf.outputError()
+}
@@ -0,0 +1,106 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+// panickingLimiter panics every time it is asked to wait.
+type panickingLimiter struct{}
+
+func (panickingLimiter) Wait(n int64) error {
+	panic("limiter panic")
+}
+
+// panickingPlugin panics when it is finalized.
+type panickingPlugin struct{}
+
+func (panickingPlugin) Finalize(parts *PluginParts) error {
+	panic("plugin panic")
+}
+
+func TestRecoverCallback(t *testing.T) {
+	sf := &StreamFile{output: &countingWriter{writer: bytes.NewBuffer(nil)}}
+	err := func() (err error) {
+		defer sf.recoverCallback("test callback", &err)
+		panic("callback panic")
+	}()
+	if !errors.Is(err, CallbackPanicError) || !errors.Is(err, OutputFailedError) {
+		t.Fatalf("Expected %v and %v, got %v", CallbackPanicError, OutputFailedError, err)
+	}
+	if _, err := sf.output.Write([]byte("Taco")); !errors.Is(err, CallbackPanicError) {
+		t.Fatalf("Expected writes after the panic to fail with %v, got %v", CallbackPanicError, err)
+	}
+}
+
+func TestColumnCheckPanic(t *testing.T) {
+	columns := &sheetColumns{
+		patterns: []*regexp.Regexp{nil},
+		checks:   []func(string) error{func(value string) error { panic("check panic") }},
+	}
+	if err := columns.check(0, "Taco"); !errors.Is(err, ColumnCheckError) || !errors.Is(err, CallbackPanicError) {
+		t.Fatalf("Expected %v and %v, got %v", ColumnCheckError, CallbackPanicError, err)
+	}
+}
+
+func TestRateLimiterPanic(t *testing.T) {
+	sf := &StreamFile{output: &countingWriter{writer: bytes.NewBuffer(nil), count: 10}, rateLimiter: panickingLimiter{}}
+	if err := sf.waitForRateLimit(); !errors.Is(err, CallbackPanicError) || !errors.Is(err, OutputFailedError) {
+		t.Fatalf("Expected %v and %v, got %v", CallbackPanicError, OutputFailedError, err)
+	}
+}
+
+func TestCallbackPanics(t *testing.T) {
+	panicking := func(sheetName, xml string) string { panic("hook panic") }
+	testCases := []struct {
+		testName string
+		setup    func(file *StreamFileBuilder) error
+		// atClose is set for the callbacks that are only run by Close.
+		atClose bool
+	}{
+		{testName: "Prefix hook", setup: func(file *StreamFileBuilder) error {
+			return file.SetSheetXMLHooks(SheetXMLHooks{Prefix: panicking})
+		}},
+		{testName: "Row hook", setup: func(file *StreamFileBuilder) error {
+			return file.SetSheetXMLHooks(SheetXMLHooks{Row: func(sheetName string, rowNumber int, xml string) string {
+				panic("hook panic")
+			}})
+		}},
+		{testName: "Suffix hook", atClose: true, setup: func(file *StreamFileBuilder) error {
+			return file.SetSheetXMLHooks(SheetXMLHooks{Suffix: panicking})
+		}},
+		{testName: "Rate limiter", setup: func(file *StreamFileBuilder) error {
+			return file.SetRateLimiter(panickingLimiter{}, nil)
+		}},
+		{testName: "Plugin", atClose: true, setup: func(file *StreamFileBuilder) error {
+			return file.AddPlugin(panickingPlugin{})
+		}},
+	}
+	for _, testCase := range testCases {
+		file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+		if err := testCase.setup(file); err != nil {
+			t.Fatalf("%s: %v", testCase.testName, err)
+		}
+		if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+			t.Fatalf("%s: %v", testCase.testName, err)
+		}
+		excelStream, err := file.Build()
+		if err == nil && !testCase.atClose {
+			err = excelStream.WriteRow([]string{"Taco"})
+		}
+		if err == nil {
+			err = excelStream.Close()
+		}
+		if !errors.Is(err, CallbackPanicError) {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, CallbackPanicError, err)
+		}
+		if excelStream == nil || testCase.atClose {
+			continue
+		}
+		if err := excelStream.WriteRow([]string{"Burrito"}); !errors.Is(err, OutputFailedError) {
+			t.Fatalf("%s: Expected %v after the panic, got %v", testCase.testName, OutputFailedError, err)
+		}
+	}
+}
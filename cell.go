@@ -0,0 +1,258 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CellType is how a cell written with WriteRowCells is written.
+type CellType int
+
+const (
+	// ColumnCell writes the cell the way WriteRow would, according to its column and the TypeInference. This is the
+	// default.
+	ColumnCell CellType = iota
+	// TextCell writes the cell as text, even in a number column and even if type inference would make it a number.
+	TextCell
+	// NumberCell writes the cell as a number. Its Value must be a number, like -12.5 or 1e3.
+	NumberCell
+	// BoolCell writes the cell as a boolean. Its Value must be TRUE, FALSE, 1 or 0, in any case.
+	BoolCell
+	// DateCell writes the cell as a date. Its Value must be a date or a date and time in RFC 3339 form, like 2006-01-02
+	// or 2006-01-02T15:04:05, which is shown as it reads, whatever its time zone. Dates before 1900 can not be written,
+	// since Excel can not store them.
+	DateCell
+	// CurrencyCell writes the cell as an amount of money. Its Value must be a number, which is shown with the cell's
+	// Format, or in US dollars if it has none.
+	CurrencyCell
+	// PercentCell writes the cell as a percentage. Its Value must be a number, which is a fraction, so 0.153 is shown
+	// as 15.3%. It is shown with the cell's Format, or with one decimal place if it has none.
+	PercentCell
+	// ErrorCell writes the cell as an Excel error, such as a computation that failed upstream. Its Value must be one of
+	// Excel's error values: #NULL!, #DIV/0!, #VALUE!, #REF!, #NAME?, #NUM!, #N/A or #GETTING_DATA, in any case.
+	ErrorCell
+	// EntityCell writes the cell's Entity as a rich value, which is experimental and needs SetRichValues. A cell
+	// without an Entity is left empty.
+	EntityCell
+)
+
+// defaultCurrencyFormat is the number format of currency cells and columns that have no Format of their own.
+var defaultCurrencyFormat = localeFormats["en-US"].Currency
+
+// defaultPercentFormat is the number format of percent cells and columns that have no Format of their own.
+const defaultPercentFormat = "0.0%"
+
+var (
+	InvalidBoolCellError  = errors.New("Boolean cell must be TRUE, FALSE, 1 or 0")
+	InvalidDateCellError  = errors.New("Date cell must be an RFC 3339 date or date and time no earlier than 1900")
+	InvalidErrorCellError = errors.New("Error cell must be one of Excel's error values, like #N/A or #DIV/0!")
+)
+
+// errorValues are the error values Excel can store in a cell.
+var errorValues = []string{"#NULL!", "#DIV/0!", "#VALUE!", "#REF!", "#NAME?", "#NUM!", "#N/A", "#GETTING_DATA"}
+
+// dateCellLayouts are the layouts that the Values of date cells are parsed with.
+var dateCellLayouts = []string{"2006-01-02T15:04:05.999999999Z07:00", "2006-01-02T15:04:05.999999999", "2006-01-02"}
+
+// Cell is a cell of a row written with WriteRowCells, for rows whose cells are not all of the same kind as their
+// columns. A Cell with only a Value is written the same way WriteRow would write it.
+type Cell struct {
+	// Value is the cell's data, in the form its Type asks for. An empty Value leaves the cell empty.
+	Value string
+	Type  CellType
+	// Format is an Excel number format code for the cell, like "0.00%" or "yyyy-mm-dd hh:mm", in place of its column's.
+	// If it is empty, the column's format is kept, except for date cells, which are given the TypeInference's
+	// DateFormat, or "yyyy-mm-dd", currency cells, which are shown in US dollars, and percent cells, which are shown
+	// with one decimal place.
+	Format string
+	// Entity is the value of an EntityCell.
+	Entity *Entity
+}
+
+// TextValue returns a cell that is written as the text.
+func TextValue(text string) Cell {
+	return Cell{Value: text, Type: TextCell}
+}
+
+// NumberValue returns a cell that is written as the number. NaN and infinite numbers are written according to the
+// SanitizePolicy's NonFiniteNumbers.
+func NumberValue(number float64) Cell {
+	return Cell{Value: strconv.FormatFloat(number, 'g', -1, 64), Type: NumberCell}
+}
+
+// BoolValue returns a cell that is written as the boolean.
+func BoolValue(b bool) Cell {
+	if b {
+		return Cell{Value: "TRUE", Type: BoolCell}
+	}
+	return Cell{Value: "FALSE", Type: BoolCell}
+}
+
+// DateValue returns a cell that is written as the date and time, as it reads on the wall clock of its time zone.
+func DateValue(date time.Time) Cell {
+	return Cell{Value: date.Format("2006-01-02T15:04:05.999999999"), Type: DateCell}
+}
+
+// CurrencyValue returns a cell that is written as the amount, shown with the currency format, such as "$"#,##0.00 or
+// #,##0.00 [$€-407]. If the format is empty, the amount is shown in US dollars.
+func CurrencyValue(amount float64, format string) Cell {
+	return Cell{Value: strconv.FormatFloat(amount, 'g', -1, 64), Type: CurrencyCell, Format: format}
+}
+
+// PercentValue returns a cell that is written as the fraction, shown as a percentage, so 0.153 is shown as 15.3%.
+func PercentValue(fraction float64) Cell {
+	return Cell{Value: strconv.FormatFloat(fraction, 'g', -1, 64), Type: PercentCell}
+}
+
+// ErrorValue returns a cell that is written as the Excel error value, such as #N/A or #DIV/0!, so that errors from a
+// computation are shown and counted by Excel as errors rather than as text.
+func ErrorValue(code string) Cell {
+	return Cell{Value: code, Type: ErrorCell}
+}
+
+// WriteRowCells will write a row of cells to the current sheet, where each cell says how it is written, so that one
+// row can mix text, numbers, booleans and dates whatever its columns are. Cells in formula columns must be empty
+// ColumnCells, since their formulas fill them in. It works like WriteRow in every other way.
+func (sf *StreamFile) WriteRowCells(cells []Cell) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	values := make([]string, len(cells))
+	for colIndex, cell := range cells {
+		values[colIndex] = cell.Value
+	}
+	return sf.writeRow(values, RowOptions{cells: cells})
+}
+
+// sanitizeTypedCell returns the value to write for a cell whose type is not ColumnCell and how to write it.
+func (sf *StreamFile) sanitizeTypedCell(sheetName string, rowNumber, colIndex int, columns *sheetColumns,
+	cell Cell) (string, cellKind, error) {
+	if columns.columnType(colIndex) == FormulaColumn {
+		return "", formulaCell, FormulaCellError
+	}
+	switch cell.Type {
+	case TextCell:
+		value, err := sf.sanitizePolicy.sanitizeCell(sheetName, rowNumber, colIndex, cell.Value)
+		return value, textCell, err
+	case NumberCell, CurrencyCell, PercentCell:
+		return sf.sanitizePolicy.sanitizeNumber(sheetName, rowNumber, colIndex, cell.Value)
+	case BoolCell:
+		value, err := parseBool(cell.Value)
+		return value, boolCell, err
+	case DateCell:
+		value, err := parseDate(cell.Value)
+		return value, dateCell, err
+	case ErrorCell:
+		value, err := parseErrorValue(cell.Value)
+		return value, errorCell, err
+	case EntityCell:
+		if cell.Entity == nil {
+			return "", entityCell, nil
+		}
+		value, err := sf.addEntity(cell.Entity)
+		return value, entityCell, err
+	}
+	return "", textCell, UnknownCellType
+}
+
+// parseBool returns the value of a boolean cell, which is 1 or 0, or empty for an empty cell.
+func parseBool(cellData string) (string, error) {
+	switch strings.ToUpper(cellData) {
+	case "":
+		return "", nil
+	case "TRUE", "1":
+		return "1", nil
+	case "FALSE", "0":
+		return "0", nil
+	}
+	return "", InvalidBoolCellError
+}
+
+// parseErrorValue returns the value of an error cell, which is the error value as Excel writes it, or empty for an
+// empty cell.
+func parseErrorValue(cellData string) (string, error) {
+	if cellData == "" {
+		return "", nil
+	}
+	for _, value := range errorValues {
+		if strings.EqualFold(cellData, value) {
+			return value, nil
+		}
+	}
+	return "", InvalidErrorCellError
+}
+
+// parseDate returns the value of a date cell, which is its Excel serial number, or empty for an empty cell.
+func parseDate(cellData string) (string, error) {
+	if cellData == "" {
+		return "", nil
+	}
+	for _, layout := range dateCellLayouts {
+		date, err := time.Parse(layout, cellData)
+		if err != nil {
+			continue
+		}
+		if serial, ok := dateSerial(date); ok {
+			return serial, nil
+		}
+		break
+	}
+	return "", InvalidDateCellError
+}
+
+// typedCellStyles returns the style attributes of the cells that have a number format of their own, or empty strings
+// for the cells that keep their column's style. It returns nil if no cell has its own format.
+func (sf *StreamFile) typedCellStyles(columns *sheetColumns, cells []Cell) ([]string, error) {
+	var attributes []string
+	for colIndex, cell := range cells {
+		format := cell.Format
+		if format == "" && cell.Type == DateCell {
+			format = sf.typeInference.dateFormat()
+		}
+		if format == "" && cell.Type == CurrencyCell {
+			format = defaultCurrencyFormat
+		}
+		if format == "" && cell.Type == PercentCell {
+			format = defaultPercentFormat
+		}
+		if format == "" || columns.columnType(colIndex) == FormulaColumn {
+			continue
+		}
+		styleID, err := sf.formatStyleID(columns, colIndex, format)
+		if err != nil {
+			return nil, err
+		}
+		if attributes == nil {
+			attributes = make([]string, len(cells))
+		}
+		attributes[colIndex] = ` s="` + strconv.Itoa(styleID) + `"`
+	}
+	return attributes, nil
+}
+
+// formatStyleID returns the ID of the cell style of the column with the number format in place of its own. Styles are
+// added to the style sheet the first time each column needs them.
+func (sf *StreamFile) formatStyleID(columns *sheetColumns, colIndex int, format string) (int, error) {
+	key := columnFormat{colIndex: colIndex, format: format}
+	if styleID, ok := columns.formatStyleIDs[key]; ok {
+		return styleID, nil
+	}
+	styleID, err := sf.styles.addCellStyle(columns.style(colIndex), format)
+	if err != nil {
+		return 0, err
+	}
+	if columns.formatStyleIDs == nil {
+		columns.formatStyleIDs = make(map[columnFormat]int)
+	}
+	columns.formatStyleIDs[key] = styleID
+	return styleID, nil
+}
+
+// columnFormat is a number format used in a column by cells written with WriteRowCells.
+type columnFormat struct {
+	colIndex int
+	format   string
+}
@@ -0,0 +1,156 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizeTypedCell(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		cell          Cell
+		column        ColumnType
+		expected      string
+		expectedKind  cellKind
+		expectedError error
+	}{
+		{testName: "Text In Number Column", cell: TextValue("00123"), column: NumberColumn, expected: "00123"},
+		{testName: "Number", cell: NumberValue(12.5), expected: "12.5", expectedKind: numberCell},
+		{
+			testName:      "Not A Number",
+			cell:          Cell{Value: "many", Type: NumberCell},
+			expectedKind:  numberCell,
+			expectedError: NotANumberError,
+		},
+		{testName: "Currency", cell: CurrencyValue(9.99, ""), expected: "9.99", expectedKind: numberCell},
+		{testName: "Percent", cell: PercentValue(0.153), expected: "0.153", expectedKind: numberCell},
+		{testName: "Error", cell: ErrorValue("#DIV/0!"), expected: "#DIV/0!", expectedKind: errorCell},
+		{testName: "Error Case", cell: Cell{Value: "#n/a", Type: ErrorCell}, expected: "#N/A", expectedKind: errorCell},
+		{
+			testName:      "Not An Error",
+			cell:          ErrorValue("#OOPS!"),
+			expectedKind:  errorCell,
+			expectedError: InvalidErrorCellError,
+		},
+		{testName: "Bool", cell: BoolValue(true), expected: "1", expectedKind: boolCell},
+		{testName: "Bool Digit", cell: Cell{Value: "0", Type: BoolCell}, expected: "0", expectedKind: boolCell},
+		{
+			testName:      "Not A Bool",
+			cell:          Cell{Value: "yes", Type: BoolCell},
+			expectedKind:  boolCell,
+			expectedError: InvalidBoolCellError,
+		},
+		{
+			testName:     "Date",
+			cell:         DateValue(time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)),
+			expected:     "45322.5",
+			expectedKind: dateCell,
+		},
+		{
+			testName:     "Date With Zone",
+			cell:         Cell{Value: "2024-01-31T12:00:00+09:00", Type: DateCell},
+			expected:     "45322.5",
+			expectedKind: dateCell,
+		},
+		{
+			testName:      "Too Early",
+			cell:          Cell{Value: "1899-12-31", Type: DateCell},
+			expectedKind:  dateCell,
+			expectedError: InvalidDateCellError,
+		},
+		{
+			testName:      "Formula Column",
+			cell:          NumberValue(1),
+			column:        FormulaColumn,
+			expectedKind:  formulaCell,
+			expectedError: FormulaCellError,
+		},
+		{testName: "Empty Entity", cell: Cell{Type: EntityCell}, expectedKind: entityCell},
+		{
+			testName:      "Rich Values Disabled",
+			cell:          EntityValue(Entity{Text: "Taco"}),
+			expectedKind:  entityCell,
+			expectedError: RichValuesDisabledError,
+		},
+		{testName: "Unknown", cell: Cell{Value: "1", Type: EntityCell + 1}, expectedError: UnknownCellType},
+	}
+	sf := &StreamFile{}
+	for _, testCase := range testCases {
+		columns := &sheetColumns{types: []ColumnType{testCase.column}}
+		actual, kind, err := sf.sanitizeTypedCell("Sheet1", 2, 0, columns, testCase.cell)
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if actual != testCase.expected || kind != testCase.expectedKind {
+			t.Fatalf("%s: Expected %s of kind %d, got %s of kind %d", testCase.testName, testCase.expected,
+				testCase.expectedKind, actual, kind)
+		}
+	}
+}
+
+func TestTypedCellStyles(t *testing.T) {
+	sf := &StreamFile{}
+	columns := &sheetColumns{types: []ColumnType{TextColumn, TextColumn, FormulaColumn}, styles: make([]Style, 3)}
+	attributes, err := sf.typedCellStyles(columns, []Cell{TextValue("Taco"), NumberValue(1), {}})
+	if err != nil || attributes != nil {
+		t.Fatalf("Expected no styles, got %v, %v", attributes, err)
+	}
+	cells := []Cell{{Value: "0.5", Type: NumberCell, Format: "0.00%"}, DateValue(time.Now()), {}}
+	attributes, err = sf.typedCellStyles(columns, cells)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attributes[0] == "" || attributes[1] == "" || attributes[0] == attributes[1] || attributes[2] != "" {
+		t.Fatalf("Expected styles for the percentage and the date, got %v", attributes)
+	}
+	again, err := sf.typedCellStyles(columns, cells)
+	if err != nil || strings.Join(again, ",") != strings.Join(attributes, ",") {
+		t.Fatalf("Expected the same styles to be reused, got %v, %v", again, err)
+	}
+	currency, err := sf.typedCellStyles(columns, []Cell{CurrencyValue(1, ""), CurrencyValue(1, `"$"#,##0.00`), {}})
+	if err != nil || currency[0] == "" || currency[1] == "" || currency[0] == currency[1] {
+		t.Fatalf("Expected the default and given currency formats, got %v, %v", currency, err)
+	}
+}
+
+func TestWriteRowCells(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name", "Price", "In Stock", "Added"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	err = excelStream.WriteRowCells([]Cell{{Value: "Taco"}, NumberValue(3.5), BoolValue(true), DateValue(added)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowCells([]Cell{{Value: "Nachos"}, ErrorValue("#N/A"), {}, {}}); err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRowCells([]Cell{{Value: "Salsa"}, {Value: "cheap", Type: NumberCell}, {}, {}})
+	if cellErr, ok := err.(*CellError); !ok || cellErr.Column != 1 || cellErr.Err != NotANumberError {
+		t.Fatalf("Expected a CellError for column 1 wrapping %v, got %v", NotANumberError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<c r="A2" t="inlineStr"><is><t>Taco</t></is></c>`,
+		`<c r="B2"><v>3.5</v></c>`,
+		`<c r="C2" t="b"><v>1</v></c>`,
+		`<c r="D2" s="`,
+		`"><v>45322</v></c>`,
+		`<c r="B3" t="e"><v>#N/A</v></c>`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
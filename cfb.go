@@ -0,0 +1,249 @@
+package excel_stream
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Compound File Binary files are the container OLE objects are stored in. They hold a small file system of streams,
+// which are stored in chains of sectors that are linked through the file allocation table (FAT). Streams smaller than
+// the cutoff are stored in the smaller sectors of the mini stream instead, which are linked through the mini FAT.
+const (
+	cfbSectorSize         = 512
+	cfbMiniSectorSize     = 64
+	cfbMiniStreamCutoff   = 4096
+	cfbDirectoryEntrySize = 128
+	// cfbHeaderDIFATEntries is the number of FAT sector locations that fit in the header. The locations of any other
+	// FAT sectors go in DIFAT sectors, which each hold cfbDIFATSectorEntries locations and the location of the next.
+	cfbHeaderDIFATEntries = 109
+	cfbDIFATSectorEntries = cfbSectorSize/4 - 1
+
+	cfbEndOfChain  = 0xFFFFFFFE
+	cfbFATSector   = 0xFFFFFFFD
+	cfbDIFATSector = 0xFFFFFFFC
+	cfbFreeSector  = 0xFFFFFFFF
+	cfbNoStream    = 0xFFFFFFFF
+
+	cfbStreamObject = 2
+	cfbRootObject   = 5
+	cfbBlack        = 1
+)
+
+// cfbSignature starts every compound file.
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// cfbStream is a stream in the root storage of a compound file. Its name must be at most 31 characters.
+type cfbStream struct {
+	name string
+	data []byte
+}
+
+// compoundFile returns a version 3 compound file holding the streams in its root storage, which has the class ID.
+// Its sectors are laid out in the order the streams, the mini stream, the mini FAT, the directory, the FAT and then
+// any DIFAT sectors.
+func compoundFile(classID [16]byte, streams []cfbStream) []byte {
+	var body []byte
+	var fat, miniFAT []uint32
+	var miniStream []byte
+	starts := make([]uint32, len(streams))
+	for i, stream := range streams {
+		if len(stream.data) < cfbMiniStreamCutoff {
+			miniFAT, starts[i] = appendChain(miniFAT, sectorCount(len(stream.data), cfbMiniSectorSize))
+			miniStream = append(miniStream, padSectors(stream.data, cfbMiniSectorSize, 0)...)
+			continue
+		}
+		fat, starts[i] = appendChain(fat, sectorCount(len(stream.data), cfbSectorSize))
+		body = append(body, padSectors(stream.data, cfbSectorSize, 0)...)
+	}
+	var miniStreamStart, miniFATStart, directoryStart uint32
+	fat, miniStreamStart = appendChain(fat, sectorCount(len(miniStream), cfbSectorSize))
+	body = append(body, padSectors(miniStream, cfbSectorSize, 0)...)
+	miniFATSectors := sectorCount(len(miniFAT)*4, cfbSectorSize)
+	fat, miniFATStart = appendChain(fat, miniFATSectors)
+	body = append(body, tableSectors(miniFAT)...)
+
+	directory := cfbDirectory(classID, streams, starts, miniStreamStart, len(miniStream))
+	fat, directoryStart = appendChain(fat, len(directory)/cfbSectorSize)
+	body = append(body, directory...)
+
+	// The FAT also has to cover its own sectors and the DIFAT sectors.
+	fatSectors, difatSectors := 0, 0
+	for len(fat)+fatSectors+difatSectors > fatSectors*cfbSectorSize/4 {
+		fatSectors++
+		if fatSectors > cfbHeaderDIFATEntries {
+			difatSectors = sectorCount(fatSectors-cfbHeaderDIFATEntries, cfbDIFATSectorEntries)
+		}
+	}
+	fatLocations := make([]uint32, fatSectors)
+	for i := range fatLocations {
+		fatLocations[i] = uint32(len(fat) + i)
+	}
+	firstDIFATSector := uint32(len(fat) + fatSectors)
+	for i := 0; i < fatSectors; i++ {
+		fat = append(fat, cfbFATSector)
+	}
+	for i := 0; i < difatSectors; i++ {
+		fat = append(fat, cfbDIFATSector)
+	}
+	body = append(body, tableSectors(fat)...)
+
+	headerLocations := fatLocations
+	if len(headerLocations) > cfbHeaderDIFATEntries {
+		headerLocations = headerLocations[:cfbHeaderDIFATEntries]
+	}
+	for i := 0; i < difatSectors; i++ {
+		var difat []uint32
+		first := cfbHeaderDIFATEntries + i*cfbDIFATSectorEntries
+		if last := first + cfbDIFATSectorEntries; last < len(fatLocations) {
+			difat = append(difat, fatLocations[first:last]...)
+		} else {
+			difat = append(difat, fatLocations[first:]...)
+		}
+		for len(difat) < cfbDIFATSectorEntries {
+			difat = append(difat, cfbFreeSector)
+		}
+		if i < difatSectors-1 {
+			difat = append(difat, firstDIFATSector+uint32(i+1))
+		} else {
+			difat = append(difat, cfbEndOfChain)
+		}
+		body = append(body, tableSectors(difat)...)
+	}
+	if difatSectors == 0 {
+		firstDIFATSector = cfbEndOfChain
+	}
+
+	header := make([]byte, cfbSectorSize)
+	copy(header, cfbSignature)
+	binary.LittleEndian.PutUint16(header[24:], 0x003E)
+	binary.LittleEndian.PutUint16(header[26:], 3)
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFE)
+	// The sizes of the sectors and the mini sectors are stored as powers of 2.
+	binary.LittleEndian.PutUint16(header[30:], 9)
+	binary.LittleEndian.PutUint16(header[32:], 6)
+	binary.LittleEndian.PutUint32(header[44:], uint32(fatSectors))
+	binary.LittleEndian.PutUint32(header[48:], directoryStart)
+	binary.LittleEndian.PutUint32(header[56:], cfbMiniStreamCutoff)
+	binary.LittleEndian.PutUint32(header[60:], miniFATStart)
+	binary.LittleEndian.PutUint32(header[64:], uint32(miniFATSectors))
+	binary.LittleEndian.PutUint32(header[68:], firstDIFATSector)
+	binary.LittleEndian.PutUint32(header[72:], uint32(difatSectors))
+	for i := 0; i < cfbHeaderDIFATEntries; i++ {
+		location := uint32(cfbFreeSector)
+		if i < len(headerLocations) {
+			location = headerLocations[i]
+		}
+		binary.LittleEndian.PutUint32(header[76+4*i:], location)
+	}
+	return append(header, body...)
+}
+
+// cfbDirectory returns the directory sectors of a compound file, with the root storage and its streams. The streams are
+// linked into a balanced tree in the order the format requires.
+func cfbDirectory(classID [16]byte, streams []cfbStream, starts []uint32, miniStreamStart uint32, miniStreamSize int) []byte {
+	order := make([]int, len(streams))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return cfbNameLess(streams[order[a]].name, streams[order[b]].name)
+	})
+	left := make([]uint32, len(streams))
+	right := make([]uint32, len(streams))
+	// link makes a tree of the sorted streams, and returns the directory entry ID of its root. The root storage is
+	// entry 0, so the streams start at 1.
+	var link func(sorted []int) uint32
+	link = func(sorted []int) uint32 {
+		if len(sorted) == 0 {
+			return cfbNoStream
+		}
+		middle := len(sorted) / 2
+		i := sorted[middle]
+		left[i] = link(sorted[:middle])
+		right[i] = link(sorted[middle+1:])
+		return uint32(i + 1)
+	}
+	child := link(order)
+
+	directory := cfbDirectoryEntry("Root Entry", cfbRootObject, cfbNoStream, cfbNoStream, child, classID,
+		miniStreamStart, miniStreamSize)
+	for i, stream := range streams {
+		directory = append(directory, cfbDirectoryEntry(stream.name, cfbStreamObject, left[i], right[i], cfbNoStream,
+			[16]byte{}, starts[i], len(stream.data))...)
+	}
+	unused := cfbDirectoryEntry("", 0, cfbNoStream, cfbNoStream, cfbNoStream, [16]byte{}, 0, 0)
+	for len(directory)%cfbSectorSize != 0 {
+		directory = append(directory, unused...)
+	}
+	return directory
+}
+
+// cfbDirectoryEntry returns a directory entry. Every entry is colored black, which readers accept for any tree.
+func cfbDirectoryEntry(name string, objectType byte, left, right, child uint32, classID [16]byte, start uint32, size int) []byte {
+	entry := make([]byte, cfbDirectoryEntrySize)
+	if name != "" {
+		units := utf16.Encode([]rune(name))
+		for i, unit := range units {
+			binary.LittleEndian.PutUint16(entry[2*i:], unit)
+		}
+		// The length includes the terminating null character.
+		binary.LittleEndian.PutUint16(entry[64:], uint16(2*(len(units)+1)))
+		entry[67] = cfbBlack
+	}
+	entry[66] = objectType
+	binary.LittleEndian.PutUint32(entry[68:], left)
+	binary.LittleEndian.PutUint32(entry[72:], right)
+	binary.LittleEndian.PutUint32(entry[76:], child)
+	copy(entry[80:], classID[:])
+	binary.LittleEndian.PutUint32(entry[116:], start)
+	binary.LittleEndian.PutUint32(entry[120:], uint32(size))
+	return entry
+}
+
+// cfbNameLess reports whether a sorts before b among the entries of a storage, which are ordered by the length of
+// their names and then by their names in upper case.
+func cfbNameLess(a, b string) bool {
+	lengthA, lengthB := len(utf16.Encode([]rune(a))), len(utf16.Encode([]rune(b)))
+	if lengthA != lengthB {
+		return lengthA < lengthB
+	}
+	return strings.ToUpper(a) < strings.ToUpper(b)
+}
+
+// appendChain adds a chain of count sectors to the end of the allocation table, and returns the table and the first
+// sector of the chain, which is cfbEndOfChain if the chain is empty.
+func appendChain(table []uint32, count int) ([]uint32, uint32) {
+	if count == 0 {
+		return table, cfbEndOfChain
+	}
+	start := len(table)
+	for i := start + 1; i < start+count; i++ {
+		table = append(table, uint32(i))
+	}
+	return append(table, cfbEndOfChain), uint32(start)
+}
+
+// sectorCount returns the number of sectors of the size needed to hold n bytes.
+func sectorCount(n, sectorSize int) int {
+	return (n + sectorSize - 1) / sectorSize
+}
+
+// padSectors returns the data padded with the byte to a whole number of sectors.
+func padSectors(data []byte, sectorSize int, padding byte) []byte {
+	padded := make([]byte, sectorCount(len(data), sectorSize)*sectorSize)
+	for i := copy(padded, data); i < len(padded); i++ {
+		padded[i] = padding
+	}
+	return padded
+}
+
+// tableSectors returns the sectors holding an allocation table, with the unused entries marked as free.
+func tableSectors(table []uint32) []byte {
+	data := make([]byte, 4*len(table))
+	for i, entry := range table {
+		binary.LittleEndian.PutUint32(data[4*i:], entry)
+	}
+	return padSectors(data, cfbSectorSize, 0xFF)
+}
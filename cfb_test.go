@@ -0,0 +1,150 @@
+package excel_stream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// readCompoundFile reads the streams in the root storage of a compound file the way a reader would, by following the
+// DIFAT, the FAT and the mini FAT, and walking the directory tree. It returns the root's class ID and the streams in
+// the order of the tree.
+func readCompoundFile(t *testing.T, file []byte) ([16]byte, []cfbStream) {
+	var classID [16]byte
+	if len(file)%cfbSectorSize != 0 || !bytes.HasPrefix(file, cfbSignature) {
+		t.Fatalf("Expected whole sectors starting with the signature, got %d bytes", len(file))
+	}
+	header := file[:cfbSectorSize]
+	sector := func(i uint32) []byte {
+		start := (int(i) + 1) * cfbSectorSize
+		if start+cfbSectorSize > len(file) {
+			t.Fatalf("Sector %d is past the end of the file", i)
+		}
+		return file[start : start+cfbSectorSize]
+	}
+	var fatLocations []uint32
+	for i := 0; i < cfbHeaderDIFATEntries; i++ {
+		if location := binary.LittleEndian.Uint32(header[76+4*i:]); location != cfbFreeSector {
+			fatLocations = append(fatLocations, location)
+		}
+	}
+	for next := binary.LittleEndian.Uint32(header[68:]); next != cfbEndOfChain; {
+		difat := sector(next)
+		for i := 0; i < cfbDIFATSectorEntries; i++ {
+			if location := binary.LittleEndian.Uint32(difat[4*i:]); location != cfbFreeSector {
+				fatLocations = append(fatLocations, location)
+			}
+		}
+		next = binary.LittleEndian.Uint32(difat[4*cfbDIFATSectorEntries:])
+	}
+	if fatSectors := binary.LittleEndian.Uint32(header[44:]); int(fatSectors) != len(fatLocations) {
+		t.Fatalf("Expected %d FAT sectors, got %d", fatSectors, len(fatLocations))
+	}
+	var fat []uint32
+	for _, location := range fatLocations {
+		data := sector(location)
+		for i := 0; i < cfbSectorSize; i += 4 {
+			fat = append(fat, binary.LittleEndian.Uint32(data[i:]))
+		}
+	}
+	chain := func(table []uint32, start uint32, read func(uint32) []byte) []byte {
+		var data []byte
+		for i := start; i != cfbEndOfChain; i = table[i] {
+			if int(i) >= len(table) || len(data) > len(file) {
+				t.Fatalf("Broken chain starting at %d", start)
+			}
+			data = append(data, read(i)...)
+		}
+		return data
+	}
+
+	directory := chain(fat, binary.LittleEndian.Uint32(header[48:]), sector)
+	entry := func(id uint32) []byte {
+		return directory[int(id)*cfbDirectoryEntrySize : int(id+1)*cfbDirectoryEntrySize]
+	}
+	root := entry(0)
+	copy(classID[:], root[80:96])
+	miniStream := chain(fat, binary.LittleEndian.Uint32(root[116:]), sector)[:binary.LittleEndian.Uint32(root[120:])]
+	var miniFAT []uint32
+	miniFATData := chain(fat, binary.LittleEndian.Uint32(header[60:]), sector)
+	for i := 0; i < len(miniFATData); i += 4 {
+		miniFAT = append(miniFAT, binary.LittleEndian.Uint32(miniFATData[i:]))
+	}
+	miniSector := func(i uint32) []byte {
+		return miniStream[int(i)*cfbMiniSectorSize : int(i+1)*cfbMiniSectorSize]
+	}
+
+	var streams []cfbStream
+	var walk func(id uint32)
+	walk = func(id uint32) {
+		if id == cfbNoStream {
+			return
+		}
+		e := entry(id)
+		walk(binary.LittleEndian.Uint32(e[68:]))
+		units := make([]uint16, binary.LittleEndian.Uint16(e[64:])/2-1)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(e[2*i:])
+		}
+		size := binary.LittleEndian.Uint32(e[120:])
+		start := binary.LittleEndian.Uint32(e[116:])
+		var data []byte
+		if size < cfbMiniStreamCutoff {
+			data = chain(miniFAT, start, miniSector)
+		} else {
+			data = chain(fat, start, sector)
+		}
+		streams = append(streams, cfbStream{name: string(utf16.Decode(units)), data: data[:size]})
+		walk(binary.LittleEndian.Uint32(e[72:]))
+	}
+	walk(binary.LittleEndian.Uint32(root[76:]))
+	return classID, streams
+}
+
+func TestCompoundFile(t *testing.T) {
+	large := bytes.Repeat([]byte("Taco"), 2000)
+	// Over 109 FAT sectors are needed for this stream, so some of their locations go in a DIFAT sector.
+	huge := bytes.Repeat([]byte("Burrito!"), 1024*1024)
+	testCases := []struct {
+		testName string
+		streams  []cfbStream
+		// expectedOrder is the order of the streams in the directory tree.
+		expectedOrder []string
+	}{
+		{testName: "Mini stream", streams: []cfbStream{{name: ole10NativeStream, data: []byte("Taco")}},
+			expectedOrder: []string{ole10NativeStream}},
+		{testName: "Empty stream", streams: []cfbStream{{name: "Empty"}}, expectedOrder: []string{"Empty"}},
+		{testName: "Large stream", streams: []cfbStream{{name: ole10NativeStream, data: large}},
+			expectedOrder: []string{ole10NativeStream}},
+		{testName: "DIFAT", streams: []cfbStream{{name: ole10NativeStream, data: huge}},
+			expectedOrder: []string{ole10NativeStream}},
+		{testName: "Ordered by length then name", streams: []cfbStream{
+			{name: "Tacos", data: large},
+			{name: "burrito", data: []byte("Burrito")},
+			{name: "Taco", data: []byte("Taco")},
+			{name: "Nacho", data: []byte("Nacho")},
+			{name: "Al Pastor", data: []byte("Al Pastor")},
+		}, expectedOrder: []string{"Taco", "Nacho", "Tacos", "burrito", "Al Pastor"}},
+	}
+	for _, testCase := range testCases {
+		classID, streams := readCompoundFile(t, compoundFile(packageClassID, testCase.streams))
+		if classID != packageClassID {
+			t.Fatalf("%s: Expected class ID %x, got %x", testCase.testName, packageClassID, classID)
+		}
+		if len(streams) != len(testCase.expectedOrder) {
+			t.Fatalf("%s: Expected %d streams, got %d", testCase.testName, len(testCase.expectedOrder), len(streams))
+		}
+		for i, stream := range streams {
+			if stream.name != testCase.expectedOrder[i] {
+				t.Fatalf("%s: Expected stream %d to be %q, got %q", testCase.testName, i, testCase.expectedOrder[i],
+					stream.name)
+			}
+			for _, expected := range testCase.streams {
+				if expected.name == stream.name && !bytes.Equal(expected.data, stream.data) {
+					t.Fatalf("%s: Stream %q does not match", testCase.testName, stream.name)
+				}
+			}
+		}
+	}
+}
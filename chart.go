@@ -0,0 +1,235 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ChartType is the kind of chart drawn by AddChart.
+type ChartType int
+
+const (
+	BarChart ChartType = iota
+	LineChart
+	PieChart
+)
+
+const (
+	chartPathPrefix       = "xl/charts/chart"
+	chartContentType      = "application/vnd.openxmlformats-officedocument.drawingml.chart+xml"
+	chartRelationshipType = relationshipsNamespace + "/chart"
+	chartNamespace        = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+	// defaultChartWidth and defaultChartHeight are the size in pixels of charts whose anchor has no size.
+	defaultChartWidth  = 480
+	defaultChartHeight = 288
+)
+
+var (
+	UnknownChartTypeError = errors.New("Unknown chart type")
+	NoChartSeriesError    = errors.New("Chart must have at least one value column")
+	PieChartSeriesError   = errors.New("Pie charts can only have one value column")
+	InvalidChartRowsError = errors.New("Chart rows must start after the header, and the last row must not be before the first")
+)
+
+// Chart describes a chart over columns of data in a sheet. Each value column becomes one series, named after the
+// column's header, and the category column labels the points.
+type Chart struct {
+	Type  ChartType
+	Title string
+	// DataSheet is the name of the sheet holding the data. If it is empty, the sheet the chart is on is used.
+	DataSheet string
+	// CategoryColumn is the index of the column holding the labels for each point, which starts at 0.
+	CategoryColumn int
+	// ValueColumns are the indexes of the columns holding the values of each series, which start at 0.
+	ValueColumns []int
+	// FirstRow and LastRow are the Excel row numbers of the first and last rows of data to chart. If FirstRow is 0,
+	// the data starts at row 2, right after the header. If LastRow is 0, the data ends at the last row that was
+	// written to the data sheet, which is only known once the file is closed.
+	FirstRow int
+	LastRow  int
+	// XAxisTitle and YAxisTitle label the axes. They are not used by pie charts.
+	XAxisTitle string
+	YAxisTitle string
+}
+
+// pendingChart is a chart whose part will be written when the StreamFile is closed.
+type pendingChart struct {
+	number         int
+	dataSheetIndex int
+	chart          Chart
+}
+
+// AddChart draws a chart over the named sheet. The sheet must be the current sheet or one that has not been started
+// yet, but the data sheet can be any sheet, and the chart's data can still be written after the chart is added. If the
+// anchor has no size, the chart is 480 by 288 pixels.
+func (sf *StreamFile) AddChart(sheetName string, anchor CellAnchor, chart Chart) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	if err := anchor.validate(); err != nil {
+		return err
+	}
+	if anchor.Width == 0 {
+		anchor.Width = defaultChartWidth
+		anchor.Height = defaultChartHeight
+	}
+	dataSheetIndex := sheetArrayIndex
+	if chart.DataSheet != "" {
+		if dataSheetIndex = sf.sheetIndexByName(chart.DataSheet); dataSheetIndex == -1 {
+			return UnknownSheetError
+		}
+	}
+	if err := chart.validate(); err != nil {
+		return err
+	}
+	chart.ValueColumns = append([]int(nil), chart.ValueColumns...)
+
+	sf.chartCount++
+	chartName := "chart" + strconv.Itoa(sf.chartCount) + ".xml"
+	sf.charts = append(sf.charts, pendingChart{number: sf.chartCount, dataSheetIndex: dataSheetIndex, chart: chart})
+	sf.contentTypes.addOverride(chartPathPrefix+strconv.Itoa(sf.chartCount)+".xml", chartContentType)
+
+	drawing := sf.drawing(sheetArrayIndex)
+	id := drawing.addRelationship(chartRelationshipType, "../charts/"+chartName)
+	objectID := strconv.Itoa(drawing.nextObjectID())
+	drawing.anchors = append(drawing.anchors, `<xdr:oneCellAnchor>`+anchor.fromXML()+anchor.extentXML()+
+		`<xdr:graphicFrame macro=""><xdr:nvGraphicFramePr><xdr:cNvPr id="`+objectID+`" name="Chart `+objectID+`"/>`+
+		`<xdr:cNvGraphicFramePr/></xdr:nvGraphicFramePr>`+
+		`<xdr:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/></xdr:xfrm>`+
+		`<a:graphic><a:graphicData uri="`+chartNamespace+`"><c:chart xmlns:c="`+chartNamespace+`" r:id="`+id+`"/>`+
+		`</a:graphicData></a:graphic></xdr:graphicFrame><xdr:clientData/></xdr:oneCellAnchor>`)
+	return nil
+}
+
+// validate checks the chart's settings that do not depend on the file.
+func (c *Chart) validate() error {
+	if c.Type < BarChart || c.Type > PieChart {
+		return UnknownChartTypeError
+	}
+	if len(c.ValueColumns) == 0 {
+		return NoChartSeriesError
+	}
+	if c.Type == PieChart && len(c.ValueColumns) > 1 {
+		return PieChartSeriesError
+	}
+	for _, column := range append([]int{c.CategoryColumn}, c.ValueColumns...) {
+		if _, err := cellReference(column, 1); err != nil {
+			return err
+		}
+	}
+	if c.FirstRow != 0 {
+		if _, err := cellReference(0, c.FirstRow); err != nil {
+			return err
+		}
+	}
+	if c.LastRow != 0 {
+		if _, err := cellReference(0, c.LastRow); err != nil {
+			return err
+		}
+	}
+	firstRow := c.FirstRow
+	if firstRow == 0 {
+		firstRow = 2
+	}
+	if firstRow == 1 || (c.LastRow != 0 && c.LastRow < firstRow) {
+		return InvalidChartRowsError
+	}
+	return nil
+}
+
+// addChartParts adds the parts for every chart, now that the number of rows in each sheet is known.
+func (sf *StreamFile) addChartParts() {
+	for _, pending := range sf.charts {
+		dataSheetName := sf.xlsxFile.Sheets[pending.dataSheetIndex].Name
+		firstRow, lastRow := pending.chart.FirstRow, pending.chart.LastRow
+		if firstRow == 0 {
+			firstRow = sf.headerRows[pending.dataSheetIndex] + 1
+		}
+		if lastRow == 0 {
+			lastRow = sf.rowCounts[pending.dataSheetIndex]
+		}
+		if lastRow < firstRow {
+			lastRow = firstRow
+		}
+		chartXML := renderChart(pending.chart, dataSheetName, firstRow, lastRow)
+		sf.addPart(chartPathPrefix+strconv.Itoa(pending.number)+".xml", []byte(chartXML))
+	}
+}
+
+// renderChart returns the XML for the chart part.
+func renderChart(chart Chart, dataSheetName string, firstRow, lastRow int) string {
+	var builder strings.Builder
+	builder.WriteString(xmlHeader + `<c:chartSpace xmlns:c="` + chartNamespace + `" xmlns:a="` + drawingMLNamespace +
+		`" xmlns:r="` + relationshipsNamespace + `"><c:chart>`)
+	if chart.Title != "" {
+		builder.WriteString(`<c:title>` + richText(chart.Title) + `<c:overlay val="0"/></c:title>` +
+			`<c:autoTitleDeleted val="0"/>`)
+	} else {
+		builder.WriteString(`<c:autoTitleDeleted val="1"/>`)
+	}
+	builder.WriteString(`<c:plotArea><c:layout/>`)
+	switch chart.Type {
+	case BarChart:
+		builder.WriteString(`<c:barChart><c:barDir val="col"/><c:grouping val="clustered"/><c:varyColors val="0"/>`)
+	case LineChart:
+		builder.WriteString(`<c:lineChart><c:grouping val="standard"/><c:varyColors val="0"/>`)
+	case PieChart:
+		builder.WriteString(`<c:pieChart><c:varyColors val="1"/>`)
+	}
+	categories := escapeXML(columnRange(dataSheetName, chart.CategoryColumn, firstRow, lastRow))
+	for i, column := range chart.ValueColumns {
+		index := strconv.Itoa(i)
+		name := escapeXML(sheetReference(dataSheetName, absoluteReference(column, 1)))
+		values := escapeXML(columnRange(dataSheetName, column, firstRow, lastRow))
+		builder.WriteString(`<c:ser><c:idx val="` + index + `"/><c:order val="` + index + `"/>` +
+			`<c:tx><c:strRef><c:f>` + name + `</c:f></c:strRef></c:tx>` +
+			`<c:cat><c:strRef><c:f>` + categories + `</c:f></c:strRef></c:cat>` +
+			`<c:val><c:numRef><c:f>` + values + `</c:f></c:numRef></c:val></c:ser>`)
+	}
+	switch chart.Type {
+	case BarChart:
+		builder.WriteString(`<c:axId val="1"/><c:axId val="2"/></c:barChart>` + chartAxes(chart))
+	case LineChart:
+		builder.WriteString(`<c:marker val="1"/><c:axId val="1"/><c:axId val="2"/></c:lineChart>` + chartAxes(chart))
+	case PieChart:
+		builder.WriteString(`<c:firstSliceAng val="0"/></c:pieChart>`)
+	}
+	builder.WriteString(`</c:plotArea><c:legend><c:legendPos val="r"/><c:overlay val="0"/></c:legend>` +
+		`<c:plotVisOnly val="1"/></c:chart></c:chartSpace>`)
+	return builder.String()
+}
+
+// chartAxes returns the XML for the category and value axes of bar and line charts.
+func chartAxes(chart Chart) string {
+	return `<c:catAx><c:axId val="1"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/>` +
+		`<c:axPos val="b"/>` + axisTitle(chart.XAxisTitle) + `<c:crossAx val="2"/></c:catAx>` +
+		`<c:valAx><c:axId val="2"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/>` +
+		`<c:axPos val="l"/><c:majorGridlines/>` + axisTitle(chart.YAxisTitle) + `<c:crossAx val="1"/></c:valAx>`
+}
+
+// axisTitle returns the XML for an axis title, or nothing if the title is empty.
+func axisTitle(title string) string {
+	if title == "" {
+		return ""
+	}
+	return `<c:title>` + richText(title) + `<c:overlay val="0"/></c:title>`
+}
+
+// richText returns the XML for a chart text element holding the text.
+func richText(text string) string {
+	return `<c:tx><c:rich><a:bodyPr/><a:p><a:r><a:t>` + escapeXML(text) + `</a:t></a:r></a:p></c:rich></c:tx>`
+}
+
+// columnRange returns an absolute reference to the rows of a column on the named sheet, such as 'Sheet1'!$B$2:$B$10.
+func columnRange(sheetName string, column, firstRow, lastRow int) string {
+	return sheetReference(sheetName, absoluteReference(column, firstRow)+":"+absoluteReference(column, lastRow))
+}
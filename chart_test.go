@@ -0,0 +1,107 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestChartValidate(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		chart         Chart
+		expectedError error
+	}{
+		{testName: "Valid", chart: Chart{ValueColumns: []int{1}}},
+		{testName: "Unknown Type", chart: Chart{Type: 10, ValueColumns: []int{1}}, expectedError: UnknownChartTypeError},
+		{testName: "No Series", chart: Chart{}, expectedError: NoChartSeriesError},
+		{testName: "Pie Series", chart: Chart{Type: PieChart, ValueColumns: []int{1, 2}}, expectedError: PieChartSeriesError},
+		{testName: "Bad Column", chart: Chart{ValueColumns: []int{maxColumns}}, expectedError: ColumnOutOfRangeError},
+		{testName: "Header Row", chart: Chart{ValueColumns: []int{1}, FirstRow: 1}, expectedError: InvalidChartRowsError},
+		{testName: "Backwards Rows", chart: Chart{ValueColumns: []int{1}, FirstRow: 5, LastRow: 4}, expectedError: InvalidChartRowsError},
+		{testName: "Last Row In Header", chart: Chart{ValueColumns: []int{1}, LastRow: 1}, expectedError: InvalidChartRowsError},
+		{testName: "Bad Row", chart: Chart{ValueColumns: []int{1}, LastRow: maxRows + 1}, expectedError: RowOutOfRangeError},
+	}
+	for _, testCase := range testCases {
+		if err := testCase.chart.validate(); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	chart := Chart{
+		Type:           LineChart,
+		Title:          "Sales & Returns",
+		CategoryColumn: 0,
+		ValueColumns:   []int{1, 2},
+		XAxisTitle:     "Month",
+	}
+	chartXML := renderChart(chart, "Tom's Data", 2, 13)
+	expectedParts := []string{
+		`<c:lineChart>`,
+		`<a:t>Sales &amp; Returns</a:t>`,
+		`<c:cat><c:strRef><c:f>&#39;Tom&#39;&#39;s Data&#39;!$A$2:$A$13</c:f></c:strRef></c:cat>`,
+		`<c:tx><c:strRef><c:f>&#39;Tom&#39;&#39;s Data&#39;!$C$1</c:f></c:strRef></c:tx>`,
+		`<c:val><c:numRef><c:f>&#39;Tom&#39;&#39;s Data&#39;!$C$2:$C$13</c:f></c:numRef></c:val>`,
+		`<a:t>Month</a:t>`,
+	}
+	for _, expectedPart := range expectedParts {
+		if !strings.Contains(chartXML, expectedPart) {
+			t.Fatalf("Expected %s in chart: %s", expectedPart, chartXML)
+		}
+	}
+	if strings.Count(chartXML, "<c:ser>") != 2 {
+		t.Fatalf("Expected two series: %s", chartXML)
+	}
+}
+
+func TestAddChart(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Data", []string{"Month", "Sales"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Summary", []string{"Notes"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"January", "10"}, {"February", "20"}, {"March", "30"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.NextSheet(); err != nil {
+		t.Fatal(err)
+	}
+	chart := Chart{Type: BarChart, Title: "Sales", DataSheet: "Data", ValueColumns: []int{1}}
+	if err := excelStream.AddChart("Summary", CellAnchor{Column: 1, Row: 2}, chart); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.AddChart("Data", CellAnchor{Column: 1, Row: 2}, chart); err != SheetFinalizedError {
+		t.Fatalf("Expected SheetFinalizedError, got %v", err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	chartXML := readZipPart(t, buffer.Bytes(), "xl/charts/chart1.xml")
+	if !strings.Contains(chartXML, `!$B$2:$B$4</c:f>`) {
+		t.Fatalf("Expected the chart to cover the rows that were written: %s", chartXML)
+	}
+	drawingRels := readZipPart(t, buffer.Bytes(), "xl/drawings/_rels/drawing2.xml.rels")
+	if !strings.Contains(drawingRels, `Target="../charts/chart1.xml"`) {
+		t.Fatalf("Expected a relationship to the chart: %s", drawingRels)
+	}
+	contentTypesXML := readZipPart(t, buffer.Bytes(), contentTypesPath)
+	if !strings.Contains(contentTypesXML, `PartName="/xl/charts/chart1.xml"`) {
+		t.Fatalf("Expected a content type for the chart: %s", contentTypesXML)
+	}
+}
@@ -0,0 +1,201 @@
+// Command csv2xlsx converts CSV files into an XLSX file with one sheet per CSV file. The rows are streamed from the CSV
+// files into the XLSX file, so files of any size can be converted without holding them in memory.
+//
+// Usage:
+//
+//	csv2xlsx [flags] file.csv...
+//
+// Each sheet is named after its CSV file, without the extension. A file name of "-" reads from stdin, and the XLSX
+// file is written to stdout unless -o is given. The first row of each CSV file is used as the sheet's header, and
+// every row of a file must have the same number of fields as its header. All cells are written as text.
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ryho/excel_stream"
+)
+
+const stdinName = "-"
+
+// byteOrderMark is written at the start of CSV files by some programs, such as Excel, to mark them as UTF-8.
+const byteOrderMark = "\ufeff"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "csv2xlsx:", err)
+		os.Exit(1)
+	}
+}
+
+// input is an open CSV file and the header that was read from it.
+type input struct {
+	name   string
+	reader *csv.Reader
+	header []string
+	closer io.Closer
+}
+
+// run converts the CSV files named in args to XLSX.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("csv2xlsx", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", stdinName, `the XLSX file to write, or "-" for stdout`)
+	delimiter := flags.String("d", ",", "the character that separates the fields of the CSV files")
+	lazyQuotes := flags.Bool("lazy-quotes", false, "allow quotes that are not escaped in the middle of fields")
+	escapeFormulas := flags.Bool("escape-formulas", false,
+		"escape cells that start like a formula, which should be used for CSV files from untrusted sources")
+	truncate := flags.Bool("truncate", false, "shorten cells that are too long for Excel instead of failing")
+	spool := flags.Bool("spool", false, "spool each sheet to a temporary file, so it can be written with its size")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: csv2xlsx [flags] file.csv...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no CSV files given")
+	}
+	comma, size := utf8.DecodeRuneInString(*delimiter)
+	if size == 0 || size != len(*delimiter) {
+		return fmt.Errorf("the delimiter must be a single character, not %q", *delimiter)
+	}
+
+	inputs, err := openInputs(flags.Args(), stdin)
+	defer func() {
+		for _, in := range inputs {
+			if in.closer != nil {
+				in.closer.Close()
+			}
+		}
+	}()
+	if err != nil {
+		return err
+	}
+	for _, in := range inputs {
+		in.reader.Comma = comma
+		in.reader.LazyQuotes = *lazyQuotes
+		in.reader.ReuseRecord = true
+		header, err := in.reader.Read()
+		if err == io.EOF {
+			return fmt.Errorf("%s: the CSV file is empty, it needs at least a header", in.name)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", in.name, err)
+		}
+		in.header = append([]string(nil), header...)
+		in.header[0] = strings.TrimPrefix(in.header[0], byteOrderMark)
+	}
+
+	var builder *excel_stream.StreamFileBuilder
+	if *output == stdinName {
+		builder = excel_stream.NewStreamFileBuilder(stdout)
+	} else if builder, err = excel_stream.NewStreamFileBuilderForPath(*output); err != nil {
+		return err
+	}
+	if err := configure(builder, *escapeFormulas, *truncate, *spool); err != nil {
+		builder.Abort()
+		return err
+	}
+	for _, in := range inputs {
+		if err := builder.AddSheet(sheetName(in.name), in.header); err != nil {
+			builder.Abort()
+			return fmt.Errorf("%s: %w", in.name, err)
+		}
+	}
+	file, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	for i, in := range inputs {
+		if i > 0 {
+			if err := file.NextSheet(); err != nil {
+				file.Abort()
+				return err
+			}
+		}
+		if err := copyRows(file, in); err != nil {
+			file.Abort()
+			return fmt.Errorf("%s: %w", in.name, err)
+		}
+	}
+	return file.Close()
+}
+
+// openInputs opens the named CSV files. The inputs that were opened are returned even if there is an error, so that
+// they can be closed.
+func openInputs(names []string, stdin io.Reader) ([]*input, error) {
+	inputs := make([]*input, 0, len(names))
+	readStdin := false
+	for _, name := range names {
+		if name == stdinName {
+			if readStdin {
+				return inputs, errors.New("stdin can only be read once")
+			}
+			readStdin = true
+			inputs = append(inputs, &input{name: "stdin", reader: csv.NewReader(stdin)})
+			continue
+		}
+		file, err := os.Open(name)
+		if err != nil {
+			return inputs, err
+		}
+		inputs = append(inputs, &input{name: name, reader: csv.NewReader(file), closer: file})
+	}
+	return inputs, nil
+}
+
+// configure sets the builder's policies from the flags. Sheet names are always fixed up rather than rejected, since
+// they come from file names.
+func configure(builder *excel_stream.StreamFileBuilder, escapeFormulas, truncate, spool bool) error {
+	if err := builder.SetSheetNamePolicy(excel_stream.NormalizeInvalidSheetNames); err != nil {
+		return err
+	}
+	if err := builder.SetDeduplicateSheetNames(true); err != nil {
+		return err
+	}
+	if escapeFormulas {
+		if err := builder.SetFormulaInjectionPolicy(excel_stream.EscapeFormulaPrefixes); err != nil {
+			return err
+		}
+	}
+	if truncate {
+		if err := builder.SetCellLengthPolicy(excel_stream.TruncateLongCells, ""); err != nil {
+			return err
+		}
+	}
+	return builder.SetSpoolSheets(spool, "")
+}
+
+// sheetName returns the name of the sheet for a CSV file, which is its file name without the extension.
+func sheetName(name string) string {
+	base := filepath.Base(name)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// copyRows writes the rest of the CSV file's rows to the current sheet.
+func copyRows(file *excel_stream.StreamFile, in *input) error {
+	for {
+		record, err := in.reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := file.WriteRow(record); err != nil {
+			line, _ := in.reader.FieldPos(0)
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ryho/excel_stream"
+)
+
+func TestSheetName(t *testing.T) {
+	testCases := map[string]string{
+		"orders.csv":             "orders",
+		"/tmp/reports/users.tsv": "users",
+		"archive.2017.csv":       "archive.2017",
+		"no_extension":           "no_extension",
+	}
+	for name, expected := range testCases {
+		if actual := sheetName(name); actual != expected {
+			t.Fatalf("Expected %s for %s, got %s", expected, name, actual)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csv2xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	orders := filepath.Join(dir, "orders.csv")
+	if err := ioutil.WriteFile(orders, []byte("\ufeffID;Total\n1;\"3;50\"\n2;7\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stdin := strings.NewReader("Name;Email\nAda;ada@example.com\n")
+	stdout := bytes.NewBuffer(nil)
+	if err := run([]string{"-d", ";", orders, "-"}, stdin, stdout, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	reader := bytes.NewReader(stdout.Bytes())
+	summaries, err := excel_stream.Validate(reader, reader.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []excel_stream.SheetSummary{{Name: "orders", Rows: 3}, {Name: "stdin", Rows: 2}}
+	if len(summaries) != len(expected) || summaries[0] != expected[0] || summaries[1] != expected[1] {
+		t.Fatalf("Expected %v, got %v", expected, summaries)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csv2xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ragged := filepath.Join(dir, "ragged.csv")
+	if err := ioutil.WriteFile(ragged, []byte("A,B\n1,2,3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.csv")
+	if err := ioutil.WriteFile(empty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		testName string
+		args     []string
+	}{
+		{"No Files", nil},
+		{"Long Delimiter", []string{"-d", "ab", ragged}},
+		{"Missing File", []string{filepath.Join(dir, "missing.csv")}},
+		{"Stdin Twice", []string{"-", "-"}},
+		{"Empty File", []string{empty}},
+		{"Wrong Number Of Fields", []string{ragged}},
+	}
+	for _, testCase := range testCases {
+		err := run(testCase.args, strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+		if err == nil {
+			t.Fatalf("%s: Expected an error", testCase.testName)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Command xlsx2csv writes a sheet of an XLSX file as CSV. The sheet is read one row at a time, so sheets of any size
+// can be converted with little memory. Only the workbook's shared strings, which are stored once for the whole file,
+// are held in memory.
+//
+// Usage:
+//
+//	xlsx2csv [flags] file.xlsx
+//
+// A file name of "-" reads the XLSX file from stdin, which is copied to a temporary file first, since XLSX files can
+// not be read in order. The CSV is written to stdout unless -o is given. Rows that are missing from the sheet are
+// written as empty rows, so that each CSV row is on the same line number as the sheet row it came from.
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"unicode/utf8"
+
+	"github.com/ryho/excel_stream/internal/xlsxread"
+)
+
+const stdinName = "-"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "xlsx2csv:", err)
+		os.Exit(1)
+	}
+}
+
+// options are the settings from the flags that control how cells are written.
+type options struct {
+	dateFormat     string
+	dateTimeFormat string
+	rawDates       bool
+}
+
+// run writes the sheet of the XLSX file named in args as CSV.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("xlsx2csv", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	sheetName := flags.String("sheet", "", "the name of the sheet to write, instead of the first sheet")
+	sheetNumber := flags.Int("sheet-number", 0, "the number of the sheet to write, starting at 1, instead of the first sheet")
+	output := flags.String("o", stdinName, `the CSV file to write, or "-" for stdout`)
+	delimiter := flags.String("d", ",", "the character that separates the fields of the CSV file")
+	var opts options
+	flags.StringVar(&opts.dateFormat, "date-format", "2006-01-02",
+		"the Go time layout for dates that have no time of day")
+	flags.StringVar(&opts.dateTimeFormat, "datetime-format", "2006-01-02 15:04:05",
+		"the Go time layout for dates that have a time of day")
+	flags.BoolVar(&opts.rawDates, "raw-dates", false, "write dates as Excel serial numbers")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: xlsx2csv [flags] file.xlsx")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("exactly one XLSX file must be given")
+	}
+	if *sheetName != "" && *sheetNumber != 0 {
+		return errors.New("only one of -sheet and -sheet-number can be given")
+	}
+	comma, size := utf8.DecodeRuneInString(*delimiter)
+	if size == 0 || size != len(*delimiter) {
+		return fmt.Errorf("the delimiter must be a single character, not %q", *delimiter)
+	}
+
+	reader, err := openInput(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	sheetIndex := 0
+	switch {
+	case *sheetName != "":
+		if sheetIndex, err = reader.SheetIndex(*sheetName); err != nil {
+			return fmt.Errorf("%w: %q", err, *sheetName)
+		}
+	case *sheetNumber != 0:
+		if *sheetNumber < 1 || *sheetNumber > len(reader.Sheets) {
+			return fmt.Errorf("the workbook has %d sheets, there is no sheet %d", len(reader.Sheets), *sheetNumber)
+		}
+		sheetIndex = *sheetNumber - 1
+	}
+
+	if *output == stdinName {
+		return writeCSV(stdout, reader, sheetIndex, comma, opts)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeCSV(file, reader, sheetIndex, comma, opts); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// openInput opens the XLSX file. If it is stdin, it is copied to a temporary file, which is removed when the reader is
+// closed.
+func openInput(name string, stdin io.Reader) (*tempFileReader, error) {
+	if name != stdinName {
+		reader, err := xlsxread.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return &tempFileReader{Reader: reader}, nil
+	}
+	file, err := ioutil.TempFile("", "xlsx2csv")
+	if err != nil {
+		return nil, err
+	}
+	reader, err := readTempFile(file, stdin)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return &tempFileReader{Reader: reader, file: file}, nil
+}
+
+// readTempFile copies the data to the file and reads it as an XLSX file.
+func readTempFile(file *os.File, data io.Reader) (*xlsxread.Reader, error) {
+	size, err := io.Copy(file, data)
+	if err != nil {
+		return nil, err
+	}
+	return xlsxread.NewReader(file, size)
+}
+
+// tempFileReader is a Reader that may be reading a temporary file, which is removed when it is closed.
+type tempFileReader struct {
+	*xlsxread.Reader
+	file *os.File
+}
+
+// Close closes the reader, and removes its temporary file if it has one.
+func (r *tempFileReader) Close() error {
+	if r.file == nil {
+		return r.Reader.Close()
+	}
+	err := r.file.Close()
+	if removeErr := os.Remove(r.file.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// writeCSV writes the rows of the sheet to the writer as CSV.
+func writeCSV(w io.Writer, reader *tempFileReader, sheetIndex int, comma rune, opts options) error {
+	rows, err := reader.Rows(sheetIndex)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma = comma
+	var record []string
+	written := 0
+	for rows.Next() {
+		// Rows that are missing from the sheet are empty, so an empty row is written for each one.
+		for ; written < rows.RowNumber()-1; written++ {
+			if err := csvWriter.Write(nil); err != nil {
+				return err
+			}
+		}
+		record = record[:0]
+		for _, cell := range rows.Cells() {
+			for len(record) < cell.Column {
+				record = append(record, "")
+			}
+			text, err := cellText(cell, opts)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", rows.RowNumber(), err)
+			}
+			record = append(record, text)
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+		written = rows.RowNumber()
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// cellText returns the text written to the CSV file for the cell.
+func cellText(cell xlsxread.Cell, opts options) (string, error) {
+	if cell.Type == xlsxread.DateCell && opts.rawDates {
+		return cell.Value, nil
+	}
+	return cell.Text(opts.dateFormat, opts.dateTimeFormat)
+}
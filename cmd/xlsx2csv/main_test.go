@@ -0,0 +1,110 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeWorkbook writes a workbook with two sheets to the file, and returns its path.
+func writeWorkbook(t *testing.T, dir string) string {
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Users" r:id="rId1"/><sheet name="Orders" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="worksheet" Target="worksheets/sheet2.xml"/>` +
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"` +
+			` Target="styles.xml"/></Relationships>`,
+		"xl/styles.xml": `<styleSheet><cellXfs><xf numFmtId="0"/><xf numFmtId="22"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c t="inlineStr"><is><t>Name</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>ID</t></is></c><c r="B1" t="inlineStr"><is><t>Placed</t></is></c>` +
+			`<c r="C1" t="inlineStr"><is><t>Paid</t></is></c></row>` +
+			`<row r="3"><c r="A3"><v>7</v></c><c r="B3" s="1"><v>43000</v></c><c r="C3" t="b"><v>0</v></c></row>` +
+			`<row r="4"><c r="B4" s="1"><v>43000.75</v></c><c r="C4" t="inlineStr"><is><t>a,b</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	name := filepath.Join(dir, "book.xlsx")
+	file, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zipWriter := zip.NewWriter(file)
+	for partName, data := range parts {
+		writer, err := zipWriter.Create(partName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlsx2csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := writeWorkbook(t, dir)
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		testName string
+		args     []string
+		expected string
+	}{
+		{"First Sheet", []string{name}, "Name\n"},
+		{
+			testName: "By Name",
+			args:     []string{"-sheet", "orders", "-d", ";", name},
+			expected: "ID;Placed;Paid\n\n7;2017-09-22;FALSE\n;2017-09-22 18:00:00;a,b\n",
+		},
+		{
+			testName: "From Stdin",
+			args:     []string{"-sheet-number", "2", "-raw-dates", "-"},
+			expected: "ID,Placed,Paid\n\n7,43000,FALSE\n,43000.75,\"a,b\"\n",
+		},
+		{
+			testName: "Date Formats",
+			args:     []string{"-sheet-number", "2", "-date-format", "02/01/2006", "-datetime-format", "15:04", "-"},
+			expected: "ID,Placed,Paid\n\n7,22/09/2017,FALSE\n,18:00,\"a,b\"\n",
+		},
+	}
+	for _, testCase := range testCases {
+		stdout := bytes.NewBuffer(nil)
+		if err := run(testCase.args, bytes.NewReader(data), stdout, ioutil.Discard); err != nil {
+			t.Fatalf("%s: %v", testCase.testName, err)
+		}
+		if stdout.String() != testCase.expected {
+			t.Fatalf("%s: Expected %q, got %q", testCase.testName, testCase.expected, stdout.String())
+		}
+	}
+
+	errorCases := [][]string{
+		nil,
+		{"-sheet", "Missing", name},
+		{"-sheet-number", "3", name},
+		{"-sheet", "Users", "-sheet-number", "1", name},
+		{"-d", "", name},
+		{filepath.Join(dir, "missing.xlsx")},
+	}
+	for _, args := range errorCases {
+		if err := run(args, bytes.NewReader(nil), ioutil.Discard, ioutil.Discard); err == nil {
+			t.Fatalf("Expected an error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Command xlsxinfo describes an XLSX file: its sheets with their row and column counts, the number of styles, the
+// defined names and the document properties. Sheets are read one row at a time, so even very large files can be
+// described with little memory, which makes it useful for debugging exports and looking into files uploaded by users.
+//
+// Usage:
+//
+//	xlsxinfo [flags] file.xlsx
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ryho/excel_stream/internal/xlsxread"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "xlsxinfo:", err)
+		os.Exit(1)
+	}
+}
+
+// info is everything xlsxinfo reports about a file.
+type info struct {
+	File         string
+	Sheets       []sheetInfo
+	DefinedNames []definedName `json:",omitempty"`
+	Styles       xlsxread.StyleCounts
+	Properties   xlsxread.Properties
+}
+
+// sheetInfo describes a sheet. The counts are only set if the rows were read.
+type sheetInfo struct {
+	Name  string
+	State string `json:",omitempty"`
+	// Rows is the number of rows in the file, and LastRow is the number of the last one. They differ when the sheet
+	// has rows that are empty, which are left out of the file.
+	Rows    int `json:",omitempty"`
+	LastRow int `json:",omitempty"`
+	// Columns is the number of columns up to the last one that has a value in any row.
+	Columns int `json:",omitempty"`
+	Cells   int `json:",omitempty"`
+}
+
+// definedName is a defined name, with the name of the sheet it belongs to.
+type definedName struct {
+	Name   string
+	Value  string
+	Sheet  string `json:",omitempty"`
+	Hidden bool   `json:",omitempty"`
+}
+
+// run describes the XLSX file named in args.
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("xlsxinfo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "write the description as JSON")
+	skipRows := flags.Bool("skip-rows", false, "do not read the sheets' rows, which is the slow part for large files")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: xlsxinfo [flags] file.xlsx")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("exactly one XLSX file must be given")
+	}
+	reader, err := xlsxread.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	fileInfo, err := describe(reader, !*skipRows)
+	if err != nil {
+		return err
+	}
+	fileInfo.File = flags.Arg(0)
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(fileInfo)
+	}
+	return writeText(stdout, fileInfo)
+}
+
+// describe reads the description of the file. If readRows is set, every sheet is read to count its rows and columns.
+func describe(reader *xlsxread.Reader, readRows bool) (info, error) {
+	var fileInfo info
+	for i, sheet := range reader.Sheets {
+		sheetInfo := sheetInfo{Name: sheet.Name, State: sheet.State}
+		if readRows {
+			if err := countRows(reader, i, &sheetInfo); err != nil {
+				return info{}, fmt.Errorf("sheet %q: %w", sheet.Name, err)
+			}
+		}
+		fileInfo.Sheets = append(fileInfo.Sheets, sheetInfo)
+	}
+	for _, name := range reader.DefinedNames {
+		definedName := definedName{Name: name.Name, Value: name.Value, Hidden: name.Hidden}
+		if name.SheetIndex >= 0 && name.SheetIndex < len(reader.Sheets) {
+			definedName.Sheet = reader.Sheets[name.SheetIndex].Name
+		}
+		fileInfo.DefinedNames = append(fileInfo.DefinedNames, definedName)
+	}
+	var err error
+	if fileInfo.Styles, err = reader.StyleCounts(); err != nil {
+		return info{}, err
+	}
+	if fileInfo.Properties, err = reader.Properties(); err != nil {
+		return info{}, err
+	}
+	return fileInfo, nil
+}
+
+// countRows reads the rows of the sheet at the index, and counts its rows, columns and cells.
+func countRows(reader *xlsxread.Reader, sheetIndex int, sheetInfo *sheetInfo) error {
+	rows, err := reader.Rows(sheetIndex)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		sheetInfo.Rows++
+		sheetInfo.LastRow = rows.RowNumber()
+		cells := rows.Cells()
+		sheetInfo.Cells += len(cells)
+		if len(cells) > 0 && cells[len(cells)-1].Column >= sheetInfo.Columns {
+			sheetInfo.Columns = cells[len(cells)-1].Column + 1
+		}
+	}
+	return rows.Err()
+}
+
+// writeText writes the description in a form meant for people to read.
+func writeText(w io.Writer, fileInfo info) error {
+	tabs := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tabs, "File:\t%s\n", fileInfo.File)
+	fmt.Fprintf(tabs, "\nSheets:\n")
+	for i, sheet := range fileInfo.Sheets {
+		fmt.Fprintf(tabs, "%d.\t%s\t", i+1, sheet.Name)
+		if sheet.LastRow != 0 {
+			fmt.Fprintf(tabs, "%d rows (last row %d), %d columns, %d cells", sheet.Rows, sheet.LastRow, sheet.Columns,
+				sheet.Cells)
+		}
+		if sheet.State != "" {
+			fmt.Fprintf(tabs, "\t%s", sheet.State)
+		}
+		fmt.Fprintln(tabs)
+	}
+	if len(fileInfo.DefinedNames) > 0 {
+		fmt.Fprintf(tabs, "\nDefined names:\n")
+		for _, name := range fileInfo.DefinedNames {
+			scope := "workbook"
+			if name.Sheet != "" {
+				scope = name.Sheet
+			}
+			hidden := ""
+			if name.Hidden {
+				hidden = "hidden"
+			}
+			fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\n", name.Name, name.Value, scope, hidden)
+		}
+	}
+	styles := fileInfo.Styles
+	fmt.Fprintf(tabs, "\nStyles:\n")
+	fmt.Fprintf(tabs, "Cell styles:\t%d\n", styles.CellStyles)
+	fmt.Fprintf(tabs, "Number formats:\t%d\n", styles.NumberFormats)
+	fmt.Fprintf(tabs, "Fonts:\t%d\n", styles.Fonts)
+	fmt.Fprintf(tabs, "Fills:\t%d\n", styles.Fills)
+	fmt.Fprintf(tabs, "Borders:\t%d\n", styles.Borders)
+	fmt.Fprintf(tabs, "Differential formats:\t%d\n", styles.DifferentialFormats)
+
+	properties := fileInfo.Properties
+	fmt.Fprintf(tabs, "\nProperties:\n")
+	for _, property := range []struct{ name, value string }{
+		{"Title", properties.Title},
+		{"Subject", properties.Subject},
+		{"Creator", properties.Creator},
+		{"Keywords", properties.Keywords},
+		{"Description", properties.Description},
+		{"Last modified by", properties.LastModifiedBy},
+		{"Created", properties.Created},
+		{"Modified", properties.Modified},
+		{"Application", properties.Application},
+		{"App version", properties.AppVersion},
+		{"Company", properties.Company},
+	} {
+		if property.value != "" {
+			fmt.Fprintf(tabs, "%s:\t%s\n", property.name, property.value)
+		}
+	}
+	return tabs.Flush()
+}
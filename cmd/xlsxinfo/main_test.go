@@ -0,0 +1,87 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlsxinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Orders" r:id="rId1"/><sheet name="Lookup" state="hidden" r:id="rId2"/></sheets>` +
+			`<definedNames><definedName name="Codes" localSheetId="1">Lookup!$A:$A</definedName></definedNames></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="worksheet" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>ID</t></is></c><c r="B1" t="inlineStr"><is><t>Total</t></is></c></row>` +
+			`<row r="5"><c r="A5"><v>1</v></c><c r="D5"><v>2</v></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData/></worksheet>`,
+	}
+	name := filepath.Join(dir, "book.xlsx")
+	buffer := bytes.NewBuffer(nil)
+	zipWriter := zip.NewWriter(buffer)
+	for partName, data := range parts {
+		writer, err := zipWriter.Create(partName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, buffer.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := bytes.NewBuffer(nil)
+	if err := run([]string{"-json", name}, stdout, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	var fileInfo info
+	if err := json.Unmarshal(stdout.Bytes(), &fileInfo); err != nil {
+		t.Fatal(err)
+	}
+	expectedSheets := []sheetInfo{
+		{Name: "Orders", Rows: 2, LastRow: 5, Columns: 4, Cells: 4},
+		{Name: "Lookup", State: "hidden"},
+	}
+	if len(fileInfo.Sheets) != 2 || fileInfo.Sheets[0] != expectedSheets[0] || fileInfo.Sheets[1] != expectedSheets[1] {
+		t.Fatalf("Expected %+v, got %+v", expectedSheets, fileInfo.Sheets)
+	}
+	expectedName := definedName{Name: "Codes", Value: "Lookup!$A:$A", Sheet: "Lookup"}
+	if len(fileInfo.DefinedNames) != 1 || fileInfo.DefinedNames[0] != expectedName {
+		t.Fatalf("Expected %+v, got %+v", expectedName, fileInfo.DefinedNames)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-skip-rows", name}, stdout, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"Orders", "Lookup", "hidden", "Codes", "Cell styles:"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Fatalf("Expected %s in %s", expected, stdout.String())
+		}
+	}
+	if strings.Contains(stdout.String(), "rows") {
+		t.Fatalf("Expected no row counts with -skip-rows: %s", stdout.String())
+	}
+
+	if err := run(nil, ioutil.Discard, ioutil.Discard); err == nil {
+		t.Fatal("Expected an error without a file")
+	}
+}
@@ -0,0 +1,337 @@
+// Command xlsxmerge combines CSV and XLSX files into one XLSX file, with one sheet for each input. The rows are
+// streamed from the inputs, so files of any size can be combined with little memory.
+//
+// Usage:
+//
+//	xlsxmerge [flags] -o out.xlsx input...
+//
+// Each input is a CSV or XLSX file, chosen by its extension. An input can be given as name=path to set the name of its
+// sheet, which is otherwise the file name without the extension. XLSX inputs use their first sheet, or the sheet
+// named after a # at the end of the path, such as report.xlsx#Totals.
+//
+// By default the first row of each input is its header. With -no-header, the inputs have no header row and each sheet
+// gets the column letters as its header instead. Rows that are missing from XLSX inputs are left out, and all cells are
+// written as text, with dates formatted by -date-format and -datetime-format.
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ryho/excel_stream"
+	"github.com/ryho/excel_stream/internal/xlsxread"
+)
+
+const stdoutName = "-"
+
+// byteOrderMark is written at the start of CSV files by some programs, such as Excel, to mark them as UTF-8.
+const byteOrderMark = "\ufeff"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "xlsxmerge:", err)
+		os.Exit(1)
+	}
+}
+
+// options are the settings from the flags that control how rows are read.
+type options struct {
+	noHeader       bool
+	dateFormat     string
+	dateTimeFormat string
+}
+
+// input is a file that becomes one sheet of the output.
+type input struct {
+	path      string
+	sheetName string
+	header    []string
+	// Only one of csvReader and xlsxReader is set, depending on the kind of file.
+	csvReader  *csv.Reader
+	xlsxReader *xlsxread.Reader
+	xlsxSheet  int
+	xlsxRows   *xlsxread.Rows
+	closer     io.Closer
+}
+
+// run combines the inputs named in args.
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("xlsxmerge", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "", `the XLSX file to write, or "-" for stdout`)
+	var opts options
+	flags.BoolVar(&opts.noHeader, "no-header", false, "the inputs have no header row, so use column letters as headers")
+	flags.StringVar(&opts.dateFormat, "date-format", "2006-01-02",
+		"the Go time layout for dates from XLSX inputs that have no time of day")
+	flags.StringVar(&opts.dateTimeFormat, "datetime-format", "2006-01-02 15:04:05",
+		"the Go time layout for dates from XLSX inputs that have a time of day")
+	escapeFormulas := flags.Bool("escape-formulas", false,
+		"escape cells that start like a formula, which should be used for inputs from untrusted sources")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: xlsxmerge [flags] -o out.xlsx [name=]file.csv|file.xlsx[#sheet]...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || *output == "" {
+		flags.Usage()
+		return errors.New("an output file and at least one input must be given")
+	}
+
+	inputs := make([]*input, 0, flags.NArg())
+	defer func() {
+		for _, in := range inputs {
+			in.close()
+		}
+	}()
+	for _, arg := range flags.Args() {
+		in, err := openInput(arg, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		inputs = append(inputs, in)
+	}
+
+	var builder *excel_stream.StreamFileBuilder
+	if *output == stdoutName {
+		builder = excel_stream.NewStreamFileBuilder(stdout)
+	} else {
+		var err error
+		if builder, err = excel_stream.NewStreamFileBuilderForPath(*output); err != nil {
+			return err
+		}
+	}
+	if err := configure(builder, *escapeFormulas); err != nil {
+		builder.Abort()
+		return err
+	}
+	for _, in := range inputs {
+		if err := builder.AddSheet(in.sheetName, in.header); err != nil {
+			builder.Abort()
+			return fmt.Errorf("%s: %w", in.path, err)
+		}
+	}
+	file, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	for i, in := range inputs {
+		if i > 0 {
+			if err := file.NextSheet(); err != nil {
+				file.Abort()
+				return err
+			}
+		}
+		if err := in.copyRows(file, opts); err != nil {
+			file.Abort()
+			return fmt.Errorf("%s: %w", in.path, err)
+		}
+	}
+	return file.Close()
+}
+
+// configure sets the builder's policies. Sheet names are always fixed up rather than rejected, since they usually come
+// from file names.
+func configure(builder *excel_stream.StreamFileBuilder, escapeFormulas bool) error {
+	if err := builder.SetSheetNamePolicy(excel_stream.NormalizeInvalidSheetNames); err != nil {
+		return err
+	}
+	if err := builder.SetDeduplicateSheetNames(true); err != nil {
+		return err
+	}
+	if escapeFormulas {
+		return builder.SetFormulaInjectionPolicy(excel_stream.EscapeFormulaPrefixes)
+	}
+	return nil
+}
+
+// openInput opens the input described by the argument and reads its header.
+func openInput(arg string, opts options) (*input, error) {
+	in := &input{path: arg}
+	if i := strings.IndexByte(arg, '='); i != -1 {
+		in.sheetName = arg[:i]
+		in.path = arg[i+1:]
+	}
+	xlsxSheetName := ""
+	if i := strings.LastIndexByte(in.path, '#'); i != -1 && isXLSX(in.path[:i]) {
+		xlsxSheetName = in.path[i+1:]
+		in.path = in.path[:i]
+	}
+	if in.sheetName == "" {
+		base := filepath.Base(in.path)
+		in.sheetName = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	var err error
+	if isXLSX(in.path) {
+		err = in.openXLSX(xlsxSheetName, opts)
+	} else {
+		err = in.openCSV(opts)
+	}
+	if err != nil {
+		in.close()
+		return nil, err
+	}
+	return in, nil
+}
+
+// isXLSX reports whether the path is for an XLSX file.
+func isXLSX(path string) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	return extension == ".xlsx" || extension == ".xlsm"
+}
+
+// openCSV opens a CSV input and reads its first row. Every row of a CSV input must have the same number of fields.
+func (in *input) openCSV(opts options) error {
+	file, err := os.Open(in.path)
+	if err != nil {
+		return err
+	}
+	in.closer = file
+	in.csvReader = csv.NewReader(file)
+	in.csvReader.ReuseRecord = true
+	first, err := in.csvReader.Read()
+	if err == io.EOF {
+		return errors.New("the CSV file is empty")
+	}
+	if err != nil {
+		return err
+	}
+	if opts.noHeader {
+		in.header = columnHeaders(len(first))
+		// The first row is data, so the file is read again from the start.
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		in.csvReader = csv.NewReader(file)
+		in.csvReader.ReuseRecord = true
+		return nil
+	}
+	in.header = append([]string(nil), first...)
+	in.header[0] = strings.TrimPrefix(in.header[0], byteOrderMark)
+	return nil
+}
+
+// openXLSX opens an XLSX input. The sheet is read once to find its number of columns, since XLSX rows can have any
+// number of cells, and then opened again to be copied.
+func (in *input) openXLSX(sheetName string, opts options) error {
+	reader, err := xlsxread.Open(in.path)
+	if err != nil {
+		return err
+	}
+	in.xlsxReader = reader
+	in.closer = reader
+	if sheetName != "" {
+		if in.xlsxSheet, err = reader.SheetIndex(sheetName); err != nil {
+			return fmt.Errorf("%w: %q", err, sheetName)
+		}
+	} else if len(reader.Sheets) == 0 {
+		return errors.New("the workbook has no sheets")
+	}
+	columns, err := in.countColumns()
+	if err != nil {
+		return err
+	}
+	if columns == 0 {
+		return errors.New("the sheet is empty")
+	}
+	if in.xlsxRows, err = reader.Rows(in.xlsxSheet); err != nil {
+		return err
+	}
+	if opts.noHeader {
+		in.header = columnHeaders(columns)
+		return nil
+	}
+	if !in.xlsxRows.Next() {
+		return in.xlsxRows.Err()
+	}
+	in.header, err = rowRecord(in.xlsxRows.Cells(), columns, opts)
+	return err
+}
+
+// countColumns reads the XLSX input's sheet and returns the number of columns up to the last one with a value.
+func (in *input) countColumns() (int, error) {
+	rows, err := in.xlsxReader.Rows(in.xlsxSheet)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	columns := 0
+	for rows.Next() {
+		if cells := rows.Cells(); len(cells) > 0 && cells[len(cells)-1].Column >= columns {
+			columns = cells[len(cells)-1].Column + 1
+		}
+	}
+	return columns, rows.Err()
+}
+
+// copyRows writes the rest of the input's rows to the current sheet.
+func (in *input) copyRows(file *excel_stream.StreamFile, opts options) error {
+	if in.csvReader != nil {
+		for {
+			record, err := in.csvReader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := file.WriteRow(record); err != nil {
+				line, _ := in.csvReader.FieldPos(0)
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+	}
+	for in.xlsxRows.Next() {
+		record, err := rowRecord(in.xlsxRows.Cells(), len(in.header), opts)
+		if err == nil {
+			err = file.WriteRow(record)
+		}
+		if err != nil {
+			return fmt.Errorf("row %d: %w", in.xlsxRows.RowNumber(), err)
+		}
+	}
+	return in.xlsxRows.Err()
+}
+
+// close closes the input's files.
+func (in *input) close() {
+	if in.xlsxRows != nil {
+		in.xlsxRows.Close()
+	}
+	if in.closer != nil {
+		in.closer.Close()
+	}
+}
+
+// rowRecord returns the text of the cells of an XLSX row, with an empty string for each column that has no value.
+func rowRecord(cells []xlsxread.Cell, columns int, opts options) ([]string, error) {
+	record := make([]string, columns)
+	for _, cell := range cells {
+		text, err := cell.Text(opts.dateFormat, opts.dateTimeFormat)
+		if err != nil {
+			return nil, err
+		}
+		record[cell.Column] = text
+	}
+	return record, nil
+}
+
+// columnHeaders returns the letters of the columns, which are used as the header of inputs without one.
+func columnHeaders(columns int) []string {
+	headers := make([]string, columns)
+	for i := range headers {
+		name := ""
+		for n := i + 1; n > 0; n = (n - 1) / 26 {
+			name = string(rune('A'+(n-1)%26)) + name
+		}
+		headers[i] = name
+	}
+	return headers
+}
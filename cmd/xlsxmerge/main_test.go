@@ -0,0 +1,122 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ryho/excel_stream"
+)
+
+// writeTestInputs writes a CSV file and an XLSX file to the directory, and returns their paths.
+func writeTestInputs(t *testing.T, dir string) (string, string) {
+	csvPath := filepath.Join(dir, "users.csv")
+	if err := ioutil.WriteFile(csvPath, []byte("Name,Email\nAda,ada@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Notes" r:id="rId1"/><sheet name="Orders" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="worksheet" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData/></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>ID</t></is></c></row>` +
+			`<row r="2"><c r="A2"><v>1</v></c><c r="C2" t="b"><v>1</v></c></row>` +
+			`<row r="4"><c r="B4" t="inlineStr"><is><t>late</t></is></c></row></sheetData></worksheet>`,
+	}
+	xlsxPath := filepath.Join(dir, "report.xlsx")
+	buffer := bytes.NewBuffer(nil)
+	zipWriter := zip.NewWriter(buffer)
+	for name, data := range parts {
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(xlsxPath, buffer.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return csvPath, xlsxPath
+}
+
+func TestOpenInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlsxmerge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	csvPath, xlsxPath := writeTestInputs(t, dir)
+	testCases := []struct {
+		testName          string
+		arg               string
+		noHeader          bool
+		expectedSheetName string
+		expectedHeader    []string
+	}{
+		{"CSV", csvPath, false, "users", []string{"Name", "Email"}},
+		{"Named CSV", "People=" + csvPath, false, "People", []string{"Name", "Email"}},
+		{"CSV Without Header", csvPath, true, "users", []string{"A", "B"}},
+		{"XLSX Sheet", xlsxPath + "#orders", false, "report", []string{"ID", "", ""}},
+		{"XLSX Without Header", "Totals=" + xlsxPath + "#Orders", true, "Totals", []string{"A", "B", "C"}},
+	}
+	for _, testCase := range testCases {
+		in, err := openInput(testCase.arg, options{noHeader: testCase.noHeader})
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.testName, err)
+		}
+		in.close()
+		if in.sheetName != testCase.expectedSheetName || !reflect.DeepEqual(in.header, testCase.expectedHeader) {
+			t.Fatalf("%s: Expected %s %v, got %s %v", testCase.testName, testCase.expectedSheetName,
+				testCase.expectedHeader, in.sheetName, in.header)
+		}
+	}
+	for _, arg := range []string{xlsxPath, xlsxPath + "#Missing", filepath.Join(dir, "missing.csv")} {
+		if _, err := openInput(arg, options{}); err == nil {
+			t.Fatalf("Expected an error for %s, whose first sheet is empty or missing", arg)
+		}
+	}
+}
+
+func TestColumnHeaders(t *testing.T) {
+	headers := columnHeaders(28)
+	if headers[0] != "A" || headers[25] != "Z" || headers[26] != "AA" || headers[27] != "AB" {
+		t.Fatalf("Unexpected headers %v", headers)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlsxmerge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	csvPath, xlsxPath := writeTestInputs(t, dir)
+	stdout := bytes.NewBuffer(nil)
+	if err := run([]string{"-o", "-", csvPath, "Orders=" + xlsxPath + "#Orders"}, stdout, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	reader := bytes.NewReader(stdout.Bytes())
+	summaries, err := excel_stream.Validate(reader, reader.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []excel_stream.SheetSummary{{Name: "users", Rows: 2}, {Name: "Orders", Rows: 3}}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Fatalf("Expected %v, got %v", expected, summaries)
+	}
+	if err := run([]string{csvPath}, ioutil.Discard, ioutil.Discard); err == nil {
+		t.Fatal("Expected an error without an output file")
+	}
+}
@@ -0,0 +1,352 @@
+package excel_stream
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxColumnWidth is the widest Excel allows a column to be, in characters.
+const maxColumnWidth = 255
+
+var (
+	UnknownColumnTypeError  = errors.New("Unknown column type")
+	InvalidColumnWidthError = errors.New("Column width must be between 0 and 255 characters")
+	NotANumberError         = errors.New("Cell in a number column is not a number")
+	EmptyColumnFormulaError = errors.New("Formula column must have a formula")
+	FormulaCellError        = errors.New("Cell in a formula column must be empty, since its formula fills it in")
+	DynamicArrayColumnError = errors.New("Only a formula column can be a dynamic array")
+)
+
+// ColumnType is the kind of data a column holds.
+type ColumnType int
+
+const (
+	// TextColumn cells are written as text, exactly as they are given. This is the default.
+	TextColumn ColumnType = iota
+	// NumberColumn cells are written as numbers, so that Excel can sum and sort them. Every cell must be empty or a
+	// number that strconv.ParseFloat accepts. Numbers in decimal notation are written with all of their digits, so
+	// DecimalCell, BigFloatCell and BigRatCell can be used for values that need more precision than a float64. NaN and
+	// infinite values are handled by the NonFiniteNumberPolicy.
+	NumberColumn
+	// FormulaColumn cells are filled in by the column's Formula, so that every row has a live computation. The cells
+	// passed to WriteRow for the column must be empty.
+	FormulaColumn
+	// BoolColumn cells are written as booleans, so that Excel shows them as TRUE and FALSE and can filter and count
+	// them. Every cell must be empty, or TRUE, FALSE, 1 or 0 in any case.
+	BoolColumn
+	// DateColumn cells are written as dates, so that Excel can sort and filter them by date. Every cell must be empty,
+	// or a date or date and time in RFC 3339 form, like the Value of a DateCell. DateValue(t).Value gives the form for
+	// a time.Time. The column's Format is the format of its dates, which is "yyyy-mm-dd" if it is empty.
+	DateColumn
+	// CurrencyColumn cells are written as numbers like the cells of a NumberColumn, and shown as amounts of money with
+	// the column's Format, such as "$"#,##0.00 or #,##0.00 [$€-407]. If the Format is empty, they are shown in US
+	// dollars.
+	CurrencyColumn
+	// PercentColumn cells are written as numbers like the cells of a NumberColumn, and shown as percentages, so 0.153
+	// is shown as 15.3%. The column's Format is used if it is set, such as "0%" for whole percentages.
+	PercentColumn
+)
+
+// rowPlaceholder is replaced with the row number in the formulas of formula columns.
+const rowPlaceholder = "{row}"
+
+// cellKind is how the data of a cell is written.
+type cellKind int
+
+const (
+	textCell cellKind = iota
+	numberCell
+	errorCell
+	formulaCell
+	// boolCell and dateCell are text cells that TypeInference writes as booleans and dates.
+	boolCell
+	dateCell
+	// entityCell is a rich value cell, whose value is its vm attribute.
+	entityCell
+)
+
+// ColumnDef declares a column of a sheet and everything about how it is shown.
+type ColumnDef struct {
+	// Name is written in the header row.
+	Name string
+	Type ColumnType
+	// Width is the width of the column in characters. If it is 0, Excel's default width is used.
+	Width float64
+	// Style and Format are applied to every cell of the column below the header. Format is an Excel number format code,
+	// like "0.00%" or "yyyy-mm-dd", and only affects number, currency, percent, formula and date columns.
+	Style  Style
+	Format string
+	Hidden bool
+	// Formula is the formula of a FormulaColumn, without a leading "=", such as C{row}*D{row}. It is written to every
+	// row with {row} replaced by the row's own number. Other references are written as they are, so they refer to the
+	// same cells from every row.
+	Formula string
+	// DynamicArray writes the Formula as a dynamic array formula, the way versions of Excel with FILTER and UNIQUE do.
+	// Formulas that return arrays then spill their results into the empty cells to the right of and below their cell,
+	// instead of Excel adding @ to cut them down to one value. Results that would spill over other values show #SPILL!,
+	// so a formula that returns more than one value should be in the last column, returning one row of values.
+	DynamicArray bool
+	// Total is written for the column in a bold totals row that is added after the last row when the sheet is finished.
+	// The first column without a Total is labeled "Total".
+	Total Aggregate
+	// LinkURL makes every cell of the column that is not empty a link to the URL, with {value} replaced by the cell's
+	// value, such as https://example.com/orders/{value}. Cells without a Style of their own are shown blue and
+	// underlined, like links Excel makes. Excel only allows 65,530 links on a sheet, so cells after that are not links.
+	LinkURL string
+	// Null is what the column's NULL cells are written as.
+	Null NullValue
+	// Unit is added to the header after the name, and sets the number format of the column if it has no Format.
+	Unit Unit
+	// Comment is shown in a note when the mouse is over the column's header cell, to describe what the column holds.
+	Comment string
+	// Pattern and Check are used by WriteRow to check the column's cells before the row is written, to catch data that
+	// does not fit the column before the file is sent on. A cell that does not match the Pattern, or that Check returns
+	// an error for, makes WriteRow return a CellError wrapping ColumnCheckError. Empty cells are not checked. If Check
+	// panics, the error also wraps CallbackPanicError, and nothing more can be written to the StreamFile.
+	Pattern *regexp.Regexp
+	Check   func(value string) error
+}
+
+// sheetColumns holds what is needed to write the cells of a sheet's columns. It only has types and styles for each
+// column for sheets added with AddSheetWithColumns.
+type sheetColumns struct {
+	types []ColumnType
+	// styleIDs are the IDs of the cell styles of the columns, or 0 for columns without one.
+	styleIDs []int
+	// defaultStyleID is the ID of the cell style of the sheet's default row style, for columns without a definition.
+	defaultStyleID int
+	// formulas are the formulas of the formula columns, with the row placeholder still in them. dynamicArrays is set
+	// for the formula columns that are dynamic arrays.
+	formulas      []string
+	dynamicArrays []bool
+	// totals is nil if none of the columns have a total.
+	totals *sheetTotals
+	// group is the current group of rows, for sheets with group subtotals.
+	group *sheetGroup
+	// aggregates sum up the cells written to each column. They are shared with totals, if the sheet has them.
+	aggregates []ColumnAggregate
+	// dateStyleIDs and defaultDateStyleID are the IDs of the cell styles of inferred dates, when dates are inferred.
+	dateStyleIDs       []int
+	defaultDateStyleID int
+	// linkURLs are the LinkURLs of the columns, or empty strings for columns without one.
+	linkURLs []string
+	// nullTexts and nullStyleIDs are the text and the ID of the cell style of the NULL cells of each column.
+	nullTexts    []string
+	nullStyleIDs []int
+	// styles are the styles of the columns, on top of the sheet's default row style, which is defaultStyle. They are
+	// kept for the cells that are written with a number format of their own, whose styles are in formatStyleIDs.
+	styles         []Style
+	defaultStyle   Style
+	formatStyleIDs map[columnFormat]int
+	// mapIndexes maps the header names to the column indexes for WriteRowFromMap. It is nil until the first map row.
+	mapIndexes map[string]int
+	// patterns and checks are the Patterns and Checks of the columns, or nil for columns without one.
+	patterns []*regexp.Regexp
+	checks   []func(value string) error
+}
+
+// AddSheetWithColumns will add a sheet whose columns are described by the column definitions. It works like AddSheet,
+// with the names of the columns as the headers.
+func (sb *StreamFileBuilder) AddSheetWithColumns(name string, columns []ColumnDef) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	headers := make([]string, len(columns))
+	for i := range columns {
+		if err := columns[i].validate(); err != nil {
+			// Set built on error so that all subsequent calls to the builder will also fail.
+			sb.built = true
+			return err
+		}
+		headers[i] = columns[i].header()
+	}
+	if err := sb.addSheet(name, headers); err != nil {
+		return err
+	}
+	sheet := sb.xlsxFile.Sheets[len(sb.xlsxFile.Sheets)-1]
+	for i, col := range sheet.Cols {
+		if i < len(columns) {
+			col.Width = columns[i].Width
+			col.Hidden = columns[i].Hidden
+		}
+	}
+	columns = append([]ColumnDef(nil), columns...)
+	for i := range columns {
+		if columns[i].LinkURL != "" && columns[i].Style == (Style{}) {
+			columns[i].Style = linkStyle
+		}
+	}
+	sb.columnDefs[len(sb.columnDefs)-1] = columns
+	return nil
+}
+
+// SetDefaultRowStyle sets the style of every data row of the named sheet, which must already have been added. The
+// header row keeps the default look. For sheets added with AddSheetWithColumns, each column's Style is applied on top
+// of the row style, so only the settings the column changes are taken from it.
+func (sb *StreamFileBuilder) SetDefaultRowStyle(sheetName string, style Style) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if err := style.validate(); err != nil {
+		return err
+	}
+	for i, sheet := range sb.xlsxFile.Sheets {
+		if sheet.Name == sheetName {
+			sb.rowStyles[i] = style
+			return nil
+		}
+	}
+	return UnknownSheetError
+}
+
+// validate checks the column definition's settings.
+func (def *ColumnDef) validate() error {
+	if def.Type < TextColumn || def.Type > PercentColumn {
+		return UnknownColumnTypeError
+	}
+	if def.Type == FormulaColumn && strings.TrimSpace(strings.TrimPrefix(def.Formula, "=")) == "" {
+		return EmptyColumnFormulaError
+	}
+	if def.DynamicArray && def.Type != FormulaColumn {
+		return DynamicArrayColumnError
+	}
+	if def.Width < 0 || def.Width > maxColumnWidth || math.IsNaN(def.Width) {
+		return InvalidColumnWidthError
+	}
+	if err := def.validateTotal(); err != nil {
+		return err
+	}
+	if err := def.validateLink(); err != nil {
+		return err
+	}
+	if err := def.validateNull(); err != nil {
+		return err
+	}
+	if err := def.validateComment(); err != nil {
+		return err
+	}
+	return def.Style.validate()
+}
+
+// resolveColumns adds the cell styles of the column definitions and the sheet's row style to the style sheet.
+func (s *styleSheet) resolveColumns(columns []ColumnDef, rowStyle Style) (sheetColumns, error) {
+	resolved := sheetColumns{defaultStyle: rowStyle}
+	var err error
+	if resolved.defaultStyleID, err = s.addCellStyle(rowStyle, ""); err != nil {
+		return sheetColumns{}, err
+	}
+	if len(columns) == 0 {
+		return resolved, nil
+	}
+	resolved.types = make([]ColumnType, len(columns))
+	resolved.styles = make([]Style, len(columns))
+	resolved.styleIDs = make([]int, len(columns))
+	resolved.formulas = make([]string, len(columns))
+	resolved.dynamicArrays = make([]bool, len(columns))
+	resolved.linkURLs = make([]string, len(columns))
+	resolved.nullTexts = make([]string, len(columns))
+	resolved.nullStyleIDs = make([]int, len(columns))
+	resolved.patterns = make([]*regexp.Regexp, len(columns))
+	resolved.checks = make([]func(value string) error, len(columns))
+	for i, def := range columns {
+		if def.Type == FormulaColumn {
+			resolved.formulas[i] = strings.TrimPrefix(def.Formula, "=")
+			resolved.dynamicArrays[i] = def.DynamicArray
+		}
+		format := def.numberFormat()
+		styleID, err := s.addCellStyle(def.Style.over(rowStyle), format)
+		if err != nil {
+			return sheetColumns{}, err
+		}
+		resolved.types[i] = def.Type
+		// Currency and percent columns are only number columns with their own format, so they are written the same way.
+		if def.Type == CurrencyColumn || def.Type == PercentColumn {
+			resolved.types[i] = NumberColumn
+		}
+		resolved.styles[i] = def.Style.over(rowStyle)
+		resolved.styleIDs[i] = styleID
+		resolved.linkURLs[i] = def.LinkURL
+		resolved.patterns[i] = def.Pattern
+		resolved.checks[i] = def.Check
+		resolved.nullTexts[i] = def.Null.Text
+		// The null values of number, boolean and date columns have already been checked.
+		switch def.Type {
+		case NumberColumn, CurrencyColumn, PercentColumn:
+			resolved.nullTexts[i], _ = parseNullNumber(def.Null.Text)
+		case BoolColumn:
+			resolved.nullTexts[i], _ = parseBool(def.Null.Text)
+		case DateColumn:
+			resolved.nullTexts[i], _ = parseDate(def.Null.Text)
+		}
+		resolved.nullStyleIDs[i] = styleID
+		if def.Null.Style != (Style{}) {
+			nullStyle := def.Null.Style.over(def.Style.over(rowStyle))
+			if resolved.nullStyleIDs[i], err = s.addCellStyle(nullStyle, format); err != nil {
+				return sheetColumns{}, err
+			}
+		}
+	}
+	if resolved.totals, err = s.resolveTotals(columns, rowStyle); err != nil {
+		return sheetColumns{}, err
+	}
+	return resolved, nil
+}
+
+// columnType returns the type of the column at the index.
+func (c *sheetColumns) columnType(colIndex int) ColumnType {
+	if colIndex < len(c.types) {
+		return c.types[colIndex]
+	}
+	return TextColumn
+}
+
+// formulaXML returns the formula element for the cell of the formula column at the index in the row. Each cell has
+// its own formula, since the last row of the sheet, which a shared formula's range would have to end at, is not known
+// until after the first row has been written. Dynamic array formulas are array formulas whose range is only their own
+// cell, which Excel grows to fit the results when it calculates them.
+func (c *sheetColumns) formulaXML(colIndex, rowNumber int) string {
+	formula := escapeXML(strings.Replace(c.formulas[colIndex], rowPlaceholder, strconv.Itoa(rowNumber), -1))
+	if c.dynamicArrays[colIndex] {
+		return `<f t="array" ref="` + columnName(colIndex) + strconv.Itoa(rowNumber) + `">` + formula + `</f>`
+	}
+	return `<f>` + formula + `</f>`
+}
+
+// cellMetadataAttribute returns the cm attribute of the cells of the column at the index, which marks the cells of
+// dynamic array formula columns, or "" for other columns.
+func (c *sheetColumns) cellMetadataAttribute(colIndex int) string {
+	if colIndex < len(c.dynamicArrays) && c.dynamicArrays[colIndex] {
+		return dynamicArrayCellMetadata
+	}
+	return ""
+}
+
+// style returns the style of the column at the index.
+func (c *sheetColumns) style(colIndex int) Style {
+	if colIndex < len(c.styles) {
+		return c.styles[colIndex]
+	}
+	return c.defaultStyle
+}
+
+// styleAttribute returns the s attribute for the cells of the column at the index, or an empty string if they use the
+// default style.
+func (c *sheetColumns) styleAttribute(colIndex int) string {
+	styleID := c.defaultStyleID
+	if colIndex < len(c.styleIDs) {
+		styleID = c.styleIDs[colIndex]
+	}
+	return styleIDAttribute(styleID)
+}
+
+// styleIDAttribute returns the s attribute for the cell style, or an empty string for the default style, so that
+// cells never have an empty or redundant s attribute.
+func styleIDAttribute(styleID int) string {
+	if styleID == 0 {
+		return ""
+	}
+	return ` s="` + strconv.Itoa(styleID) + `"`
+}
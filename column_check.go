@@ -0,0 +1,36 @@
+package excel_stream
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ColumnCheckError = errors.New("Cell does not pass the check of its column")
+
+// check returns an error that wraps ColumnCheckError if the cell does not match the Pattern of the column at the index
+// or its Check returns an error. Empty cells are not checked, so that columns can still have missing values.
+func (c *sheetColumns) check(colIndex int, cellData string) error {
+	if cellData == "" || colIndex >= len(c.patterns) {
+		return nil
+	}
+	if pattern := c.patterns[colIndex]; pattern != nil && !pattern.MatchString(cellData) {
+		return fmt.Errorf("%w: does not match %s", ColumnCheckError, pattern)
+	}
+	if check := c.checks[colIndex]; check != nil {
+		if err := runCheck(check, cellData); err != nil {
+			return fmt.Errorf("%w: %w", ColumnCheckError, err)
+		}
+	}
+	return nil
+}
+
+// runCheck calls the Check of a column. A panic in it is returned as an error wrapping CallbackPanicError, which
+// WriteRow handles like the other callbacks that panic.
+func runCheck(check func(value string) error, cellData string) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = callbackPanic("column check", value)
+		}
+	}()
+	return check(cellData)
+}
@@ -0,0 +1,88 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var noPizzaError = errors.New("No pizza allowed")
+
+func checkColumns() *sheetColumns {
+	return &sheetColumns{
+		patterns: []*regexp.Regexp{regexp.MustCompile(`^[0-9]+$`), nil},
+		checks: []func(string) error{nil, func(value string) error {
+			if strings.Contains(value, "Pizza") {
+				return noPizzaError
+			}
+			return nil
+		}},
+	}
+}
+
+func TestColumnCheck(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		colIndex      int
+		cellData      string
+		expectedError error
+	}{
+		{testName: "Matches the pattern", colIndex: 0, cellData: "123"},
+		{testName: "Does not match the pattern", colIndex: 0, cellData: "12a", expectedError: ColumnCheckError},
+		{testName: "Empty cells are not checked", colIndex: 0, cellData: ""},
+		{testName: "Passes the check", colIndex: 1, cellData: "Taco"},
+		{testName: "Fails the check", colIndex: 1, cellData: "Pizza", expectedError: noPizzaError},
+		{testName: "Columns without a definition are not checked", colIndex: 2, cellData: "Pizza"},
+	}
+	columns := checkColumns()
+	for _, testCase := range testCases {
+		err := columns.check(testCase.colIndex, testCase.cellData)
+		if testCase.expectedError == nil && err != nil || !errors.Is(err, testCase.expectedError) {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if err != nil && !errors.Is(err, ColumnCheckError) {
+			t.Fatalf("%s: Expected the error to wrap ColumnCheckError, got %v", testCase.testName, err)
+		}
+	}
+}
+
+func TestWriteRowColumnCheck(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	columns := []ColumnDef{
+		{Name: "Token", Pattern: regexp.MustCompile(`^[0-9]+$`)},
+		{Name: "Name", Check: checkColumns().checks[1]},
+	}
+	if err := file.AddSheetWithColumns("Sheet1", columns); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRow([]string{"123", "Pizza"})
+	cellErr, ok := err.(*CellError)
+	if !ok || !errors.Is(err, ColumnCheckError) || !errors.Is(err, noPizzaError) || cellErr.Row != 2 ||
+		cellErr.Column != 1 {
+		t.Fatalf("Expected a CellError for row 2 column 1 wrapping ColumnCheckError, got %v", err)
+	}
+	if err := excelStream.WriteRow([]string{"abc", "Taco"}); !errors.Is(err, ColumnCheckError) {
+		t.Fatalf("Expected ColumnCheckError, got %v", err)
+	}
+	// The rejected rows should not have been written, so the next row should still be row 2.
+	if err := excelStream.WriteRow([]string{"123", "Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	bufReader := bytes.NewReader(buffer.Bytes())
+	_, workbookData := readXLSXFile(t, "", bufReader, bufReader.Size(), false)
+	expectedData := [][][]string{{{"Token", "Name"}, {"123", "Taco"}}}
+	if !reflect.DeepEqual(workbookData, expectedData) {
+		t.Fatalf("Expected workbook data %v, got %v", expectedData, workbookData)
+	}
+}
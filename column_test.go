@@ -0,0 +1,427 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumnDefValidate(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		column        ColumnDef
+		expectedError error
+	}{
+		{testName: "Default", column: ColumnDef{Name: "Name"}},
+		{testName: "Number", column: ColumnDef{Name: "Amount", Type: NumberColumn, Width: 12, Format: "0.00"}},
+		{testName: "Unknown Type", column: ColumnDef{Type: PercentColumn + 1}, expectedError: UnknownColumnTypeError},
+		{testName: "Negative Width", column: ColumnDef{Width: -1}, expectedError: InvalidColumnWidthError},
+		{testName: "Wide", column: ColumnDef{Width: 256}, expectedError: InvalidColumnWidthError},
+		{testName: "NaN Width", column: ColumnDef{Width: math.NaN()}, expectedError: InvalidColumnWidthError},
+		{testName: "Bad Style", column: ColumnDef{Style: Style{FillColor: "red"}}, expectedError: InvalidColorError},
+		{testName: "Formula", column: ColumnDef{Type: FormulaColumn, Formula: "=B{row}*2"}},
+		{testName: "Bool", column: ColumnDef{Name: "In Stock", Type: BoolColumn}},
+		{testName: "Date", column: ColumnDef{Name: "Added", Type: DateColumn, Format: "d mmm yyyy"}},
+		{testName: "No Formula", column: ColumnDef{Type: FormulaColumn, Formula: "="}, expectedError: EmptyColumnFormulaError},
+		{testName: "Dynamic Array", column: ColumnDef{Type: FormulaColumn, Formula: "UNIQUE(A:A)", DynamicArray: true}},
+		{testName: "Dynamic Array Text", column: ColumnDef{DynamicArray: true}, expectedError: DynamicArrayColumnError},
+	}
+	for _, testCase := range testCases {
+		if err := testCase.column.validate(); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestResolveColumns(t *testing.T) {
+	var styles styleSheet
+	styles.setXML(`<styleSheet><fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs></styleSheet>`)
+	columns, err := styles.resolveColumns([]ColumnDef{
+		{Name: "Name"},
+		{Name: "Amount", Type: NumberColumn, Format: "0.00", Style: Style{Bold: true}},
+		{Name: "Total", Type: NumberColumn, Format: "0.00", Style: Style{Bold: true}},
+	}, Style{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns.styleAttribute(0) != "" || columns.styleAttribute(1) != ` s="1"` || columns.styleAttribute(2) != ` s="1"` {
+		t.Fatalf("Expected identical column styles to share an ID: %v", columns.styleIDs)
+	}
+	if columns.columnType(0) != TextColumn || columns.columnType(1) != NumberColumn || columns.columnType(5) != TextColumn {
+		t.Fatalf("Unexpected column types: %v", columns.types)
+	}
+	if columns, err := styles.resolveColumns(nil, Style{}); err != nil || columns.styleAttribute(0) != "" {
+		t.Fatalf("Expected no columns to use the default style, got %v, %v", columns, err)
+	}
+
+	rowStyle := Style{FontSize: 9, Alignment: CenterAlignment}
+	columns, err = styles.resolveColumns(nil, rowStyle)
+	if err != nil || columns.styleAttribute(0) != ` s="2"` || columns.styleAttribute(3) != ` s="2"` {
+		t.Fatalf("Expected every column to use the row style, got %v, %v", columns, err)
+	}
+	columns, err = styles.resolveColumns([]ColumnDef{{Name: "Name"}, {Name: "Amount", Style: Style{Bold: true}}}, rowStyle)
+	if err != nil || columns.styleAttribute(0) != ` s="2"` || columns.styleAttribute(1) != ` s="3"` {
+		t.Fatalf("Expected column styles on top of the row style, got %v, %v", columns, err)
+	}
+}
+
+func TestStyleOver(t *testing.T) {
+	base := Style{FontSize: 9, FontColor: "333333", Alignment: CenterAlignment, Italic: true}
+	style := Style{Bold: true, FontColor: "FF0000"}
+	expected := Style{Bold: true, Italic: true, FontSize: 9, FontColor: "FF0000", Alignment: CenterAlignment}
+	if actual := style.over(base); actual != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, actual)
+	}
+	if actual := (&Style{}).over(base); actual != base {
+		t.Fatalf("Expected the zero style to keep the base, got %+v", actual)
+	}
+}
+
+func TestSetDefaultRowStyle(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name", "Note"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetDefaultRowStyle("Missing", Style{}); err != UnknownSheetError {
+		t.Fatalf("Expected %v, got %v", UnknownSheetError, err)
+	}
+	if err := file.SetDefaultRowStyle("Sheet1", Style{FontSize: 500}); err != InvalidFontSizeError {
+		t.Fatalf("Expected %v, got %v", InvalidFontSizeError, err)
+	}
+	if err := file.SetDefaultRowStyle("Sheet1", Style{FontSize: 9, Alignment: CenterAlignment}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco", "Spicy"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `<c r="A2" s="`) || !strings.Contains(sheetXML, `<c r="B2" s="`) {
+		t.Fatalf("Expected the data cells to have the row style: %s", sheetXML)
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `<sz val="9"/>`) || !strings.Contains(stylesXML, `<alignment horizontal="center"/>`) {
+		t.Fatalf("Expected the row style in %s", stylesXML)
+	}
+}
+
+func TestFormulaXML(t *testing.T) {
+	var styles styleSheet
+	columns, err := styles.resolveColumns([]ColumnDef{
+		{Name: "Price", Type: NumberColumn},
+		{Name: "Total", Type: FormulaColumn, Formula: "=A{row}*$F$1"},
+		{Name: "Label", Type: FormulaColumn, Formula: `IF(B{row}>100,"Big","")`},
+		{Name: "Tags", Type: FormulaColumn, Formula: `TEXTSPLIT(A{row},",")`, DynamicArray: true},
+	}, Style{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<f>A2*$F$1</f>`
+	if actual := columns.formulaXML(1, 2); actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+	expected = `<f>IF(B3&gt;100,&#34;Big&#34;,&#34;&#34;)</f>`
+	if actual := columns.formulaXML(2, 3); actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+	expected = `<f t="array" ref="D3">TEXTSPLIT(A3,&#34;,&#34;)</f>`
+	if actual := columns.formulaXML(3, 3); actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+	if columns.cellMetadataAttribute(2) != "" || columns.cellMetadataAttribute(3) != dynamicArrayCellMetadata {
+		t.Fatal("Expected only the dynamic array column to have cell metadata")
+	}
+}
+
+func TestFormulaColumn(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{
+		{Name: "Quantity", Type: NumberColumn},
+		{Name: "Price", Type: NumberColumn},
+		{Name: "Total", Type: FormulaColumn, Formula: "A{row}*B{row}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRow([]string{"1", "2", "3"})
+	var cellError *CellError
+	if !errors.As(err, &cellError) || cellError.Column != 2 || cellError.Err != FormulaCellError {
+		t.Fatalf("Expected %v for column 2, got %v", FormulaCellError, err)
+	}
+	for _, row := range [][]string{{"2", "3.5", ""}, {"4", "1", ""}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<c r="C2"><f>A2*B2</f></c>`,
+		`<c r="C3"><f>A3*B3</f></c>`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
+
+func TestDynamicArrayColumn(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{
+		{Name: "Order"},
+		{Name: "Items", Type: FormulaColumn, Formula: `TEXTSPLIT(A{row},",")`, DynamicArray: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco,Salsa", ""}, {"Burrito", ""}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<c r="B2" cm="1"><f t="array" ref="B2">TEXTSPLIT(A2,&#34;,&#34;)</f></c>`,
+		`<c r="B3" cm="1"><f t="array" ref="B3">TEXTSPLIT(A3,&#34;,&#34;)</f></c>`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	metadataXML := readZipPart(t, buffer.Bytes(), metadataPath)
+	if !strings.Contains(metadataXML, `<metadataType name="XLDAPR"`) ||
+		!strings.Contains(metadataXML, `<cellMetadata count="1"><bk><rc t="1" v="0"/></bk></cellMetadata>`) {
+		t.Fatalf("Expected dynamic array metadata: %s", metadataXML)
+	}
+	rels := readZipPart(t, buffer.Bytes(), workbookRelsPath)
+	if !strings.Contains(rels, `Target="metadata.xml"`) {
+		t.Fatalf("Expected a relationship to the metadata: %s", rels)
+	}
+}
+
+func TestAddSheetWithColumns(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{
+		{Name: "Name", Width: 30},
+		{Name: "Amount", Type: NumberColumn, Format: "#,##0.000", Style: Style{Alignment: RightAlignment}},
+		{Name: "Notes", Hidden: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco", "1.50", "Spicy"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Burrito", "", ""}); err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRow([]string{"Nacho", "lots", ""})
+	var cellError *CellError
+	if !errors.As(err, &cellError) || cellError.Column != 1 || cellError.Err != NotANumberError {
+		t.Fatalf("Expected %v for column 1, got %v", NotANumberError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<c r="A2" t="inlineStr"><is><t>Taco</t></is></c>`,
+		`"><v>1.50</v></c>`,
+		`<c r="B3" s="`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	if strings.Contains(sheetXML, `r="A4"`) {
+		t.Fatalf("Expected the rejected row to not be written: %s", sheetXML)
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `formatCode="#,##0.000"`) || !strings.Contains(stylesXML, `<alignment horizontal="right"/>`) {
+		t.Fatalf("Expected the column style in %s", stylesXML)
+	}
+
+	file = NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheetWithColumns("Sheet1", []ColumnDef{{Name: "Name", Width: -1}}); err != InvalidColumnWidthError {
+		t.Fatalf("Expected %v, got %v", InvalidColumnWidthError, err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Name"}); err != BuiltExcelStreamBuilderError {
+		t.Fatalf("Expected %v, got %v", BuiltExcelStreamBuilderError, err)
+	}
+}
+
+func TestWriteBoolColumn(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{{Name: "Name"}, {Name: "In Stock", Type: BoolColumn}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco", "true"}, {"Salsa", "0"}, {"Nachos", ""}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = excelStream.WriteRow([]string{"Burrito", "yes"})
+	var cellError *CellError
+	if !errors.As(err, &cellError) || cellError.Column != 1 || cellError.Err != InvalidBoolCellError {
+		t.Fatalf("Expected %v for column 1, got %v", InvalidBoolCellError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{`<c r="B2" t="b"><v>1</v></c>`, `<c r="B3" t="b"><v>0</v></c>`} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	if strings.Contains(sheetXML, `r="B4"`) {
+		t.Fatalf("Expected the empty cell to be left out: %s", sheetXML)
+	}
+}
+
+func TestWriteDateColumn(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{{Name: "Name"}, {Name: "Added", Type: DateColumn}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := time.Date(1900, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, row := range [][]string{{"Taco", DateValue(added).Value}, {"Salsa", "1900-02-28"}, {"Nachos", ""}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = excelStream.WriteRow([]string{"Burrito", "1899-12-31"})
+	var cellError *CellError
+	if !errors.As(err, &cellError) || cellError.Column != 1 || cellError.Err != InvalidDateCellError {
+		t.Fatalf("Expected %v for column 1, got %v", InvalidDateCellError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	// Excel counts February 29, 1900, so March 1 is two days after February 28.
+	for _, expected := range []string{`"><v>61</v></c>`, `"><v>59</v></c>`, `<c r="B4" s="`} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `formatCode="yyyy-mm-dd"`) {
+		t.Fatalf("Expected the default date format in %s", stylesXML)
+	}
+}
+
+func TestWriteCurrencyColumn(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	columns := []ColumnDef{
+		{Name: "Name"},
+		{Name: "Price", Type: CurrencyColumn},
+		{Name: "Price EUR", Type: CurrencyColumn, Format: `#,##0.00 [$€-407]`},
+	}
+	if err := file.AddSheetWithColumns("Sheet1", columns); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco", "1.50", "1.25"}); err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRow([]string{"Salsa", "cheap", ""})
+	var cellError *CellError
+	if !errors.As(err, &cellError) || cellError.Column != 1 || cellError.Err != NotANumberError {
+		t.Fatalf("Expected %v for column 1, got %v", NotANumberError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `"><v>1.50</v></c>`) || !strings.Contains(sheetXML, `"><v>1.25</v></c>`) {
+		t.Fatalf("Expected the amounts as numbers in %s", sheetXML)
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `formatCode="[$$-409]#,##0.00"`) ||
+		!strings.Contains(stylesXML, `formatCode="#,##0.00 [$€-407]"`) {
+		t.Fatalf("Expected the currency formats in %s", stylesXML)
+	}
+}
+
+func TestWritePercentColumn(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{{Name: "Name"}, {Name: "Share", Type: PercentColumn}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco", "0.153"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowCells([]Cell{{Value: "Salsa"}, PercentValue(0.5)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `"><v>0.153</v></c>`) || !strings.Contains(sheetXML, `"><v>0.5</v></c>`) {
+		t.Fatalf("Expected the fractions as numbers in %s", sheetXML)
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `formatCode="0.0%"`) {
+		t.Fatalf("Expected the percent format in %s", stylesXML)
+	}
+}
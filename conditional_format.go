@@ -0,0 +1,113 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	EmptyConditionalFormulaError = errors.New("Conditional format formula must not be empty")
+	EmptyHighlightError          = errors.New("Highlight must change at least one part of the cells' format")
+)
+
+// ConditionalFormat describes a rule that highlights cells when a formula is true for them, such as highlighting a
+// whole row when its status column says FAILED.
+type ConditionalFormat struct {
+	// Formula is checked for every cell in the range, without a leading "=". It is written as if it were for the top
+	// left cell of the range, and its relative references shift for the other cells like a copied formula does. Use $ to
+	// stop a reference from shifting, so $H2="FAILED" checks column H of each cell's own row.
+	Formula string
+	// FirstColumn and LastColumn are the indexes of the first and last columns of the range, which start at 0.
+	FirstColumn int
+	LastColumn  int
+	// FirstRow and LastRow are the Excel row numbers of the first and last rows of the range, which start at 1. If
+	// LastRow is 0, the range continues to the end of the sheet, so it covers every row that is written.
+	FirstRow int
+	LastRow  int
+	// Highlight is the format applied to the cells the formula is true for.
+	Highlight Highlight
+	// StopIfTrue stops the rules added after this one from being checked for cells that this rule highlights.
+	StopIfTrue bool
+}
+
+// Highlight is a change to the format of cells. Parts of the format that are not set are left as they are.
+type Highlight struct {
+	// FontColor and FillColor are six digit hexadecimal RGB colors, such as FFC7CE for light red.
+	FontColor string
+	FillColor string
+	Bold      bool
+	Italic    bool
+}
+
+// AddConditionalFormat adds a formula-based conditional format to the named sheet. Rules are checked in the order
+// they are added. The sheet must be the current sheet or one that has not been started yet.
+func (sf *StreamFile) AddConditionalFormat(sheetName string, format ConditionalFormat) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	formula := strings.TrimPrefix(format.Formula, "=")
+	if strings.TrimSpace(formula) == "" {
+		return EmptyConditionalFormulaError
+	}
+	cells, err := rangeReference(format.FirstColumn, format.FirstRow, format.LastColumn, format.LastRow)
+	if err != nil {
+		return err
+	}
+	dxf, err := format.Highlight.xml()
+	if err != nil {
+		return err
+	}
+	dxfID := sf.styles.addDxf(dxf)
+
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	extras.conditionalFormats++
+	stopIfTrue := ""
+	if format.StopIfTrue {
+		stopIfTrue = ` stopIfTrue="1"`
+	}
+	sf.addSheetElement(sheetArrayIndex, "conditionalFormatting", `<conditionalFormatting sqref="`+cells+`">`+
+		`<cfRule type="expression" dxfId="`+strconv.Itoa(dxfID)+`" priority="`+strconv.Itoa(extras.conditionalFormats)+
+		`"`+stopIfTrue+`><formula>`+escapeXML(formula)+`</formula></cfRule></conditionalFormatting>`)
+	return nil
+}
+
+// xml returns the differential format for the highlight.
+func (h *Highlight) xml() (string, error) {
+	for _, color := range []string{h.FontColor, h.FillColor} {
+		if color != "" && !isHexColor(color) {
+			return "", InvalidColorError
+		}
+	}
+	if h.FontColor == "" && h.FillColor == "" && !h.Bold && !h.Italic {
+		return "", EmptyHighlightError
+	}
+	dxf := `<dxf>`
+	if h.FontColor != "" || h.Bold || h.Italic {
+		dxf += `<font>`
+		if h.Bold {
+			dxf += `<b/>`
+		}
+		if h.Italic {
+			dxf += `<i/>`
+		}
+		if h.FontColor != "" {
+			dxf += `<color rgb="FF` + strings.ToUpper(h.FontColor) + `"/>`
+		}
+		dxf += `</font>`
+	}
+	if h.FillColor != "" {
+		// Differential fills set the background color, unlike the fills of cell styles.
+		dxf += `<fill><patternFill patternType="solid"><bgColor rgb="FF` + strings.ToUpper(h.FillColor) +
+			`"/></patternFill></fill>`
+	}
+	return dxf + `</dxf>`, nil
+}
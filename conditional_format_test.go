@@ -0,0 +1,99 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHighlightXML(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		highlight     Highlight
+		expectedXML   string
+		expectedError error
+	}{
+		{
+			testName:    "Fill",
+			highlight:   Highlight{FillColor: "ffc7ce"},
+			expectedXML: `<dxf><fill><patternFill patternType="solid"><bgColor rgb="FFFFC7CE"/></patternFill></fill></dxf>`,
+		},
+		{
+			testName:    "Font",
+			highlight:   Highlight{FontColor: "9C0006", Bold: true, Italic: true},
+			expectedXML: `<dxf><font><b/><i/><color rgb="FF9C0006"/></font></dxf>`,
+		},
+		{testName: "Empty", expectedError: EmptyHighlightError},
+		{testName: "Bad Color", highlight: Highlight{FillColor: "red"}, expectedError: InvalidColorError},
+	}
+	for _, testCase := range testCases {
+		actual, err := testCase.highlight.xml()
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if actual != testCase.expectedXML {
+			t.Fatalf("%s: Expected %s, got %s", testCase.testName, testCase.expectedXML, actual)
+		}
+	}
+}
+
+func TestAddConditionalFormat(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Status"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := ConditionalFormat{
+		Formula:    `=$B2="FAILED"`,
+		LastColumn: 1,
+		FirstRow:   2,
+		Highlight:  Highlight{FillColor: "FFC7CE"},
+		StopIfTrue: true,
+	}
+	errorCases := []struct {
+		testName      string
+		change        func(format *ConditionalFormat)
+		expectedError error
+	}{
+		{"Empty Formula", func(format *ConditionalFormat) { format.Formula = "=" }, EmptyConditionalFormulaError},
+		{"Backwards", func(format *ConditionalFormat) { format.LastRow = 1 }, BackwardsRangeError},
+		{"Row Zero", func(format *ConditionalFormat) { format.FirstRow = 0 }, RowOutOfRangeError},
+		{"Empty Highlight", func(format *ConditionalFormat) { format.Highlight = Highlight{} }, EmptyHighlightError},
+	}
+	for _, errorCase := range errorCases {
+		format := failed
+		errorCase.change(&format)
+		if err := excelStream.AddConditionalFormat("Sheet1", format); err != errorCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", errorCase.testName, errorCase.expectedError, err)
+		}
+	}
+	if err := excelStream.AddConditionalFormat("Sheet1", failed); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.AddConditionalFormat("Sheet1", ConditionalFormat{
+		Formula: `$B2="SKIPPED"`, LastColumn: 1, FirstRow: 2, LastRow: 10, Highlight: Highlight{Italic: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123", "FAILED"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	expected := `<conditionalFormatting sqref="A2:B1048576"><cfRule type="expression" dxfId="0" priority="1"` +
+		` stopIfTrue="1"><formula>$B2=&#34;FAILED&#34;</formula></cfRule></conditionalFormatting>` +
+		`<conditionalFormatting sqref="A2:B10"><cfRule type="expression" dxfId="1" priority="2">`
+	if !strings.Contains(sheetXML, expected) {
+		t.Fatalf("Expected %s in %s", expected, sheetXML)
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `<dxfs count="2">`) {
+		t.Fatalf("Expected the highlights in %s", stylesXML)
+	}
+}
@@ -0,0 +1,77 @@
+package excel_stream
+
+import (
+	"errors"
+	"strings"
+)
+
+// XMLDeclaration is the XML declaration at the start of each sheet.
+type XMLDeclaration int
+
+const (
+	// LibraryXMLDeclaration keeps the declaration the XLSX library writes. This is the default.
+	LibraryXMLDeclaration XMLDeclaration = iota
+	// StandaloneXMLDeclaration writes the declaration Excel writes, which marks the XML as standalone.
+	StandaloneXMLDeclaration
+	// OmitXMLDeclaration leaves out the declaration, which is allowed since sheets are UTF-8.
+	OmitXMLDeclaration
+)
+
+var UnknownXMLDeclarationError = errors.New("Unknown XML declaration")
+
+// XMLConformance controls the form of the XML of the sheets, for strict consumers that only accept some of the forms
+// that are valid. The zero XMLConformance keeps the forms the library writes by default.
+type XMLConformance struct {
+	Declaration XMLDeclaration
+	// RootNamespaces declares the relationships namespace once on each sheet's worksheet element, like Excel does,
+	// instead of on each of the elements added to the sheet that refer to other parts, such as images.
+	RootNamespaces bool
+}
+
+// SetXMLConformance sets the form of the XML of the sheets. Only the sheets are affected, since the other parts are
+// written by the XLSX library or are small enough to be rewritten by the consumer.
+func (sb *StreamFileBuilder) SetXMLConformance(conformance XMLConformance) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if conformance.Declaration < LibraryXMLDeclaration || conformance.Declaration > OmitXMLDeclaration {
+		return UnknownXMLDeclarationError
+	}
+	sb.xmlConformance = conformance
+	return nil
+}
+
+// conformPrefix returns the start of a sheet's XML in the form the conformance asks for.
+func (c *XMLConformance) conformPrefix(prefix string) string {
+	if c.Declaration != LibraryXMLDeclaration && strings.HasPrefix(prefix, `<?xml`) {
+		end := strings.Index(prefix, `?>`)
+		if end != -1 {
+			body := strings.TrimLeft(prefix[end+len(`?>`):], "\r\n")
+			if c.Declaration == StandaloneXMLDeclaration {
+				body = xmlHeader + body
+			}
+			prefix = body
+		}
+	}
+	if !c.RootNamespaces {
+		return prefix
+	}
+	root := findElement(prefix, "worksheet")
+	if root == -1 {
+		return prefix
+	}
+	rootEnd := root + strings.IndexByte(prefix[root:], '>')
+	if strings.Contains(prefix[root:rootEnd], ` xmlns:r=`) {
+		return prefix
+	}
+	return prefix[:root+len(`<worksheet`)] + ` xmlns:r="` + relationshipsNamespace + `"` +
+		prefix[root+len(`<worksheet`):]
+}
+
+// conformSuffix returns the end of a sheet's XML in the form the conformance asks for.
+func (c *XMLConformance) conformSuffix(suffix string) string {
+	if !c.RootNamespaces {
+		return suffix
+	}
+	return strings.Replace(suffix, ` xmlns:r="`+relationshipsNamespace+`"`, "", -1)
+}
@@ -0,0 +1,76 @@
+package excel_stream
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConformPrefix(t *testing.T) {
+	prefix := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	worksheet := `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	rootWorksheet := `<worksheet xmlns:r="` + relationshipsNamespace +
+		`" xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	testCases := []struct {
+		testName    string
+		conformance XMLConformance
+		prefix      string
+		expected    string
+	}{
+		{testName: "Default", prefix: prefix, expected: prefix},
+		{
+			testName:    "Standalone",
+			conformance: XMLConformance{Declaration: StandaloneXMLDeclaration},
+			prefix:      prefix,
+			expected:    xmlHeader + worksheet,
+		},
+		{
+			testName:    "Omitted",
+			conformance: XMLConformance{Declaration: OmitXMLDeclaration},
+			prefix:      prefix,
+			expected:    worksheet,
+		},
+		{
+			testName:    "Root Namespaces",
+			conformance: XMLConformance{Declaration: OmitXMLDeclaration, RootNamespaces: true},
+			prefix:      prefix,
+			expected:    rootWorksheet,
+		},
+		{
+			testName:    "Already Declared",
+			conformance: XMLConformance{RootNamespaces: true},
+			prefix:      rootWorksheet,
+			expected:    rootWorksheet,
+		},
+	}
+	for _, testCase := range testCases {
+		if actual := testCase.conformance.conformPrefix(testCase.prefix); actual != testCase.expected {
+			t.Fatalf("%s: Expected %s, got %s", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestConformSuffix(t *testing.T) {
+	suffix := `</sheetData><drawing xmlns:r="` + relationshipsNamespace + `" r:id="rId1"></drawing></worksheet>`
+	if actual := (&XMLConformance{}).conformSuffix(suffix); actual != suffix {
+		t.Fatalf("Expected no change, got %s", actual)
+	}
+	expected := `</sheetData><drawing r:id="rId1"></drawing></worksheet>`
+	if actual := (&XMLConformance{RootNamespaces: true}).conformSuffix(suffix); actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestSetXMLConformance(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	err := file.SetXMLConformance(XMLConformance{Declaration: OmitXMLDeclaration + 1})
+	if err != UnknownXMLDeclarationError {
+		t.Fatalf("Expected %v, got %v", UnknownXMLDeclarationError, err)
+	}
+	if err := file.SetXMLConformance(XMLConformance{Declaration: StandaloneXMLDeclaration}); err != nil {
+		t.Fatal(err)
+	}
+	if file.xmlConformance.Declaration != StandaloneXMLDeclaration {
+		t.Fatalf("Expected the conformance to be set, got %v", file.xmlConformance)
+	}
+}
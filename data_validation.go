@@ -0,0 +1,127 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxValidationFormulaLength is the length Excel allows for a data validation formula.
+	maxValidationFormulaLength = 255
+	// maxValidationTitleLength and maxValidationMessageLength are the lengths Excel allows for the titles and text of
+	// the messages shown by data validations.
+	maxValidationTitleLength   = 32
+	maxValidationMessageLength = 255
+)
+
+var (
+	EmptyValidationFormulaError   = errors.New("Data validation formula must not be empty")
+	ValidationFormulaTooLongError = errors.New("Data validation formula must not be longer than 255 characters")
+	ValidationMessageTooLongError = errors.New("Data validation titles must not be longer than 32 characters, and messages must not be longer than 255 characters")
+)
+
+// DataValidation describes a rule that limits what can be typed into cells, for templates that are filled in and
+// imported again.
+type DataValidation struct {
+	// Formula must be true for a value to be accepted, without a leading "=". It is written as if it were for the top
+	// left cell of the range, and its relative references shift for the other cells like a copied formula does. For
+	// example, AND(ISNUMBER(A2),LEN(B2)<50) for column A.
+	Formula string
+	// FirstColumn and LastColumn are the indexes of the first and last columns of the range, which start at 0.
+	FirstColumn int
+	LastColumn  int
+	// FirstRow and LastRow are the Excel row numbers of the first and last rows of the range, which start at 1. If
+	// LastRow is 0, the range continues to the end of the sheet, so it also covers rows that are added in Excel.
+	FirstRow int
+	LastRow  int
+	// List makes the cells a dropdown of the values in the range that Formula refers to, such as the name of a lookup
+	// sheet's data or 'Sheet 2'!$A$2:$A$10, instead of a rule.
+	List bool
+	// RejectBlank makes empty cells fail the rule. By default, they are always accepted.
+	RejectBlank bool
+	// PromptTitle and Prompt are shown when one of the cells is selected, if Prompt is set.
+	PromptTitle string
+	Prompt      string
+	// ErrorTitle and ErrorMessage are shown when a value is rejected. If ErrorMessage is empty, Excel's default
+	// message is shown.
+	ErrorTitle   string
+	ErrorMessage string
+}
+
+// AddDataValidation adds a formula-based data validation to the named sheet. The sheet must be the current sheet or one
+// that has not been started yet.
+// Data validation only checks values as they are typed in Excel. The rows written to the sheet are not checked.
+func (sf *StreamFile) AddDataValidation(sheetName string, validation DataValidation) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	validationXML, err := validation.xml()
+	if err != nil {
+		return err
+	}
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	extras.dataValidations = append(extras.dataValidations, validationXML)
+	return nil
+}
+
+// xml returns the dataValidation element for the validation.
+func (v *DataValidation) xml() (string, error) {
+	formula := strings.TrimPrefix(v.Formula, "=")
+	if strings.TrimSpace(formula) == "" {
+		return "", EmptyValidationFormulaError
+	}
+	if utf8.RuneCountInString(formula) > maxValidationFormulaLength {
+		return "", ValidationFormulaTooLongError
+	}
+	if utf8.RuneCountInString(v.PromptTitle) > maxValidationTitleLength ||
+		utf8.RuneCountInString(v.ErrorTitle) > maxValidationTitleLength ||
+		utf8.RuneCountInString(v.Prompt) > maxValidationMessageLength ||
+		utf8.RuneCountInString(v.ErrorMessage) > maxValidationMessageLength {
+		return "", ValidationMessageTooLongError
+	}
+	cells, err := rangeReference(v.FirstColumn, v.FirstRow, v.LastColumn, v.LastRow)
+	if err != nil {
+		return "", err
+	}
+	validationXML := `<dataValidation type="custom"`
+	if v.List {
+		validationXML = `<dataValidation type="list"`
+	}
+	if !v.RejectBlank {
+		validationXML += ` allowBlank="1"`
+	}
+	validationXML += ` showInputMessage="1" showErrorMessage="1"`
+	if v.ErrorTitle != "" {
+		validationXML += xmlAttribute("errorTitle", v.ErrorTitle)
+	}
+	if v.ErrorMessage != "" {
+		validationXML += xmlAttribute("error", v.ErrorMessage)
+	}
+	if v.PromptTitle != "" {
+		validationXML += xmlAttribute("promptTitle", v.PromptTitle)
+	}
+	if v.Prompt != "" {
+		validationXML += xmlAttribute("prompt", v.Prompt)
+	}
+	return validationXML + ` sqref="` + cells + `"><formula1>` + escapeXML(formula) + `</formula1></dataValidation>`, nil
+}
+
+// renderDataValidations returns the dataValidations element holding the validations, or an empty string if there are
+// none.
+func renderDataValidations(validations []string) string {
+	if len(validations) == 0 {
+		return ""
+	}
+	return `<dataValidations count="` + strconv.Itoa(len(validations)) + `">` + strings.Join(validations, "") +
+		`</dataValidations>`
+}
@@ -0,0 +1,89 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDataValidationXML(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		validation    DataValidation
+		expectedXML   string
+		expectedError error
+	}{
+		{
+			testName:   "Column",
+			validation: DataValidation{Formula: "=AND(ISNUMBER(A2),LEN(B2)<50)", FirstRow: 2},
+			expectedXML: `<dataValidation type="custom" allowBlank="1" showInputMessage="1" showErrorMessage="1"` +
+				` sqref="A2:A1048576"><formula1>AND(ISNUMBER(A2),LEN(B2)&lt;50)</formula1></dataValidation>`,
+		},
+		{
+			testName: "Messages",
+			validation: DataValidation{Formula: "B2>0", FirstColumn: 1, LastColumn: 2, FirstRow: 2, LastRow: 5,
+				RejectBlank: true, PromptTitle: "Amount", Prompt: "Enter an amount", ErrorMessage: `Must be "positive"`},
+			expectedXML: `<dataValidation type="custom" showInputMessage="1" showErrorMessage="1"` +
+				` error="Must be &#34;positive&#34;" promptTitle="Amount" prompt="Enter an amount" sqref="B2:C5">` +
+				`<formula1>B2&gt;0</formula1></dataValidation>`,
+		},
+		{testName: "Empty", validation: DataValidation{Formula: " ", FirstRow: 2}, expectedError: EmptyValidationFormulaError},
+		{
+			testName:      "Long Formula",
+			validation:    DataValidation{Formula: strings.Repeat("A", 256), FirstRow: 2},
+			expectedError: ValidationFormulaTooLongError,
+		},
+		{
+			testName:      "Long Title",
+			validation:    DataValidation{Formula: "A2", FirstRow: 2, ErrorTitle: strings.Repeat("A", 33)},
+			expectedError: ValidationMessageTooLongError,
+		},
+		{
+			testName:   "List",
+			validation: DataValidation{Formula: "Products", List: true, FirstColumn: 1, LastColumn: 1, FirstRow: 2},
+			expectedXML: `<dataValidation type="list" allowBlank="1" showInputMessage="1" showErrorMessage="1"` +
+				` sqref="B2:B1048576"><formula1>Products</formula1></dataValidation>`,
+		},
+		{
+			testName:      "Backwards",
+			validation:    DataValidation{Formula: "A2", FirstColumn: 2, LastColumn: 1, FirstRow: 2},
+			expectedError: BackwardsRangeError,
+		},
+	}
+	for _, testCase := range testCases {
+		actual, err := testCase.validation.xml()
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if actual != testCase.expectedXML {
+			t.Fatalf("%s: Expected %s, got %s", testCase.testName, testCase.expectedXML, actual)
+		}
+	}
+}
+
+func TestAddDataValidation(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Amount", "Note"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, formula := range []string{"ISNUMBER(A2)", "LEN(B2)<50"} {
+		column := len(formula) % 2
+		if err := excelStream.AddDataValidation("Sheet1", DataValidation{
+			Formula: formula, FirstColumn: column, LastColumn: column, FirstRow: 2,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if strings.Count(sheetXML, "<dataValidations ") != 1 || !strings.Contains(sheetXML, `<dataValidations count="2">`) {
+		t.Fatalf("Expected both validations in one element: %s", sheetXML)
+	}
+}
@@ -0,0 +1,67 @@
+package excel_stream
+
+import (
+	"math/big"
+)
+
+// Decimal is implemented by arbitrary precision decimal types, such as shopspring/decimal's Decimal, whose String
+// method returns the exact value in decimal notation.
+type Decimal interface {
+	String() string
+}
+
+// DecimalCell returns the cell data for a decimal, for a NumberColumn. The digits are written to the file exactly as
+// the decimal gives them, so no precision is lost to float64 on the way. Excel itself keeps 15 significant digits of
+// every number, but other programs reading the file get the exact value.
+func DecimalCell(d Decimal) string {
+	return d.String()
+}
+
+// BigFloatCell returns the cell data for a big.Float, for a NumberColumn. It uses the fewest digits that give back the
+// same value at the float's precision.
+func BigFloatCell(f *big.Float) string {
+	return f.Text('g', -1)
+}
+
+// BigRatCell returns the cell data for a big.Rat, for a NumberColumn, rounded to the number of digits after the decimal
+// point. For amounts of money, use the number of digits of the currency's smallest unit.
+func BigRatCell(r *big.Rat, decimals int) string {
+	return r.FloatString(decimals)
+}
+
+// isDecimalLiteral reports whether the text is a number in plain decimal notation, with an optional sign, fraction and
+// exponent, such as -12.50 or 1.5e-3. These numbers can be written to the file as they are, which keeps all of their
+// digits.
+func isDecimalLiteral(text string) bool {
+	i := 0
+	if i < len(text) && (text[i] == '+' || text[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(text) && text[i] >= '0' && text[i] <= '9'; i++ {
+		digits++
+	}
+	if i < len(text) && text[i] == '.' {
+		i++
+		for ; i < len(text) && text[i] >= '0' && text[i] <= '9'; i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(text) && (text[i] == 'e' || text[i] == 'E') {
+		i++
+		if i < len(text) && (text[i] == '+' || text[i] == '-') {
+			i++
+		}
+		exponentDigits := 0
+		for ; i < len(text) && text[i] >= '0' && text[i] <= '9'; i++ {
+			exponentDigits++
+		}
+		if exponentDigits == 0 {
+			return false
+		}
+	}
+	return i == len(text)
+}
@@ -0,0 +1,62 @@
+package excel_stream
+
+import (
+	"math/big"
+	"testing"
+)
+
+// testDecimal stands in for decimal types like shopspring/decimal's Decimal.
+type testDecimal string
+
+func (d testDecimal) String() string {
+	return string(d)
+}
+
+func TestDecimalCells(t *testing.T) {
+	if actual := DecimalCell(testDecimal("1234567890123456789.01")); actual != "1234567890123456789.01" {
+		t.Fatalf("Expected the decimal's digits, got %s", actual)
+	}
+	f, _, err := big.ParseFloat("1234567890123456789.01", 10, 128, big.ToNearestEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := BigFloatCell(f); actual != "1.23456789012345678901e+18" {
+		t.Fatalf("Expected all of the float's digits, got %s", actual)
+	}
+	if actual := BigRatCell(big.NewRat(-1, 3), 2); actual != "-0.33" {
+		t.Fatalf("Expected the rational rounded to 2 digits, got %s", actual)
+	}
+	for _, cell := range []string{DecimalCell(testDecimal("-0.10")), BigFloatCell(f), BigRatCell(big.NewRat(5, 4), 4)} {
+		if !isDecimalLiteral(cell) {
+			t.Fatalf("Expected %s to be written as it is", cell)
+		}
+	}
+}
+
+func TestIsDecimalLiteral(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected bool
+	}{
+		{text: "0", expected: true},
+		{text: "-12.50", expected: true},
+		{text: "+.5", expected: true},
+		{text: "5.", expected: true},
+		{text: "1.5E-3", expected: true},
+		{text: "1e+21", expected: true},
+		{text: ""},
+		{text: "."},
+		{text: "-"},
+		{text: "1e"},
+		{text: "e5"},
+		{text: "0x1p-2"},
+		{text: "1_000"},
+		{text: "Inf"},
+		{text: " 1"},
+	}
+	for _, testCase := range testCases {
+		if actual := isDecimalLiteral(testCase.text); actual != testCase.expected {
+			t.Fatalf("Expected isDecimalLiteral(%q) to be %v", testCase.text, testCase.expected)
+		}
+	}
+}
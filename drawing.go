@@ -0,0 +1,111 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	drawingPathPrefix       = "xl/drawings/drawing"
+	drawingRelsPathPrefix   = "xl/drawings/_rels/drawing"
+	drawingContentType      = "application/vnd.openxmlformats-officedocument.drawing+xml"
+	drawingRelationshipType = relationshipsNamespace + "/drawing"
+	spreadsheetDrawingNS    = "http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing"
+	drawingMLNamespace      = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	// emusPerPixel converts pixels to the English Metric Units that drawings are measured in.
+	emusPerPixel = 9525
+)
+
+var InvalidAnchorSizeError = errors.New("Anchor width and height must both be positive, or both be 0 to use the default size")
+
+// sheetDrawing holds the objects that float over a sheet, like images and charts. Each sheet has at most one drawing
+// part, which is written when the StreamFile is closed.
+type sheetDrawing struct {
+	// anchors holds the XML for each object along with the position it is anchored to.
+	anchors []string
+	// relationships link the drawing to the parts holding its objects' data.
+	relationships []relationship
+}
+
+// drawing returns the drawing for the sheet, creating it and linking it to the sheet if it does not exist yet.
+func (sf *StreamFile) drawing(sheetArrayIndex int) *sheetDrawing {
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	if extras.drawing == nil {
+		extras.drawing = &sheetDrawing{}
+		drawingNumber := strconv.Itoa(sheetArrayIndex + 1)
+		id := sf.addSheetRelationship(sheetArrayIndex, drawingRelationshipType, "../drawings/drawing"+drawingNumber+".xml")
+		sf.addSheetElement(sheetArrayIndex, "drawing",
+			`<drawing xmlns:r="`+relationshipsNamespace+`" r:id="`+id+`"></drawing>`)
+		sf.contentTypes.addOverride(drawingPathPrefix+drawingNumber+".xml", drawingContentType)
+	}
+	return extras.drawing
+}
+
+// addRelationship adds a relationship from the drawing to the target, which is relative to the drawings directory, and
+// returns its ID.
+func (d *sheetDrawing) addRelationship(relType, target string) string {
+	id := "rId" + strconv.Itoa(len(d.relationships)+1)
+	d.relationships = append(d.relationships, relationship{id: id, relType: relType, target: target})
+	return id
+}
+
+// nextObjectID returns the ID for the next object in the drawing. IDs must be unique within the drawing, and 1 is
+// reserved for the drawing itself.
+func (d *sheetDrawing) nextObjectID() int {
+	return len(d.anchors) + 2
+}
+
+// addDrawingParts adds the parts for the sheet's drawing, if it has one.
+func (sf *StreamFile) addDrawingParts(sheetArrayIndex int) {
+	drawing := sf.sheetExtras[sheetArrayIndex].drawing
+	if drawing == nil {
+		return
+	}
+	drawingNumber := strconv.Itoa(sheetArrayIndex + 1)
+	var builder strings.Builder
+	builder.WriteString(xmlHeader + `<xdr:wsDr xmlns:xdr="` + spreadsheetDrawingNS + `" xmlns:a="` + drawingMLNamespace +
+		`" xmlns:r="` + relationshipsNamespace + `">`)
+	for _, anchor := range drawing.anchors {
+		builder.WriteString(anchor)
+	}
+	builder.WriteString(`</xdr:wsDr>`)
+	sf.addPart(drawingPathPrefix+drawingNumber+".xml", []byte(builder.String()))
+	if len(drawing.relationships) > 0 {
+		sf.addPart(drawingRelsPathPrefix+drawingNumber+".xml.rels", []byte(renderRelationships(drawing.relationships)))
+	}
+}
+
+// CellAnchor places an object over a sheet. The object's top left corner is at the top left corner of the cell.
+type CellAnchor struct {
+	// Column is the index of the cell's column, which starts at 0.
+	Column int
+	// Row is the Excel row number of the cell, which starts at 1.
+	Row int
+	// Width and Height are the size of the object in pixels. If both are 0, the object's default size is used.
+	Width  int
+	Height int
+}
+
+// validate checks that the anchor's cell is inside the sheet, and that its size is either set or left for the default.
+func (a CellAnchor) validate() error {
+	if _, err := cellReference(a.Column, a.Row); err != nil {
+		return err
+	}
+	if a.Width < 0 || a.Height < 0 || (a.Width == 0) != (a.Height == 0) {
+		return InvalidAnchorSizeError
+	}
+	return nil
+}
+
+// fromXML returns the XML for the cell the anchor starts at, which uses indexes that start at 0 for both the row and
+// the column.
+func (a CellAnchor) fromXML() string {
+	return `<xdr:from><xdr:col>` + strconv.Itoa(a.Column) + `</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>` +
+		strconv.Itoa(a.Row-1) + `</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:from>`
+}
+
+// extentXML returns the XML for the size of the anchored object.
+func (a CellAnchor) extentXML() string {
+	return `<xdr:ext cx="` + strconv.Itoa(a.Width*emusPerPixel) + `" cy="` + strconv.Itoa(a.Height*emusPerPixel) + `"/>`
+}
@@ -0,0 +1,92 @@
+package excel_stream
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DuplicateHeaderPolicy controls what AddSheet does with a header that has the same name as an earlier header of the
+// sheet. Excel tables, structured references and WriteRowFromMap all need every header to be unique. Like Excel,
+// headers are compared without considering case, and empty headers are never duplicates.
+type DuplicateHeaderPolicy int
+
+const (
+	// AllowDuplicateHeaders writes duplicate headers as they are. This is the default policy.
+	AllowDuplicateHeaders DuplicateHeaderPolicy = iota
+	// RejectDuplicateHeaders makes AddSheet return DuplicateHeaderError.
+	RejectDuplicateHeaders
+	// SuffixDuplicateHeaders gives duplicate headers the first free numbered suffix, such as "Total (2)".
+	SuffixDuplicateHeaders
+)
+
+var (
+	UnknownDuplicateHeaderPolicyError = errors.New("Unknown duplicate header policy")
+	DuplicateHeaderError              = errors.New("Headers of a sheet must be unique. Excel does not consider case when comparing headers.")
+)
+
+// SetDuplicateHeaderPolicy controls how AddSheet handles headers that repeat within a sheet. By default they are
+// allowed.
+func (sb *StreamFileBuilder) SetDuplicateHeaderPolicy(policy DuplicateHeaderPolicy) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if policy < AllowDuplicateHeaders || policy > SuffixDuplicateHeaders {
+		return UnknownDuplicateHeaderPolicyError
+	}
+	sb.duplicateHeaderPolicy = policy
+	return nil
+}
+
+// applyDuplicateHeaderPolicy returns the headers to write for the sheet, which are a copy of the headers if any of them
+// are renamed.
+func applyDuplicateHeaderPolicy(headers []string, policy DuplicateHeaderPolicy) ([]string, error) {
+	if policy == AllowDuplicateHeaders {
+		return headers, nil
+	}
+	seen := make(map[string]bool, len(headers))
+	var renamed []string
+	for i, header := range headers {
+		if header == "" {
+			continue
+		}
+		if !seen[strings.ToLower(header)] {
+			seen[strings.ToLower(header)] = true
+			continue
+		}
+		if policy == RejectDuplicateHeaders {
+			return nil, fmt.Errorf("%w: %q", DuplicateHeaderError, header)
+		}
+		if renamed == nil {
+			renamed = append([]string(nil), headers...)
+		}
+		renamed[i] = deduplicateHeader(header, headers, seen)
+		seen[strings.ToLower(renamed[i])] = true
+	}
+	if renamed == nil {
+		return headers, nil
+	}
+	return renamed, nil
+}
+
+// deduplicateHeader returns the header with the first numbered suffix that is not taken by any header of the sheet or
+// by a header that was already renamed.
+func deduplicateHeader(header string, headers []string, seen map[string]bool) string {
+	for i := 2; ; i++ {
+		candidate := header + " (" + strconv.Itoa(i) + ")"
+		if !seen[strings.ToLower(candidate)] && !headerExists(candidate, headers) {
+			return candidate
+		}
+	}
+}
+
+// headerExists reports whether any of the headers is the name, ignoring case.
+func headerExists(name string, headers []string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyDuplicateHeaderPolicy(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		headers       []string
+		policy        DuplicateHeaderPolicy
+		expected      []string
+		expectedError error
+	}{
+		{
+			testName: "Allow",
+			headers:  []string{"Name", "Name"},
+			policy:   AllowDuplicateHeaders,
+			expected: []string{"Name", "Name"},
+		},
+		{
+			testName: "Unique",
+			headers:  []string{"Name", "Price", "", ""},
+			policy:   RejectDuplicateHeaders,
+			expected: []string{"Name", "Price", "", ""},
+		},
+		{
+			testName:      "Reject",
+			headers:       []string{"Name", "Price", "name"},
+			policy:        RejectDuplicateHeaders,
+			expectedError: DuplicateHeaderError,
+		},
+		{
+			testName: "Suffix",
+			headers:  []string{"Total", "total", "TOTAL"},
+			policy:   SuffixDuplicateHeaders,
+			expected: []string{"Total", "total (2)", "TOTAL (3)"},
+		},
+		{
+			testName: "Suffix Taken",
+			headers:  []string{"Total", "Total", "Total (2)"},
+			policy:   SuffixDuplicateHeaders,
+			expected: []string{"Total", "Total (3)", "Total (2)"},
+		},
+	}
+	for _, testCase := range testCases {
+		actual, err := applyDuplicateHeaderPolicy(testCase.headers, testCase.policy)
+		if !errors.Is(err, testCase.expectedError) {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Fatalf("%s: Expected %q, got %q", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestSetDuplicateHeaderPolicy(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.SetDuplicateHeaderPolicy(SuffixDuplicateHeaders + 1); err != UnknownDuplicateHeaderPolicyError {
+		t.Fatalf("Expected %v, got %v", UnknownDuplicateHeaderPolicyError, err)
+	}
+	if err := file.SetDuplicateHeaderPolicy(SuffixDuplicateHeaders); err != nil {
+		t.Fatal(err)
+	}
+	headers := []string{"Name", "Name"}
+	if err := file.AddSheet("Sheet1", headers); err != nil {
+		t.Fatal(err)
+	}
+	if headers[1] != "Name" {
+		t.Fatalf("Expected the caller's headers to be unchanged, got %q", headers)
+	}
+	if err := file.SetDuplicateHeaderPolicy(RejectDuplicateHeaders); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", headers); !errors.Is(err, DuplicateHeaderError) {
+		t.Fatalf("Expected %v, got %v", DuplicateHeaderError, err)
+	}
+	if err := file.AddSheet("Sheet3", []string{"Name"}); err != BuiltExcelStreamBuilderError {
+		t.Fatalf("Expected %v, got %v", BuiltExcelStreamBuilderError, err)
+	}
+}
@@ -0,0 +1,14 @@
+package excel_stream
+
+// SetOmitEmptyCells controls whether empty text cells are written. Normally every cell of a row is written, with empty
+// cells written as empty text. When enabled, empty cells are left out of the sheet, which makes the files of wide,
+// sparse data much smaller, and Excel treats them as blank cells rather than as empty text. Empty cells that have a
+// style are still written, without a value, so that the style shows. Nil values given to WriteRowAny or
+// WriteRowFromMap in columns without a NullValue are left out too.
+func (sb *StreamFileBuilder) SetOmitEmptyCells(omit bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.omitEmptyCells = omit
+	return nil
+}
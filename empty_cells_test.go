@@ -0,0 +1,58 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOmitEmptyCells(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetOmitEmptyCells(true); err != nil {
+		t.Fatal(err)
+	}
+	columns := []ColumnDef{
+		{Name: "Name"},
+		{Name: "Notes"},
+		{Name: "Flagged", Style: Style{Bold: true}},
+		{Name: "Count"},
+	}
+	if err := file.AddSheetWithColumns("Sheet1", columns); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowAny([]interface{}{"Taco", nil, "", 3}); err != nil {
+		t.Fatal(err)
+	}
+	encoder, err := excelStream.NewRowEncoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := encoder.EncodeRow([]string{"Burrito", "", "", "4"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteEncodedRows(encoded, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if strings.Contains(sheetXML, `<t></t>`) || strings.Contains(sheetXML, `r="B2"`) ||
+		strings.Contains(sheetXML, `r="B3"`) {
+		t.Fatalf("Expected the empty cells to be left out: %s", sheetXML)
+	}
+	for _, expected := range []string{
+		`<c r="A2" t="inlineStr"><is><t>Taco</t></is></c><c r="C2" s="`,
+		`<row r="3"><c r="A3" t="inlineStr"><is><t>Burrito</t></is></c><c r="C3" s="`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	EncoderUnsupportedError = errors.New("Rows can not be encoded ahead of time for sheets with formula columns, totals, group subtotals, link columns, audit columns, optional columns, merged repeated cells or a row hook")
+	InvalidEncodedRowsError = errors.New("Encoded row count must be the number of rows encoded by a RowEncoder")
+)
+
+// Rows encoded by a RowEncoder have no row number, so each row starts with encodedRowStart and each cell reference is
+// only its column, like <c r="B". WriteEncodedRows adds the row numbers.
+const (
+	encodedRowStart  = `<row>`
+	encodedCellStart = `<c r="`
+)
+
+// RowEncoder encodes rows of one sheet without writing them, so that rows can be encoded on many goroutines and then
+// written in order with WriteEncodedRows. Encoding is most of the work of writing a row. A RowEncoder is not safe for
+// concurrent use, but each goroutine can have its own.
+type RowEncoder struct {
+	sheetName      string
+	columnCount    int
+	columns        *sheetColumns
+	sanitizePolicy SanitizePolicy
+	typeInference  TypeInference
+	omitEmptyCells bool
+}
+
+// NewRowEncoder returns a RowEncoder for the current sheet. It can not be used for sheets whose rows depend on the
+// rows before them or that change the cells of a row, which are sheets with formula columns, totals, group subtotals,
+// link columns, audit columns, optional columns, merged repeated cells or a row hook. The cells are cleaned up like
+// WriteRow does, but warnings are given a Row of 0, since the row number is not known yet, and are not counted in
+// Stats. If the encoders run on several goroutines, the warning handler and the Checks of the columns must be safe for
+// concurrent use. Encoded rows are not counted in Aggregates either.
+func (sf *StreamFile) NewRowEncoder() (*RowEncoder, error) {
+	if err := sf.acquire(); err != nil {
+		return nil, err
+	}
+	defer sf.release()
+	if err := sf.checkEncodedSheet(); err != nil {
+		return nil, err
+	}
+	sheetIndex := sf.currentSheet.index - 1
+	encoder := &RowEncoder{
+		sheetName:      sf.xlsxFile.Sheets[sheetIndex].Name,
+		columnCount:    sf.currentSheet.columnCount,
+		columns:        &sf.columns[sheetIndex],
+		sanitizePolicy: sf.sanitizePolicy,
+		typeInference:  sf.typeInference,
+		omitEmptyCells: sf.omitEmptyCells,
+	}
+	// The StreamFile's handler counts warnings for Stats, which can not be done from other goroutines.
+	encoder.sanitizePolicy.OnWarning = sf.onWarning
+	return encoder, nil
+}
+
+// EncodeRow appends the XML of a row of cells to dst and returns the extended slice. The row has the same cells
+// WriteRow would write, but without its row number, so it can be written anywhere in the sheet. If any cell is
+// rejected, a *CellError is returned and dst is returned unchanged.
+func (e *RowEncoder) EncodeRow(cells []string, dst []byte) ([]byte, error) {
+	if len(cells) != e.columnCount {
+		return dst, WrongNumberOfRowsError
+	}
+	start := len(dst)
+	buffer := bytes.NewBuffer(dst)
+	buffer.WriteString(encodedRowStart)
+	for colIndex, cellData := range cells {
+		if err := e.columns.check(colIndex, cellData); err != nil {
+			return dst[:start], &CellError{Sheet: e.sheetName, Column: colIndex, Err: err}
+		}
+		var value string
+		var kind cellKind
+		var err error
+		switch e.columns.columnType(colIndex) {
+		case NumberColumn:
+			value, kind, err = e.sanitizePolicy.sanitizeNumber(e.sheetName, 0, colIndex, cellData)
+		case BoolColumn:
+			kind = boolCell
+			value, err = parseBool(cellData)
+		case DateColumn:
+			kind = dateCell
+			value, err = parseDate(cellData)
+		default:
+			var inferred bool
+			value, kind, inferred = e.typeInference.infer(cellData)
+			if !inferred {
+				value, err = e.sanitizePolicy.sanitizeCell(e.sheetName, 0, colIndex, cellData)
+			}
+			// Encoded rows can not add continuation rows, so cells that would be split are rejected.
+			if err == nil && excelLength(value) > maxCellLength {
+				err = CellTooLongError
+			}
+		}
+		if err != nil {
+			return dst[:start], &CellError{Sheet: e.sheetName, Column: colIndex, Err: err}
+		}
+		styleAttribute := e.columns.styleAttribute(colIndex)
+		if kind == dateCell && e.columns.columnType(colIndex) != DateColumn {
+			styleAttribute = e.columns.dateStyleAttribute(colIndex)
+		}
+		cellStart := encodedCellStart + columnName(colIndex) + `"` + styleAttribute
+		// Empty text cells are left out like empty values are, when they are omitted.
+		if kind == textCell && (value != "" || !e.omitEmptyCells) {
+			textOpen := `<t>`
+			if needsSpacePreserved(value) {
+				textOpen = `<t xml:space="preserve">`
+			}
+			buffer.WriteString(cellStart + ` t="inlineStr"><is>` + textOpen)
+			// Writing to a bytes.Buffer never fails.
+			writeEscapedText(buffer, value)
+			buffer.WriteString(`</t></is></c>`)
+			continue
+		}
+		if value == "" {
+			if styleAttribute != "" {
+				buffer.WriteString(cellStart + `/>`)
+			}
+			continue
+		}
+		typeAttribute := ""
+		switch kind {
+		case errorCell:
+			typeAttribute = ` t="e"`
+		case boolCell:
+			typeAttribute = ` t="b"`
+		}
+		buffer.WriteString(cellStart + typeAttribute + `><v>` + value + `</v></c>`)
+	}
+	buffer.WriteString(`</row>`)
+	return buffer.Bytes(), nil
+}
+
+// WriteEncodedRows writes rows encoded by a RowEncoder for the current sheet, which must be given in the order they
+// are to appear. rowCount is the number of rows in encoded, and InvalidEncodedRowsError is returned if it is not, since
+// the sheet's size is kept track of from it. The rows and their cells are given their row numbers, so that the rows
+// follow any rows written before them. Like WriteRow, it triggers a flush on success.
+func (sf *StreamFile) WriteEncodedRows(encoded []byte, rowCount int) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if err := sf.checkEncodedSheet(); err != nil {
+		return err
+	}
+	if err := sf.outputError(); err != nil {
+		return err
+	}
+	if err := sf.checkQuota(); err != nil {
+		return err
+	}
+	if rowCount > maxRows-sf.currentSheet.rowCount {
+		return RowOutOfRangeError
+	}
+	rows, err := numberEncodedRows(encoded, sf.currentSheet.rowCount+1, rowCount)
+	if err != nil {
+		return err
+	}
+	if err := sf.currentSheet.write(rows); err != nil {
+		return sf.wrapOutputError(err)
+	}
+	sf.currentSheet.rowCount += rowCount
+	sf.quotaRows += rowCount
+	if sf.currentSheet.spool != nil {
+		return nil
+	}
+	return sf.flush()
+}
+
+// numberEncodedRows returns the rows encoded by a RowEncoder with row numbers starting at firstRow added to the rows
+// and their cell references. InvalidEncodedRowsError is returned unless encoded is exactly rowCount rows. Values in the
+// cells are escaped, so the only tags starting with encodedRowStart or encodedCellStart are the rows and cells.
+func numberEncodedRows(encoded []byte, firstRow, rowCount int) (string, error) {
+	if len(encoded) > 0 && !bytes.HasPrefix(encoded, []byte(encodedRowStart)) {
+		return "", InvalidEncodedRowsError
+	}
+	var rows strings.Builder
+	rows.Grow(len(encoded) + rowCount*len(`r=""`))
+	count := 0
+	rowNumber := ""
+	for len(encoded) > 0 {
+		tag := bytes.IndexByte(encoded, '<')
+		if tag < 0 {
+			rows.Write(encoded)
+			break
+		}
+		rows.Write(encoded[:tag])
+		encoded = encoded[tag:]
+		switch {
+		case bytes.HasPrefix(encoded, []byte(encodedRowStart)):
+			count++
+			rowNumber = strconv.Itoa(firstRow + count - 1)
+			rows.WriteString(`<row r="` + rowNumber + `">`)
+			encoded = encoded[len(encodedRowStart):]
+		case bytes.HasPrefix(encoded, []byte(encodedCellStart)):
+			column := bytes.IndexByte(encoded[len(encodedCellStart):], '"')
+			if column < 0 {
+				return "", InvalidEncodedRowsError
+			}
+			rows.Write(encoded[:len(encodedCellStart)+column])
+			rows.WriteString(rowNumber)
+			encoded = encoded[len(encodedCellStart)+column:]
+		default:
+			rows.WriteByte('<')
+			encoded = encoded[1:]
+		}
+	}
+	if count != rowCount {
+		return "", InvalidEncodedRowsError
+	}
+	return rows.String(), nil
+}
+
+// checkEncodedSheet returns an error if rows can not be encoded for the current sheet.
+func (sf *StreamFile) checkEncodedSheet() error {
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	if sf.currentSheet == nil {
+		return NoCurrentSheetError
+	}
+	if sf.currentSheet.finalized {
+		return SheetFinalizedError
+	}
+	if sf.currentSheet.columnCount == 0 {
+		return EmptySheetError
+	}
+	sheetIndex := sf.currentSheet.index - 1
+	columns := &sf.columns[sheetIndex]
+	if columns.totals != nil || columns.group != nil || sf.xmlHooks.Row != nil || sf.auditColumnCount(sheetIndex) > 0 ||
+		sf.optionalColumns[sheetIndex] > 0 || sf.mergeRepeatedCells[sheetIndex] {
+		return EncoderUnsupportedError
+	}
+	for colIndex := range columns.types {
+		if columns.types[colIndex] == FormulaColumn || columns.linkURLs[colIndex] != "" {
+			return EncoderUnsupportedError
+		}
+	}
+	return nil
+}
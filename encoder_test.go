@@ -0,0 +1,261 @@
+package excel_stream
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEncodeRow(t *testing.T) {
+	encoder := &RowEncoder{
+		sheetName:   "Sheet1",
+		columnCount: 3,
+		columns: &sheetColumns{
+			types:    []ColumnType{TextColumn, NumberColumn, NumberColumn},
+			styleIDs: []int{0, 2, 0},
+		},
+		sanitizePolicy: SanitizePolicy{FormulaInjection: EscapeFormulaPrefixes},
+	}
+	dst := []byte(`<row r="2"></row>`)
+	dst, err := encoder.EncodeRow([]string{"=1+1 & <b>", "12.5", ""}, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<row r="2"></row><row><c r="A" t="inlineStr"><is><t>&#39;=1+1 &amp; &lt;b&gt;</t></is></c>` +
+		`<c r="B" s="2"><v>12.5</v></c></row>`
+	if string(dst) != expected {
+		t.Fatalf("Expected %s, got %s", expected, dst)
+	}
+	actual, err := encoder.EncodeRow([]string{"Taco", "many", ""}, dst)
+	cellErr, ok := err.(*CellError)
+	if !ok || cellErr.Column != 1 || cellErr.Err != NotANumberError {
+		t.Fatalf("Expected a CellError for column 1 wrapping NotANumberError, got %v", err)
+	}
+	if string(actual) != expected {
+		t.Fatalf("Expected a rejected row to leave dst unchanged, got %s", actual)
+	}
+	if _, err := encoder.EncodeRow([]string{"Taco"}, nil); err != WrongNumberOfRowsError {
+		t.Fatalf("Expected %v, got %v", WrongNumberOfRowsError, err)
+	}
+}
+
+func TestCheckEncodedSheet(t *testing.T) {
+	testCases := []struct {
+		testName        string
+		columns         sheetColumns
+		optionalColumns int
+		merge           bool
+		expectedError   error
+	}{
+		{testName: "Plain"},
+		{testName: "Typed", columns: sheetColumns{types: []ColumnType{NumberColumn}, linkURLs: []string{""}}},
+		{
+			testName:      "Formula",
+			columns:       sheetColumns{types: []ColumnType{FormulaColumn}, linkURLs: []string{""}},
+			expectedError: EncoderUnsupportedError,
+		},
+		{
+			testName:      "Link",
+			columns:       sheetColumns{types: []ColumnType{TextColumn}, linkURLs: []string{"https://app/{value}"}},
+			expectedError: EncoderUnsupportedError,
+		},
+		{testName: "Totals", columns: sheetColumns{totals: &sheetTotals{}}, expectedError: EncoderUnsupportedError},
+		{testName: "Optional columns", optionalColumns: 1, expectedError: EncoderUnsupportedError},
+		{testName: "Merged cells", merge: true, expectedError: EncoderUnsupportedError},
+	}
+	for _, testCase := range testCases {
+		sf := &StreamFile{
+			currentSheet:       &streamSheet{index: 1, columnCount: 1, rowCount: 1},
+			columns:            []sheetColumns{testCase.columns},
+			optionalColumns:    []int{testCase.optionalColumns},
+			mergeRepeatedCells: []bool{testCase.merge},
+		}
+		if err := sf.checkEncodedSheet(); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestWriteEncodedRows(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name", "Count"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder, err := excelStream.NewRowEncoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encoded []byte
+	for _, row := range [][]string{{"Taco", "1"}, {"Burrito", "2"}} {
+		if encoded, err = encoder.EncodeRow(row, encoded); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, rowCount := range []int{-1, 1, 3} {
+		if err := excelStream.WriteEncodedRows(encoded, rowCount); err != InvalidEncodedRowsError {
+			t.Fatalf("%d rows: Expected %v, got %v", rowCount, InvalidEncodedRowsError, err)
+		}
+	}
+	if err := excelStream.WriteEncodedRows(encoded, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Nachos", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteBlankRows(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteEncodedRows(encoded, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<row r="2"><c r="A2" t="inlineStr"><is><t>Taco</t></is></c><c r="B2" t="inlineStr">`,
+		`<row r="3"><c r="A3" t="inlineStr"><is><t>Burrito</t></is></c>`,
+		`<row r="4"><c r="A4" t="inlineStr"><is><t>Nachos</t></is></c>`,
+		`<row r="7"><c r="A7" t="inlineStr"><is><t>Taco</t></is></c>`,
+		`<row r="8"><c r="A8" t="inlineStr"><is><t>Burrito</t></is></c>`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
+
+func TestNumberEncodedRows(t *testing.T) {
+	encoded := `<row><c r="A" t="inlineStr"><is><t>&lt;row&gt;&lt;c r=&quot;</t></is></c><c r="AB"><v>1</v></c></row>` +
+		`<row><c r="C" s="1"/></row>`
+	testCases := []struct {
+		testName      string
+		encoded       string
+		rowCount      int
+		expected      string
+		expectedError error
+	}{
+		{testName: "Empty"},
+		{
+			testName: "Rows",
+			encoded:  encoded,
+			rowCount: 2,
+			expected: `<row r="9"><c r="A9" t="inlineStr"><is><t>&lt;row&gt;&lt;c r=&quot;</t></is></c>` +
+				`<c r="AB9"><v>1</v></c></row><row r="10"><c r="C10" s="1"/></row>`,
+		},
+		{testName: "Too few rows", encoded: encoded, rowCount: 3, expectedError: InvalidEncodedRowsError},
+		{testName: "Too many rows", encoded: encoded, rowCount: 1, expectedError: InvalidEncodedRowsError},
+		{testName: "Not a row", encoded: `<c r="A"/>`, expectedError: InvalidEncodedRowsError},
+		{testName: "Unfinished cell", encoded: `<row><c r="A`, rowCount: 1, expectedError: InvalidEncodedRowsError},
+	}
+	for _, testCase := range testCases {
+		actual, err := numberEncodedRows([]byte(testCase.encoded), 9, testCase.rowCount)
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if actual != testCase.expected {
+			t.Fatalf("%s: Expected %s, got %s", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+// benchmarkRows are the rows written by the benchmarks.
+var benchmarkRows = func() [][]string {
+	rows := make([][]string, 1000)
+	for i := range rows {
+		number := strconv.Itoa(i)
+		rows[i] = []string{"Customer " + number, "customer" + number + "@example.com", number, "Notes & <details>"}
+	}
+	return rows
+}()
+
+// BenchmarkWriteRow writes every row with WriteRow, which encodes each row on the goroutine writing the file.
+func BenchmarkWriteRow(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+		if err := file.AddSheet("Sheet1", []string{"Name", "Email", "Number", "Notes"}); err != nil {
+			b.Fatal(err)
+		}
+		excelStream, err := file.Build()
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, row := range benchmarkRows {
+			if err := excelStream.WriteRow(row); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := excelStream.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWriteEncodedRows encodes the rows on a goroutine for each CPU and writes them in order with
+// WriteEncodedRows, which only leaves the writing on the goroutine writing the file.
+func BenchmarkWriteEncodedRows(b *testing.B) {
+	workers := runtime.GOMAXPROCS(0)
+	chunkSize := (len(benchmarkRows) + workers - 1) / workers
+	var chunks [][][]string
+	for start := 0; start < len(benchmarkRows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(benchmarkRows) {
+			end = len(benchmarkRows)
+		}
+		chunks = append(chunks, benchmarkRows[start:end])
+	}
+	for i := 0; i < b.N; i++ {
+		file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+		if err := file.AddSheet("Sheet1", []string{"Name", "Email", "Number", "Notes"}); err != nil {
+			b.Fatal(err)
+		}
+		excelStream, err := file.Build()
+		if err != nil {
+			b.Fatal(err)
+		}
+		results := make([]chan []byte, len(chunks))
+		for chunkIndex, chunk := range chunks {
+			encoder, err := excelStream.NewRowEncoder()
+			if err != nil {
+				b.Fatal(err)
+			}
+			results[chunkIndex] = make(chan []byte, 1)
+			go func(chunk [][]string, encoder *RowEncoder, result chan<- []byte) {
+				var encoded []byte
+				for _, row := range chunk {
+					encoded, _ = encoder.EncodeRow(row, encoded)
+				}
+				result <- encoded
+			}(chunk, encoder, results[chunkIndex])
+		}
+		for chunkIndex, chunk := range chunks {
+			if err := excelStream.WriteEncodedRows(<-results[chunkIndex], len(chunk)); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := excelStream.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncodeRow measures encoding alone, spread over every CPU.
+func BenchmarkEncodeRow(b *testing.B) {
+	encoder := &RowEncoder{sheetName: "Sheet1", columnCount: 4, columns: &sheetColumns{}}
+	b.RunParallel(func(pb *testing.PB) {
+		encoder := *encoder
+		var encoded []byte
+		row := 0
+		for pb.Next() {
+			encoded, _ = encoder.EncodeRow(benchmarkRows[row%len(benchmarkRows)], encoded[:0])
+			row++
+		}
+	})
+}
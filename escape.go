@@ -0,0 +1,81 @@
+package excel_stream
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Everything written by this package that comes from callers, such as cell data and sheet names, must be escaped
+// before it is put into the XML of the file. The functions in this file are the one place that escaping is done, so
+// that names with characters like &, < or quotes work the same way everywhere they appear.
+
+// writeEscapedText writes the string to the writer with the characters that are special in XML escaped. Unlike
+// xml.EscapeText, bytes that are not valid UTF-8 are written unchanged.
+func writeEscapedText(w io.Writer, s string) error {
+	if utf8.ValidString(s) {
+		return xml.EscapeText(w, []byte(s))
+	}
+	start := 0
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		if r != utf8.RuneError || width != 1 {
+			i += width
+			continue
+		}
+		if err := xml.EscapeText(w, []byte(s[start:i])); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{s[i]}); err != nil {
+			return err
+		}
+		i++
+		start = i
+	}
+	return xml.EscapeText(w, []byte(s[start:]))
+}
+
+// escapeXML returns the string with the characters that are special in XML escaped. The result can be used both as
+// element text and as an attribute value in double or single quotes.
+func escapeXML(s string) string {
+	var builder strings.Builder
+	// Writing to a strings.Builder never fails.
+	writeEscapedText(&builder, s)
+	return builder.String()
+}
+
+// xmlAttribute returns the XML for an attribute with the given name and value, including a leading space.
+func xmlAttribute(name, value string) string {
+	return ` ` + name + `="` + escapeXML(value) + `"`
+}
+
+// quoteSheetName returns the sheet name in the form used to refer to it from formulas and defined names. The name is
+// wrapped in apostrophes, with any apostrophes inside of it doubled, which Excel accepts for every sheet name. The
+// result still needs to be escaped with escapeXML when it is put into XML.
+func quoteSheetName(name string) string {
+	return `'` + strings.Replace(name, `'`, `''`, -1) + `'`
+}
+
+// sheetReference returns a reference to the cell or range on the named sheet, such as 'Sheet 1'!A1:B2.
+func sheetReference(sheetName, ref string) string {
+	return quoteSheetName(sheetName) + "!" + ref
+}
+
+// needsSpacePreserved reports whether the text has whitespace that Excel would remove unless the element holding it is
+// marked with xml:space="preserve". This is whitespace at the start or end of the text, runs of more than one space,
+// and tabs or line breaks.
+func needsSpacePreserved(s string) bool {
+	if s == "" {
+		return false
+	}
+	if isXMLSpace(s[0]) || isXMLSpace(s[len(s)-1]) {
+		return true
+	}
+	return strings.Contains(s, "  ") || strings.ContainsAny(s, "\t\n\r")
+}
+
+// isXMLSpace reports whether the byte is one of the whitespace characters defined by XML.
+func isXMLSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
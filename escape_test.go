@@ -0,0 +1,127 @@
+package excel_stream
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteEscapedText(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	if err := writeEscapedText(buffer, "<a>\xff&\"b\""); err != nil {
+		t.Fatal(err)
+	}
+	expected := "&lt;a&gt;\xff&amp;&#34;b&#34;"
+	if buffer.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, buffer.String())
+	}
+}
+
+func TestEscapeXML(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "Sheet1", expected: "Sheet1"},
+		{input: "R&D", expected: "R&amp;D"},
+		{input: `<"Q1">`, expected: "&lt;&#34;Q1&#34;&gt;"},
+		{input: "Tom's", expected: "Tom&#39;s"},
+	}
+	for _, testCase := range testCases {
+		if actual := escapeXML(testCase.input); actual != testCase.expected {
+			t.Fatalf("Expected %q to escape to %q, got %q", testCase.input, testCase.expected, actual)
+		}
+	}
+	if actual := xmlAttribute("name", `A&"B"`); actual != ` name="A&amp;&#34;B&#34;"` {
+		t.Fatalf("Unexpected attribute %q", actual)
+	}
+}
+
+func TestSheetReference(t *testing.T) {
+	testCases := []struct {
+		sheetName string
+		expected  string
+	}{
+		{sheetName: "Sheet1", expected: "'Sheet1'!A1:B2"},
+		{sheetName: "My Sheet", expected: "'My Sheet'!A1:B2"},
+		{sheetName: "Tom's", expected: "'Tom''s'!A1:B2"},
+		{sheetName: "R&D", expected: "'R&D'!A1:B2"},
+	}
+	for _, testCase := range testCases {
+		if actual := sheetReference(testCase.sheetName, "A1:B2"); actual != testCase.expected {
+			t.Fatalf("Expected %q, got %q", testCase.expected, actual)
+		}
+	}
+}
+
+func TestHostileSheetNames(t *testing.T) {
+	sheetNames := []string{`R&D <"Q1">`, "Tom's & Jerry's", "a<b>c"}
+	workbookData := make([][][]string, len(sheetNames))
+	for i := range sheetNames {
+		workbookData[i] = [][]string{{"Token", "Name"}, {"<123>", "&Taco\""}}
+	}
+	buffer := bytes.NewBuffer(nil)
+	if err := writeStreamFile("", buffer, sheetNames, workbookData, false); err != nil {
+		t.Fatal(err)
+	}
+	reader := bytes.NewReader(buffer.Bytes())
+	summaries, err := Validate(reader, reader.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	actualNames := make([]string, len(summaries))
+	for i, summary := range summaries {
+		actualNames[i] = summary.Name
+	}
+	if !reflect.DeepEqual(actualNames, sheetNames) {
+		t.Fatalf("Expected sheet names %v, got %v", sheetNames, actualNames)
+	}
+	workbookXML := readZipPart(t, buffer.Bytes(), "xl/workbook.xml")
+	if strings.Contains(workbookXML, "R&D") {
+		t.Fatalf("Sheet name was not escaped in the workbook: %s", workbookXML)
+	}
+}
+
+func TestNeedsSpacePreserved(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "", expected: false},
+		{input: "Taco", expected: false},
+		{input: "Taco Salsa", expected: false},
+		{input: " Taco", expected: true},
+		{input: "Taco ", expected: true},
+		{input: "Taco  Salsa", expected: true},
+		{input: "Taco\tSalsa", expected: true},
+		{input: "Taco\nSalsa", expected: true},
+		{input: " ", expected: true},
+	}
+	for _, testCase := range testCases {
+		if actual := needsSpacePreserved(testCase.input); actual != testCase.expected {
+			t.Fatalf("Expected needsSpacePreserved(%q) to be %v", testCase.input, testCase.expected)
+		}
+	}
+}
+
+func TestWriteRowPreservesSpaces(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	sheetNames := []string{"Sheet1"}
+	workbookData := [][][]string{
+		{
+			{"Token", "Name"},
+			{"  123", "Taco"},
+		},
+	}
+	if err := writeStreamFile("", buffer, sheetNames, workbookData, false); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">  123</t></is></c>`) {
+		t.Fatalf("Expected leading spaces to be preserved: %s", sheetXML)
+	}
+	if !strings.Contains(sheetXML, `<c r="B2" t="inlineStr"><is><t>Taco</t></is></c>`) {
+		t.Fatalf("Expected cell without spaces to be unchanged: %s", sheetXML)
+	}
+}
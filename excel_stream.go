@@ -2,10 +2,14 @@ package excel_stream
 
 import (
 	"archive/zip"
-	"encoding/xml"
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"reflect"
 	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/tealeg/xlsx"
 )
@@ -14,8 +18,90 @@ type StreamFile struct {
 	xlsxFile       *xlsx.File
 	sheetXmlPrefix []string
 	sheetXmlSuffix []string
-	zipWriter      *zip.Writer
+	// dimensionIndex is the position in each sheet's prefix where the dimension tag was removed from.
+	dimensionIndex []int
+	// rowCounts is the number of rows in each sheet, including the header. It is updated as each sheet is finished.
+	rowCounts []int
+	// headerRows is the Excel row number of each sheet's header, which is below the sheet's preamble rows.
+	headerRows     []int
+	zipWriter      ArchiveWriter
+	output         *countingWriter
+	flushOutput    func() error
+	countParts     bool
 	currentSheet   *streamSheet
+	sanitizePolicy SanitizePolicy
+	typeInference  TypeInference
+	// finalizeLastSheet makes NextSheet finish the last sheet instead of returning AlreadyOnLastSheetError.
+	finalizeLastSheet bool
+	// ignoreUnknownMapKeys makes WriteRowFromMap skip keys that are not the name of a column.
+	ignoreUnknownMapKeys bool
+	// omitEmptyCells makes empty text cells get left out of the sheets.
+	omitEmptyCells bool
+	// optionalColumns holds the number of trailing columns of each sheet that rows may leave out.
+	optionalColumns []int
+	// mergeRepeatedCells is set for the sheets whose runs of repeated cells are merged.
+	mergeRepeatedCells []bool
+	// structTypes holds the struct type of each sheet added with AddSheetForStruct, or nil for other sheets.
+	structTypes []reflect.Type
+	// spoolSheets makes each sheet get written to a temporary file in spoolDir until it is finished.
+	spoolSheets  bool
+	spoolDir     string
+	spoolBackend SpoolBackend
+	// outputFile is the file being written, when the StreamFile was built for a path.
+	outputFile *atomicFile
+	// computeManifest makes every part written to the zip get checksummed into partHashes.
+	computeManifest bool
+	partHashes      []partHash
+	// completionMarkers makes Close write CompleteMarkerPath if nothing failed.
+	completionMarkers bool
+	// sheetExtras holds what has been added to each sheet beyond its rows, such as images.
+	sheetExtras []sheetExtras
+	// columns holds the types and styles of each sheet's columns, for sheets added with AddSheetWithColumns.
+	columns []sheetColumns
+	// parts are written to the zip when the StreamFile is closed, followed by styles and contentTypes.
+	parts        []packagePart
+	styles       styleSheet
+	contentTypes contentTypes
+	imageCount   int
+	charts       []pendingChart
+	chartCount   int
+	// attachmentCount is the number of files embedded with AddAttachment.
+	attachmentCount int
+	// quotaRows counts the rows written by WriteRow, and quotaError is set once the quota has been exceeded.
+	quota      Quota
+	quotaRows  int
+	quotaError *QuotaError
+	// rateLimiter is consulted on every flush, and rateLimitedBytes is how many bytes had been written to the output
+	// when it last waited.
+	rateLimiter      RateLimiter
+	onRateLimitWait  func(bytes int64, wait time.Duration)
+	rateLimitedBytes int64
+	xmlHooks         SheetXMLHooks
+	// sheetStats counts the warnings of each sheet. The rest of the statistics are filled in by Stats. onWarning is the
+	// caller's warning handler, without the counting.
+	sheetStats []SheetStats
+	onWarning  func(SanitizeWarning)
+	// workbookXML is the workbook, when it is written at Close so that the definedNames can be added to it.
+	workbookXML    string
+	definedNames   []definedName
+	xmlConformance XMLConformance
+	// plugins add their parts at Close, along with the relationships in pluginRelationships.
+	plugins             []Plugin
+	pluginRelationships pluginRelationships
+	// richValues holds the rich values of the cells written as entities, whose parts are written at Close.
+	richValues richValues
+	// audits holds the audit columns of each sheet, or nil for sheets without them.
+	audits []*sheetAudit
+	// directorySize is the size of the entries of the zip's central directory for the parts created so far, which is
+	// written when the zip is closed.
+	directorySize int64
+	// closed is set once Close or Abort has been called. Nothing can be written after that.
+	closed bool
+	// complete is set once Close has finished the file without any errors.
+	complete bool
+	// inUse is set to 1 while one of the StreamFile's exported functions is running. It is used to detect callers
+	// sharing a StreamFile between goroutines, which would otherwise interleave XML and silently corrupt the file.
+	inUse int32
 }
 
 type streamSheet struct {
@@ -25,78 +111,437 @@ type streamSheet struct {
 	rowCount int
 	// The number of columns in the sheet
 	columnCount int
-	// The writer to write to this sheet's file in the XLSX Zip file
+	// The writer to write to this sheet's file in the XLSX Zip file, or to the sheet's spool
 	writer io.Writer
+	// The temporary file holding the sheet's rows, if sheets are being spooled, and the count of the bytes written to it
+	spool   sheetSpool
+	spooled *countingWriter
+	// finalized is set once the end of the sheet has been written, or writing it has failed. No more rows can be
+	// written to the sheet after that.
+	finalized bool
 }
 
 var (
 	NoCurrentSheetError     = errors.New("No Current Sheet")
 	WrongNumberOfRowsError  = errors.New("Invalid number of cells passed to WriteRow. All calls to WriteRow on the same sheet must have the same number of cells.")
 	AlreadyOnLastSheetError = errors.New("NextSheet() called, but already on last sheet.")
+	StreamFileClosedError   = errors.New("StreamFile has already been closed, functions may no longer be used")
+	SheetFinalizedError     = errors.New("The current sheet has already been finished, no more rows can be written to it.")
+	EmptySheetError         = errors.New("WriteRow called on a sheet that was added without any headers. Sheets without headers are empty placeholders and rows cannot be written to them.")
+	ConcurrentUseError      = errors.New("StreamFile used by more than one goroutine at the same time. A StreamFile is not safe for concurrent use.")
 	UnsupportedCellType     = errors.New("Unsupported cell type")
 	UnknownCellType         = errors.New("Unknown cell type")
+	NegativeBlankRowsError  = errors.New("WriteBlankRows called with a negative number of rows")
+	OutputFailedError       = errors.New("Writing to the output failed, so nothing more can be written to the StreamFile")
 )
 
+// CellError is returned when a single cell could not be written. It describes which cell caused the problem, and wraps
+// the error that explains why.
+type CellError struct {
+	// Sheet is the name of the sheet the cell was being written to.
+	Sheet string
+	// Row is the Excel row number of the cell, which starts at 1.
+	Row int
+	// Column is the index of the cell in the slice passed to WriteRow, which starts at 0.
+	Column int
+	Err    error
+}
+
+func (e *CellError) Error() string {
+	return fmt.Sprintf("Sheet %q row %d column %d: %v", e.Sheet, e.Row, e.Column, e.Err)
+}
+
+func (e *CellError) Unwrap() error {
+	return e.Err
+}
+
 // WriteRow will write a row of cells to the current sheet. Every call to WriteRow on the same sheet must contain the
 // same number of cells as the header provided when the sheet was created or an error will be returned. This function
-// will always trigger a flush on success. Currently the only supported data type is string data.
+// will always trigger a flush on success. Cells are written as text, unless the sheet was added with
+// AddSheetWithColumns and the cell is in a NumberColumn, FormulaColumn, BoolColumn or DateColumn. Text cells are
+// cleaned up according to the policies set on the StreamFileBuilder before they are written, and if any cell is
+// rejected a *CellError is returned and nothing is written for the row.
 func (sf *StreamFile) WriteRow(cells []string) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	return sf.writeRow(cells, RowOptions{})
+}
+
+// WriteBlankRows leaves n empty rows in the current sheet before the next row is written. Nothing is written for the
+// empty rows, since Excel treats rows that are missing from a sheet as empty, so gaps in a layout cost nothing. Blank
+// rows do not count against the Quota.
+func (sf *StreamFile) WriteBlankRows(n int) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	if err := sf.outputError(); err != nil {
+		return err
+	}
+	if sf.currentSheet == nil {
+		return NoCurrentSheetError
+	}
+	if sf.currentSheet.finalized {
+		return SheetFinalizedError
+	}
+	if sf.currentSheet.columnCount == 0 {
+		return EmptySheetError
+	}
+	if n < 0 {
+		return NegativeBlankRowsError
+	}
+	if n > maxRows-sf.currentSheet.rowCount {
+		return RowOutOfRangeError
+	}
+	sf.currentSheet.rowCount += n
+	return nil
+}
+
+func (sf *StreamFile) writeRow(cells []string, options RowOptions) error {
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	if err := sf.outputError(); err != nil {
+		return err
+	}
 	if sf.currentSheet == nil {
 		return NoCurrentSheetError
 	}
-	if len(cells) != sf.currentSheet.columnCount {
+	if sf.currentSheet.finalized {
+		return SheetFinalizedError
+	}
+	if err := sf.checkQuota(); err != nil {
+		return err
+	}
+	if sf.currentSheet.columnCount == 0 {
+		return EmptySheetError
+	}
+	// Rows leave out the audit columns, which are filled in here.
+	audit := sf.audits[sf.currentSheet.index-1]
+	columnCount := sf.currentSheet.columnCount - sf.auditColumnCount(sf.currentSheet.index-1)
+	missing := columnCount - len(cells)
+	if missing > 0 && missing <= sf.optionalColumns[sf.currentSheet.index-1] {
+		cells, options = padOptionalColumns(cells, options, columnCount)
+	}
+	if len(cells) != columnCount {
 		return WrongNumberOfRowsError
 	}
-	sf.currentSheet.rowCount++
-	if err := sf.currentSheet.write(`<row r="` + strconv.Itoa(sf.currentSheet.rowCount) + `">`); err != nil {
+	if audit != nil {
+		cells, options = audit.addCells(cells, options)
+	}
+	if sf.currentSheet.rowCount >= maxRows {
+		return RowOutOfRangeError
+	}
+	if err := options.validate(&sf.styles); err != nil {
 		return err
 	}
+	if options.Phonetics != nil && len(options.Phonetics) != len(cells) {
+		return PhoneticCountError
+	}
+	if options.Nulls != nil {
+		if len(options.Nulls) != len(cells) {
+			return NullCountError
+		}
+		cells = options.blankNulls(cells)
+	}
+	// Sanitize every cell before writing anything, so that a cell that is rejected does not leave a partial row behind.
+	sheetName := sf.xlsxFile.Sheets[sf.currentSheet.index-1].Name
+	rowNumber := sf.currentSheet.rowCount + 1
+	columns := &sf.columns[sf.currentSheet.index-1]
+	// When the row starts a new group, the subtotal row of the last group is written before it.
+	groupEnds := columns.group != nil && columns.group.ends(cells)
+	if groupEnds {
+		rowNumber++
+		if rowNumber > maxRows {
+			return RowOutOfRangeError
+		}
+	}
+	sanitizedCells := make([]string, len(cells))
+	kinds := make([]cellKind, len(cells))
 	for colIndex, cellData := range cells {
-		cellCoordinate := xlsx.GetCellIDStringFromCoords(colIndex, sf.currentSheet.rowCount-1)
+		if err := columns.check(colIndex, cellData); err != nil {
+			if errors.Is(err, CallbackPanicError) {
+				err = sf.poison(err)
+			}
+			return &CellError{Sheet: sheetName, Row: rowNumber, Column: colIndex, Err: err}
+		}
+		var err error
+		switch {
+		case options.cells != nil && options.cells[colIndex].Type != ColumnCell:
+			sanitizedCells[colIndex], kinds[colIndex], err = sf.sanitizeTypedCell(sheetName, rowNumber, colIndex,
+				columns, options.cells[colIndex])
+		case columns.columnType(colIndex) == FormulaColumn:
+			kinds[colIndex] = formulaCell
+			if cellData != "" {
+				err = FormulaCellError
+			}
+		case columns.columnType(colIndex) == NumberColumn:
+			// Numbers are written as values rather than text, so they can not be read as formulas.
+			sanitizedCells[colIndex], kinds[colIndex], err = sf.sanitizePolicy.sanitizeNumber(sheetName, rowNumber, colIndex,
+				cellData)
+		case columns.columnType(colIndex) == BoolColumn:
+			kinds[colIndex] = boolCell
+			sanitizedCells[colIndex], err = parseBool(cellData)
+		case columns.columnType(colIndex) == DateColumn:
+			kinds[colIndex] = dateCell
+			sanitizedCells[colIndex], err = parseDate(cellData)
+		default:
+			var inferred bool
+			sanitizedCells[colIndex], kinds[colIndex], inferred = sf.typeInference.infer(cellData)
+			if !inferred {
+				sanitizedCells[colIndex], err = sf.sanitizePolicy.sanitizeCell(sheetName, rowNumber, colIndex, cellData)
+			}
+		}
+		if err == nil && kinds[colIndex] != textCell && options.phonetic(colIndex) != "" {
+			err = PhoneticCellError
+		}
+		if err != nil {
+			return &CellError{Sheet: sheetName, Row: rowNumber, Column: colIndex, Err: err}
+		}
+	}
+	if options.cells != nil {
+		var err error
+		if options.cellStyles, err = sf.typedCellStyles(columns, options.cells); err != nil {
+			return err
+		}
+	}
+	continuations := splitLongCells(sanitizedCells, kinds, sf.sanitizePolicy.TruncationMarker)
+	if len(continuations) > maxRows-rowNumber {
+		return RowOutOfRangeError
+	}
+	if groupEnds {
+		if err := sf.writeSubtotalRow(columns); err != nil {
+			return err
+		}
+	}
+	if columns.group != nil && options.OutlineLevel == 0 {
+		options.OutlineLevel = 1
+	}
+	sf.currentSheet.rowCount++
+	writtenCells := sanitizedCells
+	if options.Nulls != nil {
+		writtenCells = columns.nullCells(sanitizedCells, &options)
+	}
+	var merges []string
+	if sf.mergeRepeatedCells[sf.currentSheet.index-1] {
+		runs := repeatedCellRuns(columns, writtenCells, kinds)
+		writtenCells, merges = mergeRepeatedCells(runs, writtenCells, sf.currentSheet.rowCount)
+	}
+	if err := sf.writeRowXML(sheetName, columns, writtenCells, kinds, options); err != nil {
+		return sf.wrapOutputError(err)
+	}
+	if audit != nil {
+		audit.sequence++
+	}
+	extras := &sf.sheetExtras[sf.currentSheet.index-1]
+	extras.mergeCells = append(extras.mergeCells, merges...)
+	for colIndex, cellData := range sanitizedCells {
+		columns.aggregates[colIndex].add(kinds[colIndex], cellData)
+	}
+	sf.addLinks(sf.currentSheet.index-1, columns, cells)
+	if columns.group != nil {
+		columns.group.add(cells, sanitizedCells, kinds, sf.currentSheet.rowCount, columns.totals)
+	}
+	// Continuation rows are written last, since the links and groups above refer to the row's own number.
+	if err := sf.writeContinuationRows(sheetName, columns, continuations, options); err != nil {
+		return sf.wrapOutputError(err)
+	}
+	sf.quotaRows++
+	if sf.currentSheet.spool != nil {
+		// Spooled rows do not go to the zip writer until the sheet is finished, so there is nothing to flush.
+		return nil
+	}
+	return sf.flush()
+}
+
+// writeRowXML writes the row element for the row's cells. If there is a row hook, the row is written to a buffer and
+// the hook's XML is written in its place.
+func (sf *StreamFile) writeRowXML(sheetName string, columns *sheetColumns, sanitizedCells []string, kinds []cellKind,
+	options RowOptions) error {
+	if sf.xmlHooks.Row == nil {
+		return sf.writeRowCells(columns, sanitizedCells, kinds, options)
+	}
+	writer := sf.currentSheet.writer
+	var row bytes.Buffer
+	sf.currentSheet.writer = &row
+	err := sf.writeRowCells(columns, sanitizedCells, kinds, options)
+	sf.currentSheet.writer = writer
+	if err != nil {
+		return err
+	}
+	hooked, err := sf.hookRow(sheetName, row.String())
+	if err != nil {
+		return err
+	}
+	return sf.currentSheet.write(hooked)
+}
+
+// writeRowCells writes the row element for the row's cells to the current sheet.
+func (sf *StreamFile) writeRowCells(columns *sheetColumns, sanitizedCells []string, kinds []cellKind,
+	options RowOptions) error {
+	rowOpen := `<row r="` + strconv.Itoa(sf.currentSheet.rowCount) + `"` + options.attributes() + `>`
+	if err := sf.currentSheet.write(rowOpen); err != nil {
+		return err
+	}
+	for colIndex, cellData := range sanitizedCells {
+		cellCoordinate, err := cellReference(colIndex, sf.currentSheet.rowCount)
+		if err != nil {
+			return err
+		}
+		styleAttribute := columns.styleAttribute(colIndex)
+		if kinds[colIndex] == dateCell && columns.columnType(colIndex) != DateColumn {
+			styleAttribute = columns.dateStyleAttribute(colIndex)
+		}
+		if options.null(colIndex) {
+			styleAttribute = columns.nullStyleAttribute(colIndex)
+		}
+		if options.cellStyles != nil && options.cellStyles[colIndex] != "" {
+			styleAttribute = options.cellStyles[colIndex]
+		}
+		if options.StyleID != 0 {
+			styleAttribute = ` s="` + strconv.Itoa(int(options.StyleID)) + `"`
+		}
+		if kinds[colIndex] == formulaCell {
+			formula := columns.formulaXML(colIndex, sf.currentSheet.rowCount)
+			if err := sf.currentSheet.write(`<c r="` + cellCoordinate + `"` + styleAttribute +
+				columns.cellMetadataAttribute(colIndex) + `>` + formula + `</c>`); err != nil {
+				return err
+			}
+			continue
+		}
+		// Empty text cells are left out like empty values are, when they are omitted.
+		if kinds[colIndex] != textCell || cellData == "" && sf.omitEmptyCells {
+			if err := sf.writeValueCell(cellCoordinate, styleAttribute, kinds[colIndex], cellData); err != nil {
+				return err
+			}
+			continue
+		}
 		cellType, err := cellTypeString(xlsx.CellTypeInline)
 		if err != nil {
 			return err
 		}
 
-		cellOpen := `<c r="` + cellCoordinate + `" t="` + cellType + `"><is><t>`
-		cellClose := `</t></is></c>`
+		textOpen := `<t>`
+		if needsSpacePreserved(cellData) {
+			textOpen = `<t xml:space="preserve">`
+		}
+		cellOpen := `<c r="` + cellCoordinate + `"` + styleAttribute + ` t="` + cellType + `"><is>` + textOpen
+		cellClose := `</t>` + phoneticXML(cellData, options.phonetic(colIndex)) + `</is></c>`
 
 		if err := sf.currentSheet.write(cellOpen); err != nil {
 			return err
 		}
-		if err := xml.EscapeText(sf.currentSheet.writer, []byte(cellData)); err != nil {
+		if err := writeEscapedText(sf.currentSheet.writer, cellData); err != nil {
 			return err
 		}
 		if err := sf.currentSheet.write(cellClose); err != nil {
 			return err
 		}
 	}
-	if err := sf.currentSheet.write(`</row>`); err != nil {
-		return err
+	return sf.currentSheet.write(`</row>`)
+}
+
+// writeValueCell writes a number, error, boolean or date cell. Empty cells are still written when they have a style, so
+// that the style shows when a value is typed in.
+func (sf *StreamFile) writeValueCell(cellCoordinate, styleAttribute string, kind cellKind, value string) error {
+	if value == "" {
+		if styleAttribute == "" {
+			return nil
+		}
+		return sf.currentSheet.write(`<c r="` + cellCoordinate + `"` + styleAttribute + `/>`)
+	}
+	typeAttribute := ""
+	switch kind {
+	case errorCell:
+		typeAttribute = ` t="e"`
+	case boolCell:
+		typeAttribute = ` t="b"`
+	case entityCell:
+		return sf.currentSheet.write(`<c r="` + cellCoordinate + `"` + styleAttribute + ` t="e" vm="` + value + `"><v>` +
+			richValueFallback + `</v></c>`)
 	}
-	return sf.zipWriter.Flush()
+	return sf.currentSheet.write(`<c r="` + cellCoordinate + `"` + styleAttribute + typeAttribute + `><v>` + value +
+		`</v></c>`)
 }
 
 // NextSheet will switch to the next sheet. Sheets are selected in the same order they were added.
 // Once you leave a sheet, you cannot return to it.
+// Calling NextSheet on the last sheet returns AlreadyOnLastSheetError, unless SetFinalizeLastSheet was enabled on the
+// builder. In that case the first call finishes the last sheet, leaving the StreamFile ready to be closed, and only
+// later calls return the error.
 func (sf *StreamFile) NextSheet() error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	if err := sf.outputError(); err != nil {
+		return err
+	}
+	return sf.nextSheet()
+}
+
+// nextSheet finishes the current sheet and starts the next one.
+func (sf *StreamFile) nextSheet() error {
 	var sheetIndex int
 	if sf.currentSheet != nil {
 		if sf.currentSheet.index >= len(sf.xlsxFile.Sheets) {
+			if sf.finalizeLastSheet && !sf.currentSheet.finalized {
+				return sf.writeSheetEnd()
+			}
 			return AlreadyOnLastSheetError
 		}
-		if err := sf.writeSheetEnd(); err != nil {
-			sf.currentSheet = nil
-			return err
+		if !sf.currentSheet.finalized {
+			if err := sf.writeSheetEnd(); err != nil {
+				return err
+			}
 		}
 		sheetIndex = sf.currentSheet.index
 	}
-	sheetIndex++
+	return sf.startSheet(sheetIndex + 1)
+}
+
+// startSheet makes the sheet with the given Excel index, which starts at 1, the current sheet and starts writing it.
+// If starting the sheet fails, the new sheet is still made current, but it is finalized so that nothing more is
+// written to it.
+func (sf *StreamFile) startSheet(sheetIndex int) error {
 	sf.currentSheet = &streamSheet{
 		index:       sheetIndex,
 		columnCount: len(sf.xlsxFile.Sheets[sheetIndex-1].Cols),
-		rowCount:    1,
+		rowCount:    sf.headerRows[sheetIndex-1],
+	}
+	if sf.spoolSheets {
+		spool, err := newSheetSpool(sf.spoolDir, sf.spoolBackend)
+		if err != nil {
+			sf.currentSheet.finalized = true
+			return err
+		}
+		sf.currentSheet.spool = spool
+		sf.currentSheet.spooled = &countingWriter{writer: spool}
+		sf.currentSheet.writer = sf.currentSheet.spooled
+		// The start of the sheet is written when the sheet is finished, once the dimension is known.
+		return nil
+	}
+	if err := sf.createSheetFile(); err != nil {
+		sf.currentSheet.finalized = true
+		return err
+	}
+	if err := sf.writeSheetStart(); err != nil {
+		sf.currentSheet.finalized = true
+		return err
 	}
+	return nil
+}
+
+// createSheetFile creates the file for the current sheet in the XLSX Zip file, and makes it the sheet's writer.
+func (sf *StreamFile) createSheetFile() error {
 	sheetPath := sheetFilePathPrefix + strconv.Itoa(sf.currentSheet.index) + sheetFilePathSuffix
 	// There are two compression methods that the Golang zip.Writer supports, Store and Deflate, and we must use
 	// Store here.
@@ -105,35 +550,151 @@ func (sf *StreamFile) NextSheet() error {
 	// library from streaming with in an Excel sheet.
 	// Store uses no compression and is just a no-op wrapper. Using this will allow data passed to WriteRow to get written
 	// and then immediately flushed out to the network.
-	fileWriter, err := sf.zipWriter.CreateHeader(&zip.FileHeader{Name: sheetPath, Method: zip.Store})
+	fileWriter, err := sf.createPart(&zip.FileHeader{Name: sheetPath, Method: zip.Store})
 	if err != nil {
 		return err
 	}
 	sf.currentSheet.writer = fileWriter
-
-	if err := sf.writeSheetStart(); err != nil {
-		return err
-	}
 	return nil
 }
 
 // Close closes the Stream File.
-// Any sheets that have not yet been written to will have an empty sheet created for them.
+// Any sheets that have not yet been written to will have an empty sheet created for them. Anything else that was added
+// to the file while streaming, such as images, is written after the sheets.
+// If finishing any of the sheets fails, Close carries on with the remaining sheets and returns all of the errors
+// joined together, each one labeled with the name of the sheet it came from.
 func (sf *StreamFile) Close() error {
-	// If there are sheets that have not been written yet, call NextSheet() which will add files to the zip for them.
-	// XLSX readers may error if the sheets registered in the metadata are not present in the file.
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sf.closed = true
+	// Nothing more is written once the output has failed, since the file can not be finished.
+	if err := sf.outputError(); err != nil {
+		if sf.currentSheet != nil && sf.currentSheet.spool != nil {
+			sf.currentSheet.spool.remove()
+			sf.currentSheet.spool = nil
+		}
+		if sf.outputFile != nil {
+			sf.outputFile.abort()
+		}
+		return err
+	}
+	// If there are sheets that have not been written yet, start and finish each one, which will add files to the zip for
+	// them. XLSX readers may error if the sheets registered in the metadata are not present in the file.
+	var errs []error
 	if sf.currentSheet != nil {
-		for sf.currentSheet.index < len(sf.xlsxFile.Sheets) {
-			if err := sf.NextSheet(); err != nil {
-				return err
+		for {
+			if !sf.currentSheet.finalized {
+				if err := sf.writeSheetEnd(); err != nil {
+					errs = append(errs, sf.sheetError(err))
+				}
+			}
+			if sf.currentSheet.index >= len(sf.xlsxFile.Sheets) {
+				break
+			}
+			if err := sf.startSheet(sf.currentSheet.index + 1); err != nil {
+				errs = append(errs, sf.sheetError(err))
 			}
 		}
-		// Write the end of the last sheet.
-		if err := sf.writeSheetEnd(); err != nil {
-			return err
+	}
+	sf.addMetadataPart()
+	sf.addRichValueParts()
+	if err := sf.runPlugins(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := sf.writeParts(); err != nil {
+		errs = append(errs, err)
+	}
+	// The complete marker is the last part, so that it is only there if everything before it was written.
+	if sf.completionMarkers && len(errs) == 0 {
+		if err := sf.writeMarker(CompleteMarkerPath, "This export is complete."); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	return sf.zipWriter.Close()
+	if err := sf.zipWriter.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if sf.outputFile != nil {
+		// Only replace the file at the path if everything was written successfully.
+		if len(errs) > 0 {
+			sf.outputFile.abort()
+		} else if err := sf.outputFile.commit(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	sf.complete = len(errs) == 0
+	return errors.Join(errs...)
+}
+
+// Abort gives up on the Stream File without finishing it. If it was built for a path, the temporary file is removed
+// and the file at the path is left untouched. Otherwise the output is left incomplete and should be discarded.
+func (sf *StreamFile) Abort() error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sf.closed = true
+	var errs []error
+	if sf.currentSheet != nil && sf.currentSheet.spool != nil {
+		errs = append(errs, sf.currentSheet.spool.remove())
+		sf.currentSheet.spool = nil
+	}
+	if sf.outputFile != nil {
+		errs = append(errs, sf.outputFile.abort())
+	}
+	return errors.Join(errs...)
+}
+
+// outputError returns an error wrapping OutputFailedError and the error the output failed with, or nil if writing to
+// the output has not failed. Once it fails, the file is missing whatever was being written, so rows, sheets and Close
+// all return this error instead of writing more XML after the gap.
+func (sf *StreamFile) outputError() error {
+	if sf.output == nil || sf.output.err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", OutputFailedError, sf.output.err)
+}
+
+// wrapOutputError returns the error from outputError in place of the error from a write, if the write failed because
+// the output did.
+func (sf *StreamFile) wrapOutputError(err error) error {
+	if outputErr := sf.outputError(); outputErr != nil {
+		return outputErr
+	}
+	return err
+}
+
+// sheetError labels the error with the name of the current sheet.
+func (sf *StreamFile) sheetError(err error) error {
+	return fmt.Errorf("sheet %q: %w", sf.xlsxFile.Sheets[sf.currentSheet.index-1].Name, err)
+}
+
+// ReadyToClose reports whether every sheet has been finished, which happens when NextSheet is called on the last sheet
+// with SetFinalizeLastSheet enabled. Close can be called at any time, this only lets loops that call NextSheet after
+// every sheet know that there is nothing left to write.
+func (sf *StreamFile) ReadyToClose() bool {
+	return sf.currentSheet != nil && sf.currentSheet.finalized && sf.currentSheet.index >= len(sf.xlsxFile.Sheets)
+}
+
+// acquire marks the StreamFile as in use, or returns ConcurrentUseError if another goroutine is already using it.
+// Every successful call must be followed by a call to release.
+func (sf *StreamFile) acquire() error {
+	if !atomic.CompareAndSwapInt32(&sf.inUse, 0, 1) {
+		return ConcurrentUseError
+	}
+	return nil
+}
+
+// release marks the StreamFile as no longer in use.
+func (sf *StreamFile) release() {
+	atomic.StoreInt32(&sf.inUse, 0)
 }
 
 // cellTypeString returns the string value that should be used for the cell type.
@@ -177,7 +738,11 @@ func (sf *StreamFile) writeSheetStart() error {
 	if sf.currentSheet == nil {
 		return NoCurrentSheetError
 	}
-	return sf.currentSheet.write(sf.sheetXmlPrefix[sf.currentSheet.index-1])
+	prefix, err := sf.hookPrefix(sf.sheetXmlPrefix[sf.currentSheet.index-1])
+	if err != nil {
+		return err
+	}
+	return sf.currentSheet.write(prefix)
 }
 
 // writeSheetEnd will write the end of the Sheet's XML as returned from the XMSX library.
@@ -185,10 +750,54 @@ func (sf *StreamFile) writeSheetEnd() error {
 	if sf.currentSheet == nil {
 		return NoCurrentSheetError
 	}
+	// Whether or not writing the end succeeds, the sheet can no longer be written to.
+	sf.currentSheet.finalized = true
+	if err := sf.writeTotalsRow(); err != nil {
+		return err
+	}
+	sf.rowCounts[sf.currentSheet.index-1] = sf.currentSheet.rowCount
+	if sf.currentSheet.spool != nil {
+		if err := sf.writeSpooledSheet(); err != nil {
+			return err
+		}
+	}
 	if err := sf.currentSheet.write(endSheetDataTag); err != nil {
 		return err
 	}
-	return sf.currentSheet.write(sf.sheetXmlSuffix[sf.currentSheet.index-1])
+	suffix, err := sf.hookSuffix(sf.sheetSuffix(sf.currentSheet.index - 1))
+	if err != nil {
+		return err
+	}
+	return sf.currentSheet.write(suffix)
+}
+
+// writeSpooledSheet creates the current sheet's file in the XLSX Zip file, and writes the start of the sheet with an
+// accurate dimension tag followed by the rows from the spool. The spool is removed afterwards.
+func (sf *StreamFile) writeSpooledSheet() error {
+	spool := sf.currentSheet.spool
+	sf.currentSheet.spool = nil
+	if err := sf.createSheetFile(); err != nil {
+		spool.remove()
+		return err
+	}
+	sheetArrayIndex := sf.currentSheet.index - 1
+	prefix := sf.sheetXmlPrefix[sheetArrayIndex]
+	dimensionIndex := sf.dimensionIndex[sheetArrayIndex]
+	dimension := fmt.Sprintf(dimensionTag, dimensionRef(sf.currentSheet.columnCount, sf.currentSheet.rowCount))
+	prefix, err := sf.hookPrefix(prefix[:dimensionIndex] + dimension + prefix[dimensionIndex:])
+	if err != nil {
+		spool.remove()
+		return err
+	}
+	if err := sf.currentSheet.write(prefix); err != nil {
+		spool.remove()
+		return err
+	}
+	if err := spool.copyTo(sf.currentSheet.writer); err != nil {
+		spool.remove()
+		return err
+	}
+	return spool.remove()
 }
 
 func (ss *streamSheet) write(data string) error {
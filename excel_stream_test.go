@@ -1,10 +1,16 @@
 package excel_stream
 
 import (
+	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/tealeg/xlsx"
@@ -303,3 +309,291 @@ func readXLSXFile(t *testing.T, filePath string, fileBuffer io.ReaderAt, size in
 	}
 	return sheetNames, actualWorkbookData
 }
+
+// readZipPart returns the contents of the part with the given path from the XLSX file data, so that tests can check
+// the XML that was written.
+func readZipPart(t *testing.T, data []byte, path string) string {
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range zipReader.File {
+		if file.Name != path {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		contents, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(contents)
+	}
+	t.Fatalf("Part %s not found", path)
+	return ""
+}
+
+func TestWritesAfterClose(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Sheet1", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"456"}); err != StreamFileClosedError {
+		t.Fatalf("Expected StreamFileClosedError from WriteRow, got %v", err)
+	}
+	if err := excelStream.NextSheet(); err != StreamFileClosedError {
+		t.Fatalf("Expected StreamFileClosedError from NextSheet, got %v", err)
+	}
+	if err := excelStream.Close(); err != StreamFileClosedError {
+		t.Fatalf("Expected StreamFileClosedError from Close, got %v", err)
+	}
+}
+
+func TestWriteToFinalizedSheet(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Sheet1", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.writeSheetEnd(); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123"}); err != SheetFinalizedError {
+		t.Fatalf("Expected SheetFinalizedError, got %v", err)
+	}
+	// Close should not try to finish the sheet a second time.
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentUseIsDetected(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Sheet1", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pretend another goroutine is in the middle of a call.
+	if err := excelStream.acquire(); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123"}); err != ConcurrentUseError {
+		t.Fatalf("Expected ConcurrentUseError from WriteRow, got %v", err)
+	}
+	if err := excelStream.NextSheet(); err != ConcurrentUseError {
+		t.Fatalf("Expected ConcurrentUseError from NextSheet, got %v", err)
+	}
+	if err := excelStream.Close(); err != ConcurrentUseError {
+		t.Fatalf("Expected ConcurrentUseError from Close, got %v", err)
+	}
+	excelStream.release()
+	if err := excelStream.WriteRow([]string{"123"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteRowToEmptySheet(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Placeholder", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{}); err != EmptySheetError {
+		t.Fatalf("Expected EmptySheetError, got %v", err)
+	}
+	if err := excelStream.NextSheet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	bufReader := bytes.NewReader(buffer.Bytes())
+	_, workbookData := readXLSXFile(t, "", bufReader, bufReader.Size(), false)
+	expectedData := [][][]string{{{}}, {{"Token"}, {"123"}}}
+	if !reflect.DeepEqual(workbookData, expectedData) {
+		t.Fatalf("Expected workbook data %v, got %v", expectedData, workbookData)
+	}
+}
+
+func TestNextSheetFinalizesLastSheet(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetFinalizeLastSheet(true); err != nil {
+		t.Fatal(err)
+	}
+	workbookData := [][][]string{
+		{{"Token", "Name"}, {"123", "Taco"}},
+		{{"Token"}, {"456"}},
+	}
+	for i, sheetData := range workbookData {
+		if err := file.AddSheet(fmt.Sprintf("Sheet%d", i+1), sheetData[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write each sheet and then move on, the way a loop over sheets would.
+	for _, sheetData := range workbookData {
+		if excelStream.ReadyToClose() {
+			t.Fatal("Expected the StreamFile not to be ready to close yet")
+		}
+		for _, row := range sheetData[1:] {
+			if err := excelStream.WriteRow(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := excelStream.NextSheet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !excelStream.ReadyToClose() {
+		t.Fatal("Expected the StreamFile to be ready to close")
+	}
+	if err := excelStream.WriteRow([]string{"789"}); err != SheetFinalizedError {
+		t.Fatalf("Expected SheetFinalizedError, got %v", err)
+	}
+	if err := excelStream.NextSheet(); err != AlreadyOnLastSheetError {
+		t.Fatalf("Expected AlreadyOnLastSheetError, got %v", err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	bufReader := bytes.NewReader(buffer.Bytes())
+	_, actualWorkbookData := readXLSXFile(t, "", bufReader, bufReader.Size(), false)
+	if !reflect.DeepEqual(actualWorkbookData, workbookData) {
+		t.Fatal("Expected workbook data to be equal")
+	}
+}
+
+func TestCloseJoinsSheetErrors(t *testing.T) {
+	spoolDir, err := ioutil.TempDir("", "excel_stream_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(spoolDir)
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.SetSpoolSheets(true, spoolDir); err != nil {
+		t.Fatal(err)
+	}
+	for _, sheetName := range []string{"Sheet1", "Sheet2", "Sheet3"} {
+		if err := file.AddSheet(sheetName, []string{"Token"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Make starting every remaining sheet fail.
+	excelStream.spoolDir = filepath.Join(spoolDir, "does_not_exist")
+	err = excelStream.Close()
+	if err == nil {
+		t.Fatal("Expected Close to fail")
+	}
+	if strings.Contains(err.Error(), `"Sheet1"`) || !strings.Contains(err.Error(), `"Sheet2"`) || !strings.Contains(err.Error(), `"Sheet3"`) {
+		t.Fatalf("Expected errors for Sheet2 and Sheet3 only, got %v", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected the joined error to wrap the underlying errors, got %v", err)
+	}
+}
+
+func TestWriteBlankRows(t *testing.T) {
+	testCases := []struct {
+		testName         string
+		rowCount         int
+		blankRows        int
+		expectedRowCount int
+		expectedError    error
+	}{
+		{testName: "Gap", rowCount: 3, blankRows: 2, expectedRowCount: 5},
+		{testName: "None", rowCount: 3, blankRows: 0, expectedRowCount: 3},
+		{testName: "Negative", rowCount: 3, blankRows: -1, expectedRowCount: 3, expectedError: NegativeBlankRowsError},
+		{testName: "Last Row", rowCount: maxRows - 2, blankRows: 2, expectedRowCount: maxRows},
+		{
+			testName:         "Past Last Row",
+			rowCount:         maxRows - 2,
+			blankRows:        3,
+			expectedRowCount: maxRows - 2,
+			expectedError:    RowOutOfRangeError,
+		},
+	}
+	for _, testCase := range testCases {
+		sf := &StreamFile{currentSheet: &streamSheet{index: 1, columnCount: 2, rowCount: testCase.rowCount}}
+		err := sf.WriteBlankRows(testCase.blankRows)
+		if err != testCase.expectedError || sf.currentSheet.rowCount != testCase.expectedRowCount {
+			t.Fatalf("%s: Expected %d, %v, got %d, %v", testCase.testName, testCase.expectedRowCount,
+				testCase.expectedError, sf.currentSheet.rowCount, err)
+		}
+	}
+	sf := &StreamFile{currentSheet: &streamSheet{index: 1, rowCount: 1}}
+	if err := sf.WriteBlankRows(1); err != EmptySheetError {
+		t.Fatalf("Expected %v, got %v", EmptySheetError, err)
+	}
+	sf = &StreamFile{
+		output:       &countingWriter{err: failedWriteError},
+		currentSheet: &streamSheet{index: 1, columnCount: 2, rowCount: 1},
+	}
+	if err := sf.WriteBlankRows(1); !errors.Is(err, OutputFailedError) || sf.currentSheet.rowCount != 1 {
+		t.Fatalf("Expected %v without adding rows, got %d, %v", OutputFailedError, sf.currentSheet.rowCount, err)
+	}
+}
+
+func TestWriteRowAfterBlankRows(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteBlankRows(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if strings.Contains(sheetXML, `<row r="2"`) || !strings.Contains(sheetXML, `<c r="A5" t="inlineStr">`) {
+		t.Fatalf("Expected the row to be written after the gap: %s", sheetXML)
+	}
+}
@@ -0,0 +1,58 @@
+package excel_stream
+
+import (
+	"io"
+)
+
+// Flusher is implemented by writers that hold on to data until they are flushed, like bufio.Writer. Writers that
+// implement it, or http.Flusher, are flushed every time the StreamFile flushes.
+type Flusher interface {
+	Flush() error
+}
+
+// httpFlusher has the method set of http.Flusher, which can not report errors.
+type httpFlusher interface {
+	Flush()
+}
+
+// writerFlusher returns the function that flushes the writer, or nil if the writer does not need to be flushed.
+func writerFlusher(writer io.Writer) func() error {
+	switch writer := writer.(type) {
+	case Flusher:
+		return writer.Flush
+	case httpFlusher:
+		return func() error {
+			writer.Flush()
+			return nil
+		}
+	}
+	return nil
+}
+
+// Flush sends everything written so far to the writer the StreamFile was built with, and flushes that writer too if
+// it is a Flusher or an http.Flusher. WriteRow already flushes after every row, so this is only needed after writing
+// other things, such as at the start of a download before the first row is ready. Rows of spooled sheets are not
+// written to the output until the sheet is finished, so they are not flushed.
+func (sf *StreamFile) Flush() error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	return sf.flush()
+}
+
+// flush flushes the zip writer and then the writer it writes to, and then waits for the rate limiter.
+func (sf *StreamFile) flush() error {
+	if err := sf.zipWriter.Flush(); err != nil {
+		return sf.poison(err)
+	}
+	if sf.flushOutput != nil {
+		if err := sf.flushOutput(); err != nil {
+			return sf.poison(err)
+		}
+	}
+	return sf.waitForRateLimit()
+}
@@ -0,0 +1,81 @@
+package excel_stream
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// countingFlusher counts the times it is flushed.
+type countingFlusher struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *countingFlusher) Flush() {
+	f.flushes++
+}
+
+func TestWriterFlusher(t *testing.T) {
+	if writerFlusher(bytes.NewBuffer(nil)) != nil {
+		t.Fatal("Expected writers that are not flushers to not be flushed")
+	}
+	var buffer bytes.Buffer
+	buffered := bufio.NewWriter(&buffer)
+	buffered.WriteString("data")
+	if err := writerFlusher(buffered)(); err != nil || buffer.String() != "data" {
+		t.Fatalf("Expected the bufio.Writer to be flushed, got %q, %v", buffer.String(), err)
+	}
+	recorder := httptest.NewRecorder()
+	if err := writerFlusher(recorder)(); err != nil || !recorder.Flushed {
+		t.Fatalf("Expected the http.Flusher to be flushed, got %v, %v", recorder.Flushed, err)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	output := &countingFlusher{}
+	file := NewStreamFileBuilder(output)
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if output.flushes != 1 {
+		t.Fatalf("Expected Flush to flush the output, got %d flushes", output.flushes)
+	}
+	if err := excelStream.WriteRow([]string{"Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if output.flushes != 2 {
+		t.Fatalf("Expected WriteRow to flush the output, got %d flushes", output.flushes)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Flush(); err != StreamFileClosedError {
+		t.Fatalf("Expected %v, got %v", StreamFileClosedError, err)
+	}
+}
+
+func TestFlushOutputFailure(t *testing.T) {
+	output := &countingWriter{writer: bytes.NewBuffer(nil)}
+	sf := &StreamFile{
+		zipWriter:   zip.NewWriter(output),
+		output:      output,
+		flushOutput: func() error { return failedWriteError },
+	}
+	if err := sf.flush(); !errors.Is(err, OutputFailedError) || !errors.Is(err, failedWriteError) {
+		t.Fatalf("Expected %v, got %v", OutputFailedError, err)
+	}
+	if _, err := output.Write([]byte("<row/>")); err != failedWriteError {
+		t.Fatalf("Expected writes after the failed flush to fail with %v, got %v", failedWriteError, err)
+	}
+}
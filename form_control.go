@@ -0,0 +1,91 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+)
+
+// FormControlType is the kind of form control added with AddFormControl.
+type FormControlType int
+
+const (
+	CheckboxControl FormControlType = iota
+	OptionButtonControl
+)
+
+// controlShapeType is the VML shape type Excel uses for form controls.
+const controlShapeType = `<v:shapetype id="_x0000_t201" coordsize="21600,21600" o:spt="201" path="m,l,21600r21600,l21600,xe">` +
+	`<v:stroke joinstyle="miter"/><v:path shadowok="f" o:extrusionok="f" strokeok="f" fillok="f" o:connecttype="rect"/>` +
+	`<o:lock v:ext="edit" shapetype="t"/></v:shapetype>`
+
+var UnknownFormControlTypeError = errors.New("Unknown form control type")
+
+// FormControl describes a checkbox or option button drawn in a cell, such as a sign off box at the end of each row.
+type FormControl struct {
+	Type FormControlType
+	// Column and Row are the cell the control is drawn in. Column starts at 0, and Row is the Excel row number, which
+	// starts at 1.
+	Column int
+	Row    int
+	// Label is the text shown next to the control.
+	Label   string
+	Checked bool
+	// LinkColumn and LinkRow are the cell the control is bound to, which holds TRUE or FALSE for a checkbox, or the
+	// number of the selected option for option buttons. If LinkRow is 0, the control is not bound to a cell.
+	LinkColumn int
+	LinkRow    int
+}
+
+// AddFormControl draws a legacy form control in a cell of the named sheet. The sheet must be the current sheet or one
+// that has not been started yet.
+// Option buttons that are drawn next to each other on a sheet act as one group, so only one of them can be checked.
+func (sf *StreamFile) AddFormControl(sheetName string, control FormControl) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	if control.Type != CheckboxControl && control.Type != OptionButtonControl {
+		return UnknownFormControlTypeError
+	}
+	if _, err := cellReference(control.Column, control.Row); err != nil {
+		return err
+	}
+	link := ""
+	if control.LinkRow != 0 {
+		if _, err := cellReference(control.LinkColumn, control.LinkRow); err != nil {
+			return err
+		}
+		link = `<x:FmlaLink>` + absoluteReference(control.LinkColumn, control.LinkRow) + `</x:FmlaLink>`
+	}
+	objectType := "Checkbox"
+	if control.Type == OptionButtonControl {
+		objectType = "Radio"
+	}
+	checked := ""
+	if control.Checked {
+		checked = `<x:Checked>1</x:Checked>`
+	}
+	// The anchor is the column, offset, row and offset of the top left and bottom right corners, with rows starting at
+	// 0 and offsets in pixels. The control fills the cell.
+	column := strconv.Itoa(control.Column)
+	row := strconv.Itoa(control.Row - 1)
+	anchor := column + ", 4, " + row + ", 1, " + strconv.Itoa(control.Column+1) + ", 0, " + row + ", 19"
+
+	vml := sf.vml(sheetArrayIndex)
+	vml.addShapeType("_x0000_t201", controlShapeType)
+	vml.addShape("", ` type="#_x0000_t201" style="position:absolute;z-index:`+strconv.Itoa(len(vml.shapes)+1)+`"`+
+		` filled="f" stroked="f" o:insetmode="auto">`+
+		`<v:textbox style="mso-direction-alt:auto" o:singleclick="f"><div style="text-align:left">`+
+		`<font face="Calibri" size="220" color="#000000">`+escapeXML(control.Label)+`</font></div></v:textbox>`+
+		`<x:ClientData ObjectType="`+objectType+`"><x:Anchor>`+anchor+`</x:Anchor><x:AutoFill>False</x:AutoFill>`+
+		`<x:AutoLine>False</x:AutoLine><x:TextVAlign>Center</x:TextVAlign>`+link+checked+`<x:NoThreeD/>`+
+		`</x:ClientData></v:shape>`)
+	return nil
+}
@@ -0,0 +1,75 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAddFormControl(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Approved", "Approved Value"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123", "", ""}); err != nil {
+		t.Fatal(err)
+	}
+	control := FormControl{Column: 1, Row: 2, Label: "Approved <by> me", Checked: true, LinkColumn: 2, LinkRow: 2}
+	if err := excelStream.AddFormControl("Sheet1", control); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.AddFormControl("Sheet1", FormControl{Type: 10, Column: 1, Row: 3}); err != UnknownFormControlTypeError {
+		t.Fatalf("Expected UnknownFormControlTypeError, got %v", err)
+	}
+	if err := excelStream.AddFormControl("Sheet1", FormControl{Column: 1, Row: 3, LinkRow: maxRows + 1}); err != RowOutOfRangeError {
+		t.Fatalf("Expected RowOutOfRangeError, got %v", err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `r:id="rId1"></legacyDrawing>`) {
+		t.Fatalf("Expected a legacy drawing in the sheet: %s", sheetXML)
+	}
+	vmlXML := readZipPart(t, buffer.Bytes(), "xl/drawings/vmlDrawing1.vml")
+	expectedParts := []string{
+		`<v:shape id="_x0000_s1025" type="#_x0000_t201"`,
+		`Approved &lt;by&gt; me`,
+		`<x:ClientData ObjectType="Checkbox"><x:Anchor>1, 4, 1, 1, 2, 0, 1, 19</x:Anchor>`,
+		`<x:FmlaLink>$C$2</x:FmlaLink><x:Checked>1</x:Checked>`,
+	}
+	for _, expectedPart := range expectedParts {
+		if !strings.Contains(vmlXML, expectedPart) {
+			t.Fatalf("Expected %s in %s", expectedPart, vmlXML)
+		}
+	}
+}
+
+func TestVMLShapeIDBlocks(t *testing.T) {
+	sf := &StreamFile{sheetExtras: make([]sheetExtras, 3)}
+	sf.sheetExtras[0].vml = &sheetVML{name: "vmlDrawing1", shapes: make([]vmlShape, vmlShapeIDBlockSize)}
+	sf.sheetExtras[2].vml = &sheetVML{name: "vmlDrawing3", shapes: make([]vmlShape, 1)}
+	sf.addVMLParts()
+	if len(sf.parts) != 2 {
+		t.Fatalf("Expected two VML parts, got %d", len(sf.parts))
+	}
+	first := string(sf.parts[0].data)
+	if !strings.Contains(first, `data="1,2"`) || !strings.Contains(first, `id="_x0000_s2048"`) {
+		t.Fatalf("Expected the first drawing to use two blocks of IDs: %s", first)
+	}
+	second := string(sf.parts[1].data)
+	if sf.parts[1].name != "xl/drawings/vmlDrawing3.vml" || !strings.Contains(second, `data="3"`) ||
+		!strings.Contains(second, `id="_x0000_s3073"`) {
+		t.Fatalf("Expected the second drawing to use the next block of IDs: %s", second)
+	}
+}
@@ -0,0 +1,68 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	commentsPathPrefix       = "xl/comments"
+	commentsContentType      = "application/vnd.openxmlformats-officedocument.spreadsheetml.comments+xml"
+	commentsRelationshipType = relationshipsNamespace + "/comments"
+	spreadsheetMLNamespace   = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+)
+
+// noteShapeType is the VML shape type Excel uses for the boxes of comments.
+const noteShapeType = `<v:shapetype id="_x0000_t202" coordsize="21600,21600" o:spt="202" path="m,l,21600r21600,l21600,xe">` +
+	`<v:stroke joinstyle="miter"/><v:path gradientshapeok="t" o:connecttype="rect"/></v:shapetype>`
+
+var ColumnCommentTooLongError = errors.New("Column comment is longer than Excel allows")
+
+// validateComment checks the column's header comment.
+func (def *ColumnDef) validateComment() error {
+	if excelLength(def.Comment) > maxCellLength {
+		return ColumnCommentTooLongError
+	}
+	return nil
+}
+
+// addHeaderComments adds the comments of the column definitions to their header cells, if any of them have one.
+func (sf *StreamFile) addHeaderComments(sheetArrayIndex int, columns []ColumnDef) {
+	headerRow := sf.headerRows[sheetArrayIndex]
+	var comments strings.Builder
+	for i, def := range columns {
+		if def.Comment == "" {
+			continue
+		}
+		ref, _ := cellReference(i, headerRow)
+		comments.WriteString(`<comment ref="` + ref + `" authorId="0"><text><t xml:space="preserve">` +
+			escapeXML(def.Comment) + `</t></text></comment>`)
+		sf.addNoteShape(sheetArrayIndex, i, headerRow)
+	}
+	if comments.Len() == 0 {
+		return
+	}
+	number := strconv.Itoa(sheetArrayIndex + 1)
+	sf.addSheetRelationship(sheetArrayIndex, commentsRelationshipType, "../comments"+number+".xml")
+	sf.contentTypes.addOverride(commentsPathPrefix+number+".xml", commentsContentType)
+	sf.addPart(commentsPathPrefix+number+".xml", []byte(`<comments xmlns="`+spreadsheetMLNamespace+`">`+
+		`<authors><author></author></authors><commentList>`+comments.String()+`</commentList></comments>`))
+}
+
+// addNoteShape adds the hidden box Excel shows a cell's comment in when the mouse is over the cell. Excel does not show
+// comments that do not have one.
+func (sf *StreamFile) addNoteShape(sheetArrayIndex, column, row int) {
+	// The box is anchored to the right of the cell, like Excel places new comments. Rows in the anchor start at 0.
+	anchor := strconv.Itoa(column+1) + ", 15, " + strconv.Itoa(row-1) + ", 2, " + strconv.Itoa(column+3) + ", 15, " +
+		strconv.Itoa(row+3) + ", 16"
+	vml := sf.vml(sheetArrayIndex)
+	vml.addShapeType("_x0000_t202", noteShapeType)
+	vml.addShape("", ` type="#_x0000_t202" style="position:absolute;width:108pt;height:59.25pt;z-index:`+
+		strconv.Itoa(len(vml.shapes)+1)+`;visibility:hidden" fillcolor="#ffffe1" o:insetmode="auto">`+
+		`<v:fill color2="#ffffe1"/><v:shadow on="t" color="black" obscured="t"/><v:path o:connecttype="none"/>`+
+		`<v:textbox style="mso-direction-alt:auto"><div style="text-align:left"></div></v:textbox>`+
+		`<x:ClientData ObjectType="Note"><x:MoveWithCells/><x:SizeWithCells/><x:Anchor>`+anchor+`</x:Anchor>`+
+		`<x:AutoFill>False</x:AutoFill><x:Row>`+strconv.Itoa(row-1)+`</x:Row><x:Column>`+strconv.Itoa(column)+
+		`</x:Column></x:ClientData></v:shape>`)
+}
@@ -0,0 +1,59 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHeaderComments(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	columns := []ColumnDef{
+		{Name: "Token"},
+		{Name: "Latency", Type: NumberColumn, Comment: "Milliseconds from <request> to response"},
+	}
+	if err := file.AddSheetWithColumns("Sheet1", columns); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123", "45"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	commentsXML := readZipPart(t, buffer.Bytes(), "xl/comments1.xml")
+	if !strings.Contains(commentsXML, `<comment ref="B1" authorId="0"><text><t xml:space="preserve">`+
+		`Milliseconds from &lt;request&gt; to response</t></text></comment>`) {
+		t.Fatalf("Expected a comment on the header cell: %s", commentsXML)
+	}
+	vmlXML := readZipPart(t, buffer.Bytes(), "xl/drawings/vmlDrawing1.vml")
+	if !strings.Contains(vmlXML, `<x:ClientData ObjectType="Note">`) ||
+		!strings.Contains(vmlXML, `<x:Row>0</x:Row><x:Column>1</x:Column>`) {
+		t.Fatalf("Expected a note shape for the header cell: %s", vmlXML)
+	}
+	relsXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/_rels/sheet1.xml.rels")
+	if !strings.Contains(relsXML, `Target="../comments1.xml"`) {
+		t.Fatalf("Expected the sheet to link to its comments: %s", relsXML)
+	}
+	contentTypes := readZipPart(t, buffer.Bytes(), "[Content_Types].xml")
+	if !strings.Contains(contentTypes, `PartName="/xl/comments1.xml"`) {
+		t.Fatalf("Expected a content type for the comments: %s", contentTypes)
+	}
+}
+
+func TestValidateColumnComment(t *testing.T) {
+	def := ColumnDef{Name: "Name", Comment: strings.Repeat("a", maxCellLength+1)}
+	if err := def.validate(); err != ColumnCommentTooLongError {
+		t.Fatalf("Expected ColumnCommentTooLongError, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package excel_stream
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"image"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// HeaderFooterPosition is one of the six sections of the header and footer printed on every page of a sheet.
+type HeaderFooterPosition int
+
+const (
+	LeftHeader HeaderFooterPosition = iota
+	CenterHeader
+	RightHeader
+	LeftFooter
+	CenterFooter
+	RightFooter
+	headerFooterPositions
+)
+
+// headerFooterImageShapeType is the VML shape type Excel uses for pictures.
+const headerFooterImageShapeType = `<v:shapetype id="_x0000_t75" coordsize="21600,21600" o:spt="75" o:preferrelative="t"` +
+	` path="m@4@5l@4@11@9@11@9@5xe" filled="f" stroked="f"><v:stroke joinstyle="miter"/><v:formulas>` +
+	`<v:f eqn="if lineDrawn pixelLineWidth 0"/><v:f eqn="sum @0 1 0"/><v:f eqn="sum 0 0 @1"/><v:f eqn="prod @2 1 2"/>` +
+	`<v:f eqn="prod @3 21600 pixelWidth"/><v:f eqn="prod @3 21600 pixelHeight"/><v:f eqn="sum @0 0 1"/>` +
+	`<v:f eqn="prod @6 1 2"/><v:f eqn="prod @7 21600 pixelWidth"/><v:f eqn="sum @8 21600 0"/>` +
+	`<v:f eqn="prod @7 21600 pixelHeight"/><v:f eqn="sum @10 21600 0"/></v:formulas>` +
+	`<v:path o:extrusionok="f" gradientshapeok="t" o:connecttype="rect"/><o:lock v:ext="edit" aspectratio="t"/>` +
+	`</v:shapetype>`
+
+var (
+	UnknownHeaderFooterPositionError = errors.New("Unknown header or footer position")
+	HeaderFooterImageAlreadySetError = errors.New("Header or footer position already has an image")
+)
+
+// shapeName returns the name Excel gives the VML shape for an image in the position.
+func (p HeaderFooterPosition) shapeName() string {
+	return [headerFooterPositions]string{"LH", "CH", "RH", "LF", "CF", "RF"}[p]
+}
+
+// SetHeaderFooterImage prints an image, like a company logo, in a section of the header or footer of every page of
+// the named sheet. The image replaces any text the section had. The sheet must be the current sheet or one that has
+// not been started yet.
+// The image data is read immediately and held in memory until Close, when it is written to the file.
+func (sf *StreamFile) SetHeaderFooterImage(sheetName string, position HeaderFooterPosition, r io.Reader, format ImageFormat) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	if position < LeftHeader || position >= headerFooterPositions {
+		return UnknownHeaderFooterPositionError
+	}
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	if extras.headerFooterImages[position] {
+		return HeaderFooterImageAlreadySetError
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	mediaName, err := sf.addMedia(data, format)
+	if err != nil {
+		return err
+	}
+	extras.headerFooterImages[position] = true
+	if extras.headerFooterVML == nil {
+		extras.headerFooterVML = sf.newVML(sheetArrayIndex, "vmlDrawingHF"+strconv.Itoa(sheetArrayIndex+1), "legacyDrawingHF")
+	}
+	vml := extras.headerFooterVML
+	vml.addShapeType("_x0000_t75", headerFooterImageShapeType)
+	id := vml.addRelationship(imageRelationshipType, "../media/"+mediaName)
+	// VML sizes are in points, and there are 0.75 points in a pixel.
+	width := strconv.FormatFloat(float64(config.Width)*0.75, 'f', -1, 64)
+	height := strconv.FormatFloat(float64(config.Height)*0.75, 'f', -1, 64)
+	title := strings.TrimSuffix(mediaName, path.Ext(mediaName))
+	vml.addShape(position.shapeName(), ` type="#_x0000_t75" style="position:absolute;margin-left:0;margin-top:0;`+
+		`width:`+width+`pt;height:`+height+`pt;z-index:`+strconv.Itoa(len(vml.shapes)+1)+`">`+
+		`<v:imagedata`+xmlAttribute("o:relid", id)+xmlAttribute("o:title", title)+`/>`+
+		`<o:lock v:ext="edit" rotation="t"/></v:shape>`)
+	return nil
+}
+
+// setHeaderFooterImages replaces the headerFooter element at the end of a sheet's XML with one that has the &G image
+// code in each section with an image. The text of the other sections is kept.
+func setHeaderFooterImages(suffix string, images [headerFooterPositions]bool) string {
+	var headerFooter struct {
+		OddHeader string `xml:"oddHeader"`
+		OddFooter string `xml:"oddFooter"`
+	}
+	if start := findElement(suffix, "headerFooter"); start != -1 {
+		endTag := "</headerFooter>"
+		if end := strings.Index(suffix[start:], endTag); end != -1 {
+			end += start + len(endTag)
+			// If the existing element can not be read, its text is dropped, which only loses the default header.
+			xml.Unmarshal([]byte(suffix[start:end]), &headerFooter)
+			suffix = suffix[:start] + suffix[end:]
+		}
+	}
+	header := splitHeaderFooter(headerFooter.OddHeader)
+	footer := splitHeaderFooter(headerFooter.OddFooter)
+	for position, hasImage := range images {
+		if !hasImage {
+			continue
+		}
+		if position < int(LeftFooter) {
+			header[position] = "&G"
+		} else {
+			footer[position-int(LeftFooter)] = "&G"
+		}
+	}
+	element := `<headerFooter>`
+	if text := joinHeaderFooter(header); text != "" {
+		element += `<oddHeader>` + escapeXML(text) + `</oddHeader>`
+	}
+	if text := joinHeaderFooter(footer); text != "" {
+		element += `<oddFooter>` + escapeXML(text) + `</oddFooter>`
+	}
+	element += `</headerFooter>`
+	return insertSheetElement(suffix, "headerFooter", element)
+}
+
+// splitHeaderFooter splits the text of a header or footer into its left, center and right sections, which are started
+// by the &L, &C and &R codes. Text before the first of these codes is in the center section.
+func splitHeaderFooter(text string) [3]string {
+	var sections [3]string
+	section := 1
+	start := 0
+	for i := 0; i < len(text)-1; i++ {
+		if text[i] != '&' {
+			continue
+		}
+		next := strings.IndexByte("LCR", text[i+1])
+		if next == -1 {
+			// Skip the code's character, so that an escaped ampersand, &&, is not read as the start of a code.
+			i++
+			continue
+		}
+		sections[section] += text[start:i]
+		section = next
+		start = i + 2
+		i++
+	}
+	sections[section] += text[start:]
+	return sections
+}
+
+// joinHeaderFooter joins the left, center and right sections of a header or footer back into its text.
+func joinHeaderFooter(sections [3]string) string {
+	text := ""
+	for i, code := range []string{"&L", "&C", "&R"} {
+		if sections[i] != "" {
+			text += code + sections[i]
+		}
+	}
+	return text
+}
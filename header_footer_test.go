@@ -0,0 +1,92 @@
+package excel_stream
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestSplitHeaderFooter(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected [3]string
+	}{
+		{text: "", expected: [3]string{"", "", ""}},
+		{text: "Page &P", expected: [3]string{"", "Page &P", ""}},
+		{text: "&LLeft&CCenter&RRight", expected: [3]string{"Left", "Center", "Right"}},
+		{text: `&C&"Times New Roman,Regular"&12&A`, expected: [3]string{"", `&"Times New Roman,Regular"&12&A`, ""}},
+		{text: "&LR&&D&RX", expected: [3]string{"R&&D", "", "X"}},
+	}
+	for _, testCase := range testCases {
+		actual := splitHeaderFooter(testCase.text)
+		if actual != testCase.expected {
+			t.Fatalf("Splitting %q: expected %q, got %q", testCase.text, testCase.expected, actual)
+		}
+	}
+}
+
+func TestJoinHeaderFooter(t *testing.T) {
+	if actual := joinHeaderFooter([3]string{"Left", "", "Right"}); actual != "&LLeft&RRight" {
+		t.Fatalf("Unexpected header %q", actual)
+	}
+}
+
+func TestSetHeaderFooterImages(t *testing.T) {
+	suffix := `<pageSetup></pageSetup><headerFooter differentFirst="false"><oddHeader>&amp;C&amp;A</oddHeader>` +
+		`<oddFooter>&amp;CPage &amp;P</oddFooter></headerFooter></worksheet>`
+	var images [headerFooterPositions]bool
+	images[LeftHeader] = true
+	images[CenterFooter] = true
+	expected := `<pageSetup></pageSetup><headerFooter><oddHeader>&amp;L&amp;G&amp;C&amp;A</oddHeader>` +
+		`<oddFooter>&amp;C&amp;G</oddFooter></headerFooter></worksheet>`
+	if actual := setHeaderFooterImages(suffix, images); actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestSetHeaderFooterImage(t *testing.T) {
+	imageBuffer := bytes.NewBuffer(nil)
+	if err := png.Encode(imageBuffer, image.NewRGBA(image.Rect(0, 0, 4, 2))); err != nil {
+		t.Fatal(err)
+	}
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.SetHeaderFooterImage("Sheet1", RightHeader, bytes.NewReader(imageBuffer.Bytes()), PNG); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.SetHeaderFooterImage("Sheet1", RightHeader, bytes.NewReader(imageBuffer.Bytes()), PNG); err != HeaderFooterImageAlreadySetError {
+		t.Fatalf("Expected HeaderFooterImageAlreadySetError, got %v", err)
+	}
+	if err := excelStream.SetHeaderFooterImage("Sheet1", 10, bytes.NewReader(imageBuffer.Bytes()), PNG); err != UnknownHeaderFooterPositionError {
+		t.Fatalf("Expected UnknownHeaderFooterPositionError, got %v", err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `&amp;R&amp;G</oddHeader>`) || !strings.Contains(sheetXML, `</legacyDrawingHF>`) {
+		t.Fatalf("Expected the header to show the image: %s", sheetXML)
+	}
+	vmlXML := readZipPart(t, buffer.Bytes(), "xl/drawings/vmlDrawingHF1.vml")
+	if !strings.Contains(vmlXML, `<v:shape id="RH" o:spid="_x0000_s1025"`) || !strings.Contains(vmlXML, `width:3pt;height:1.5pt`) {
+		t.Fatalf("Expected a shape for the image: %s", vmlXML)
+	}
+	vmlRels := readZipPart(t, buffer.Bytes(), "xl/drawings/_rels/vmlDrawingHF1.vml.rels")
+	if !strings.Contains(vmlRels, `Target="../media/image1.png"`) {
+		t.Fatalf("Expected a relationship to the image: %s", vmlRels)
+	}
+}
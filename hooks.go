@@ -0,0 +1,50 @@
+package excel_stream
+
+// SheetXMLHooks change the XML of each sheet just before it is written, as a way to use parts of the file format that
+// the library does not support yet. The hooks are given the sheet's name and the XML the library would write, and
+// return the XML to write in its place. Hooks that are nil leave the XML as it is. The XML they return is not
+// checked, so it must keep the sheet valid, and rows must keep their row numbers. If a hook panics, the call that ran it
+// returns an error wrapping CallbackPanicError, and nothing more can be written to the StreamFile.
+type SheetXMLHooks struct {
+	// Prefix changes the start of the sheet, everything up to and including the header row.
+	Prefix func(sheetName, xml string) string
+	// Row changes each row written by WriteRow or WriteRowOpts. The rows the library adds, such as totals, are not
+	// passed to it. rowNumber is the Excel row number of the row, which starts at 1.
+	Row func(sheetName string, rowNumber int, xml string) string
+	// Suffix changes the end of the sheet, everything after the sheetData element, once everything that was added to
+	// the sheet while streaming is in it.
+	Suffix func(sheetName, xml string) string
+}
+
+// SetSheetXMLHooks sets the hooks that change the XML of every sheet before it is written.
+func (sb *StreamFileBuilder) SetSheetXMLHooks(hooks SheetXMLHooks) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.xmlHooks = hooks
+	return nil
+}
+
+// hookPrefix returns the start of the current sheet after the prefix hook has changed it.
+func (sf *StreamFile) hookPrefix(xml string) (hooked string, err error) {
+	if sf.xmlHooks.Prefix == nil {
+		return xml, nil
+	}
+	defer sf.recoverCallback("prefix hook", &err)
+	return sf.xmlHooks.Prefix(sf.xlsxFile.Sheets[sf.currentSheet.index-1].Name, xml), nil
+}
+
+// hookRow returns the XML of a row after the row hook has changed it.
+func (sf *StreamFile) hookRow(sheetName, xml string) (hooked string, err error) {
+	defer sf.recoverCallback("row hook", &err)
+	return sf.xmlHooks.Row(sheetName, sf.currentSheet.rowCount, xml), nil
+}
+
+// hookSuffix returns the end of the current sheet after the suffix hook has changed it.
+func (sf *StreamFile) hookSuffix(xml string) (hooked string, err error) {
+	if sf.xmlHooks.Suffix == nil {
+		return xml, nil
+	}
+	defer sf.recoverCallback("suffix hook", &err)
+	return sf.xmlHooks.Suffix(sf.xlsxFile.Sheets[sf.currentSheet.index-1].Name, xml), nil
+}
@@ -0,0 +1,80 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSheetXMLHooks(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	var rowNumbers []int
+	err := file.SetSheetXMLHooks(SheetXMLHooks{
+		Prefix: func(sheetName, xml string) string {
+			return strings.Replace(xml, `<sheetData>`, `<!--`+sheetName+` start--><sheetData>`, 1)
+		},
+		Row: func(sheetName string, rowNumber int, xml string) string {
+			rowNumbers = append(rowNumbers, rowNumber)
+			return strings.Replace(xml, `<row r="`+strconv.Itoa(rowNumber)+`"`, `<row r="`+strconv.Itoa(rowNumber)+
+				`" thickBot="1"`, 1)
+		},
+		Suffix: func(sheetName, xml string) string {
+			return `<!--` + sheetName + ` end-->` + xml
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Taco", "Burrito"} {
+		if err := excelStream.WriteRow([]string{name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rowNumbers) != 2 || rowNumbers[0] != 2 || rowNumbers[1] != 3 {
+		t.Fatalf("Expected the row hook for rows 2 and 3, got %v", rowNumbers)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<!--Sheet1 start--><sheetData>`,
+		`<row r="2" thickBot="1"><c r="A2" t="inlineStr"><is><t>Taco</t></is></c></row>`,
+		`<row r="3" thickBot="1">`,
+		`</sheetData><!--Sheet1 end-->`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
+
+func TestWriteRowXMLHook(t *testing.T) {
+	var output bytes.Buffer
+	sf := &StreamFile{
+		currentSheet: &streamSheet{index: 1, rowCount: 2, columnCount: 1, writer: &output},
+		xmlHooks: SheetXMLHooks{Row: func(sheetName string, rowNumber int, xml string) string {
+			return sheetName + ":" + strconv.Itoa(rowNumber) + ":" + xml
+		}},
+	}
+	columns := &sheetColumns{}
+	if err := sf.writeRowXML("Sheet1", columns, []string{"Taco"}, []cellKind{textCell}, RowOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Sheet1:2:<row r="2"><c r="A2" t="inlineStr"><is><t>Taco</t></is></c></row>`
+	if output.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, output.String())
+	}
+	if sf.currentSheet.writer != &output {
+		t.Fatal("Expected the sheet's writer to be put back")
+	}
+}
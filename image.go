@@ -0,0 +1,143 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// ImageFormat is the file format of an image added with AddImage.
+type ImageFormat int
+
+const (
+	PNG ImageFormat = iota
+	JPEG
+	GIF
+)
+
+const (
+	mediaPathPrefix       = "xl/media/image"
+	imageRelationshipType = relationshipsNamespace + "/image"
+)
+
+var (
+	UnknownImageFormatError        = errors.New("Unknown image format")
+	BackgroundImageAlreadySetError = errors.New("Sheet already has a background image")
+)
+
+// extension returns the file extension and content type for images in the format.
+func (f ImageFormat) extension() (string, string, error) {
+	switch f {
+	case PNG:
+		return "png", "image/png", nil
+	case JPEG:
+		return "jpeg", "image/jpeg", nil
+	case GIF:
+		return "gif", "image/gif", nil
+	}
+	return "", "", UnknownImageFormatError
+}
+
+// AddImage places an image over the named sheet, such as a logo or a thumbnail next to a row. The sheet must be the
+// current sheet or one that has not been started yet. If the anchor's width and height are 0, the image is shown at
+// its own size, which requires the image data to be decodable.
+// The image data is read immediately and held in memory until Close, when it is written to the file.
+func (sf *StreamFile) AddImage(sheetName string, anchor CellAnchor, r io.Reader, format ImageFormat) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	if err := anchor.validate(); err != nil {
+		return err
+	}
+	if _, _, err := format.extension(); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if anchor.Width == 0 {
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		anchor.Width = config.Width
+		anchor.Height = config.Height
+	}
+
+	mediaName, err := sf.addMedia(data, format)
+	if err != nil {
+		return err
+	}
+	drawing := sf.drawing(sheetArrayIndex)
+	id := drawing.addRelationship(imageRelationshipType, "../media/"+mediaName)
+	objectID := strconv.Itoa(drawing.nextObjectID())
+	drawing.anchors = append(drawing.anchors, `<xdr:oneCellAnchor>`+anchor.fromXML()+anchor.extentXML()+
+		`<xdr:pic><xdr:nvPicPr><xdr:cNvPr id="`+objectID+`" name="Picture `+objectID+`"/>`+
+		`<xdr:cNvPicPr><a:picLocks noChangeAspect="1"/></xdr:cNvPicPr></xdr:nvPicPr>`+
+		`<xdr:blipFill><a:blip r:embed="`+id+`"/><a:stretch><a:fillRect/></a:stretch></xdr:blipFill>`+
+		`<xdr:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="`+strconv.Itoa(anchor.Width*emusPerPixel)+
+		`" cy="`+strconv.Itoa(anchor.Height*emusPerPixel)+`"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom>`+
+		`</xdr:spPr></xdr:pic><xdr:clientData/></xdr:oneCellAnchor>`)
+	return nil
+}
+
+// SetBackgroundImage sets the picture that is tiled behind the cells of the named sheet, such as a DRAFT or
+// CONFIDENTIAL watermark. Each sheet can have one background image. The sheet must be the current sheet or one that has
+// not been started yet.
+// The image data is read immediately and held in memory until Close, when it is written to the file.
+func (sf *StreamFile) SetBackgroundImage(sheetName string, r io.Reader, format ImageFormat) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	if sf.sheetExtras[sheetArrayIndex].hasBackground {
+		return BackgroundImageAlreadySetError
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	mediaName, err := sf.addMedia(data, format)
+	if err != nil {
+		return err
+	}
+	sf.sheetExtras[sheetArrayIndex].hasBackground = true
+	id := sf.addSheetRelationship(sheetArrayIndex, imageRelationshipType, "../media/"+mediaName)
+	sf.addSheetElement(sheetArrayIndex, "picture", `<picture xmlns:r="`+relationshipsNamespace+`" r:id="`+id+`"></picture>`)
+	return nil
+}
+
+// addMedia adds the image data to the file's media, and returns the name of its part in the media directory.
+func (sf *StreamFile) addMedia(data []byte, format ImageFormat) (string, error) {
+	extension, contentType, err := format.extension()
+	if err != nil {
+		return "", err
+	}
+	sf.imageCount++
+	mediaName := "image" + strconv.Itoa(sf.imageCount) + "." + extension
+	sf.addPart(mediaPathPrefix+strconv.Itoa(sf.imageCount)+"."+extension, data)
+	sf.contentTypes.addDefault(extension, contentType)
+	return mediaName, nil
+}
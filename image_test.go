@@ -0,0 +1,129 @@
+package excel_stream
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestAddImage(t *testing.T) {
+	imageBuffer := bytes.NewBuffer(nil)
+	if err := png.Encode(imageBuffer, image.NewRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.AddImage("Sheet1", CellAnchor{Column: 2, Row: 1}, bytes.NewReader(imageBuffer.Bytes()), PNG); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123", "Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	anchor := CellAnchor{Column: 2, Row: 2, Width: 16, Height: 16}
+	if err := excelStream.AddImage("Sheet1", anchor, bytes.NewReader(imageBuffer.Bytes()), PNG); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.AddImage("Missing", anchor, bytes.NewReader(imageBuffer.Bytes()), PNG); err != UnknownSheetError {
+		t.Fatalf("Expected UnknownSheetError, got %v", err)
+	}
+	if err := excelStream.AddImage("Sheet1", CellAnchor{Width: 16}, bytes.NewReader(imageBuffer.Bytes()), PNG); err != RowOutOfRangeError {
+		t.Fatalf("Expected RowOutOfRangeError, got %v", err)
+	}
+	if err := excelStream.AddImage("Sheet1", CellAnchor{Row: 1, Width: 16}, bytes.NewReader(imageBuffer.Bytes()), PNG); err != InvalidAnchorSizeError {
+		t.Fatalf("Expected InvalidAnchorSizeError, got %v", err)
+	}
+	if err := excelStream.NextSheet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.AddImage("Sheet1", anchor, bytes.NewReader(imageBuffer.Bytes()), PNG); err != SheetFinalizedError {
+		t.Fatalf("Expected SheetFinalizedError, got %v", err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `r:id="rId1"></drawing></worksheet>`) {
+		t.Fatalf("Expected a drawing at the end of the sheet: %s", sheetXML)
+	}
+	sheetRels := readZipPart(t, buffer.Bytes(), "xl/worksheets/_rels/sheet1.xml.rels")
+	if !strings.Contains(sheetRels, `Target="../drawings/drawing1.xml"`) {
+		t.Fatalf("Expected a relationship to the drawing: %s", sheetRels)
+	}
+	drawingXML := readZipPart(t, buffer.Bytes(), "xl/drawings/drawing1.xml")
+	if strings.Count(drawingXML, "<xdr:oneCellAnchor>") != 2 {
+		t.Fatalf("Expected two images in the drawing: %s", drawingXML)
+	}
+	// The first image uses its own size of 4 by 3 pixels.
+	if !strings.Contains(drawingXML, `<xdr:ext cx="38100" cy="28575"/>`) {
+		t.Fatalf("Expected the image's own size: %s", drawingXML)
+	}
+	drawingRels := readZipPart(t, buffer.Bytes(), "xl/drawings/_rels/drawing1.xml.rels")
+	if !strings.Contains(drawingRels, `Target="../media/image2.png"`) {
+		t.Fatalf("Expected a relationship to the second image: %s", drawingRels)
+	}
+	if readZipPart(t, buffer.Bytes(), "xl/media/image1.png") != imageBuffer.String() {
+		t.Fatal("Expected the image data to be written unchanged")
+	}
+	contentTypesXML := readZipPart(t, buffer.Bytes(), contentTypesPath)
+	if !strings.Contains(contentTypesXML, `Extension="png"`) || !strings.Contains(contentTypesXML, `/xl/drawings/drawing1.xml`) {
+		t.Fatalf("Expected content types for the image and drawing: %s", contentTypesXML)
+	}
+}
+
+func TestSetBackgroundImage(t *testing.T) {
+	imageBuffer := bytes.NewBuffer(nil)
+	if err := png.Encode(imageBuffer, image.NewRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.SetBackgroundImage("Sheet1", bytes.NewReader(imageBuffer.Bytes()), 10); err != UnknownImageFormatError {
+		t.Fatalf("Expected UnknownImageFormatError, got %v", err)
+	}
+	if err := excelStream.SetBackgroundImage("Sheet1", bytes.NewReader(imageBuffer.Bytes()), PNG); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.SetBackgroundImage("Sheet1", bytes.NewReader(imageBuffer.Bytes()), PNG); err != BackgroundImageAlreadySetError {
+		t.Fatalf("Expected BackgroundImageAlreadySetError, got %v", err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `r:id="rId1"></picture></worksheet>`) {
+		t.Fatalf("Expected a background picture in the sheet: %s", sheetXML)
+	}
+	sheetRels := readZipPart(t, buffer.Bytes(), "xl/worksheets/_rels/sheet1.xml.rels")
+	if !strings.Contains(sheetRels, `Type="`+imageRelationshipType+`" Target="../media/image1.png"`) {
+		t.Fatalf("Expected a relationship to the image: %s", sheetRels)
+	}
+}
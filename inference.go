@@ -0,0 +1,195 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultInferredDateFormat is the number format of inferred dates when TypeInference does not set one.
+	defaultInferredDateFormat = "yyyy-mm-dd"
+	// maxInferredDigits is the most digits a number can have and still be inferred. Excel only keeps 15 significant
+	// digits, so longer numbers, such as account or card numbers, stay text instead of losing their last digits.
+	maxInferredDigits = 15
+)
+
+var (
+	EmptyDateLayoutError = errors.New("Date layouts for type inference must not be empty")
+	StrictTextError      = errors.New("Type inference can not be used with strict text")
+)
+
+// excelEpoch is day 0 of Excel's date serial numbers. It is the last day of 1899 rather than the first day of 1900
+// because Excel counts February 29, 1900, which did not happen.
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// excelLeapBug is the first day whose serial number is not shifted by Excel's February 29, 1900.
+var excelLeapBug = time.Date(1900, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	// maxDateYear is the last year Excel can store a date in.
+	maxDateYear = 9999
+	// secondsPerDay is the length of a calendar day in Unix time.
+	secondsPerDay = 24 * 60 * 60
+)
+
+// TypeInference controls which cells of text columns WriteRow writes as typed cells instead of as text. The zero
+// TypeInference infers nothing, which is the default. Cells in number and formula columns are not affected.
+type TypeInference struct {
+	// Numbers writes cells that are numbers in plain decimal notation, such as -12.5 or 1e3, as numbers. To keep codes
+	// like zip codes and IDs intact, numbers with leading zeros, a leading + or more than 15 digits stay text.
+	Numbers bool
+	// Booleans writes cells that are TRUE or FALSE, in any case, as booleans.
+	Booleans bool
+	// DateLayouts are Go time layouts, such as "2006-01-02", that are tried in order on each cell. Cells that match one
+	// are written as dates, with DateFormat as their number format. Dates before 1900 stay text, since Excel can not
+	// store them.
+	DateLayouts []string
+	// DateFormat is the number format of inferred dates. If it is empty, "yyyy-mm-dd" is used.
+	DateFormat string
+}
+
+// SetTypeInference makes WriteRow write the cells of text columns that look like numbers, booleans or dates as typed
+// cells, for callers that only have text to pass. By default every cell of a text column is written as text exactly as
+// it is given, which is what keeps values like gene names and part numbers from being turned into dates and numbers.
+func (sb *StreamFileBuilder) SetTypeInference(inference TypeInference) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	for _, layout := range inference.DateLayouts {
+		if layout == "" {
+			return EmptyDateLayoutError
+		}
+	}
+	if sb.strictText && inference.enabled() {
+		return StrictTextError
+	}
+	inference.DateLayouts = append([]string(nil), inference.DateLayouts...)
+	sb.typeInference = inference
+	return nil
+}
+
+// SetStrictText guarantees that no cell is ever reinterpreted: every cell of a text column is written as text exactly
+// as it is given, apart from the changes the SanitizePolicy makes, and only number and formula columns hold anything
+// else. Values like SEPT2, 1-2 or 00123 are never turned into dates or numbers. Strict text is the same as the default
+// behavior, but it makes the guarantee explicit, so that SetTypeInference returns StrictTextError instead of quietly
+// turning inference on later. Turning it on after type inference was set up also returns StrictTextError.
+func (sb *StreamFileBuilder) SetStrictText(strict bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if strict && sb.typeInference.enabled() {
+		return StrictTextError
+	}
+	sb.strictText = strict
+	return nil
+}
+
+// enabled reports whether the type inference infers anything.
+func (t *TypeInference) enabled() bool {
+	return t.Numbers || t.Booleans || len(t.DateLayouts) > 0
+}
+
+// dateFormat returns the number format of inferred dates.
+func (t *TypeInference) dateFormat() string {
+	if t.DateFormat == "" {
+		return defaultInferredDateFormat
+	}
+	return t.DateFormat
+}
+
+// infer returns the value to write for the cell and how to write it, if the cell is one of the types being inferred.
+// It returns false for cells that should be written as text.
+func (t *TypeInference) infer(cellData string) (string, cellKind, bool) {
+	if cellData == "" {
+		return "", textCell, false
+	}
+	if t.Numbers && isInferredNumber(cellData) {
+		return cellData, numberCell, true
+	}
+	if t.Booleans {
+		if strings.EqualFold(cellData, "TRUE") {
+			return "1", boolCell, true
+		}
+		if strings.EqualFold(cellData, "FALSE") {
+			return "0", boolCell, true
+		}
+	}
+	for _, layout := range t.DateLayouts {
+		date, err := time.Parse(layout, cellData)
+		if err != nil {
+			continue
+		}
+		if serial, ok := dateSerial(date); ok {
+			return serial, dateCell, true
+		}
+	}
+	return "", textCell, false
+}
+
+// isInferredNumber reports whether the text is a number that type inference writes as a number. It is stricter than
+// isDecimalLiteral, so that text which only happens to be made of digits keeps its exact form.
+func isInferredNumber(text string) bool {
+	if !isDecimalLiteral(text) || text[0] == '+' {
+		return false
+	}
+	mantissa := strings.TrimPrefix(text, "-")
+	if exponent := strings.IndexAny(mantissa, "eE"); exponent != -1 {
+		mantissa = mantissa[:exponent]
+	}
+	// Leading zeros are only allowed in front of the decimal point, like 0.5.
+	if len(mantissa) > 1 && mantissa[0] == '0' && mantissa[1] != '.' {
+		return false
+	}
+	// Numbers like 5. and .5 are left as text.
+	if mantissa[0] == '.' || mantissa[len(mantissa)-1] == '.' {
+		return false
+	}
+	return len(strings.Replace(mantissa, ".", "", 1)) <= maxInferredDigits
+}
+
+// dateSerial returns the Excel date serial number of the date and time, as it reads on the wall clock of its time
+// zone. It returns false for dates before 1900 or after 9999, which Excel can not store. The whole days are counted
+// from the calendar date and the time of day is added to them, since a time.Duration can not span more than about 292
+// years.
+func dateSerial(date time.Time) (string, bool) {
+	year, month, day := date.Date()
+	if year < 1900 || year > maxDateYear {
+		return "", false
+	}
+	hour, minute, second := date.Clock()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	days := (midnight.Unix() - excelEpoch.Unix()) / secondsPerDay
+	if midnight.Before(excelLeapBug) {
+		days--
+	}
+	clock := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second +
+		time.Duration(date.Nanosecond())
+	return strconv.FormatFloat(float64(days)+float64(clock)/float64(24*time.Hour), 'f', -1, 64), true
+}
+
+// resolveDateStyles adds the cell styles of inferred dates in each column of a sheet to the style sheet. They are the
+// styles of the columns with the date format in place of their own.
+func (s *styleSheet) resolveDateStyles(columns *sheetColumns, defs []ColumnDef, rowStyle Style, format string) error {
+	var err error
+	if columns.defaultDateStyleID, err = s.addCellStyle(rowStyle, format); err != nil {
+		return err
+	}
+	columns.dateStyleIDs = make([]int, len(defs))
+	for i, def := range defs {
+		if columns.dateStyleIDs[i], err = s.addCellStyle(def.Style.over(rowStyle), format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dateStyleAttribute returns the s attribute for inferred dates in the column at the index.
+func (c *sheetColumns) dateStyleAttribute(colIndex int) string {
+	styleID := c.defaultDateStyleID
+	if colIndex < len(c.dateStyleIDs) {
+		styleID = c.dateStyleIDs[colIndex]
+	}
+	return styleIDAttribute(styleID)
+}
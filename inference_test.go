@@ -0,0 +1,202 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypeInferenceInfer(t *testing.T) {
+	inference := TypeInference{Numbers: true, Booleans: true, DateLayouts: []string{"2006-01-02", "01/02/2006 15:04"}}
+	testCases := []struct {
+		testName      string
+		cellData      string
+		expected      string
+		expectedKind  cellKind
+		expectedFound bool
+	}{
+		{testName: "Empty", cellData: ""},
+		{testName: "Text", cellData: "Taco"},
+		{testName: "Integer", cellData: "42", expected: "42", expectedKind: numberCell, expectedFound: true},
+		{testName: "Decimal", cellData: "-12.50", expected: "-12.50", expectedKind: numberCell, expectedFound: true},
+		{testName: "Exponent", cellData: "1.5e3", expected: "1.5e3", expectedKind: numberCell, expectedFound: true},
+		{testName: "Zero", cellData: "0.25", expected: "0.25", expectedKind: numberCell, expectedFound: true},
+		{testName: "Leading Zero", cellData: "02134"},
+		{testName: "Plus Sign", cellData: "+15551234567"},
+		{testName: "Card Number", cellData: "4111111111111111"},
+		{testName: "Trailing Point", cellData: "5."},
+		{testName: "Gene Name", cellData: "MARCH1"},
+		{testName: "True", cellData: "TRUE", expected: "1", expectedKind: boolCell, expectedFound: true},
+		{testName: "False", cellData: "false", expected: "0", expectedKind: boolCell, expectedFound: true},
+		{testName: "Yes", cellData: "yes"},
+		{testName: "Date", cellData: "2024-03-01", expected: "45352", expectedKind: dateCell, expectedFound: true},
+		{
+			testName:      "Date Time",
+			cellData:      "01/02/2024 18:00",
+			expected:      "45293.75",
+			expectedKind:  dateCell,
+			expectedFound: true,
+		},
+		{testName: "Old Date", cellData: "1850-06-01"},
+		{testName: "Bad Date", cellData: "2024-02-30"},
+	}
+	for _, testCase := range testCases {
+		actual, kind, found := inference.infer(testCase.cellData)
+		if actual != testCase.expected || kind != testCase.expectedKind || found != testCase.expectedFound {
+			t.Fatalf("%s: Expected %q, %v, %v, got %q, %v, %v", testCase.testName, testCase.expected,
+				testCase.expectedKind, testCase.expectedFound, actual, kind, found)
+		}
+	}
+	if _, _, found := (&TypeInference{}).infer("42"); found {
+		t.Fatal("Expected the zero TypeInference to infer nothing")
+	}
+}
+
+func TestDateSerial(t *testing.T) {
+	testCases := []struct {
+		date     time.Time
+		expected string
+	}{
+		{date: time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC), expected: "1"},
+		{date: time.Date(1900, time.February, 28, 0, 0, 0, 0, time.UTC), expected: "59"},
+		{date: time.Date(1900, time.March, 1, 0, 0, 0, 0, time.UTC), expected: "61"},
+		{date: time.Date(2000, time.January, 1, 12, 0, 0, 0, time.UTC), expected: "36526.5"},
+		{date: time.Date(2000, time.January, 1, 12, 0, 0, 0, time.FixedZone("JST", 9*60*60)), expected: "36526.5"},
+		{date: time.Date(2300, time.January, 1, 0, 0, 0, 0, time.UTC), expected: "146099"},
+		{date: time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC), expected: "2958465"},
+	}
+	for _, testCase := range testCases {
+		if actual, ok := dateSerial(testCase.date); !ok || actual != testCase.expected {
+			t.Fatalf("Expected %v to be %s, got %s", testCase.date, testCase.expected, actual)
+		}
+	}
+	if _, ok := dateSerial(time.Date(1899, time.December, 31, 0, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("Expected dates before 1900 to not have a serial number")
+	}
+	if _, ok := dateSerial(time.Date(10000, time.January, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("Expected dates after 9999 to not have a serial number")
+	}
+}
+
+func TestSetTypeInference(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.SetTypeInference(TypeInference{DateLayouts: []string{""}}); err != EmptyDateLayoutError {
+		t.Fatalf("Expected %v, got %v", EmptyDateLayoutError, err)
+	}
+	if err := file.SetTypeInference(TypeInference{Numbers: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetTypeInference(TypeInference{}); err != BuiltExcelStreamBuilderError {
+		t.Fatalf("Expected %v, got %v", BuiltExcelStreamBuilderError, err)
+	}
+}
+
+func TestWriteRowTypeInference(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.SetTypeInference(TypeInference{Numbers: true, Booleans: true, DateLayouts: []string{"2006-01-02"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name", "Count", "Active", "Since", "Zip"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"SEPT2", "12", "True", "2024-03-01", "02134"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<c r="A2" t="inlineStr"><is><t>SEPT2</t></is></c>`,
+		`<c r="B2"><v>12</v></c>`,
+		`<c r="C2" t="b"><v>1</v></c>`,
+		`"><v>45352</v></c>`,
+		`<c r="E2" t="inlineStr"><is><t>02134</t></is></c>`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `formatCode="yyyy-mm-dd"`) {
+		t.Fatalf("Expected the date format in %s", stylesXML)
+	}
+}
+
+func TestSetStrictText(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.SetStrictText(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetTypeInference(TypeInference{Booleans: true}); err != StrictTextError {
+		t.Fatalf("Expected %v, got %v", StrictTextError, err)
+	}
+	if err := file.SetTypeInference(TypeInference{DateFormat: "d/m/yyyy"}); err != nil {
+		t.Fatalf("Expected type inference that infers nothing to be allowed, got %v", err)
+	}
+	if err := file.SetStrictText(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetTypeInference(TypeInference{Numbers: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetStrictText(true); err != StrictTextError {
+		t.Fatalf("Expected %v, got %v", StrictTextError, err)
+	}
+}
+
+func TestStrictTextWritesText(t *testing.T) {
+	// These are all values that Excel or CSV imports are known to turn into dates, numbers, booleans or formulas.
+	cells := []string{"SEPT2", "MARCH1", "1-2", "2024-03-01", "00123", "1E5", "4111111111111111", "TRUE", "1/2", "=1+1"}
+	headers := make([]string, len(cells))
+	for i := range cells {
+		headers[i] = "Column " + strconv.Itoa(i)
+	}
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetStrictText(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", headers); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow(cells); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for i, cellData := range cells {
+		cellCoordinate, err := cellReference(i, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `<c r="` + cellCoordinate + `" t="inlineStr"><is><t>` + escapeXML(cellData) + `</t></is></c>`
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	dataRow := sheetXML[strings.Index(sheetXML, `<row r="2"`):]
+	if strings.Contains(dataRow, `<v>`) || strings.Contains(dataRow, `<f>`) {
+		t.Fatalf("Expected no values or formulas: %s", dataRow)
+	}
+}
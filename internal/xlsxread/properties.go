@@ -0,0 +1,101 @@
+package xlsxread
+
+// Properties are the document properties of the workbook, which Excel shows on the Info page of the File menu.
+type Properties struct {
+	Title          string `json:",omitempty"`
+	Subject        string `json:",omitempty"`
+	Creator        string `json:",omitempty"`
+	Keywords       string `json:",omitempty"`
+	Description    string `json:",omitempty"`
+	LastModifiedBy string `json:",omitempty"`
+	// Created and Modified are W3C date times, as they are written in the file.
+	Created  string `json:",omitempty"`
+	Modified string `json:",omitempty"`
+	// Application and AppVersion are the program that saved the file.
+	Application string `json:",omitempty"`
+	AppVersion  string `json:",omitempty"`
+	Company     string `json:",omitempty"`
+}
+
+// StyleCounts are the number of each kind of style in the workbook. Files with a very large number of cell styles are
+// slow to open, which these help to find.
+type StyleCounts struct {
+	NumberFormats int
+	Fonts         int
+	Fills         int
+	Borders       int
+	CellStyles    int
+	// DifferentialFormats are used by conditional formats and tables.
+	DifferentialFormats int
+}
+
+// Properties reads the document properties. Files without properties parts have empty properties.
+func (r *Reader) Properties() (Properties, error) {
+	var properties Properties
+	if partName := r.packagePart(corePropertiesRelationshipType); partName != "" {
+		var core struct {
+			Title          string `xml:"title"`
+			Subject        string `xml:"subject"`
+			Creator        string `xml:"creator"`
+			Keywords       string `xml:"keywords"`
+			Description    string `xml:"description"`
+			LastModifiedBy string `xml:"lastModifiedBy"`
+			Created        string `xml:"created"`
+			Modified       string `xml:"modified"`
+		}
+		if err := r.decodePart(partName, &core); err != nil {
+			return Properties{}, err
+		}
+		properties = Properties{
+			Title:          core.Title,
+			Subject:        core.Subject,
+			Creator:        core.Creator,
+			Keywords:       core.Keywords,
+			Description:    core.Description,
+			LastModifiedBy: core.LastModifiedBy,
+			Created:        core.Created,
+			Modified:       core.Modified,
+		}
+	}
+	if partName := r.packagePart(appPropertiesRelationshipType); partName != "" {
+		var app struct {
+			Application string `xml:"Application"`
+			AppVersion  string `xml:"AppVersion"`
+			Company     string `xml:"Company"`
+		}
+		if err := r.decodePart(partName, &app); err != nil {
+			return Properties{}, err
+		}
+		properties.Application = app.Application
+		properties.AppVersion = app.AppVersion
+		properties.Company = app.Company
+	}
+	return properties, nil
+}
+
+// StyleCounts counts the styles in the workbook.
+func (r *Reader) StyleCounts() (StyleCounts, error) {
+	partName := r.workbookPart(stylesRelationshipType)
+	if partName == "" {
+		return StyleCounts{}, nil
+	}
+	var styleSheet struct {
+		NumFmts []struct{} `xml:"numFmts>numFmt"`
+		Fonts   []struct{} `xml:"fonts>font"`
+		Fills   []struct{} `xml:"fills>fill"`
+		Borders []struct{} `xml:"borders>border"`
+		CellXfs []struct{} `xml:"cellXfs>xf"`
+		Dxfs    []struct{} `xml:"dxfs>dxf"`
+	}
+	if err := r.decodePart(partName, &styleSheet); err != nil {
+		return StyleCounts{}, err
+	}
+	return StyleCounts{
+		NumberFormats:       len(styleSheet.NumFmts),
+		Fonts:               len(styleSheet.Fonts),
+		Fills:               len(styleSheet.Fills),
+		Borders:             len(styleSheet.Borders),
+		CellStyles:          len(styleSheet.CellXfs),
+		DifferentialFormats: len(styleSheet.Dxfs),
+	}, nil
+}
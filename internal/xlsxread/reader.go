@@ -0,0 +1,347 @@
+// Package xlsxread reads XLSX files one row at a time, so that sheets of any size can be read without holding them in
+// memory. Only the parts that describe the whole workbook, like the shared strings and the styles, are kept in memory.
+package xlsxread
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	officeDocumentRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
+	sharedStringsRelationshipType  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings"
+	stylesRelationshipType         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"
+	corePropertiesRelationshipType = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	appPropertiesRelationshipType  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"
+	// defaultWorkbookPath is used if the package relationships do not say where the workbook is.
+	defaultWorkbookPath = "xl/workbook.xml"
+)
+
+var (
+	MissingPartError   = errors.New("Part missing from XLSX file")
+	MalformedPartError = errors.New("Part of XLSX file is not well formed XML")
+	UnknownSheetError  = errors.New("No sheet has the given name")
+)
+
+// Sheet describes a sheet in the workbook.
+type Sheet struct {
+	Name string
+	// State is "hidden" or "veryHidden" for sheets that are hidden, and empty otherwise.
+	State string
+	// partName is the name of the sheet's part in the zip file.
+	partName string
+}
+
+// Reader reads an XLSX file.
+type Reader struct {
+	zipReader *zip.Reader
+	parts     map[string]*zip.File
+	// closer is set when the Reader opened the file itself.
+	closer io.Closer
+	// Sheets are the sheets in the workbook, in the order they appear in Excel.
+	Sheets []Sheet
+	// DefinedNames are the named ranges and formulas in the workbook, including the ones Excel uses for features like
+	// print titles.
+	DefinedNames []DefinedName
+	// Date1904 is set when the workbook's dates count from 1904 instead of 1900, as files from old Mac versions of Excel
+	// do.
+	Date1904 bool
+	// workbookPath is the name of the workbook part. Other workbook parts are found through its relationships.
+	workbookPath  string
+	relationships map[string]relationship
+	// packageRelationships link the package to the workbook and to the document properties.
+	packageRelationships map[string]relationship
+	// sharedStrings and styles are read the first time they are needed.
+	sharedStrings []string
+	stringsRead   bool
+	styles        []cellStyle
+	stylesRead    bool
+}
+
+// DefinedName is a name for a range or formula.
+type DefinedName struct {
+	Name string
+	// Value is the range or formula the name stands for.
+	Value string
+	// SheetIndex is the index in Sheets of the sheet the name belongs to, or -1 if it belongs to the whole workbook.
+	SheetIndex int
+	Hidden     bool
+}
+
+// relationship is a link from one part to another.
+type relationship struct {
+	relType  string
+	partName string
+}
+
+// NewReader reads the workbook part of the XLSX file, which lists its sheets.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	reader := &Reader{zipReader: zipReader, parts: make(map[string]*zip.File, len(zipReader.File))}
+	for _, file := range zipReader.File {
+		reader.parts[file.Name] = file
+	}
+	if err := reader.readWorkbook(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Open opens the XLSX file at the path. The Reader must be closed when it is no longer needed.
+func Open(name string) (*Reader, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	reader, err := NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	reader.closer = file
+	return reader, nil
+}
+
+// Close closes the file, if the Reader was created by Open.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// SheetIndex returns the index in Sheets of the sheet with the name, or UnknownSheetError if there is none. Like in
+// Excel, names are not case sensitive.
+func (r *Reader) SheetIndex(name string) (int, error) {
+	for i, sheet := range r.Sheets {
+		if strings.EqualFold(sheet.Name, name) {
+			return i, nil
+		}
+	}
+	return -1, UnknownSheetError
+}
+
+// readWorkbook finds the workbook part and reads its sheets and relationships.
+func (r *Reader) readWorkbook() error {
+	r.workbookPath = defaultWorkbookPath
+	if rels, err := r.readRelationships("", "_rels/.rels"); err == nil {
+		r.packageRelationships = rels
+		for _, rel := range rels {
+			if rel.relType == officeDocumentRelationshipType {
+				r.workbookPath = rel.partName
+				break
+			}
+		}
+	}
+	directory, file := path.Split(r.workbookPath)
+	relationships, err := r.readRelationships(strings.TrimSuffix(directory, "/"), directory+"_rels/"+file+".rels")
+	if err != nil {
+		return err
+	}
+	r.relationships = relationships
+
+	var workbook struct {
+		WorkbookPr struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name  string `xml:"name,attr"`
+			State string `xml:"state,attr"`
+			ID    string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+		DefinedNames []struct {
+			Name         string `xml:"name,attr"`
+			LocalSheetID *int   `xml:"localSheetId,attr"`
+			Hidden       string `xml:"hidden,attr"`
+			Value        string `xml:",chardata"`
+		} `xml:"definedNames>definedName"`
+	}
+	if err := r.decodePart(r.workbookPath, &workbook); err != nil {
+		return err
+	}
+	r.Date1904 = workbook.WorkbookPr.Date1904 == "1" || workbook.WorkbookPr.Date1904 == "true"
+	r.Sheets = make([]Sheet, 0, len(workbook.Sheets))
+	for _, sheet := range workbook.Sheets {
+		rel, ok := relationships[sheet.ID]
+		if !ok {
+			return fmt.Errorf("%w: relationship %s for sheet %q", MissingPartError, sheet.ID, sheet.Name)
+		}
+		r.Sheets = append(r.Sheets, Sheet{Name: sheet.Name, State: sheet.State, partName: rel.partName})
+	}
+	for _, name := range workbook.DefinedNames {
+		definedName := DefinedName{
+			Name:       name.Name,
+			Value:      name.Value,
+			SheetIndex: -1,
+			Hidden:     name.Hidden == "1" || name.Hidden == "true",
+		}
+		if name.LocalSheetID != nil {
+			definedName.SheetIndex = *name.LocalSheetID
+		}
+		r.DefinedNames = append(r.DefinedNames, definedName)
+	}
+	return nil
+}
+
+// readRelationships reads a relationships part, resolving the targets against the directory of the part they are
+// from.
+func (r *Reader) readRelationships(directory, partName string) (map[string]relationship, error) {
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+			Mode   string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := r.decodePart(partName, &rels); err != nil {
+		return nil, err
+	}
+	relationships := make(map[string]relationship, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if rel.Mode == "External" {
+			continue
+		}
+		relationships[rel.ID] = relationship{relType: rel.Type, partName: resolvePartName(directory, rel.Target)}
+	}
+	return relationships, nil
+}
+
+// packagePart returns the name of the part the package has a relationship of the type with, or an empty string if
+// there is none.
+func (r *Reader) packagePart(relType string) string {
+	for _, rel := range r.packageRelationships {
+		if rel.relType == relType {
+			return rel.partName
+		}
+	}
+	return ""
+}
+
+// workbookPart returns the name of the part the workbook has a relationship of the type with, or an empty string if
+// there is none.
+func (r *Reader) workbookPart(relType string) string {
+	for _, rel := range r.relationships {
+		if rel.relType == relType {
+			return rel.partName
+		}
+	}
+	return ""
+}
+
+// openPart opens the named part for reading.
+func (r *Reader) openPart(name string) (io.ReadCloser, error) {
+	file := r.parts[name]
+	if file == nil {
+		return nil, fmt.Errorf("%w: %s", MissingPartError, name)
+	}
+	return file.Open()
+}
+
+// decodePart unmarshals the named XML part into v.
+func (r *Reader) decodePart(name string, v interface{}) error {
+	reader, err := r.openPart(name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err := xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w: %v", name, MalformedPartError, err)
+	}
+	return nil
+}
+
+// resolvePartName returns the name of the part a relationship target refers to. Targets are relative to the directory
+// of the part the relationship is from, unless they start with a slash.
+func resolvePartName(directory, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(path.Clean(target), "/")
+	}
+	return strings.TrimPrefix(path.Clean(path.Join("/", directory, target)), "/")
+}
+
+// readSharedStrings reads the workbook's shared strings, which cells of type "s" refer to by index.
+func (r *Reader) readSharedStrings() error {
+	if r.stringsRead {
+		return nil
+	}
+	r.stringsRead = true
+	partName := r.workbookPart(sharedStringsRelationshipType)
+	if partName == "" {
+		return nil
+	}
+	reader, err := r.openPart(partName)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w: %v", partName, MalformedPartError, err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "si" {
+			text, err := readText(decoder)
+			if err != nil {
+				return fmt.Errorf("%s: %w: %v", partName, MalformedPartError, err)
+			}
+			r.sharedStrings = append(r.sharedStrings, text)
+		}
+	}
+}
+
+// readText reads the text of a string item, from just after its start tag to its end tag. Rich text runs are joined
+// together, and phonetic hints are skipped.
+func readText(decoder *xml.Decoder) (string, error) {
+	var text strings.Builder
+	depth := 1
+	inText := false
+	inPhonetic := 0
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch token.Name.Local {
+			case "t":
+				inText = true
+			case "rPh":
+				inPhonetic++
+			}
+		case xml.EndElement:
+			depth--
+			switch token.Name.Local {
+			case "t":
+				inText = false
+			case "rPh":
+				inPhonetic--
+			}
+		case xml.CharData:
+			if inText && inPhonetic == 0 {
+				text.Write(token)
+			}
+		}
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,283 @@
+package xlsxread
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+)
+
+// testParts are the parts of a small workbook with shared strings, inline strings, numbers, dates and booleans.
+var testParts = map[string]string{
+	"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"` +
+		` Target="xl/workbook.xml"/></Relationships>`,
+	"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"` +
+		` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><workbookPr/><sheets>` +
+		`<sheet name="Orders" sheetId="1" r:id="rId1"/><sheet name="Hidden" sheetId="2" state="hidden" r:id="rId2"/>` +
+		`</sheets></workbook>`,
+	"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet"` +
+		` Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet"` +
+		` Target="/xl/worksheets/sheet2.xml"/>` +
+		`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings"` +
+		` Target="sharedStrings.xml"/>` +
+		`<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"` +
+		` Target="styles.xml"/></Relationships>`,
+	"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<si><t>ID</t></si><si><r><t>Or</t></r><r><t>dered</t></r><rPh><t>x</t></rPh></si><si><t>a_x000D_b</t></si></sst>`,
+	"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd hh:mm"/></numFmts>` +
+		`<cellXfs count="3"><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/></cellXfs></styleSheet>`,
+	"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+		`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>Note</t></is></c></row>` +
+		`<row r="3"><c r="A3"><v>12.5</v></c><c r="B3" s="1"><v>61</v></c><c r="C3" s="2"><f>NOW()</f><v>43000.5</v></c>` +
+		`<c r="D3" t="b"><v>1</v></c><c r="E3" s="1"/></row>` +
+		`<row><c t="e"><v>#N/A</v></c><c t="s"><v>2</v></c><c t="str"><v> padded </v></c></row>` +
+		`</sheetData></worksheet>`,
+	"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`,
+}
+
+// testWorkbook returns the zip file for the parts.
+func testWorkbook(t *testing.T, parts map[string]string) *bytes.Reader {
+	buffer := bytes.NewBuffer(nil)
+	zipWriter := zip.NewWriter(buffer)
+	for name, data := range parts {
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buffer.Bytes())
+}
+
+func TestReader(t *testing.T) {
+	data := testWorkbook(t, testParts)
+	reader, err := NewReader(data, data.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reader.Sheets) != 2 || reader.Sheets[0].Name != "Orders" || reader.Sheets[1].State != "hidden" ||
+		reader.Sheets[1].partName != "xl/worksheets/sheet2.xml" {
+		t.Fatalf("Unexpected sheets %+v", reader.Sheets)
+	}
+	if index, err := reader.SheetIndex("hidden"); index != 1 || err != nil {
+		t.Fatalf("Expected sheet 1, got %d and %v", index, err)
+	}
+	if _, err := reader.SheetIndex("Missing"); err != UnknownSheetError {
+		t.Fatalf("Expected UnknownSheetError, got %v", err)
+	}
+
+	rows, err := reader.Rows(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	expected := []struct {
+		rowNumber int
+		cells     []Cell
+	}{
+		{1, []Cell{{Column: 0, Type: StringCell, Value: "ID"}, {Column: 1, Type: StringCell, Value: "Ordered"},
+			{Column: 3, Type: StringCell, Value: "Note"}}},
+		{3, []Cell{{Column: 0, Type: NumberCell, Value: "12.5", NumberFormat: ""},
+			{Column: 1, Type: DateCell, Value: "61", NumberFormat: "14"},
+			{Column: 2, Type: DateCell, Value: "43000.5", NumberFormat: `yyyy\-mm\-dd hh:mm`},
+			{Column: 3, Type: BoolCell, Value: "1"}}},
+		{4, []Cell{{Column: 0, Type: ErrorCell, Value: "#N/A"}, {Column: 1, Type: StringCell, Value: "a\rb"},
+			{Column: 2, Type: StringCell, Value: " padded "}}},
+	}
+	for _, expectedRow := range expected {
+		if !rows.Next() {
+			t.Fatalf("Expected row %d, got %v", expectedRow.rowNumber, rows.Err())
+		}
+		if rows.RowNumber() != expectedRow.rowNumber {
+			t.Fatalf("Expected row %d, got %d", expectedRow.rowNumber, rows.RowNumber())
+		}
+		cells := rows.Cells()
+		if len(cells) != len(expectedRow.cells) {
+			t.Fatalf("Row %d: expected %+v, got %+v", expectedRow.rowNumber, expectedRow.cells, cells)
+		}
+		for i, cell := range cells {
+			cell.date1904 = false
+			if cell != expectedRow.cells[i] {
+				t.Fatalf("Row %d: expected %+v, got %+v", expectedRow.rowNumber, expectedRow.cells[i], cell)
+			}
+		}
+	}
+	if rows.Next() || rows.Err() != nil {
+		t.Fatalf("Expected the end of the sheet, got %v", rows.Err())
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	missingSheet := make(map[string]string, len(testParts))
+	for name, data := range testParts {
+		missingSheet[name] = data
+	}
+	delete(missingSheet, "xl/worksheets/sheet2.xml")
+	data := testWorkbook(t, missingSheet)
+	reader, err := NewReader(data, data.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Rows(1); err == nil {
+		t.Fatal("Expected an error for the missing sheet")
+	}
+	if _, err := reader.Rows(2); err != UnknownSheetError {
+		t.Fatalf("Expected UnknownSheetError, got %v", err)
+	}
+
+	badString := map[string]string{}
+	for name, data := range testParts {
+		badString[name] = data
+	}
+	badString["xl/worksheets/sheet1.xml"] = `<worksheet><sheetData><row><c t="s"><v>7</v></c></row></sheetData></worksheet>`
+	data = testWorkbook(t, badString)
+	if reader, err = NewReader(data, data.Size()); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := reader.Rows(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if rows.Next() || rows.Err() == nil {
+		t.Fatal("Expected an error for the shared string index")
+	}
+}
+
+func TestIsDateFormat(t *testing.T) {
+	testCases := map[string]bool{
+		"yyyy-mm-dd":          true,
+		`d\-mmm`:              true,
+		"h:mm AM/PM":          true,
+		"[h]:mm:ss":           true,
+		"[$-409]mmmm d, yyyy": true,
+		"0.00":                false,
+		"#,##0;[Red]-#,##0":   false,
+		`0 "days"`:            false,
+		`0.00_);\(0.00\)`:     false,
+		"[Red]0.00":           false,
+		"0.00E+00":            false,
+		"@":                   false,
+	}
+	for code, expected := range testCases {
+		if actual := isDateFormat(code); actual != expected {
+			t.Fatalf("Expected %v for %s, got %v", expected, code, actual)
+		}
+	}
+}
+
+func TestSerialTime(t *testing.T) {
+	testCases := []struct {
+		serial   float64
+		date1904 bool
+		expected time.Time
+	}{
+		{1, false, time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{59, false, time.Date(1900, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{60, false, time.Date(1900, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{61, false, time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{43000.5, false, time.Date(2017, 9, 22, 12, 0, 0, 0, time.UTC)},
+		{0.25, false, time.Date(1899, 12, 31, 6, 0, 0, 0, time.UTC)},
+		{41640.333333333336, false, time.Date(2014, 1, 1, 8, 0, 0, 0, time.UTC)},
+		{0, true, time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{1462, true, time.Date(1908, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, testCase := range testCases {
+		if actual := serialTime(testCase.serial, testCase.date1904); !actual.Equal(testCase.expected) {
+			t.Fatalf("Expected %v for %v, got %v", testCase.expected, testCase.serial, actual)
+		}
+	}
+}
+
+func TestWorkbookDetails(t *testing.T) {
+	parts := make(map[string]string, len(testParts))
+	for name, data := range testParts {
+		parts[name] = data
+	}
+	parts["_rels/.rels"] = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"` +
+		` Target="xl/workbook.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"` +
+		` Target="docProps/core.xml"/>` +
+		`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"` +
+		` Target="docProps/app.xml"/></Relationships>`
+	parts["docProps/core.xml"] = `<cp:coreProperties` +
+		` xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"` +
+		` xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">` +
+		`<dc:title>Orders</dc:title><dc:creator>Exporter</dc:creator>` +
+		`<dcterms:created>2017-06-01T10:00:00Z</dcterms:created></cp:coreProperties>`
+	parts["docProps/app.xml"] = `<Properties><Application>Microsoft Excel</Application><AppVersion>16.0300</AppVersion>` +
+		`</Properties>`
+	parts["xl/workbook.xml"] = `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<workbookPr date1904="1"/><sheets><sheet name="Orders" r:id="rId1"/></sheets><definedNames>` +
+		`<definedName name="_xlnm.Print_Titles" localSheetId="0" hidden="1">Orders!$1:$1</definedName>` +
+		`<definedName name="Rate">0.2</definedName></definedNames></workbook>`
+	data := testWorkbook(t, parts)
+	reader, err := NewReader(data, data.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reader.Date1904 {
+		t.Fatal("Expected 1904 dates")
+	}
+	expectedNames := []DefinedName{
+		{Name: "_xlnm.Print_Titles", Value: "Orders!$1:$1", SheetIndex: 0, Hidden: true},
+		{Name: "Rate", Value: "0.2", SheetIndex: -1},
+	}
+	if len(reader.DefinedNames) != len(expectedNames) || reader.DefinedNames[0] != expectedNames[0] ||
+		reader.DefinedNames[1] != expectedNames[1] {
+		t.Fatalf("Expected %+v, got %+v", expectedNames, reader.DefinedNames)
+	}
+	properties, err := reader.Properties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedProperties := Properties{Title: "Orders", Creator: "Exporter", Created: "2017-06-01T10:00:00Z",
+		Application: "Microsoft Excel", AppVersion: "16.0300"}
+	if properties != expectedProperties {
+		t.Fatalf("Expected %+v, got %+v", expectedProperties, properties)
+	}
+	styles, err := reader.StyleCounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (StyleCounts{NumberFormats: 1, CellStyles: 3}); styles != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, styles)
+	}
+}
+
+func TestCellText(t *testing.T) {
+	testCases := []struct {
+		cell     Cell
+		expected string
+	}{
+		{Cell{Type: StringCell, Value: "text"}, "text"},
+		{Cell{Type: NumberCell, Value: "1.5"}, "1.5"},
+		{Cell{Type: BoolCell, Value: "1"}, "TRUE"},
+		{Cell{Type: BoolCell, Value: "0"}, "FALSE"},
+		{Cell{Type: DateCell, Value: "43000"}, "22/09/2017"},
+		{Cell{Type: DateCell, Value: "43000.25"}, "22/09/2017 06:00"},
+		{Cell{Type: DateCell, Value: "0", date1904: true}, "01/01/1904"},
+	}
+	for _, testCase := range testCases {
+		actual, err := testCase.cell.Text("02/01/2006", "02/01/2006 15:04")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != testCase.expected {
+			t.Fatalf("Expected %s for %+v, got %s", testCase.expected, testCase.cell, actual)
+		}
+	}
+	if _, err := (Cell{Type: DateCell, Value: "soon"}).Text("", ""); err == nil {
+		t.Fatal("Expected an error for a date that is not a number")
+	}
+}
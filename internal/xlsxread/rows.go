@@ -0,0 +1,348 @@
+package xlsxread
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CellType is the kind of value a cell holds.
+type CellType int
+
+const (
+	NumberCell CellType = iota
+	StringCell
+	BoolCell
+	ErrorCell
+	// DateCell is a number that is formatted as a date or a time.
+	DateCell
+)
+
+var (
+	InvalidCellReferenceError = errors.New("Invalid cell reference")
+	InvalidSharedStringError  = errors.New("Shared string index is out of range")
+	NotADateError             = errors.New("Cell does not hold a date")
+)
+
+// Cell is a cell that has a value.
+type Cell struct {
+	// Column is the index of the cell's column, which starts at 0.
+	Column int
+	Type   CellType
+	// Value is the text of strings and errors, the number as written in the file for numbers and dates, and 1 or 0 for
+	// booleans.
+	Value string
+	// NumberFormat is the format code of the cell's number format, or the ID of the format if it is one of Excel's
+	// built in formats.
+	NumberFormat string
+	date1904     bool
+}
+
+// Time returns the date and time held by a date cell, in UTC.
+func (c Cell) Time() (time.Time, error) {
+	if c.Type != DateCell {
+		return time.Time{}, NotADateError
+	}
+	serial, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return serialTime(serial, c.date1904), nil
+}
+
+// Text returns the cell's value as text. Booleans are TRUE or FALSE, like Excel shows them. Dates are formatted with the
+// Go time layout for dates, or the one for dates with times if the cell has a time of day.
+func (c Cell) Text(dateLayout, dateTimeLayout string) (string, error) {
+	switch c.Type {
+	case BoolCell:
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case DateCell:
+		date, err := c.Time()
+		if err != nil {
+			return "", err
+		}
+		if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 && date.Nanosecond() == 0 {
+			return date.Format(dateLayout), nil
+		}
+		return date.Format(dateTimeLayout), nil
+	}
+	return c.Value, nil
+}
+
+// Rows reads the rows of a sheet in order. Only the rows that are in the file are read, so rows that Excel shows as
+// empty are skipped, and only the cells that have values are returned.
+type Rows struct {
+	reader  *Reader
+	part    io.ReadCloser
+	decoder *xml.Decoder
+	// rowNumber and cells are the current row.
+	rowNumber int
+	cells     []Cell
+	err       error
+	done      bool
+}
+
+// Rows starts reading the rows of the sheet at the index in Sheets. The Rows must be closed when they are no longer
+// needed.
+func (r *Reader) Rows(sheetIndex int) (*Rows, error) {
+	if sheetIndex < 0 || sheetIndex >= len(r.Sheets) {
+		return nil, UnknownSheetError
+	}
+	if err := r.readSharedStrings(); err != nil {
+		return nil, err
+	}
+	if err := r.readStyles(); err != nil {
+		return nil, err
+	}
+	part, err := r.openPart(r.Sheets[sheetIndex].partName)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{reader: r, part: part, decoder: xml.NewDecoder(part)}, nil
+}
+
+// Next reads the next row. It returns false once there are no more rows, or if reading fails, which Err reports.
+func (rows *Rows) Next() bool {
+	if rows.done {
+		return false
+	}
+	for {
+		token, err := rows.decoder.Token()
+		if err != nil {
+			rows.done = true
+			if err != io.EOF {
+				rows.err = fmt.Errorf("%w: %v", MalformedPartError, err)
+			}
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "row" {
+			if err := rows.readRow(start); err != nil {
+				rows.done = true
+				rows.err = err
+				return false
+			}
+			return true
+		}
+	}
+}
+
+// RowNumber returns the Excel row number of the current row, which starts at 1.
+func (rows *Rows) RowNumber() int {
+	return rows.rowNumber
+}
+
+// Cells returns the cells of the current row that have values, in column order. The slice is reused by the next call
+// to Next.
+func (rows *Rows) Cells() []Cell {
+	return rows.cells
+}
+
+// Err returns the error that stopped Next, if there was one.
+func (rows *Rows) Err() error {
+	return rows.err
+}
+
+// Close stops reading the sheet.
+func (rows *Rows) Close() error {
+	rows.done = true
+	return rows.part.Close()
+}
+
+// readRow reads a row element, from just after its start tag to its end tag.
+func (rows *Rows) readRow(start xml.StartElement) error {
+	rowNumber := rows.rowNumber + 1
+	if value := attribute(start, "r"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return fmt.Errorf("%w: row %q", InvalidCellReferenceError, value)
+		}
+		rowNumber = number
+	}
+	rows.rowNumber = rowNumber
+	rows.cells = rows.cells[:0]
+	column := -1
+	for {
+		token, err := rows.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("%w: %v", MalformedPartError, err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local != "c" {
+				if err := rows.decoder.Skip(); err != nil {
+					return fmt.Errorf("%w: %v", MalformedPartError, err)
+				}
+				continue
+			}
+			column++
+			if reference := attribute(token, "r"); reference != "" {
+				if column, err = referenceColumn(reference); err != nil {
+					return err
+				}
+			}
+			cell, hasValue, err := rows.readCell(token, column)
+			if err != nil {
+				return err
+			}
+			if hasValue {
+				rows.cells = append(rows.cells, cell)
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// readCell reads a cell element, from just after its start tag to its end tag. It reports whether the cell has a
+// value, since cells can be written only to give them a style.
+func (rows *Rows) readCell(start xml.StartElement, column int) (Cell, bool, error) {
+	cellType := attribute(start, "t")
+	var value string
+	hasValue := false
+	for {
+		token, err := rows.decoder.Token()
+		if err != nil {
+			return Cell{}, false, fmt.Errorf("%w: %v", MalformedPartError, err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			var text string
+			var err error
+			switch {
+			case token.Name.Local == "v" && cellType != "inlineStr":
+				text, err = readCharData(rows.decoder)
+				if cellType != "str" {
+					text = strings.TrimSpace(text)
+				}
+			case token.Name.Local == "is" && cellType == "inlineStr":
+				text, err = readText(rows.decoder)
+			default:
+				// Formulas are skipped, since only their cached values are read.
+				if err := rows.decoder.Skip(); err != nil {
+					return Cell{}, false, fmt.Errorf("%w: %v", MalformedPartError, err)
+				}
+				continue
+			}
+			if err != nil {
+				return Cell{}, false, fmt.Errorf("%w: %v", MalformedPartError, err)
+			}
+			value = text
+			hasValue = true
+		case xml.EndElement:
+			if !hasValue {
+				return Cell{}, false, nil
+			}
+			cell, err := rows.cell(cellType, attribute(start, "s"), column, value)
+			return cell, err == nil, err
+		}
+	}
+}
+
+// cell makes a Cell from the type and style attributes and the value of a cell element.
+func (rows *Rows) cell(cellType, style string, column int, value string) (Cell, error) {
+	cell := Cell{Column: column, Value: value, date1904: rows.reader.Date1904}
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || index >= len(rows.reader.sharedStrings) {
+			return Cell{}, fmt.Errorf("%w: %q", InvalidSharedStringError, value)
+		}
+		cell.Type = StringCell
+		cell.Value = decodeEscapes(rows.reader.sharedStrings[index])
+	case "inlineStr", "str":
+		cell.Type = StringCell
+		cell.Value = decodeEscapes(value)
+	case "b":
+		cell.Type = BoolCell
+	case "e":
+		cell.Type = ErrorCell
+	case "d":
+		// Dates written as ISO 8601 text are rare, and are returned as strings.
+		cell.Type = StringCell
+	default:
+		cell.Type = NumberCell
+	}
+	if styleIndex, err := strconv.Atoi(style); err == nil && styleIndex >= 0 && styleIndex < len(rows.reader.styles) {
+		cellStyle := rows.reader.styles[styleIndex]
+		cell.NumberFormat = cellStyle.numberFormat
+		if cell.Type == NumberCell && cellStyle.isDate {
+			cell.Type = DateCell
+		}
+	}
+	return cell, nil
+}
+
+// readCharData reads the text of an element, from just after its start tag to its end tag.
+func readCharData(decoder *xml.Decoder) (string, error) {
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		switch token := token.(type) {
+		case xml.CharData:
+			text.Write(token)
+		case xml.EndElement:
+			return text.String(), nil
+		case xml.StartElement:
+			if err := decoder.Skip(); err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// attribute returns the value of the element's attribute with the local name, or an empty string if it has none.
+func attribute(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// referenceColumn returns the column index, which starts at 0, of an A1 style cell reference.
+func referenceColumn(reference string) (int, error) {
+	column := 0
+	letters := 0
+	for letters < len(reference) && reference[letters] >= 'A' && reference[letters] <= 'Z' {
+		column = column*26 + int(reference[letters]-'A') + 1
+		letters++
+		if letters > 3 {
+			return 0, fmt.Errorf("%w: %q", InvalidCellReferenceError, reference)
+		}
+	}
+	if letters == 0 {
+		return 0, fmt.Errorf("%w: %q", InvalidCellReferenceError, reference)
+	}
+	return column - 1, nil
+}
+
+// decodeEscapes replaces the _xHHHH_ escapes that Excel uses for characters that can not be written in XML with the
+// characters they stand for.
+func decodeEscapes(text string) string {
+	if !strings.Contains(text, "_x") {
+		return text
+	}
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '_' && i+7 <= len(text) && text[i+1] == 'x' && text[i+6] == '_' {
+			if code, err := strconv.ParseUint(text[i+2:i+6], 16, 16); err == nil {
+				builder.WriteRune(rune(code))
+				i += 6
+				continue
+			}
+		}
+		builder.WriteByte(text[i])
+	}
+	return builder.String()
+}
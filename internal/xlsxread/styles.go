@@ -0,0 +1,119 @@
+package xlsxread
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cellStyle is the part of a cell style that matters for reading values.
+type cellStyle struct {
+	numberFormat string
+	isDate       bool
+}
+
+// builtInDateFormats are the IDs of the built in number formats that show dates or times. Their format codes are not
+// stored in the file.
+var builtInDateFormats = map[int]bool{
+	14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 20: true, 21: true, 22: true,
+	27: true, 28: true, 29: true, 30: true, 31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+	45: true, 46: true, 47: true,
+	50: true, 51: true, 52: true, 53: true, 54: true, 55: true, 56: true, 57: true, 58: true,
+}
+
+// readStyles reads the number format of each cell style in the workbook.
+func (r *Reader) readStyles() error {
+	if r.stylesRead {
+		return nil
+	}
+	r.stylesRead = true
+	partName := r.workbookPart(stylesRelationshipType)
+	if partName == "" {
+		return nil
+	}
+	var styleSheet struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := r.decodePart(partName, &styleSheet); err != nil {
+		return err
+	}
+	codes := make(map[int]string, len(styleSheet.NumFmts))
+	for _, numFmt := range styleSheet.NumFmts {
+		codes[numFmt.ID] = numFmt.Code
+	}
+	r.styles = make([]cellStyle, len(styleSheet.CellXfs))
+	for i, xf := range styleSheet.CellXfs {
+		code, custom := codes[xf.NumFmtID]
+		if !custom {
+			r.styles[i] = cellStyle{numberFormat: strconv.Itoa(xf.NumFmtID), isDate: builtInDateFormats[xf.NumFmtID]}
+			continue
+		}
+		r.styles[i] = cellStyle{numberFormat: code, isDate: isDateFormat(code)}
+	}
+	return nil
+}
+
+// isDateFormat reports whether the number format code shows a date or a time. Text in quotes, escaped characters and
+// sections in brackets, like colors, are ignored, and what is left is checked for the letters of date and time codes.
+func isDateFormat(code string) bool {
+	// Only the first section, which is used for positive numbers, matters.
+	inQuotes := false
+	inBrackets := false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inQuotes:
+			inQuotes = c != '"'
+		case inBrackets:
+			// Elapsed time codes like [h] are times, but other bracketed sections like [Red] are not.
+			if c == ']' {
+				inBrackets = false
+			} else if strings.IndexByte("hHmMsS", c) != -1 && i > 0 && code[i-1] == '[' {
+				return true
+			}
+		case c == '"':
+			inQuotes = true
+		case c == '[':
+			inBrackets = true
+		case c == '\\' || c == '_' || c == '*':
+			// The next character is literal, or is used for spacing.
+			i++
+		case c == ';':
+			return false
+		case strings.IndexByte("dDmMyYhHsS", c) != -1:
+			return true
+		}
+	}
+	return false
+}
+
+// serialTime converts an Excel date serial number to a time. Whole numbers are days, and the fraction is the time of
+// day. The result is in UTC, since Excel dates have no time zone.
+func serialTime(serial float64, date1904 bool) time.Time {
+	var epoch time.Time
+	switch {
+	case date1904:
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	case serial < 60:
+		// Excel treats 1900 as a leap year, so serial 60 is February 29th 1900, which did not exist. Serials before it
+		// count from one day later than the ones after it.
+		epoch = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	case serial < 61:
+		// February 29th 1900 is shown as the last day of February.
+		epoch = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+		serial--
+	default:
+		epoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	// Times are rounded to the millisecond, since the serial number can not hold them exactly.
+	milliseconds := math.Round((serial - days) * 24 * 60 * 60 * 1000)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(milliseconds) * time.Millisecond)
+}
@@ -0,0 +1,68 @@
+package excel_stream
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	linkRelationshipType = relationshipsNamespace + "/hyperlink"
+	// valuePlaceholder is replaced with the cell's value in the URL of a link column.
+	valuePlaceholder = "{value}"
+	// maxLinks is the most links Excel allows on a sheet.
+	maxLinks = 65530
+)
+
+var InvalidLinkURLError = errors.New("Link URL must be an absolute URL, such as https://example.com/orders/{value}")
+
+// linkStyle is the style of the cells of link columns that do not have their own, which is how Excel shows links.
+var linkStyle = Style{Underline: true, FontColor: "0563C1"}
+
+// validateLink checks the column's LinkURL, if it has one.
+func (def *ColumnDef) validateLink() error {
+	if def.LinkURL == "" {
+		return nil
+	}
+	link, err := url.Parse(linkURL(def.LinkURL, "value"))
+	if err != nil || !link.IsAbs() {
+		return InvalidLinkURLError
+	}
+	return nil
+}
+
+// linkURL returns the URL of a link column's cell. The value is percent-encoded, so that it stays a single segment of
+// the URL's path or a single value of its query.
+func linkURL(template, value string) string {
+	escaped := strings.Replace(url.QueryEscape(value), "+", "%20", -1)
+	return strings.Replace(template, valuePlaceholder, escaped, -1)
+}
+
+// addLinks adds a link for each of the row's cells that are in a link column and not empty. Once a sheet has as many
+// links as Excel allows, the rest of its cells are left without links.
+func (sf *StreamFile) addLinks(sheetArrayIndex int, columns *sheetColumns, cells []string) {
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	for colIndex, template := range columns.linkURLs {
+		if template == "" || cells[colIndex] == "" || len(extras.links) >= maxLinks {
+			continue
+		}
+		cellCoordinate, err := cellReference(colIndex, sf.currentSheet.rowCount)
+		if err != nil {
+			continue
+		}
+		id := "rId" + strconv.Itoa(len(extras.relationships)+1)
+		extras.relationships = append(extras.relationships, relationship{
+			id:       id,
+			relType:  linkRelationshipType,
+			target:   linkURL(template, cells[colIndex]),
+			external: true,
+		})
+		extras.links = append(extras.links, `<hyperlink ref="`+cellCoordinate+`" r:id="`+id+`"/>`)
+	}
+}
+
+// renderLinks returns the hyperlinks element holding the links of a sheet.
+func renderLinks(links []string) string {
+	return `<hyperlinks xmlns:r="` + relationshipsNamespace + `">` + strings.Join(links, "") + `</hyperlinks>`
+}
@@ -0,0 +1,110 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLinkURL(t *testing.T) {
+	testCases := []struct {
+		template string
+		value    string
+		expected string
+	}{
+		{template: "https://app/orders/{value}", value: "1234", expected: "https://app/orders/1234"},
+		{template: "https://app/orders/{value}", value: "a/b c", expected: "https://app/orders/a%2Fb%20c"},
+		{
+			template: "https://app/search?q={value}&x=1",
+			value:    "a&b=c+d",
+			expected: "https://app/search?q=a%26b%3Dc%2Bd&x=1",
+		},
+		{template: "https://app/", value: "1234", expected: "https://app/"},
+	}
+	for _, testCase := range testCases {
+		if actual := linkURL(testCase.template, testCase.value); actual != testCase.expected {
+			t.Fatalf("Expected %s, got %s", testCase.expected, actual)
+		}
+	}
+}
+
+func TestValidateLink(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		linkURL       string
+		expectedError error
+	}{
+		{testName: "No Link"},
+		{testName: "Template", linkURL: "https://app/orders/{value}"},
+		{testName: "Mail", linkURL: "mailto:{value}"},
+		{testName: "Relative", linkURL: "/orders/{value}", expectedError: InvalidLinkURLError},
+		{testName: "Invalid", linkURL: "https://app/%zz/{value}", expectedError: InvalidLinkURLError},
+	}
+	for _, testCase := range testCases {
+		def := ColumnDef{Name: "Order", LinkURL: testCase.linkURL}
+		if err := def.validate(); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestAddLinks(t *testing.T) {
+	sf := &StreamFile{
+		currentSheet: &streamSheet{index: 1, rowCount: 3},
+		sheetExtras:  make([]sheetExtras, 1),
+	}
+	columns := &sheetColumns{linkURLs: []string{"", "https://app/orders/{value}"}}
+	sf.addLinks(0, columns, []string{"Taco", "17"})
+	sf.addLinks(0, columns, []string{"Burrito", ""})
+	extras := &sf.sheetExtras[0]
+	if len(extras.links) != 1 || extras.links[0] != `<hyperlink ref="B3" r:id="rId1"/>` {
+		t.Fatalf("Expected one link, got %v", extras.links)
+	}
+	expected := relationship{id: "rId1", relType: linkRelationshipType, target: "https://app/orders/17", external: true}
+	if len(extras.relationships) != 1 || extras.relationships[0] != expected {
+		t.Fatalf("Expected %v, got %v", expected, extras.relationships)
+	}
+	extras.links = make([]string, maxLinks)
+	sf.addLinks(0, columns, []string{"Taco", "18"})
+	if len(extras.links) != maxLinks {
+		t.Fatalf("Expected no more than %d links, got %d", maxLinks, len(extras.links))
+	}
+}
+
+func TestRenderExternalRelationship(t *testing.T) {
+	rels := renderRelationships([]relationship{
+		{id: "rId1", relType: linkRelationshipType, target: "https://app/?a=1&b=2", external: true},
+	})
+	expected := `<Relationship Id="rId1" Type="` + linkRelationshipType +
+		`" Target="https://app/?a=1&amp;b=2" TargetMode="External"></Relationship>`
+	if !strings.Contains(rels, expected) {
+		t.Fatalf("Expected %s in %s", expected, rels)
+	}
+}
+
+func TestWriteRowLinkColumn(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{{Name: "Order", LinkURL: "https://app/orders/{value}"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"A-17"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `<hyperlink ref="A2" r:id="rId1"/>`) {
+		t.Fatalf("Expected a link in %s", sheetXML)
+	}
+	relsXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/_rels/sheet1.xml.rels")
+	if !strings.Contains(relsXML, `Target="https://app/orders/A-17" TargetMode="External"`) {
+		t.Fatalf("Expected the link's URL in %s", relsXML)
+	}
+}
@@ -0,0 +1,72 @@
+package excel_stream
+
+import (
+	"errors"
+	"strings"
+)
+
+var UnknownLocaleError = errors.New("No number format preset for the locale")
+
+// NumberFormats are number format codes for the columns of a report, for use as ColumnDef.Format.
+type NumberFormats struct {
+	// Integer and Decimal are whole numbers and numbers with two decimal places, with thousands separators.
+	Integer string
+	Decimal string
+	// Currency is an amount in the locale's currency.
+	Currency string
+	Percent  string
+	// Date and DateTime are for date serial numbers, the number of days since 1900 that Excel stores dates as.
+	Date     string
+	DateTime string
+}
+
+// localeFormats are the presets returned by LocaleNumberFormats. Format codes always use a comma to group thousands
+// and a period before the decimals; Excel shows them with the separators of the computer the file is opened on, so
+// they only differ between locales where the grouping or the order of the date is different.
+var localeFormats = map[string]NumberFormats{
+	"en-US": {
+		Integer:  "#,##0",
+		Decimal:  "#,##0.00",
+		Currency: `[$$-409]#,##0.00`,
+		Percent:  "0.00%",
+		Date:     "m/d/yyyy",
+		DateTime: "m/d/yyyy h:mm AM/PM",
+	},
+	"de-DE": {
+		Integer:  "#,##0",
+		Decimal:  "#,##0.00",
+		Currency: `#,##0.00 [$€-407]`,
+		Percent:  "0.00%",
+		Date:     "dd.mm.yyyy",
+		DateTime: "dd.mm.yyyy hh:mm",
+	},
+	"fr-FR": {
+		Integer:  "#,##0",
+		Decimal:  "#,##0.00",
+		Currency: `#,##0.00 [$€-40C]`,
+		Percent:  "0.00%",
+		Date:     "dd/mm/yyyy",
+		DateTime: "dd/mm/yyyy hh:mm",
+	},
+	// Indian numbers are grouped in lakhs and crores after the first thousand, which needs a section for each size.
+	// Negative numbers below -99,999 fall through to the last section, so they are only grouped by thousands.
+	"en-IN": {
+		Integer:  `[>=10000000]##\,##\,##\,##0;[>=100000]##\,##\,##0;##,##0`,
+		Decimal:  `[>=10000000]##\,##\,##\,##0.00;[>=100000]##\,##\,##0.00;##,##0.00`,
+		Currency: `[>=10000000][$₹-4009] ##\,##\,##\,##0.00;[>=100000][$₹-4009] ##\,##\,##0.00;[$₹-4009] ##,##0.00`,
+		Percent:  "0.00%",
+		Date:     "dd-mm-yyyy",
+		DateTime: "dd-mm-yyyy hh:mm",
+	},
+}
+
+// LocaleNumberFormats returns number formats that look the way reports in the locale usually do. The locales en-US,
+// de-DE, fr-FR and en-IN are supported, and are matched without regard to case or whether they use - or _.
+func LocaleNumberFormats(locale string) (NumberFormats, error) {
+	for name, formats := range localeFormats {
+		if strings.EqualFold(name, strings.Replace(locale, "_", "-", 1)) {
+			return formats, nil
+		}
+	}
+	return NumberFormats{}, UnknownLocaleError
+}
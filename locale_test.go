@@ -0,0 +1,31 @@
+package excel_stream
+
+import (
+	"testing"
+)
+
+func TestLocaleNumberFormats(t *testing.T) {
+	for _, locale := range []string{"en-US", "de-DE", "fr-FR", "en-IN", "de_de", "EN-in"} {
+		formats, err := LocaleNumberFormats(locale)
+		if err != nil {
+			t.Fatalf("%s: %v", locale, err)
+		}
+		var styles styleSheet
+		styles.setXML(`<styleSheet></styleSheet>`)
+		for _, format := range []string{formats.Integer, formats.Decimal, formats.Currency, formats.Percent,
+			formats.Date, formats.DateTime} {
+			if format == "" {
+				t.Fatalf("%s: Expected every format to be set: %+v", locale, formats)
+			}
+			if _, err := styles.addCellStyle(Style{}, format); err != nil {
+				t.Fatalf("%s: %v", locale, err)
+			}
+		}
+	}
+	if formats, _ := LocaleNumberFormats("de-DE"); formats.Date != "dd.mm.yyyy" {
+		t.Fatalf("Expected German dates to put the day first, got %s", formats.Date)
+	}
+	if _, err := LocaleNumberFormats("xx-XX"); err != UnknownLocaleError {
+		t.Fatalf("Expected %v, got %v", UnknownLocaleError, err)
+	}
+}
@@ -0,0 +1,134 @@
+package excel_stream
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const (
+	workbookPath = "xl/workbook.xml"
+	// maxDefinedNameLength is the longest name Excel allows.
+	maxDefinedNameLength = 255
+)
+
+var (
+	InvalidDefinedNameError   = errors.New("Defined name must start with a letter or underscore, only contain letters, digits, underscores and periods, and not look like a cell reference")
+	DuplicateDefinedNameError = errors.New("Defined name is already used")
+	LookupSheetFirstError     = errors.New("Lookup sheet can not be the first sheet, since the first sheet is shown when the workbook is opened")
+)
+
+// definedName is a workbook level name for the data rows of a lookup sheet.
+type definedName struct {
+	name            string
+	sheetArrayIndex int
+}
+
+// AddLookupSheet adds a hidden sheet for values that other sheets refer to, such as the choices of a dropdown or a
+// table for VLOOKUP. It works like AddSheet, and its rows are written like any other sheet's. When the file is closed,
+// name is defined as the range of the sheet's data rows, below the header, so that formulas and data validations can
+// refer to it by name, like VLOOKUP(A2,Products,2,FALSE) or a DataValidation with List set and the Formula Products.
+// Since a workbook opens on its first sheet, a lookup sheet can not be the first sheet.
+func (sb *StreamFileBuilder) AddLookupSheet(sheetName, name string, headers []string) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	var err error
+	switch {
+	case len(sb.xlsxFile.Sheets) == 0:
+		err = LookupSheetFirstError
+	case !isValidDefinedName(name):
+		err = InvalidDefinedNameError
+	}
+	for _, defined := range sb.definedNames {
+		if strings.EqualFold(defined.name, name) {
+			err = DuplicateDefinedNameError
+		}
+	}
+	if err != nil {
+		// Set built on error so that all subsequent calls to the builder will also fail.
+		sb.built = true
+		return err
+	}
+	if err := sb.addSheet(sheetName, headers); err != nil {
+		return err
+	}
+	sb.xlsxFile.Sheets[len(sb.xlsxFile.Sheets)-1].Hidden = true
+	sb.definedNames = append(sb.definedNames, definedName{name: name, sheetArrayIndex: len(sb.xlsxFile.Sheets) - 1})
+	return nil
+}
+
+// isValidDefinedName reports whether Excel accepts the name as a defined name.
+func isValidDefinedName(name string) bool {
+	if name == "" || len([]rune(name)) > maxDefinedNameLength {
+		return false
+	}
+	for i, r := range name {
+		if unicode.IsLetter(r) || r == '_' || r == '\\' {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '.') {
+			continue
+		}
+		return false
+	}
+	return !looksLikeReference(name)
+}
+
+// looksLikeReference reports whether the name could be read as a cell reference, either in the A1 style, like AB12, or
+// in the R1C1 style, like R1C1 or C.
+func looksLikeReference(name string) bool {
+	upper := strings.ToUpper(name)
+	letters := strings.IndexFunc(upper, func(r rune) bool { return r < 'A' || r > 'Z' })
+	if letters > 0 && letters <= 3 && strings.Trim(upper[letters:], "0123456789") == "" {
+		return true
+	}
+	rest := upper
+	found := false
+	for _, prefix := range []string{"R", "C"} {
+		if strings.HasPrefix(rest, prefix) {
+			rest = strings.TrimLeft(rest[1:], "0123456789")
+			found = true
+		}
+	}
+	return found && rest == ""
+}
+
+// addDefinedNames adds the defined names of the lookup sheets to the workbook's XML, now that the number of rows in
+// each sheet is known.
+func (sf *StreamFile) addDefinedNames(workbook string) string {
+	if len(sf.definedNames) == 0 {
+		return workbook
+	}
+	var builder strings.Builder
+	for _, defined := range sf.definedNames {
+		sheet := sf.xlsxFile.Sheets[defined.sheetArrayIndex]
+		firstRow := sf.headerRows[defined.sheetArrayIndex] + 1
+		lastRow := sf.rowCounts[defined.sheetArrayIndex]
+		if lastRow < firstRow {
+			lastRow = firstRow
+		}
+		lastColumn := len(sheet.Cols) - 1
+		if lastColumn < 0 {
+			lastColumn = 0
+		}
+		ref := sheetReference(sheet.Name, absoluteReference(0, firstRow)+":"+absoluteReference(lastColumn, lastRow))
+		builder.WriteString(`<definedName` + xmlAttribute("name", defined.name) + `>` + escapeXML(ref) +
+			`</definedName>`)
+	}
+	// The XLSX library may have written an empty definedNames element, which the names go into. Otherwise they go right
+	// after the sheets, before the calculation properties.
+	if position := strings.Index(workbook, `</definedNames>`); position != -1 {
+		return workbook[:position] + builder.String() + workbook[position:]
+	}
+	if position := strings.Index(workbook, `<definedNames/>`); position != -1 {
+		return workbook[:position] + `<definedNames>` + builder.String() + `</definedNames>` +
+			workbook[position+len(`<definedNames/>`):]
+	}
+	position := strings.Index(workbook, `</sheets>`)
+	if position == -1 {
+		return workbook
+	}
+	position += len(`</sheets>`)
+	return workbook[:position] + `<definedNames>` + builder.String() + `</definedNames>` + workbook[position:]
+}
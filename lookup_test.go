@@ -0,0 +1,141 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestIsValidDefinedName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "Products", expected: true},
+		{name: "_tax.rates2", expected: true},
+		{name: "Région", expected: true},
+		{name: "ABCD1", expected: true},
+		{name: "Rate", expected: true},
+		{name: ""},
+		{name: "2Products"},
+		{name: "Product List"},
+		{name: "A1"},
+		{name: "xfd1048576"},
+		{name: "R"},
+		{name: "c"},
+		{name: "R1C1"},
+		{name: "R12"},
+		{name: strings.Repeat("a", maxDefinedNameLength+1)},
+	}
+	for _, testCase := range testCases {
+		if actual := isValidDefinedName(testCase.name); actual != testCase.expected {
+			t.Fatalf("Expected %q to be %v, got %v", testCase.name, testCase.expected, actual)
+		}
+	}
+}
+
+func TestAddDefinedNames(t *testing.T) {
+	sf := &StreamFile{
+		xlsxFile: &xlsx.File{
+			Sheets: []*xlsx.Sheet{{Name: "Report"}, {Name: "Products", Cols: make([]*xlsx.Col, 2)}},
+		},
+		headerRows:   []int{1, 1},
+		rowCounts:    []int{10, 4},
+		definedNames: []definedName{{name: "Products", sheetArrayIndex: 1}},
+	}
+	expectedNames := `<definedName name="Products">&#39;Products&#39;!$A$2:$B$4</definedName>`
+	testCases := []struct {
+		testName string
+		workbook string
+		expected string
+	}{
+		{
+			testName: "No Element",
+			workbook: `<workbook><sheets></sheets><calcPr/></workbook>`,
+			expected: `<workbook><sheets></sheets><definedNames>` + expectedNames +
+				`</definedNames><calcPr/></workbook>`,
+		},
+		{
+			testName: "Empty Element",
+			workbook: `<workbook><sheets></sheets><definedNames></definedNames></workbook>`,
+			expected: `<workbook><sheets></sheets><definedNames>` + expectedNames + `</definedNames></workbook>`,
+		},
+		{
+			testName: "Self Closing Element",
+			workbook: `<workbook><sheets></sheets><definedNames/></workbook>`,
+			expected: `<workbook><sheets></sheets><definedNames>` + expectedNames + `</definedNames></workbook>`,
+		},
+	}
+	for _, testCase := range testCases {
+		if actual := sf.addDefinedNames(testCase.workbook); actual != testCase.expected {
+			t.Fatalf("%s: Expected %s, got %s", testCase.testName, testCase.expected, actual)
+		}
+	}
+	sf.rowCounts[1] = 1
+	if actual := sf.addDefinedNames(`<sheets></sheets>`); !strings.Contains(actual, `!$A$2:$B$2<`) {
+		t.Fatalf("Expected a lookup sheet without rows to have a one row range, got %s", actual)
+	}
+}
+
+func TestAddLookupSheet(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		firstSheet    bool
+		name          string
+		expectedError error
+	}{
+		{testName: "Lookup", firstSheet: true, name: "Products"},
+		{testName: "First Sheet", name: "Products", expectedError: LookupSheetFirstError},
+		{testName: "Invalid Name", firstSheet: true, name: "A1", expectedError: InvalidDefinedNameError},
+		{testName: "Duplicate Name", firstSheet: true, name: "REGIONS", expectedError: DuplicateDefinedNameError},
+	}
+	for _, testCase := range testCases {
+		file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+		file.definedNames = []definedName{{name: "Regions"}}
+		if testCase.firstSheet {
+			if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := file.AddLookupSheet("Lookup", testCase.name, []string{"Product", "Price"})
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if err != nil && file.AddSheet("Sheet2", []string{"Name"}) != BuiltExcelStreamBuilderError {
+			t.Fatalf("%s: Expected the builder to be unusable after an error", testCase.testName)
+		}
+	}
+}
+
+func TestWriteLookupSheet(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Orders", []string{"Product"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddLookupSheet("Product List", "Products", []string{"Product", "Price"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.NextSheet(); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco", "3"}, {"Burrito", "8"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	workbookXML := readZipPart(t, buffer.Bytes(), workbookPath)
+	expected := `<definedName name="Products">&#39;Product List&#39;!$A$2:$B$3</definedName>`
+	if !strings.Contains(workbookXML, expected) || !strings.Contains(workbookXML, `state="hidden"`) {
+		t.Fatalf("Expected a hidden sheet and %s in %s", expected, workbookXML)
+	}
+}
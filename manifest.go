@@ -0,0 +1,86 @@
+package excel_stream
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"sort"
+)
+
+var (
+	ManifestDisabledError    = errors.New("Manifest requested, but SetComputeManifest was not enabled on the StreamFileBuilder")
+	StreamFileNotClosedError = errors.New("StreamFile must be closed before its manifest is available")
+	IncompleteManifestError  = errors.New("StreamFile was aborted or failed to close, so it has no manifest")
+)
+
+// Manifest describes the contents of a finished XLSX file, so that it can be recorded alongside the file and used to
+// check later that the file has not been changed.
+type Manifest struct {
+	// Parts has the checksum of every part in the XLSX Zip file, sorted by name.
+	Parts []PartChecksum `json:"parts"`
+	// Sheets has the number of rows in each sheet, in the order the sheets were added.
+	Sheets []SheetSummary `json:"sheets"`
+}
+
+// PartChecksum is the checksum of the uncompressed contents of one part of an XLSX file.
+type PartChecksum struct {
+	Name string `json:"name"`
+	// SHA256 is the hex encoded SHA-256 checksum of the part.
+	SHA256 string `json:"sha256"`
+}
+
+// partHash is the running checksum of a part that has been written to the XLSX Zip file.
+type partHash struct {
+	name string
+	hash hash.Hash
+}
+
+// createPart adds a new part to the XLSX Zip file and returns the writer for its contents. If a manifest is being
+// computed, the writer also adds everything written to it to the part's checksum.
+func (sf *StreamFile) createPart(header *zip.FileHeader) (io.Writer, error) {
+	writer, err := sf.zipWriter.CreateHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	sf.directorySize += zipDirectoryHeaderSize + int64(len(header.Name))
+	if sf.countParts {
+		writer = &partCounter{writer: writer, output: sf.output}
+	}
+	if !sf.computeManifest {
+		return writer, nil
+	}
+	partHash := partHash{name: header.Name, hash: sha256.New()}
+	sf.partHashes = append(sf.partHashes, partHash)
+	return io.MultiWriter(writer, partHash.hash), nil
+}
+
+// Manifest returns the checksums of all of the parts of the file and the number of rows in each sheet. It can only be
+// called after Close has finished the file, and only if SetComputeManifest was enabled on the StreamFileBuilder.
+func (sf *StreamFile) Manifest() (*Manifest, error) {
+	if !sf.computeManifest {
+		return nil, ManifestDisabledError
+	}
+	if !sf.closed {
+		return nil, StreamFileNotClosedError
+	}
+	if !sf.complete {
+		return nil, IncompleteManifestError
+	}
+	manifest := &Manifest{
+		Parts:  make([]PartChecksum, len(sf.partHashes)),
+		Sheets: sf.SheetSummaries(),
+	}
+	for i, partHash := range sf.partHashes {
+		manifest.Parts[i] = PartChecksum{
+			Name:   partHash.name,
+			SHA256: hex.EncodeToString(partHash.hash.Sum(nil)),
+		}
+	}
+	sort.Slice(manifest.Parts, func(i, j int) bool {
+		return manifest.Parts[i].Name < manifest.Parts[j].Name
+	})
+	return manifest, nil
+}
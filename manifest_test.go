@@ -0,0 +1,113 @@
+package excel_stream
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetComputeManifest(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123", "Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := excelStream.Manifest(); err != StreamFileNotClosedError {
+		t.Fatalf("Expected StreamFileNotClosedError, got %v", err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := excelStream.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSheets := []SheetSummary{{Name: "Sheet1", Rows: 2}, {Name: "Sheet2", Rows: 1}}
+	if !reflect.DeepEqual(manifest.Sheets, expectedSheets) {
+		t.Fatalf("Expected sheets %v, got %v", expectedSheets, manifest.Sheets)
+	}
+	if len(manifest.Parts) == 0 {
+		t.Fatal("Expected the manifest to list the parts of the file")
+	}
+	for _, part := range manifest.Parts {
+		checksum := sha256.Sum256([]byte(readZipPart(t, buffer.Bytes(), part.Name)))
+		if part.SHA256 != hex.EncodeToString(checksum[:]) {
+			t.Fatalf("Checksum for %s does not match its contents", part.Name)
+		}
+	}
+}
+
+func TestManifestDisabled(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Sheet1", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := excelStream.Manifest(); err != ManifestDisabledError {
+		t.Fatalf("Expected ManifestDisabledError, got %v", err)
+	}
+}
+
+func TestManifestIncomplete(t *testing.T) {
+	testCases := []struct {
+		testName string
+		finish   func(excelStream *StreamFile) error
+	}{
+		{testName: "Aborted", finish: func(excelStream *StreamFile) error {
+			return excelStream.Abort()
+		}},
+		{testName: "Failed", finish: func(excelStream *StreamFile) error {
+			excelStream.output.writer = &failingWriter{}
+			if err := excelStream.Close(); !errors.Is(err, failedWriteError) {
+				return fmt.Errorf("Expected Close to fail with %v, got %v", failedWriteError, err)
+			}
+			return nil
+		}},
+	}
+	for _, testCase := range testCases {
+		file := NewStreamFileBuilder(&failingWriter{limit: 1 << 20})
+		if err := file.SetComputeManifest(true); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.AddSheet("Sheet1", []string{"Token"}); err != nil {
+			t.Fatal(err)
+		}
+		excelStream, err := file.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := excelStream.WriteRow([]string{"123"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := testCase.finish(excelStream); err != nil {
+			t.Fatalf("%s: %v", testCase.testName, err)
+		}
+		if _, err := excelStream.Manifest(); err != IncompleteManifestError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, IncompleteManifestError, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package excel_stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	UnknownMapKeyError       = errors.New("Row map has a key that is not the name of a column of the sheet")
+	AmbiguousMapKeyError     = errors.New("Row map has a key that is the name of more than one column of the sheet")
+	UnsupportedMapValueError = errors.New("Row map value must be a string, number, boolean, time.Time, nil or fmt.Stringer")
+)
+
+// SetIgnoreUnknownMapKeys controls what WriteRowFromMap does with keys that are not the name of a column. Normally it
+// returns UnknownMapKeyError and writes nothing. When enabled, the keys are ignored, which suits sources that have
+// more fields than the sheet shows.
+func (sb *StreamFileBuilder) SetIgnoreUnknownMapKeys(ignore bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.ignoreUnknownMapKeys = ignore
+	return nil
+}
+
+// WriteRowFromMap will write a row to the current sheet from a map of header names to values, like an object decoded
+// from JSON. Strings are written like the cells of WriteRow, numbers and booleans as their text, time.Times as dates
+// like DateValue, and fmt.Stringers as the text they return. Columns that are missing from the map or whose value is
+// nil are NULL, and are written as the NullValue of the column. Keys that are not the name of a column return an error
+// wrapping UnknownMapKeyError, unless SetIgnoreUnknownMapKeys was enabled. Headers that are used by more than one
+// column can not be used as keys. It works like WriteRow in every other way.
+func (sf *StreamFile) WriteRowFromMap(values map[string]interface{}) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	if sf.currentSheet == nil {
+		return NoCurrentSheetError
+	}
+	sheetArrayIndex := sf.currentSheet.index - 1
+	columnIndexes := sf.mapColumnIndexes(sheetArrayIndex)
+	cells := make([]string, sf.currentSheet.columnCount-sf.auditColumnCount(sheetArrayIndex))
+	typed := make([]Cell, len(cells))
+	nulls := make([]bool, len(cells))
+	for colIndex := range nulls {
+		nulls[colIndex] = true
+	}
+	// The keys are gone through in order so that the same map always returns the same error.
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		colIndex, ok := columnIndexes[key]
+		if !ok {
+			if sf.ignoreUnknownMapKeys {
+				continue
+			}
+			return fmt.Errorf("%w: %q", UnknownMapKeyError, key)
+		}
+		if colIndex == -1 {
+			return fmt.Errorf("%w: %q", AmbiguousMapKeyError, key)
+		}
+		if values[key] == nil {
+			continue
+		}
+		cell, err := mapValueCell(values[key])
+		if err != nil {
+			sheetName := sf.xlsxFile.Sheets[sheetArrayIndex].Name
+			return &CellError{Sheet: sheetName, Row: sf.currentSheet.rowCount + 1, Column: colIndex, Err: err}
+		}
+		cells[colIndex] = cell.Value
+		typed[colIndex] = cell
+		nulls[colIndex] = false
+	}
+	return sf.writeRow(cells, RowOptions{Nulls: nulls, cells: typed})
+}
+
+// mapColumnIndexes returns the index of the column with each header name of the sheet, or -1 for names that more than
+// one column has. It is worked out the first time it is needed for each sheet.
+func (sf *StreamFile) mapColumnIndexes(sheetArrayIndex int) map[string]int {
+	columns := &sf.columns[sheetArrayIndex]
+	if columns.mapIndexes != nil {
+		return columns.mapIndexes
+	}
+	columns.mapIndexes = make(map[string]int)
+	sheet := sf.xlsxFile.Sheets[sheetArrayIndex]
+	if len(sheet.Rows) == 0 {
+		return columns.mapIndexes
+	}
+	// The audit columns are filled in by the library, so they are not keys.
+	dataColumns := len(sheet.Cols) - sf.auditColumnCount(sheetArrayIndex)
+	for colIndex, cell := range sheet.Rows[0].Cells {
+		if colIndex >= dataColumns {
+			break
+		}
+		if _, ok := columns.mapIndexes[cell.Value]; ok {
+			columns.mapIndexes[cell.Value] = -1
+			continue
+		}
+		columns.mapIndexes[cell.Value] = colIndex
+	}
+	return columns.mapIndexes
+}
+
+// mapValueCell returns the cell for a value from a row map.
+func mapValueCell(value interface{}) (Cell, error) {
+	if date, ok := value.(time.Time); ok {
+		return DateValue(date), nil
+	}
+	text, err := mapValueString(value)
+	return Cell{Value: text}, err
+}
+
+// mapValueString returns the text of a value from a row map.
+func mapValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		// JSON numbers are decoded as float64, so whole numbers are written without an exponent, the way they were
+		// sent.
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case json.Number:
+		return v.String(), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return "", UnsupportedMapValueError
+}
@@ -0,0 +1,109 @@
+package excel_stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMapValueString(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		value         interface{}
+		expected      string
+		expectedError error
+	}{
+		{testName: "String", value: "Taco", expected: "Taco"},
+		{testName: "Bool", value: true, expected: "TRUE"},
+		{testName: "Int", value: -12, expected: "-12"},
+		{testName: "Uint", value: uint64(18446744073709551615), expected: "18446744073709551615"},
+		{testName: "Whole Float", value: 12345678.0, expected: "12345678"},
+		{testName: "Float", value: 0.25, expected: "0.25"},
+		{testName: "JSON Number", value: json.Number("1e3"), expected: "1e3"},
+		{testName: "Stringer", value: time.Duration(90) * time.Second, expected: "1m30s"},
+		{testName: "Array", value: []interface{}{1, 2}, expectedError: UnsupportedMapValueError},
+	}
+	for _, testCase := range testCases {
+		actual, err := mapValueString(testCase.value)
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if actual != testCase.expected {
+			t.Fatalf("%s: Expected %s, got %s", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestWriteRowFromMap(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{
+		{Name: "Name", Null: NullValue{Text: "N/A"}},
+		{Name: "Count", Type: NumberColumn},
+		{Name: "Notes"},
+		{Name: "Notes"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetIgnoreUnknownMapKeys(true); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"Name": null, "Count": 3, "Extra": "ignored"}`), &row); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowFromMap(row); err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRowFromMap(map[string]interface{}{"Notes": "Spicy"})
+	if !errors.Is(err, AmbiguousMapKeyError) {
+		t.Fatalf("Expected %v, got %v", AmbiguousMapKeyError, err)
+	}
+	err = excelStream.WriteRowFromMap(map[string]interface{}{"Count": []interface{}{}})
+	if cellErr, ok := err.(*CellError); !ok || cellErr.Column != 1 || cellErr.Err != UnsupportedMapValueError {
+		t.Fatalf("Expected a CellError for column 1 wrapping %v, got %v", UnsupportedMapValueError, err)
+	}
+	excelStream.ignoreUnknownMapKeys = false
+	if err := excelStream.WriteRowFromMap(map[string]interface{}{"Extra": 1}); !errors.Is(err, UnknownMapKeyError) {
+		t.Fatalf("Expected %v, got %v", UnknownMapKeyError, err)
+	}
+	if err := excelStream.NextSheet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowFromMap(map[string]interface{}{"Name": "Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	expected := `<row r="2"><c r="A2" t="inlineStr"><is><t>N/A</t></is></c><c r="B2"><v>3</v></c>`
+	if !strings.Contains(sheetXML, expected) {
+		t.Fatalf("Expected %s in %s", expected, sheetXML)
+	}
+	sheetXML = readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet2.xml")
+	if !strings.Contains(sheetXML, `<t>Taco</t>`) {
+		t.Fatalf("Expected the row in %s", sheetXML)
+	}
+}
+
+func TestMapValueCell(t *testing.T) {
+	date := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	if cell, err := mapValueCell(date); err != nil || cell != DateValue(date) {
+		t.Fatalf("Expected %v, got %v, %v", DateValue(date), cell, err)
+	}
+	if cell, err := mapValueCell(12); err != nil || cell != (Cell{Value: "12"}) {
+		t.Fatalf("Expected a cell written like WriteRow would, got %v, %v", cell, err)
+	}
+}
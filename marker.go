@@ -0,0 +1,51 @@
+package excel_stream
+
+import "archive/zip"
+
+const (
+	// IncompleteMarkerPath is the part written first when completion markers are enabled.
+	IncompleteMarkerPath = "docProps/incomplete.marker"
+	// CompleteMarkerPath is the part written last when completion markers are enabled, once everything else has been
+	// written successfully.
+	CompleteMarkerPath = "docProps/complete.marker"
+	markerContentType  = "text/plain"
+)
+
+// SetCompletionMarkers controls whether the file is written with markers that show whether the export finished. Parts
+// can not be removed from a streamed zip once they have been written, so instead of removing a marker when the file is
+// finished, IncompleteMarkerPath is written before anything else and CompleteMarkerPath is written at Close after
+// everything else, but only if nothing failed. A file that has the first marker but not the second was cut short or
+// failed, even if its zip happens to parse, such as when a reader scans the parts from the start of the file.
+// IsIncompleteExport checks for this. Excel ignores both markers.
+func (sb *StreamFileBuilder) SetCompletionMarkers(enabled bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.completionMarkers = enabled
+	return nil
+}
+
+// IsIncompleteExport reports whether the XLSX file was written with completion markers but was not finished. Files
+// written without completion markers are never reported as incomplete.
+func IsIncompleteExport(reader *zip.Reader) bool {
+	incomplete, complete := false, false
+	for _, file := range reader.File {
+		switch file.Name {
+		case IncompleteMarkerPath:
+			incomplete = true
+		case CompleteMarkerPath:
+			complete = true
+		}
+	}
+	return incomplete && !complete
+}
+
+// writeMarker writes a completion marker part, which holds a line saying what it marks.
+func (sf *StreamFile) writeMarker(path, text string) error {
+	writer, err := sf.createPart(&zip.FileHeader{Name: path, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write([]byte(text + "\n"))
+	return err
+}
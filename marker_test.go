@@ -0,0 +1,76 @@
+package excel_stream
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func TestCompletionMarkers(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetCompletionMarkers(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsIncompleteExport(zipReader) {
+		t.Fatal("Expected a closed file to be complete")
+	}
+	if first := zipReader.File[0].Name; first != IncompleteMarkerPath {
+		t.Fatalf("Expected %s to be the first part, got %s", IncompleteMarkerPath, first)
+	}
+	if last := zipReader.File[len(zipReader.File)-1].Name; last != CompleteMarkerPath {
+		t.Fatalf("Expected %s to be the last part, got %s", CompleteMarkerPath, last)
+	}
+	contentTypes := readZipPart(t, buffer.Bytes(), contentTypesPath)
+	if !bytes.Contains([]byte(contentTypes), []byte(`<Default Extension="marker" ContentType="text/plain">`)) {
+		t.Fatalf("Expected a content type for the markers in %s", contentTypes)
+	}
+}
+
+func TestIsIncompleteExport(t *testing.T) {
+	testCases := []struct {
+		testName   string
+		parts      []string
+		incomplete bool
+	}{
+		{testName: "No Markers", parts: []string{"xl/workbook.xml"}},
+		{testName: "Incomplete", parts: []string{IncompleteMarkerPath, "xl/workbook.xml"}, incomplete: true},
+		{testName: "Complete", parts: []string{IncompleteMarkerPath, "xl/workbook.xml", CompleteMarkerPath}},
+	}
+	for _, testCase := range testCases {
+		buffer := bytes.NewBuffer(nil)
+		zipWriter := zip.NewWriter(buffer)
+		for _, part := range testCase.parts {
+			if _, err := zipWriter.Create(part); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zipWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+		zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := IsIncompleteExport(zipReader); actual != testCase.incomplete {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.incomplete, actual)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package excel_stream
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SetMergeRepeatedCells controls whether runs of adjacent text cells in a row of the named sheet that hold the same
+// value are merged into a single cell, such as a category that spans several columns of a pivot-like export. Only the
+// first cell of each run keeps its value. Numbers, formulas, empty cells and the cells of link columns are never
+// merged, so totals and links are not affected. Every merge is kept in memory until the sheet is finished.
+func (sb *StreamFileBuilder) SetMergeRepeatedCells(sheetName string, merge bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	for i, sheet := range sb.xlsxFile.Sheets {
+		if sheet.Name == sheetName {
+			sb.mergeRepeatedCells[i] = merge
+			return nil
+		}
+	}
+	return UnknownSheetError
+}
+
+// cellRun is a run of adjacent cells with the same value, from the first column to the last column.
+type cellRun struct {
+	first, last int
+}
+
+// repeatedCellRuns returns the runs of two or more adjacent text cells of a row that hold the same value.
+func repeatedCellRuns(columns *sheetColumns, cells []string, kinds []cellKind) []cellRun {
+	mergeable := func(colIndex int) bool {
+		return kinds[colIndex] == textCell && cells[colIndex] != "" &&
+			(colIndex >= len(columns.linkURLs) || columns.linkURLs[colIndex] == "")
+	}
+	var runs []cellRun
+	for first := 0; first < len(cells); {
+		last := first
+		if mergeable(first) {
+			for last+1 < len(cells) && mergeable(last+1) && cells[last+1] == cells[first] {
+				last++
+			}
+		}
+		if last > first {
+			runs = append(runs, cellRun{first: first, last: last})
+		}
+		first = last + 1
+	}
+	return runs
+}
+
+// mergeRepeatedCells returns the cells of a row with all but the first cell of each run emptied, and the mergeCell
+// elements of the runs, for the given row number.
+func mergeRepeatedCells(runs []cellRun, cells []string, rowNumber int) ([]string, []string) {
+	cells = append([]string(nil), cells...)
+	merges := make([]string, len(runs))
+	row := strconv.Itoa(rowNumber)
+	for i, run := range runs {
+		for colIndex := run.first + 1; colIndex <= run.last; colIndex++ {
+			cells[colIndex] = ""
+		}
+		merges[i] = `<mergeCell ref="` + columnName(run.first) + row + `:` + columnName(run.last) + row + `"/>`
+	}
+	return cells, merges
+}
+
+// renderMergeCells returns the mergeCells element holding the merged ranges of a sheet.
+func renderMergeCells(merges []string) string {
+	return `<mergeCells count="` + strconv.Itoa(len(merges)) + `">` + strings.Join(merges, "") + `</mergeCells>`
+}
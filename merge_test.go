@@ -0,0 +1,96 @@
+package excel_stream
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepeatedCellRuns(t *testing.T) {
+	testCases := []struct {
+		testName string
+		cells    []string
+		kinds    []cellKind
+		linkURLs []string
+		expected []cellRun
+	}{
+		{
+			testName: "No Runs",
+			cells:    []string{"West", "East", "West"},
+			kinds:    []cellKind{textCell, textCell, textCell},
+		},
+		{
+			testName: "Runs",
+			cells:    []string{"West", "West", "West", "East", "East", "North"},
+			kinds:    []cellKind{textCell, textCell, textCell, textCell, textCell, textCell},
+			expected: []cellRun{{first: 0, last: 2}, {first: 3, last: 4}},
+		},
+		{
+			testName: "Empty And Numbers",
+			cells:    []string{"", "", "5", "5", "West"},
+			kinds:    []cellKind{textCell, textCell, numberCell, numberCell, textCell},
+		},
+		{
+			testName: "Link",
+			cells:    []string{"West", "West", "West"},
+			kinds:    []cellKind{textCell, textCell, textCell},
+			linkURLs: []string{"", "", "https://example.com/{value}"},
+			expected: []cellRun{{first: 0, last: 1}},
+		},
+	}
+	for _, testCase := range testCases {
+		columns := &sheetColumns{linkURLs: testCase.linkURLs}
+		actual := repeatedCellRuns(columns, testCase.cells, testCase.kinds)
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestMergeRepeatedCells(t *testing.T) {
+	cells := []string{"West", "West", "West", "East"}
+	merged, merges := mergeRepeatedCells([]cellRun{{first: 0, last: 2}}, cells, 7)
+	if !reflect.DeepEqual(merged, []string{"West", "", "", "East"}) {
+		t.Fatalf("Expected the repeated cells to be emptied, got %v", merged)
+	}
+	if cells[1] != "West" {
+		t.Fatal("Expected the original cells to be untouched")
+	}
+	if expected := `<mergeCells count="1"><mergeCell ref="A7:C7"/></mergeCells>`; renderMergeCells(merges) != expected {
+		t.Fatalf("Expected %s, got %s", expected, renderMergeCells(merges))
+	}
+}
+
+func TestWriteRowWithMergedCells(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Q1", "Q2", "Q3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetMergeRepeatedCells("Sheet2", true); err != UnknownSheetError {
+		t.Fatalf("Expected %v, got %v", UnknownSheetError, err)
+	}
+	if err := file.SetMergeRepeatedCells("Sheet1", true); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Launch", "Launch", "Review"}, {"Plan", "Build", "Ship"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `<mergeCells count="1"><mergeCell ref="A2:B2"/></mergeCells>`) {
+		t.Fatalf("Expected the repeated cells to be merged in %s", sheetXML)
+	}
+	if strings.Count(sheetXML, `<t>Launch</t>`) != 1 {
+		t.Fatalf("Expected only the first cell of the merge to keep its value in %s", sheetXML)
+	}
+}
@@ -0,0 +1,96 @@
+package excel_stream
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	metadataPath          = "xl/metadata.xml"
+	dynamicArrayNamespace = "http://schemas.microsoft.com/office/spreadsheetml/2017/dynamicarray"
+	// dynamicArrayMetadataExtension is the URI of the extension that marks a future metadata block as a dynamic array.
+	dynamicArrayMetadataExtension = "{bdbb8cdc-fa1e-496e-a857-3c3f30c029c3}"
+	// dynamicArrayCellMetadata is the cm attribute of dynamic array formula cells, which refers to the only block of
+	// cell metadata.
+	dynamicArrayCellMetadata = ` cm="1"`
+	// metadataTypeAttributes are the attributes Excel gives the metadata types it writes, which keep the metadata with
+	// the cells when they are copied, moved or changed.
+	metadataTypeAttributes = ` minSupportedVersion="120000" copy="1" pasteAll="1" pasteValues="1" merge="1"` +
+		` splitFirst="1" rowColShift="1" clearFormats="1" clearComments="1" assign="1" coerce="1"`
+)
+
+// hasDynamicArrays reports whether any of the sheets have dynamic array formula columns.
+func (sb *StreamFileBuilder) hasDynamicArrays() bool {
+	for _, columns := range sb.columnDefs {
+		for _, def := range columns {
+			if def.DynamicArray {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasDynamicArrays reports whether any of the sheets have dynamic array formula columns.
+func (sf *StreamFile) hasDynamicArrays() bool {
+	for i := range sf.columns {
+		for _, dynamicArray := range sf.columns[i].dynamicArrays {
+			if dynamicArray {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addMetadataPart adds the metadata part that the cm and vm attributes of cells refer to, if there are dynamic array
+// formula columns or rich values. Each metadata type has its future metadata, which holds what older versions of the
+// format can not. Dynamic array formula cells share a block of cell metadata pointing to the dynamic array properties,
+// and each rich value has a block of value metadata pointing to its own block of future metadata.
+func (sf *StreamFile) addMetadataPart() {
+	dynamicArrays := sf.hasDynamicArrays()
+	values := sf.richValues.values
+	if !dynamicArrays && len(values) == 0 {
+		return
+	}
+	var metadataTypes, futureMetadata, cellMetadata, valueMetadata strings.Builder
+	typeCount := 0
+	if dynamicArrays {
+		typeCount++
+		metadataTypes.WriteString(`<metadataType name="XLDAPR"` + metadataTypeAttributes + ` cellMeta="1"/>`)
+		futureMetadata.WriteString(`<futureMetadata name="XLDAPR" count="1"><bk><extLst><ext uri="` +
+			dynamicArrayMetadataExtension + `"><xda:dynamicArrayProperties fDynamic="1" fCollapsed="0"/></ext>` +
+			`</extLst></bk></futureMetadata>`)
+		cellMetadata.WriteString(`<cellMetadata count="1"><bk><rc t="` + strconv.Itoa(typeCount) + `" v="0"/></bk>` +
+			`</cellMetadata>`)
+	}
+	if len(values) > 0 {
+		typeCount++
+		metadataTypes.WriteString(`<metadataType name="XLRICHVALUE"` + metadataTypeAttributes + `/>`)
+		count := strconv.Itoa(len(values))
+		futureMetadata.WriteString(`<futureMetadata name="XLRICHVALUE" count="` + count + `">`)
+		valueMetadata.WriteString(`<valueMetadata count="` + count + `">`)
+		for i := range values {
+			futureMetadata.WriteString(`<bk><extLst><ext uri="` + richValueMetadataExtension + `"><xlrd:rvb i="` +
+				strconv.Itoa(i) + `"/></ext></extLst></bk>`)
+			valueMetadata.WriteString(`<bk><rc t="` + strconv.Itoa(typeCount) + `" v="` + strconv.Itoa(i) + `"/></bk>`)
+		}
+		futureMetadata.WriteString(`</futureMetadata>`)
+		valueMetadata.WriteString(`</valueMetadata>`)
+	}
+	sf.addWorkbookPart(metadataPath, relationshipsNamespace+"/sheetMetadata", "metadata.xml",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheetMetadata+xml",
+		xmlHeader+`<metadata xmlns="`+spreadsheetMLNamespace+`" xmlns:xlrd="`+richDataNamespace+`" xmlns:xda="`+
+			dynamicArrayNamespace+`"><metadataTypes count="`+strconv.Itoa(typeCount)+`">`+metadataTypes.String()+
+			`</metadataTypes>`+futureMetadata.String()+cellMetadata.String()+valueMetadata.String()+`</metadata>`)
+}
+
+// addWorkbookPart adds a part for the cell metadata or the rich values, with a relationship from the workbook to the
+// target, which is relative to the xl directory.
+func (sf *StreamFile) addWorkbookPart(name, relType, target, contentType, xml string) {
+	rels := &sf.pluginRelationships
+	id := "rIdCellData" + strconv.Itoa(len(rels.workbookRels)+1)
+	rels.workbookRels = append(rels.workbookRels, relationship{id: id, relType: relType, target: target})
+	sf.contentTypes.addOverride(name, contentType)
+	sf.addPart(name, []byte(xml))
+}
@@ -0,0 +1,72 @@
+package excel_stream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddMetadataPart(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		dynamicArrays []bool
+		values        []string
+		expected      []string
+	}{
+		{testName: "None", dynamicArrays: []bool{false}},
+		{
+			testName:      "Dynamic arrays",
+			dynamicArrays: []bool{false, true},
+			expected: []string{
+				`<metadataTypes count="1"><metadataType name="XLDAPR"`,
+				`<futureMetadata name="XLDAPR" count="1"><bk><extLst><ext uri="` + dynamicArrayMetadataExtension +
+					`"><xda:dynamicArrayProperties fDynamic="1" fCollapsed="0"/></ext></extLst></bk></futureMetadata>`,
+				`<cellMetadata count="1"><bk><rc t="1" v="0"/></bk></cellMetadata></metadata>`,
+			},
+		},
+		{
+			testName: "Rich values",
+			values:   []string{`<rv s="0"><v>Taco</v></rv>`, `<rv s="0"><v>Salsa</v></rv>`},
+			expected: []string{
+				`<metadataTypes count="1"><metadataType name="XLRICHVALUE"`,
+				`<futureMetadata name="XLRICHVALUE" count="2">`,
+				`<valueMetadata count="2"><bk><rc t="1" v="0"/></bk><bk><rc t="1" v="1"/></bk></valueMetadata>`,
+			},
+		},
+		{
+			testName:      "Both",
+			dynamicArrays: []bool{true},
+			values:        []string{`<rv s="0"><v>Taco</v></rv>`},
+			expected: []string{
+				`<metadataTypes count="2"><metadataType name="XLDAPR"`,
+				`</futureMetadata><futureMetadata name="XLRICHVALUE" count="1">`,
+				`<cellMetadata count="1"><bk><rc t="1" v="0"/></bk></cellMetadata>` +
+					`<valueMetadata count="1"><bk><rc t="2" v="0"/></bk></valueMetadata>`,
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		sf := &StreamFile{
+			columns:    []sheetColumns{{dynamicArrays: testCase.dynamicArrays}},
+			richValues: richValues{enabled: true, values: testCase.values},
+		}
+		sf.addMetadataPart()
+		if testCase.expected == nil {
+			if len(sf.parts) != 0 {
+				t.Fatalf("%s: Expected no metadata, got %v", testCase.testName, sf.parts)
+			}
+			continue
+		}
+		if len(sf.parts) != 1 || sf.parts[0].name != metadataPath {
+			t.Fatalf("%s: Expected the metadata part, got %v", testCase.testName, sf.parts)
+		}
+		metadataXML := string(sf.parts[0].data)
+		for _, expected := range testCase.expected {
+			if !strings.Contains(metadataXML, expected) {
+				t.Fatalf("%s: Expected %s in %s", testCase.testName, expected, metadataXML)
+			}
+		}
+		if rels := sf.pluginRelationships.workbookRels; len(rels) != 1 || rels[0].target != "metadata.xml" {
+			t.Fatalf("%s: Expected a relationship to the metadata, got %v", testCase.testName, rels)
+		}
+	}
+}
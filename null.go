@@ -0,0 +1,115 @@
+package excel_stream
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+var (
+	InvalidNullValueError = errors.New("Null value of a number, boolean or date column must be a number, boolean or date, formula columns can not have one, and it can not be longer than a cell")
+	NullCountError        = errors.New("Row must have one null flag per cell")
+)
+
+// NullValue is what the NULL cells of a column are written as, so that NULLs from a database look the same in every
+// export. The zero NullValue leaves NULL cells empty. Cells are marked as NULL with RowOptions.Nulls, or by
+// WriteNullableRow.
+type NullValue struct {
+	// Text is written in place of NULL cells, like "N/A", or "0" to show them as zero. In a number column it must be a
+	// number. It is not counted in the column's Aggregates and it is not made a link, but the formulas of a totals row
+	// count it like any other value.
+	Text string
+	// Style is applied on top of the column's style for NULL cells, so a placeholder can stand out from real values,
+	// such as in grey italics.
+	Style Style
+}
+
+// WriteNullableRow will write a row of cells to the current sheet, where nil cells are NULL and are written as the
+// NullValue of their column. It works like WriteRow in every other way.
+func (sf *StreamFile) WriteNullableRow(cells []*string) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	values := make([]string, len(cells))
+	nulls := make([]bool, len(cells))
+	for colIndex, cell := range cells {
+		if cell == nil {
+			nulls[colIndex] = true
+			continue
+		}
+		values[colIndex] = *cell
+	}
+	return sf.writeRow(values, RowOptions{Nulls: nulls})
+}
+
+// validateNull checks the column definition's null value.
+func (def *ColumnDef) validateNull() error {
+	if def.Null == (NullValue{}) {
+		return nil
+	}
+	if def.Type == FormulaColumn || excelLength(def.Null.Text) > maxCellLength {
+		return InvalidNullValueError
+	}
+	if _, err := parseBool(def.Null.Text); def.Type == BoolColumn && err != nil {
+		return InvalidNullValueError
+	}
+	if _, err := parseDate(def.Null.Text); def.Type == DateColumn && err != nil {
+		return InvalidNullValueError
+	}
+	numeric := def.Type == NumberColumn || def.Type == CurrencyColumn || def.Type == PercentColumn
+	if _, err := parseNullNumber(def.Null.Text); numeric && err != nil {
+		return InvalidNullValueError
+	}
+	return def.Null.Style.validate()
+}
+
+// parseNullNumber returns the null value of a number column as it is written, which is the number in the form Excel
+// reads, so that other forms ParseFloat accepts, like 0x1p4, are not written as they are. Infinities and NaN are not
+// numbers Excel can store.
+func parseNullNumber(text string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return "", InvalidNullValueError
+	}
+	return strconv.FormatFloat(number, 'g', -1, 64), nil
+}
+
+// null reports whether the options mark the cell at the index as NULL.
+func (o *RowOptions) null(colIndex int) bool {
+	return colIndex < len(o.Nulls) && o.Nulls[colIndex]
+}
+
+// blankNulls returns the cells with the NULL cells made empty, so that whatever they hold is not written, counted in
+// the aggregates or made a link.
+func (o *RowOptions) blankNulls(cells []string) []string {
+	blanked := append([]string(nil), cells...)
+	for colIndex := range blanked {
+		if o.null(colIndex) {
+			blanked[colIndex] = ""
+		}
+	}
+	return blanked
+}
+
+// nullCells returns the cleaned up cells of a row with the null values of their columns in place of the NULL cells.
+func (c *sheetColumns) nullCells(sanitizedCells []string, options *RowOptions) []string {
+	cells := append([]string(nil), sanitizedCells...)
+	for colIndex := range cells {
+		if options.null(colIndex) && colIndex < len(c.nullTexts) {
+			cells[colIndex] = c.nullTexts[colIndex]
+		}
+	}
+	return cells
+}
+
+// nullStyleAttribute returns the style attribute of the NULL cells of the column.
+func (c *sheetColumns) nullStyleAttribute(colIndex int) string {
+	if colIndex >= len(c.nullStyleIDs) {
+		return c.styleAttribute(colIndex)
+	}
+	return styleIDAttribute(c.nullStyleIDs[colIndex])
+}
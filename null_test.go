@@ -0,0 +1,143 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValidateNull(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		def           ColumnDef
+		expectedError error
+	}{
+		{testName: "None", def: ColumnDef{Name: "Name"}},
+		{testName: "Text", def: ColumnDef{Name: "Name", Null: NullValue{Text: "N/A", Style: Style{Italic: true}}}},
+		{testName: "Number", def: ColumnDef{Name: "Count", Type: NumberColumn, Null: NullValue{Text: "0"}}},
+		{
+			testName:      "Not A Number",
+			def:           ColumnDef{Name: "Count", Type: NumberColumn, Null: NullValue{Text: "N/A"}},
+			expectedError: InvalidNullValueError,
+		},
+		{
+			testName:      "Not Finite",
+			def:           ColumnDef{Name: "Count", Type: NumberColumn, Null: NullValue{Text: "NaN"}},
+			expectedError: InvalidNullValueError,
+		},
+		{
+			testName:      "Infinite",
+			def:           ColumnDef{Name: "Count", Type: CurrencyColumn, Null: NullValue{Text: "Inf"}},
+			expectedError: InvalidNullValueError,
+		},
+		{testName: "Bool", def: ColumnDef{Name: "Paid", Type: BoolColumn, Null: NullValue{Text: "false"}}},
+		{testName: "Date", def: ColumnDef{Name: "Paid", Type: DateColumn, Null: NullValue{Text: "1900-01-01"}}},
+		{
+			testName:      "Not A Date",
+			def:           ColumnDef{Name: "Paid", Type: DateColumn, Null: NullValue{Text: "never"}},
+			expectedError: InvalidNullValueError,
+		},
+		{
+			testName:      "Not A Bool",
+			def:           ColumnDef{Name: "Paid", Type: BoolColumn, Null: NullValue{Text: "N/A"}},
+			expectedError: InvalidNullValueError,
+		},
+		{
+			testName:      "Formula",
+			def:           ColumnDef{Name: "Sum", Type: FormulaColumn, Formula: "A{row}", Null: NullValue{Text: "0"}},
+			expectedError: InvalidNullValueError,
+		},
+		{
+			testName:      "Too Long",
+			def:           ColumnDef{Name: "Name", Null: NullValue{Text: strings.Repeat("a", maxCellLength+1)}},
+			expectedError: InvalidNullValueError,
+		},
+	}
+	for _, testCase := range testCases {
+		if err := testCase.def.validate(); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestParseNullNumber(t *testing.T) {
+	testCases := []struct {
+		text          string
+		expected      string
+		expectedError error
+	}{
+		{text: "", expected: ""},
+		{text: "0", expected: "0"},
+		{text: "-1.50", expected: "-1.5"},
+		{text: "0x1p4", expected: "16"},
+		{text: "1e3", expected: "1000"},
+		{text: "Inf", expectedError: InvalidNullValueError},
+		{text: "1_0", expected: "10"},
+	}
+	for _, testCase := range testCases {
+		actual, err := parseNullNumber(testCase.text)
+		if actual != testCase.expected || err != testCase.expectedError {
+			t.Fatalf("%s: Expected %q, %v, got %q, %v", testCase.text, testCase.expected, testCase.expectedError, actual,
+				err)
+		}
+	}
+}
+
+func TestNullCells(t *testing.T) {
+	columns := &sheetColumns{nullTexts: []string{"N/A", "", "0"}, nullStyleIDs: []int{3, 0, 2}}
+	options := &RowOptions{Nulls: []bool{true, true, false}}
+	actual := columns.nullCells(options.blankNulls([]string{"Taco", "Salsa", "5"}), options)
+	expected := []string{"N/A", "", "5"}
+	if strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected %v, got %v", expected, actual)
+	}
+	if attribute := columns.nullStyleAttribute(0); attribute != ` s="3"` {
+		t.Fatalf("Expected the null style, got %s", attribute)
+	}
+	if attribute := columns.nullStyleAttribute(1); attribute != "" {
+		t.Fatalf("Expected no style, got %s", attribute)
+	}
+}
+
+func TestWriteNullableRow(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{
+		{Name: "Name", Null: NullValue{Text: "N/A", Style: Style{Italic: true}}},
+		{Name: "Count", Type: NumberColumn, Null: NullValue{Text: "0"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, count := "Taco", "5"
+	if err := excelStream.WriteNullableRow([]*string{nil, &count}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteNullableRow([]*string{&name, nil}); err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRowOpts([]string{"Salsa", "1"}, RowOptions{Nulls: []bool{true}})
+	if err != NullCountError {
+		t.Fatalf("Expected %v, got %v", NullCountError, err)
+	}
+	aggregates, err := excelStream.Aggregates("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregates[1].Count != 1 || aggregates[1].Sum != 5 {
+		t.Fatalf("Expected the NULL count to be left out of the aggregates, got %+v", aggregates[1])
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{`<t>N/A</t>`, `<c r="B3"><v>0</v></c>`} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
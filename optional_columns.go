@@ -0,0 +1,42 @@
+package excel_stream
+
+import "errors"
+
+var InvalidOptionalColumnsError = errors.New("Number of optional columns must be between 0 and the number of columns of the sheet")
+
+// SetOptionalColumns makes the last count columns of the named sheet optional, so that rows written to it may leave
+// out any number of them, such as rows from sources that drop empty trailing fields. The columns a row leaves out are
+// written as empty cells. Rows with more cells than the sheet has columns, or that leave out more than the optional
+// columns, still return WrongNumberOfRowsError. Rows encoded by a RowEncoder must still have every cell.
+func (sb *StreamFileBuilder) SetOptionalColumns(sheetName string, count int) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	for i, sheet := range sb.xlsxFile.Sheets {
+		if sheet.Name == sheetName {
+			if count < 0 || count > len(sheet.Cols) {
+				return InvalidOptionalColumnsError
+			}
+			sb.optionalColumns[i] = count
+			return nil
+		}
+	}
+	return UnknownSheetError
+}
+
+// padOptionalColumns returns the cells of a row that left out some of the optional columns, and its options, with
+// empty cells in place of the columns that were left out.
+func padOptionalColumns(cells []string, options RowOptions, columnCount int) ([]string, RowOptions) {
+	given := len(cells)
+	cells = append(append([]string(nil), cells...), make([]string, columnCount-given)...)
+	if len(options.Phonetics) == given {
+		options.Phonetics = append(append([]string(nil), options.Phonetics...), make([]string, columnCount-given)...)
+	}
+	if len(options.Nulls) == given {
+		options.Nulls = append(append([]bool(nil), options.Nulls...), make([]bool, columnCount-given)...)
+	}
+	if len(options.cells) == given {
+		options.cells = append(append([]Cell(nil), options.cells...), make([]Cell, columnCount-given)...)
+	}
+	return cells, options
+}
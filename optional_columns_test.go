@@ -0,0 +1,81 @@
+package excel_stream
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetOptionalColumns(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Sheet1", []string{"Name", "Price", "Notes"}); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		testName      string
+		sheetName     string
+		count         int
+		expectedError error
+	}{
+		{testName: "Unknown Sheet", sheetName: "Sheet2", count: 1, expectedError: UnknownSheetError},
+		{testName: "Negative", sheetName: "Sheet1", count: -1, expectedError: InvalidOptionalColumnsError},
+		{testName: "Too Many", sheetName: "Sheet1", count: 4, expectedError: InvalidOptionalColumnsError},
+		{testName: "All", sheetName: "Sheet1", count: 3},
+		{testName: "Some", sheetName: "Sheet1", count: 2},
+	}
+	for _, testCase := range testCases {
+		if err := file.SetOptionalColumns(testCase.sheetName, testCase.count); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+	if file.optionalColumns[0] != 2 {
+		t.Fatalf("Expected 2 optional columns, got %d", file.optionalColumns[0])
+	}
+}
+
+func TestPadOptionalColumns(t *testing.T) {
+	options := RowOptions{Phonetics: []string{"タコ"}, Nulls: []bool{true}, cells: []Cell{TextValue("Taco")}}
+	cells, padded := padOptionalColumns([]string{"Taco"}, options, 3)
+	if !reflect.DeepEqual(cells, []string{"Taco", "", ""}) {
+		t.Fatalf("Expected the cells to be padded, got %v", cells)
+	}
+	if len(padded.Phonetics) != 3 || len(padded.Nulls) != 3 || len(padded.cells) != 3 {
+		t.Fatalf("Expected the options to be padded, got %+v", padded)
+	}
+	if len(options.Phonetics) != 1 || padded.Nulls[2] || padded.cells[2].Type != ColumnCell {
+		t.Fatalf("Expected the original options to be untouched and the new cells to be empty, got %+v", padded)
+	}
+}
+
+func TestWriteRowWithOptionalColumns(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name", "Price", "Notes"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetOptionalColumns("Sheet1", 2); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco"}, {"Salsa", "2"}, {"Nachos", "3", "Large"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, row := range [][]string{{}, {"Burrito", "4", "Large", "Extra"}} {
+		if err := excelStream.WriteRow(row); err != WrongNumberOfRowsError {
+			t.Fatalf("Expected %v for %v, got %v", WrongNumberOfRowsError, row, err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `<t>Large</t>`) || !strings.Contains(sheetXML, `<row r="4">`) {
+		t.Fatalf("Expected every row to be written, got %s", sheetXML)
+	}
+}
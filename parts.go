@@ -0,0 +1,368 @@
+package excel_stream
+
+import (
+	"archive/zip"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Features like images and charts need more than the sheet's rows. They add parts to the XLSX Zip file, relationships
+// from the sheet to those parts, elements to the end of the sheet's XML, and entries to [Content_Types].xml. Since the
+// sheets are streamed, these are collected as they are added, the sheet elements are written when each sheet is
+// finished, and everything else is written when the StreamFile is closed.
+
+const (
+	contentTypesPath       = "[Content_Types].xml"
+	sheetRelsPathPrefix    = "xl/worksheets/_rels/sheet"
+	endContentTypesTag     = "</Types>"
+	relationshipsNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	packageRelationships   = "http://schemas.openxmlformats.org/package/2006/relationships"
+	xmlHeader              = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+)
+
+var (
+	InvalidContentTypesError = errors.New("Invalid [Content_Types].xml, the closing Types tag was not found")
+	UnknownSheetError        = errors.New("No sheet has the given name")
+)
+
+// worksheetElementOrder is the order that the elements after sheetData must appear in, according to the schema for
+// worksheets. Elements that are out of order make Excel report that the file is corrupt.
+var worksheetElementOrder = []string{
+	"sheetCalcPr",
+	"sheetProtection",
+	"protectedRanges",
+	"scenarios",
+	"autoFilter",
+	"sortState",
+	"dataConsolidate",
+	"customSheetViews",
+	"mergeCells",
+	"phoneticPr",
+	"conditionalFormatting",
+	"dataValidations",
+	"hyperlinks",
+	"printOptions",
+	"pageMargins",
+	"pageSetup",
+	"headerFooter",
+	"rowBreaks",
+	"colBreaks",
+	"customProperties",
+	"cellWatches",
+	"ignoredErrors",
+	"smartTags",
+	"drawing",
+	"legacyDrawing",
+	"legacyDrawingHF",
+	"picture",
+	"oleObjects",
+	"controls",
+	"webPublishItems",
+	"tableParts",
+	"extLst",
+}
+
+// relationship is a link from one part of the file to another.
+type relationship struct {
+	id string
+	// relType is the URI for the kind of relationship.
+	relType string
+	// target is the path of the target part, relative to the directory of the part the relationship is from. For
+	// external relationships, such as links, it is a URL instead.
+	target   string
+	external bool
+}
+
+// sheetElement is an element that goes after the sheetData element of a sheet.
+type sheetElement struct {
+	name string
+	xml  string
+}
+
+// sheetExtras holds everything that has been added to a sheet beyond its rows.
+type sheetExtras struct {
+	relationships []relationship
+	elements      []sheetElement
+	// drawing holds the images and charts on the sheet. It is nil until the first one is added.
+	drawing *sheetDrawing
+	// hasBackground is set once the sheet has been given a background image.
+	hasBackground bool
+	// vml holds the sheet's legacy shapes, like form controls. It is nil until the first one is added.
+	vml *sheetVML
+	// headerFooterVML holds the images in the sheet's headers and footers, which are set in headerFooterImages.
+	headerFooterVML    *sheetVML
+	headerFooterImages [headerFooterPositions]bool
+	// attachments are the files embedded in the sheet, which are drawn by shapes in vml.
+	attachments []attachmentObject
+	// sparklines are rendered into the sheet's extension list once the number of rows is known.
+	sparklines []sparklineColumn
+	// conditionalFormats is the number of conditional formats on the sheet, which sets the priority of the next one.
+	conditionalFormats int
+	// dataValidations holds the XML of each data validation, which all go in one dataValidations element.
+	dataValidations []string
+	// links holds the XML of each hyperlink, which all go in one hyperlinks element.
+	links []string
+	// mergeCells holds the XML of each merged range, which all go in one mergeCells element.
+	mergeCells []string
+	// report is set for sheets added with AddReportSheet, which get a filter and shaded rows once their rows are
+	// written. stripeDxfID is the differential format of the shading.
+	report      bool
+	stripeDxfID int
+	// autoFilter is set for sheets added with the AutoFilter flag.
+	autoFilter bool
+}
+
+// packagePart is a part that will be written to the XLSX Zip file when the StreamFile is closed.
+type packagePart struct {
+	name string
+	data []byte
+}
+
+// contentTypes holds [Content_Types].xml, so that entries for the parts added while streaming can be added to it
+// before it is written at Close.
+type contentTypes struct {
+	xml string
+	// defaults maps file extensions to their content types.
+	defaults map[string]string
+	// defaultOrder is the order the defaults were added in, so that the output does not depend on map order.
+	defaultOrder []string
+	// overrides maps part names to their content types.
+	overrides     map[string]string
+	overrideOrder []string
+}
+
+// addDefault sets the content type for every part with the extension, unless the extension already has one.
+func (ct *contentTypes) addDefault(extension, contentType string) {
+	if ct.defaults == nil {
+		ct.defaults = make(map[string]string)
+	}
+	if _, ok := ct.defaults[extension]; ok || strings.Contains(ct.xml, xmlAttribute("Extension", extension)) {
+		return
+	}
+	ct.defaults[extension] = contentType
+	ct.defaultOrder = append(ct.defaultOrder, extension)
+}
+
+// addOverride sets the content type of a single part.
+func (ct *contentTypes) addOverride(partName, contentType string) {
+	if ct.overrides == nil {
+		ct.overrides = make(map[string]string)
+	}
+	if _, ok := ct.overrides[partName]; !ok {
+		ct.overrideOrder = append(ct.overrideOrder, partName)
+	}
+	ct.overrides[partName] = contentType
+}
+
+// render returns [Content_Types].xml with all of the added entries.
+func (ct *contentTypes) render() (string, error) {
+	end := strings.LastIndex(ct.xml, endContentTypesTag)
+	if end == -1 {
+		return "", InvalidContentTypesError
+	}
+	var entries strings.Builder
+	for _, extension := range ct.defaultOrder {
+		entries.WriteString(`<Default` + xmlAttribute("Extension", extension) +
+			xmlAttribute("ContentType", ct.defaults[extension]) + `></Default>`)
+	}
+	for _, partName := range ct.overrideOrder {
+		entries.WriteString(`<Override` + xmlAttribute("PartName", "/"+partName) +
+			xmlAttribute("ContentType", ct.overrides[partName]) + `></Override>`)
+	}
+	return ct.xml[:end] + entries.String() + ct.xml[end:], nil
+}
+
+// sheetIndexByName returns the index in the sheet array of the sheet with the name, or -1 if there is none.
+func (sf *StreamFile) sheetIndexByName(name string) int {
+	for i, sheet := range sf.xlsxFile.Sheets {
+		if sheet.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// editableSheet returns the index in the sheet array of the named sheet, as long as its end has not been written yet.
+func (sf *StreamFile) editableSheet(name string) (int, error) {
+	sheetArrayIndex := sf.sheetIndexByName(name)
+	if sheetArrayIndex == -1 {
+		return 0, UnknownSheetError
+	}
+	if sf.currentSheet != nil {
+		currentArrayIndex := sf.currentSheet.index - 1
+		if sheetArrayIndex < currentArrayIndex || (sheetArrayIndex == currentArrayIndex && sf.currentSheet.finalized) {
+			return 0, SheetFinalizedError
+		}
+	}
+	return sheetArrayIndex, nil
+}
+
+// addSheetRelationship adds a relationship from the sheet to the target, which is relative to the worksheets
+// directory, and returns its ID.
+func (sf *StreamFile) addSheetRelationship(sheetArrayIndex int, relType, target string) string {
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	id := "rId" + strconv.Itoa(len(extras.relationships)+1)
+	extras.relationships = append(extras.relationships, relationship{id: id, relType: relType, target: target})
+	return id
+}
+
+// addSheetElement adds an element that will be written after the sheet's sheetData, in the position the schema
+// requires.
+func (sf *StreamFile) addSheetElement(sheetArrayIndex int, name, xml string) {
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	extras.elements = append(extras.elements, sheetElement{name: name, xml: xml})
+}
+
+// addPart adds a part that will be written to the XLSX Zip file when the StreamFile is closed.
+func (sf *StreamFile) addPart(name string, data []byte) {
+	sf.parts = append(sf.parts, packagePart{name: name, data: data})
+}
+
+// sheetSuffix returns the end of the sheet's XML, after the sheetData, with any elements that were added to the sheet.
+// It must only be called once the sheet is finished, since some elements depend on the number of rows.
+func (sf *StreamFile) sheetSuffix(sheetArrayIndex int) string {
+	suffix := sf.sheetXmlSuffix[sheetArrayIndex]
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	dataRowCount := sf.rowCounts[sheetArrayIndex]
+	if totals := sf.columns[sheetArrayIndex].totals; totals != nil && totals.written {
+		// The totals row is left out of the filter, the shading and the sparklines, so that sorting does not move it.
+		dataRowCount--
+	}
+	for _, element := range extras.elements {
+		suffix = insertSheetElement(suffix, element.name, element.xml)
+	}
+	if len(extras.mergeCells) > 0 {
+		suffix = insertSheetElement(suffix, "mergeCells", renderMergeCells(extras.mergeCells))
+	}
+	if validations := renderDataValidations(extras.dataValidations); validations != "" {
+		suffix = insertSheetElement(suffix, "dataValidations", validations)
+	}
+	if len(extras.links) > 0 {
+		suffix = insertSheetElement(suffix, "hyperlinks", renderLinks(extras.links))
+	}
+	columnCount := len(sf.xlsxFile.Sheets[sheetArrayIndex].Cols)
+	headerRow := sf.headerRows[sheetArrayIndex]
+	suffix = renderReportElements(suffix, extras, columnCount, headerRow, dataRowCount)
+	if len(extras.attachments) > 0 {
+		suffix = insertSheetElement(suffix, "oleObjects",
+			renderAttachments(extras.attachments, sf.firstVMLShapeID(sheetArrayIndex)))
+	}
+	if extras.headerFooterVML != nil {
+		suffix = setHeaderFooterImages(suffix, extras.headerFooterImages)
+	}
+	// Extensions from newer versions of Excel all go in a single extLst element at the end of the sheet.
+	sheetName := sf.xlsxFile.Sheets[sheetArrayIndex].Name
+	extensions := renderSparklines(sheetName, extras.sparklines, headerRow, dataRowCount)
+	if extensions != "" {
+		suffix = insertSheetElement(suffix, "extLst", "<extLst>"+extensions+"</extLst>")
+	}
+	return sf.xmlConformance.conformSuffix(suffix)
+}
+
+// insertSheetElement inserts the XML for the named element into the end of a sheet's XML, before the first element
+// that the schema says must come after it. If there is no such element, it is inserted before the closing worksheet
+// tag.
+func insertSheetElement(suffix, name, xml string) string {
+	position := strings.LastIndex(suffix, "</worksheet>")
+	if position == -1 {
+		position = len(suffix)
+	}
+	after := false
+	for _, elementName := range worksheetElementOrder {
+		if elementName == name {
+			after = true
+			continue
+		}
+		if !after {
+			continue
+		}
+		if index := findElement(suffix, elementName); index != -1 && index < position {
+			position = index
+		}
+	}
+	return suffix[:position] + xml + suffix[position:]
+}
+
+// findElement returns the position of the first start tag for the element in the XML, or -1 if there is none.
+func findElement(xml, name string) int {
+	tag := "<" + name
+	offset := 0
+	for {
+		index := strings.Index(xml[offset:], tag)
+		if index == -1 {
+			return -1
+		}
+		end := offset + index + len(tag)
+		if end < len(xml) && strings.ContainsRune(" />\t\r\n", rune(xml[end])) {
+			return offset + index
+		}
+		offset = end
+	}
+}
+
+// writeParts writes everything that was added to the file while streaming: the charts, the VML drawings, the
+// relationships of each sheet, the drawings, the added parts, the styles and finally [Content_Types].xml.
+func (sf *StreamFile) writeParts() error {
+	sf.addChartParts()
+	sf.addVMLParts()
+	for i := range sf.sheetExtras {
+		sf.addDrawingParts(i)
+		relationships := sf.sheetExtras[i].relationships
+		if len(relationships) == 0 {
+			continue
+		}
+		relsPath := sheetRelsPathPrefix + strconv.Itoa(i+1) + sheetFilePathSuffix + ".rels"
+		sf.addPart(relsPath, []byte(renderRelationships(relationships)))
+	}
+	if sf.workbookXML != "" {
+		sf.addPart(workbookPath, []byte(sf.addDefinedNames(sf.workbookXML)))
+	}
+	for _, part := range sf.parts {
+		if err := sf.writePart(part.name, part.data); err != nil {
+			return err
+		}
+	}
+	styles, err := sf.styles.render()
+	if err != nil {
+		return err
+	}
+	if err := sf.writePart(stylesPath, []byte(styles)); err != nil {
+		return err
+	}
+	data, err := sf.contentTypes.render()
+	if err != nil {
+		return err
+	}
+	return sf.writePart(contentTypesPath, []byte(data))
+}
+
+// writePart writes a complete part to the XLSX Zip file.
+func (sf *StreamFile) writePart(name string, data []byte) error {
+	writer, err := sf.createPart(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+// renderRelationships returns the XML for a relationships part.
+func renderRelationships(relationships []relationship) string {
+	return xmlHeader + `<Relationships xmlns="` + packageRelationships + `">` +
+		renderRelationshipElements(relationships) + `</Relationships>`
+}
+
+// renderRelationshipElements returns the XML of the relationships, without the element around them.
+func renderRelationshipElements(relationships []relationship) string {
+	var builder strings.Builder
+	for _, rel := range relationships {
+		builder.WriteString(`<Relationship` + xmlAttribute("Id", rel.id) + xmlAttribute("Type", rel.relType) +
+			xmlAttribute("Target", rel.target))
+		if rel.external {
+			builder.WriteString(` TargetMode="External"`)
+		}
+		builder.WriteString(`></Relationship>`)
+	}
+	return builder.String()
+}
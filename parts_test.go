@@ -0,0 +1,71 @@
+package excel_stream
+
+import (
+	"testing"
+)
+
+func TestInsertSheetElement(t *testing.T) {
+	suffix := `<printOptions headings="false"></printOptions><pageMargins left="0.7"></pageMargins>` +
+		`<headerFooter><oddHeader></oddHeader></headerFooter></worksheet>`
+	testCases := []struct {
+		name     string
+		xml      string
+		expected string
+	}{
+		{
+			name: "mergeCells",
+			xml:  "<mergeCells></mergeCells>",
+			expected: `<mergeCells></mergeCells><printOptions headings="false"></printOptions>` +
+				`<pageMargins left="0.7"></pageMargins><headerFooter><oddHeader></oddHeader></headerFooter></worksheet>`,
+		},
+		{
+			name: "drawing",
+			xml:  "<drawing></drawing>",
+			expected: `<printOptions headings="false"></printOptions><pageMargins left="0.7"></pageMargins>` +
+				`<headerFooter><oddHeader></oddHeader></headerFooter><drawing></drawing></worksheet>`,
+		},
+		{
+			// Elements that can repeat are added after the ones already there.
+			name: "printOptions",
+			xml:  "<printOptions></printOptions>",
+			expected: `<printOptions headings="false"></printOptions><printOptions></printOptions>` +
+				`<pageMargins left="0.7"></pageMargins><headerFooter><oddHeader></oddHeader></headerFooter></worksheet>`,
+		},
+	}
+	for _, testCase := range testCases {
+		if actual := insertSheetElement(suffix, testCase.name, testCase.xml); actual != testCase.expected {
+			t.Fatalf("Inserting %s: expected %s, got %s", testCase.name, testCase.expected, actual)
+		}
+	}
+}
+
+func TestFindElement(t *testing.T) {
+	xml := `<pageSetup></pageSetup><pageMargins></pageMargins>`
+	if index := findElement(xml, "pageMargins"); index != 23 {
+		t.Fatalf("Expected pageMargins at 23, got %d", index)
+	}
+	if index := findElement(xml, "page"); index != -1 {
+		t.Fatalf("Expected a prefix of an element name not to match, got %d", index)
+	}
+}
+
+func TestContentTypesRender(t *testing.T) {
+	ct := contentTypes{xml: `<Types><Default Extension="xml" ContentType="application/xml"></Default></Types>`}
+	ct.addDefault("xml", "text/xml")
+	ct.addDefault("png", "image/png")
+	ct.addDefault("png", "image/png")
+	ct.addOverride("xl/drawings/drawing1.xml", drawingContentType)
+	actual, err := ct.render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<Types><Default Extension="xml" ContentType="application/xml"></Default>` +
+		`<Default Extension="png" ContentType="image/png"></Default>` +
+		`<Override PartName="/xl/drawings/drawing1.xml" ContentType="` + drawingContentType + `"></Override></Types>`
+	if actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+	if _, err := (&contentTypes{xml: "<Types>"}).render(); err != InvalidContentTypesError {
+		t.Fatalf("Expected InvalidContentTypesError, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package excel_stream
+
+import (
+	"strconv"
+	"unicode/utf16"
+)
+
+// phonetic returns the phonetic reading of the cell at the index in the row, or an empty string if it has none.
+func (o *RowOptions) phonetic(colIndex int) string {
+	if colIndex < len(o.Phonetics) {
+		return o.Phonetics[colIndex]
+	}
+	return ""
+}
+
+// phoneticXML returns the phonetic run that gives the whole text the reading, followed by the phonetic properties,
+// for the end of an inline string. It returns an empty string if there is no reading. The run's start and end are
+// counted in UTF-16 code units, the way Excel counts the characters of a string.
+func phoneticXML(text, reading string) string {
+	if reading == "" || text == "" {
+		return ""
+	}
+	textOpen := `<t>`
+	if needsSpacePreserved(reading) {
+		textOpen = `<t xml:space="preserve">`
+	}
+	end := len(utf16.Encode([]rune(text)))
+	return `<rPh sb="0" eb="` + strconv.Itoa(end) + `">` + textOpen + escapeXML(reading) + `</t></rPh>` +
+		`<phoneticPr fontId="0"/>`
+}
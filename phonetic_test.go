@@ -0,0 +1,80 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPhoneticXML(t *testing.T) {
+	testCases := []struct {
+		testName string
+		text     string
+		reading  string
+		expected string
+	}{
+		{testName: "None", text: "山田", reading: "", expected: ""},
+		{testName: "Empty Text", text: "", reading: "ヤマダ", expected: ""},
+		{
+			testName: "Name",
+			text:     "山田 太郎",
+			reading:  "ヤマダ タロウ",
+			expected: `<rPh sb="0" eb="5"><t>ヤマダ タロウ</t></rPh><phoneticPr fontId="0"/>`,
+		},
+		{
+			testName: "Surrogate Pair",
+			text:     "𠮷田",
+			reading:  " ヨシダ",
+			expected: `<rPh sb="0" eb="3"><t xml:space="preserve"> ヨシダ</t></rPh><phoneticPr fontId="0"/>`,
+		},
+		{
+			testName: "Escaped",
+			text:     "A&B",
+			reading:  "<ab>",
+			expected: `<rPh sb="0" eb="3"><t>&lt;ab&gt;</t></rPh><phoneticPr fontId="0"/>`,
+		},
+	}
+	for _, testCase := range testCases {
+		if actual := phoneticXML(testCase.text, testCase.reading); actual != testCase.expected {
+			t.Fatalf("%s: Expected %s, got %s", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestWriteRowPhonetics(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{{Name: "Name"}, {Name: "Age", Type: NumberColumn}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowOpts([]string{"山田", "30"}, RowOptions{Phonetics: []string{"ヤマダ", ""}}); err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRowOpts([]string{"田中", "40"}, RowOptions{Phonetics: []string{"タナカ"}})
+	if err != PhoneticCountError {
+		t.Fatalf("Expected %v, got %v", PhoneticCountError, err)
+	}
+	err = excelStream.WriteRowOpts([]string{"田中", "40"}, RowOptions{Phonetics: []string{"", "ヨンジュウ"}})
+	var cellError *CellError
+	if !errors.As(err, &cellError) || cellError.Column != 1 || cellError.Err != PhoneticCellError {
+		t.Fatalf("Expected %v for column 1, got %v", PhoneticCellError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	expected := `<c r="A2" t="inlineStr"><is><t>山田</t><rPh sb="0" eb="2"><t>ヤマダ</t></rPh>` +
+		`<phoneticPr fontId="0"/></is></c>`
+	if !strings.Contains(sheetXML, expected) {
+		t.Fatalf("Expected %s in %s", expected, sheetXML)
+	}
+	if strings.Contains(sheetXML, `<row r="3"`) {
+		t.Fatalf("Expected the rejected rows to not be written: %s", sheetXML)
+	}
+}
@@ -0,0 +1,141 @@
+package excel_stream
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	packageRelsPath  = "_rels/.rels"
+	workbookRelsPath = "xl/_rels/workbook.xml.rels"
+)
+
+var (
+	NilPluginError           = errors.New("Plugin must not be nil")
+	InvalidPluginPartError   = errors.New("Plugin part must have a relative name without .. and a content type")
+	ReservedPluginPartError  = errors.New("Plugin part can not be in the xl, _rels or docProps directories, which the library writes")
+	DuplicatePluginPartError = errors.New("Plugin part has already been added")
+)
+
+// Plugin adds parts of its own to the file when it is closed, such as a part with analytics about the export. Plugins
+// are added to the builder with AddPlugin, and Close calls their Finalize methods in the order they were added, after
+// every sheet has been written and before the parts that are kept until the end. If a plugin returns an error, the
+// plugins after it are not called and Close returns the error. A plugin that panics is treated the same way, with an
+// error wrapping CallbackPanicError.
+type Plugin interface {
+	Finalize(parts *PluginParts) error
+}
+
+// PluginParts is what a plugin can add to the file. It can only be used during the plugin's Finalize call.
+type PluginParts struct {
+	sf *StreamFile
+}
+
+// pluginRelationships holds the relationships added by plugins, which are added to the package and workbook
+// relationship parts when they are written at Close. The workbook relationships of the rich value parts are also kept
+// here.
+type pluginRelationships struct {
+	// packageRelsXML and workbookRelsXML are the relationship parts, which are only written at Close when there are
+	// plugins, or rich values for the workbook's.
+	packageRelsXML  string
+	workbookRelsXML string
+	packageRels     []relationship
+	workbookRels    []relationship
+	// partNames are the names of the parts added by plugins.
+	partNames map[string]bool
+}
+
+// AddPlugin adds a plugin that adds parts to the file when it is closed. Plugins are called in the order they are
+// added.
+func (sb *StreamFileBuilder) AddPlugin(plugin Plugin) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if plugin == nil {
+		return NilPluginError
+	}
+	sb.plugins = append(sb.plugins, plugin)
+	return nil
+}
+
+// AddPart adds a part to the file with the content type, such as "application/json". The name is the part's path in
+// the zip, like "analytics/export.json", which can not be in the directories the library writes to.
+func (p *PluginParts) AddPart(name string, data []byte, contentType string) error {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "..") || contentType == "" {
+		return InvalidPluginPartError
+	}
+	for _, reserved := range []string{"xl/", "_rels/", "docProps/", contentTypesPath} {
+		if strings.HasPrefix(name, reserved) {
+			return ReservedPluginPartError
+		}
+	}
+	plugins := &p.sf.pluginRelationships
+	if plugins.partNames[name] {
+		return DuplicatePluginPartError
+	}
+	if plugins.partNames == nil {
+		plugins.partNames = make(map[string]bool)
+	}
+	plugins.partNames[name] = true
+	p.sf.contentTypes.addOverride(name, contentType)
+	p.sf.addPart(name, data)
+	return nil
+}
+
+// AddPackageRelationship adds a relationship of the type from the package to the target, which is the name of a part
+// like "analytics/export.json", and returns its ID. This is how parts that belong to the whole file are found.
+func (p *PluginParts) AddPackageRelationship(relType, target string) string {
+	plugins := &p.sf.pluginRelationships
+	id := "rIdPlugin" + strconv.Itoa(len(plugins.packageRels)+1)
+	plugins.packageRels = append(plugins.packageRels, relationship{id: id, relType: relType, target: target})
+	return id
+}
+
+// AddWorkbookRelationship adds a relationship of the type from the workbook to the target, and returns its ID. The
+// target is relative to the xl directory, so a part at "analytics/export.json" is "../analytics/export.json".
+func (p *PluginParts) AddWorkbookRelationship(relType, target string) string {
+	plugins := &p.sf.pluginRelationships
+	id := "rIdPlugin" + strconv.Itoa(len(plugins.workbookRels)+1)
+	plugins.workbookRels = append(plugins.workbookRels, relationship{id: id, relType: relType, target: target})
+	return id
+}
+
+// SheetSummaries returns the name and number of rows of each sheet, for plugins that describe the export.
+func (p *PluginParts) SheetSummaries() []SheetSummary {
+	return p.sf.SheetSummaries()
+}
+
+// runPlugins calls the Finalize method of each plugin in order, and adds the relationship parts with the relationships
+// they added.
+func (sf *StreamFile) runPlugins() error {
+	for i, plugin := range sf.plugins {
+		if err := sf.finalizePlugin(plugin); err != nil {
+			return fmt.Errorf("plugin %d: %w", i+1, err)
+		}
+	}
+	plugins := &sf.pluginRelationships
+	if plugins.packageRelsXML != "" {
+		sf.addPart(packageRelsPath, []byte(addRelationships(plugins.packageRelsXML, plugins.packageRels)))
+	}
+	if plugins.workbookRelsXML != "" {
+		sf.addPart(workbookRelsPath, []byte(addRelationships(plugins.workbookRelsXML, plugins.workbookRels)))
+	}
+	return nil
+}
+
+// finalizePlugin runs the plugin's Finalize.
+func (sf *StreamFile) finalizePlugin(plugin Plugin) (err error) {
+	defer sf.recoverCallback("plugin", &err)
+	return plugin.Finalize(&PluginParts{sf: sf})
+}
+
+// addRelationships adds the relationships to the end of the XML of a relationships part.
+func addRelationships(xml string, relationships []relationship) string {
+	end := strings.LastIndex(xml, `</Relationships>`)
+	if end == -1 {
+		return xml
+	}
+	return xml[:end] + renderRelationshipElements(relationships) + xml[end:]
+}
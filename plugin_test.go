@@ -0,0 +1,114 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// analyticsPlugin adds a part with the number of rows of each sheet.
+type analyticsPlugin struct {
+	err error
+}
+
+func (p *analyticsPlugin) Finalize(parts *PluginParts) error {
+	if p.err != nil {
+		return p.err
+	}
+	var data strings.Builder
+	for _, summary := range parts.SheetSummaries() {
+		data.WriteString(summary.Name + "\n")
+	}
+	if err := parts.AddPart("analytics/export.txt", []byte(data.String()), "text/plain"); err != nil {
+		return err
+	}
+	parts.AddPackageRelationship("http://example.com/analytics", "analytics/export.txt")
+	parts.AddWorkbookRelationship("http://example.com/analytics", "../analytics/export.txt")
+	return nil
+}
+
+func TestPluginAddPart(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		name          string
+		contentType   string
+		expectedError error
+	}{
+		{testName: "Valid", name: "customXml/item1.xml", contentType: "application/xml"},
+		{testName: "Empty Name", contentType: "text/plain", expectedError: InvalidPluginPartError},
+		{testName: "Absolute", name: "/a.txt", contentType: "text/plain", expectedError: InvalidPluginPartError},
+		{testName: "Parent", name: "a/../b.txt", contentType: "text/plain", expectedError: InvalidPluginPartError},
+		{testName: "No Content Type", name: "a.txt", expectedError: InvalidPluginPartError},
+		{testName: "Reserved", name: "xl/custom.xml", contentType: "text/plain", expectedError: ReservedPluginPartError},
+		{testName: "Duplicate", name: "customXml/item1.xml", contentType: "text/plain", expectedError: DuplicatePluginPartError},
+	}
+	parts := &PluginParts{sf: &StreamFile{}}
+	for _, testCase := range testCases {
+		if err := parts.AddPart(testCase.name, nil, testCase.contentType); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+	if len(parts.sf.parts) != 1 || parts.sf.contentTypes.overrides["customXml/item1.xml"] != "application/xml" {
+		t.Fatalf("Expected one part with its content type, got %v", parts.sf.parts)
+	}
+}
+
+func TestAddRelationships(t *testing.T) {
+	xml := `<Relationships xmlns="` + packageRelationships + `"><Relationship Id="rId1"></Relationship></Relationships>`
+	expected := `<Relationships xmlns="` + packageRelationships + `"><Relationship Id="rId1"></Relationship>` +
+		`<Relationship Id="rIdPlugin1" Type="t" Target="a.txt"></Relationship></Relationships>`
+	actual := addRelationships(xml, []relationship{{id: "rIdPlugin1", relType: "t", target: "a.txt"}})
+	if actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddPlugin(nil); err != NilPluginError {
+		t.Fatalf("Expected %v, got %v", NilPluginError, err)
+	}
+	if err := file.AddPlugin(&analyticsPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	if data := readZipPart(t, buffer.Bytes(), "analytics/export.txt"); data != "Sheet1\n" {
+		t.Fatalf("Expected the plugin's part, got %q", data)
+	}
+	for _, path := range []string{packageRelsPath, workbookRelsPath} {
+		if rels := readZipPart(t, buffer.Bytes(), path); !strings.Contains(rels, `Id="rIdPlugin1"`) {
+			t.Fatalf("Expected the plugin's relationship in %s: %s", path, rels)
+		}
+	}
+
+	failure := errors.New("failed")
+	file = NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddPlugin(&analyticsPlugin{err: failure}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err = file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); !errors.Is(err, failure) {
+		t.Fatalf("Expected the plugin's error, got %v", err)
+	}
+}
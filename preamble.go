@@ -0,0 +1,142 @@
+package excel_stream
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxPreambleRows is the most preamble rows a sheet can have. Preambles are for titles and notes, not data.
+const maxPreambleRows = 100
+
+var TooManyPreambleRowsError = errors.New("Sheet can not have more than 100 preamble rows")
+
+// PreambleRow is a row written above a sheet's header, such as a report title, the time the report was generated or a
+// description of the filters it was run with.
+type PreambleRow struct {
+	// Text is written in the first column of the row. An empty Text leaves a blank row.
+	Text  string
+	Style Style
+	// Height is the height of the row in points. If it is 0, the row has the sheet's default height, which may be too
+	// short for a large font.
+	Height float64
+	// Merge merges the row's cells across all of the header's columns, so that long text is not cut off by the next
+	// column and the row's style covers the whole width of the sheet.
+	Merge bool
+}
+
+// SetPreamble sets the rows written above the header of the named sheet, which must already have been added. The
+// header and the data rows are moved down to make room for them, and the frozen header, filter and shading of report
+// sheets, totals, sparklines and charts follow the header. Calling it again replaces the sheet's preamble.
+func (sb *StreamFileBuilder) SetPreamble(sheetName string, rows []PreambleRow) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if len(rows) > maxPreambleRows {
+		return TooManyPreambleRowsError
+	}
+	for _, row := range rows {
+		if err := row.validate(); err != nil {
+			return err
+		}
+	}
+	for i, sheet := range sb.xlsxFile.Sheets {
+		if sheet.Name == sheetName {
+			sb.preambles[i] = append([]PreambleRow(nil), rows...)
+			return nil
+		}
+	}
+	return UnknownSheetError
+}
+
+// validate checks the row's settings.
+func (r *PreambleRow) validate() error {
+	if excelLength(r.Text) > maxCellLength {
+		return CellTooLongError
+	}
+	if r.Height < 0 || r.Height > maxRowHeight || math.IsNaN(r.Height) {
+		return InvalidRowHeightError
+	}
+	return r.Style.validate()
+}
+
+// renderPreamble adds the preamble rows to the start of a sheet's XML, in front of its header row, which is renumbered
+// to come after them. It returns the new start of the sheet and the mergeCell elements for the merged rows.
+func (s *styleSheet) renderPreamble(prefix string, rows []PreambleRow, columnCount int) (string, []string, error) {
+	sheetData := findElement(prefix, "sheetData")
+	if sheetData == -1 {
+		return prefix, nil, nil
+	}
+	sheetDataEnd := sheetData + strings.IndexByte(prefix[sheetData:], '>') + 1
+	var builder strings.Builder
+	var merges []string
+	for i, row := range rows {
+		styleID, err := s.addCellStyle(row.Style, "")
+		if err != nil {
+			return "", nil, err
+		}
+		rowNumber := strconv.Itoa(i + 1)
+		style := ""
+		if styleID != 0 {
+			style = ` s="` + strconv.Itoa(styleID) + `"`
+		}
+		builder.WriteString(`<row r="` + rowNumber + `"`)
+		if row.Height != 0 {
+			builder.WriteString(` ht="` + strconv.FormatFloat(row.Height, 'f', -1, 64) + `" customHeight="1"`)
+		}
+		builder.WriteString(`>`)
+		if row.Text != "" {
+			textOpen := `<t>`
+			if needsSpacePreserved(row.Text) {
+				textOpen = `<t xml:space="preserve">`
+			}
+			builder.WriteString(`<c r="A` + rowNumber + `"` + style + ` t="inlineStr"><is>` + textOpen +
+				escapeXML(row.Text) + `</t></is></c>`)
+		} else if style != "" {
+			builder.WriteString(`<c r="A` + rowNumber + `"` + style + `/>`)
+		}
+		if row.Merge && columnCount > 1 {
+			merges = append(merges, `<mergeCell ref="A`+rowNumber+`:`+columnName(columnCount-1)+rowNumber+`"/>`)
+			// The other cells of the merge get the style too, so that its fill and borders cover the whole row.
+			if style != "" {
+				for colIndex := 1; colIndex < columnCount; colIndex++ {
+					builder.WriteString(`<c r="` + columnName(colIndex) + rowNumber + `"` + style + `/>`)
+				}
+			}
+		}
+		builder.WriteString(`</row>`)
+	}
+	header := renumberRows(prefix[sheetDataEnd:], len(rows))
+	return prefix[:sheetDataEnd] + builder.String() + header, merges, nil
+}
+
+// renumberRows moves the rows and cells in the XML down by the offset, by changing the r attributes of their elements.
+func renumberRows(rows string, offset int) string {
+	var builder strings.Builder
+	for {
+		start := strings.Index(rows, ` r="`)
+		if start == -1 {
+			builder.WriteString(rows)
+			return builder.String()
+		}
+		start += len(` r="`)
+		end := strings.IndexByte(rows[start:], '"')
+		if end == -1 {
+			builder.WriteString(rows)
+			return builder.String()
+		}
+		end += start
+		reference := rows[start:end]
+		builder.WriteString(rows[:start])
+		digits := strings.IndexAny(reference, "0123456789")
+		if digits == -1 {
+			builder.WriteString(reference)
+		} else if rowNumber, err := strconv.Atoi(reference[digits:]); err != nil {
+			builder.WriteString(reference)
+		} else {
+			builder.WriteString(reference[:digits] + strconv.Itoa(rowNumber+offset))
+		}
+		rows = rows[end:]
+	}
+}
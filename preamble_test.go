@@ -0,0 +1,127 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenumberRows(t *testing.T) {
+	rows := `<row r="1" spans="1:2"><c r="A1" t="s"><v>0</v></c><c r="AB1" t="s"><v>1</v></c></row>`
+	expected := `<row r="4" spans="1:2"><c r="A4" t="s"><v>0</v></c><c r="AB4" t="s"><v>1</v></c></row>`
+	if actual := renumberRows(rows, 3); actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+	if actual := renumberRows(rows, 0); actual != rows {
+		t.Fatalf("Expected no change, got %s", actual)
+	}
+}
+
+func TestRenderPreamble(t *testing.T) {
+	var styles styleSheet
+	styles.setXML(`<styleSheet><fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs></styleSheet>`)
+	prefix := `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>`
+	rows := []PreambleRow{
+		{Text: "Sales & Returns", Style: Style{Bold: true, FontSize: 16, FillColor: "DDEBF7"}, Height: 24, Merge: true},
+		{Text: " Region: West"},
+		{},
+	}
+	actual, merges, err := styles.renderPreamble(prefix, rows, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<worksheet><sheetData>` +
+		`<row r="1" ht="24" customHeight="1"><c r="A1" s="1" t="inlineStr"><is><t>Sales &amp; Returns</t></is></c>` +
+		`<c r="B1" s="1"/><c r="C1" s="1"/></row>` +
+		`<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve"> Region: West</t></is></c></row>` +
+		`<row r="3"></row>` +
+		`<row r="4"><c r="A4" t="s"><v>0</v></c><c r="B4" t="s"><v>1</v></c></row>`
+	if actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+	if expected := `<mergeCell ref="A1:C1"/>`; len(merges) != 1 || merges[0] != expected {
+		t.Fatalf("Expected %s, got %v", expected, merges)
+	}
+	if _, merges, _ := styles.renderPreamble(prefix, rows, 1); len(merges) != 0 {
+		t.Fatalf("Expected no merges for a single column, got %v", merges)
+	}
+}
+
+func TestSetPreamble(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		sheetName     string
+		rows          []PreambleRow
+		expectedError error
+	}{
+		{testName: "Title", sheetName: "Sheet1", rows: []PreambleRow{{Text: "Report", Merge: true}}},
+		{testName: "Unknown Sheet", sheetName: "Sheet2", expectedError: UnknownSheetError},
+		{
+			testName:      "Too Tall",
+			sheetName:     "Sheet1",
+			rows:          []PreambleRow{{Text: "Report", Height: 500}},
+			expectedError: InvalidRowHeightError,
+		},
+		{
+			testName:      "Too Long",
+			sheetName:     "Sheet1",
+			rows:          []PreambleRow{{Text: strings.Repeat("a", maxCellLength+1)}},
+			expectedError: CellTooLongError,
+		},
+		{
+			testName:      "Too Many",
+			sheetName:     "Sheet1",
+			rows:          make([]PreambleRow, maxPreambleRows+1),
+			expectedError: TooManyPreambleRowsError,
+		},
+	}
+	for _, testCase := range testCases {
+		file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+		if err := file.AddSheet("Sheet1", []string{"Name", "Amount"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.SetPreamble(testCase.sheetName, testCase.rows); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestWriteRowAfterPreamble(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddReportSheet("Sheet1", []ColumnDef{{Name: "Name"}, {Name: "Amount", Type: NumberColumn}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = file.SetPreamble("Sheet1", []PreambleRow{{Text: "Sales", Merge: true}, {Text: "Generated today"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco", "1"}, {"Burrito", "2"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<c r="A1" t="inlineStr"><is><t>Sales</t></is></c>`,
+		`<row r="3"`,
+		`<c r="A4" t="inlineStr"><is><t>Taco</t></is></c>`,
+		`<c r="B5"><v>2</v></c>`,
+		`ySplit="3"`,
+		`<autoFilter ref="A3:B5"/>`,
+		`<mergeCell ref="A1:B1"/>`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package excel_stream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var (
+	InvalidQuotaError      = errors.New("Quota limits must not be negative")
+	RowQuotaExceededError  = errors.New("Row quota exceeded")
+	ByteQuotaExceededError = errors.New("Byte quota exceeded")
+)
+
+// Quota limits how much a StreamFile writes, to protect services that export data for many users from exports that
+// never end. Once a limit is reached, WriteRow returns a *QuotaError for every row after that. The file can still be
+// closed, which leaves a valid file with the rows written so far, or aborted.
+type Quota struct {
+	// MaxRows is the most rows WriteRow can write, counting every sheet. The headers and the rows the library adds,
+	// such as totals, are not counted. 0 means there is no limit.
+	MaxRows int
+	// MaxBytes is the most bytes that can be written to the output. It is checked before each row, so the last row can
+	// go past it. Rows of spooled sheets only count once their sheet is finished. 0 means there is no limit.
+	MaxBytes int64
+	// Notice is written in the first column of a row after the last row that fit, so that readers of the file know it
+	// was cut short. If it is empty, no row is added.
+	Notice string
+}
+
+// QuotaError is returned by WriteRow once the Quota has been exceeded. It wraps RowQuotaExceededError or
+// ByteQuotaExceededError.
+type QuotaError struct {
+	// Rows and Bytes are how much had been written when the quota was exceeded.
+	Rows  int
+	Bytes int64
+	Err   error
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v after %d rows and %d bytes", e.Err, e.Rows, e.Bytes)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return e.Err
+}
+
+// SetQuota limits how many rows and bytes the StreamFile can write. By default there are no limits.
+func (sb *StreamFileBuilder) SetQuota(quota Quota) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if quota.MaxRows < 0 || quota.MaxBytes < 0 {
+		return InvalidQuotaError
+	}
+	sb.quota = quota
+	return nil
+}
+
+// countingWriter counts the bytes written through it. Once a write fails, every write after it returns the same error
+// without writing anything, so that nothing is written after a part of the file that is missing.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+	err    error
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	w.err = err
+	return n, err
+}
+
+// checkQuota returns the *QuotaError for the row about to be written, if the quota has been exceeded. The first time
+// it is exceeded, the notice row is written to the current sheet.
+func (sf *StreamFile) checkQuota() error {
+	if sf.quotaError != nil {
+		return sf.quotaError
+	}
+	var err error
+	switch {
+	case sf.quota.MaxRows != 0 && sf.quotaRows >= sf.quota.MaxRows:
+		err = RowQuotaExceededError
+	case sf.quota.MaxBytes != 0 && sf.output.count >= sf.quota.MaxBytes:
+		err = ByteQuotaExceededError
+	default:
+		return nil
+	}
+	sf.quotaError = &QuotaError{Rows: sf.quotaRows, Bytes: sf.output.count, Err: err}
+	if sf.quota.Notice != "" && !sf.currentSheet.finalized && sf.currentSheet.rowCount < maxRows {
+		if err := sf.writeQuotaNotice(); err != nil {
+			return err
+		}
+	}
+	return sf.quotaError
+}
+
+// writeQuotaNotice writes the quota's notice as a row of the current sheet.
+func (sf *StreamFile) writeQuotaNotice() error {
+	sf.currentSheet.rowCount++
+	rowNumber := strconv.Itoa(sf.currentSheet.rowCount)
+	textOpen := `<t>`
+	if needsSpacePreserved(sf.quota.Notice) {
+		textOpen = `<t xml:space="preserve">`
+	}
+	if err := sf.currentSheet.write(`<row r="` + rowNumber + `"><c r="A` + rowNumber + `" t="inlineStr"><is>` +
+		textOpen + escapeXML(sf.quota.Notice) + `</t></is></c></row>`); err != nil {
+		return err
+	}
+	if sf.currentSheet.spool != nil {
+		return nil
+	}
+	return sf.flush()
+}
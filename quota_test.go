@@ -0,0 +1,139 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSetQuota(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		quota         Quota
+		expectedError error
+	}{
+		{testName: "None", quota: Quota{}},
+		{testName: "Limits", quota: Quota{MaxRows: 10, MaxBytes: 1 << 20, Notice: "Truncated"}},
+		{testName: "Negative Rows", quota: Quota{MaxRows: -1}, expectedError: InvalidQuotaError},
+		{testName: "Negative Bytes", quota: Quota{MaxBytes: -1}, expectedError: InvalidQuotaError},
+	}
+	for _, testCase := range testCases {
+		file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+		if err := file.SetQuota(testCase.quota); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestCountingWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := &countingWriter{writer: &buffer}
+	writer.Write([]byte("Taco"))
+	writer.Write([]byte("Burrito"))
+	if writer.count != 11 || buffer.String() != "TacoBurrito" {
+		t.Fatalf("Expected 11 bytes to be counted, got %d and %q", writer.count, buffer.String())
+	}
+}
+
+// failingWriter fails every write once more than limit bytes have been written to it.
+type failingWriter struct {
+	limit   int
+	written int
+}
+
+var failedWriteError = errors.New("connection reset")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.written+len(p) > w.limit {
+		return 0, failedWriteError
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+func TestCountingWriterFailure(t *testing.T) {
+	writer := &countingWriter{writer: &failingWriter{limit: 4}}
+	if _, err := writer.Write([]byte("Taco")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("Burrito")); err != failedWriteError {
+		t.Fatalf("Expected %v, got %v", failedWriteError, err)
+	}
+	writer.writer = &failingWriter{limit: 100}
+	if n, err := writer.Write([]byte("Salsa")); n != 0 || err != failedWriteError {
+		t.Fatalf("Expected writes after a failure to fail, got %d, %v", n, err)
+	}
+}
+
+func TestOutputFailure(t *testing.T) {
+	file := NewStreamFileBuilder(&failingWriter{limit: 1 << 20})
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream.output.writer = &failingWriter{}
+	row := []string{strings.Repeat("Taco ", 1<<14)}
+	for err == nil {
+		err = excelStream.WriteRow(row)
+	}
+	for _, err := range []error{err, excelStream.WriteRow(row), excelStream.NextSheet(), excelStream.Close()} {
+		if !errors.Is(err, OutputFailedError) || !errors.Is(err, failedWriteError) {
+			t.Fatalf("Expected %v wrapping %v, got %v", OutputFailedError, failedWriteError, err)
+		}
+	}
+}
+
+func TestQuotaError(t *testing.T) {
+	err := error(&QuotaError{Rows: 3, Bytes: 1024, Err: RowQuotaExceededError})
+	if !errors.Is(err, RowQuotaExceededError) {
+		t.Fatalf("Expected %v to wrap %v", err, RowQuotaExceededError)
+	}
+	if expected := "Row quota exceeded after 3 rows and 1024 bytes"; err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestRowQuota(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetQuota(Quota{MaxRows: 2, Notice: "Export truncated"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Taco", "Burrito"} {
+		if err := excelStream.WriteRow([]string{name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		err := excelStream.WriteRow([]string{"Nacho"})
+		var quotaError *QuotaError
+		if !errors.As(err, &quotaError) || quotaError.Rows != 2 || quotaError.Err != RowQuotaExceededError {
+			t.Fatalf("Expected a %v after 2 rows, got %v", RowQuotaExceededError, err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	expected := `<row r="4"><c r="A4" t="inlineStr"><is><t>Export truncated</t></is></c></row>`
+	if !strings.Contains(sheetXML, expected) || strings.Count(sheetXML, "Export truncated") != 1 {
+		t.Fatalf("Expected one notice row %s in %s", expected, sheetXML)
+	}
+	if strings.Contains(sheetXML, "Nacho") {
+		t.Fatalf("Expected the rows over the quota to not be written: %s", sheetXML)
+	}
+}
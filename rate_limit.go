@@ -0,0 +1,46 @@
+package excel_stream
+
+import (
+	"time"
+)
+
+// RateLimiter paces how fast a StreamFile sends data to its output, so that large exports do not take all of a shared
+// network link. An adapter around a token bucket such as golang.org/x/time/rate.Limiter can be used.
+type RateLimiter interface {
+	// Wait blocks until n more bytes may be sent. If it returns an error, the flush that called it fails with that
+	// error.
+	Wait(n int64) error
+}
+
+// SetRateLimiter makes the StreamFile consult the limiter every time it flushes, which WriteRow does after each row.
+// The limiter is asked to wait for the bytes the flush sent, so the rows written after it are held back until the
+// output is within the limiter's rate. onWait, if it is not nil, is called after each wait with the number of bytes
+// and how long the wait took, so that the time spent being held back can be recorded. If the limiter or onWait panics,
+// the flush returns an error wrapping CallbackPanicError, and nothing more can be written to the StreamFile.
+func (sb *StreamFileBuilder) SetRateLimiter(limiter RateLimiter, onWait func(bytes int64, wait time.Duration)) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.rateLimiter = limiter
+	sb.onRateLimitWait = onWait
+	return nil
+}
+
+// waitForRateLimit asks the rate limiter to wait for the bytes written to the output since it last waited.
+func (sf *StreamFile) waitForRateLimit() (err error) {
+	if sf.rateLimiter == nil {
+		return nil
+	}
+	bytes := sf.output.count - sf.rateLimitedBytes
+	if bytes <= 0 {
+		return nil
+	}
+	sf.rateLimitedBytes = sf.output.count
+	defer sf.recoverCallback("rate limiter", &err)
+	start := time.Now()
+	err = sf.rateLimiter.Wait(bytes)
+	if sf.onRateLimitWait != nil {
+		sf.onRateLimitWait(bytes, time.Since(start))
+	}
+	return err
+}
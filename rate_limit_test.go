@@ -0,0 +1,74 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// recordingLimiter records the waits it is asked for, and fails them once it has been given an error.
+type recordingLimiter struct {
+	waits []int64
+	err   error
+}
+
+func (l *recordingLimiter) Wait(n int64) error {
+	l.waits = append(l.waits, n)
+	return l.err
+}
+
+func TestWaitForRateLimit(t *testing.T) {
+	limiter := &recordingLimiter{}
+	var waited []int64
+	sf := &StreamFile{
+		output:          &countingWriter{writer: bytes.NewBuffer(nil)},
+		rateLimiter:     limiter,
+		onRateLimitWait: func(bytes int64, wait time.Duration) { waited = append(waited, bytes) },
+	}
+	if err := sf.waitForRateLimit(); err != nil || len(limiter.waits) != 0 {
+		t.Fatalf("Expected no wait before anything is written, got %v, %v", limiter.waits, err)
+	}
+	sf.output.Write(make([]byte, 100))
+	if err := sf.waitForRateLimit(); err != nil {
+		t.Fatal(err)
+	}
+	sf.output.Write(make([]byte, 30))
+	limiter.err = errors.New("Export cancelled")
+	if err := sf.waitForRateLimit(); err != limiter.err {
+		t.Fatalf("Expected %v, got %v", limiter.err, err)
+	}
+	if len(limiter.waits) != 2 || limiter.waits[0] != 100 || limiter.waits[1] != 30 {
+		t.Fatalf("Expected waits for 100 and 30 bytes, got %v", limiter.waits)
+	}
+	if len(waited) != 2 || waited[0] != 100 || waited[1] != 30 {
+		t.Fatalf("Expected the wait callback for 100 and 30 bytes, got %v", waited)
+	}
+}
+
+func TestSetRateLimiter(t *testing.T) {
+	limiter := &recordingLimiter{}
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.SetRateLimiter(limiter, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(limiter.waits) == 0 {
+		t.Fatal("Expected WriteRow to wait for the rate limiter")
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetRateLimiter(nil, nil); err != BuiltExcelStreamBuilderError {
+		t.Fatalf("Expected %v, got %v", BuiltExcelStreamBuilderError, err)
+	}
+}
@@ -0,0 +1,71 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+)
+
+const (
+	// maxRows is the number of rows Excel allows in a sheet.
+	maxRows = 1048576
+	// maxColumns is the number of columns Excel allows in a sheet, the last of which is XFD.
+	maxColumns = 16384
+)
+
+var (
+	RowOutOfRangeError    = errors.New("Row is outside of the 1,048,576 rows Excel allows in a sheet")
+	ColumnOutOfRangeError = errors.New("Column is outside of the 16,384 columns Excel allows in a sheet")
+	BackwardsRangeError   = errors.New("Range must not end before it starts")
+)
+
+// cellReference returns the A1 style reference for a cell, such as "C12". The column index starts at 0 and the row
+// number starts at 1. Cells outside of the range Excel allows return an error instead of a reference Excel would
+// reject.
+func cellReference(columnIndex, rowNumber int) (string, error) {
+	if columnIndex < 0 || columnIndex >= maxColumns {
+		return "", ColumnOutOfRangeError
+	}
+	if rowNumber < 1 || rowNumber > maxRows {
+		return "", RowOutOfRangeError
+	}
+	return columnName(columnIndex) + strconv.Itoa(rowNumber), nil
+}
+
+// columnName returns the letters Excel uses for the column index, which starts at 0, such as "A" for 0 and "AA" for
+// 26. The index must be in range.
+func columnName(columnIndex int) string {
+	// Column names are bijective base 26, there is no zero digit, so each step takes one off before dividing.
+	var letters [3]byte
+	i := len(letters)
+	for n := columnIndex + 1; n > 0; n = (n - 1) / 26 {
+		i--
+		letters[i] = byte('A' + (n-1)%26)
+	}
+	return string(letters[i:])
+}
+
+// absoluteReference returns the A1 style reference for a cell with both the column and row fixed, such as "$C$12",
+// for use in formulas. The cell must be in range.
+func absoluteReference(columnIndex, rowNumber int) string {
+	return "$" + columnName(columnIndex) + "$" + strconv.Itoa(rowNumber)
+}
+
+// rangeReference returns the A1 style reference for the cells between two corners, such as "A2:C10". Column indexes
+// start at 0 and row numbers start at 1. If the last row is 0, the range continues to the end of the sheet.
+func rangeReference(firstColumn, firstRow, lastColumn, lastRow int) (string, error) {
+	if lastRow == 0 {
+		lastRow = maxRows
+	}
+	first, err := cellReference(firstColumn, firstRow)
+	if err != nil {
+		return "", err
+	}
+	last, err := cellReference(lastColumn, lastRow)
+	if err != nil {
+		return "", err
+	}
+	if lastColumn < firstColumn || lastRow < firstRow {
+		return "", BackwardsRangeError
+	}
+	return first + ":" + last, nil
+}
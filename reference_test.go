@@ -0,0 +1,153 @@
+package excel_stream
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+var cellReferencePattern = regexp.MustCompile(`^[A-Z]{1,3}[0-9]{1,7}$`)
+
+func TestCellReference(t *testing.T) {
+	testCases := []struct {
+		columnIndex   int
+		rowNumber     int
+		expected      string
+		expectedError error
+	}{
+		{columnIndex: 0, rowNumber: 1, expected: "A1"},
+		{columnIndex: 25, rowNumber: 2, expected: "Z2"},
+		{columnIndex: 26, rowNumber: 3, expected: "AA3"},
+		{columnIndex: 701, rowNumber: 4, expected: "ZZ4"},
+		{columnIndex: 702, rowNumber: 5, expected: "AAA5"},
+		{columnIndex: maxColumns - 1, rowNumber: maxRows, expected: "XFD1048576"},
+		{columnIndex: maxColumns, rowNumber: 1, expectedError: ColumnOutOfRangeError},
+		{columnIndex: -1, rowNumber: 1, expectedError: ColumnOutOfRangeError},
+		{columnIndex: 0, rowNumber: maxRows + 1, expectedError: RowOutOfRangeError},
+		{columnIndex: 0, rowNumber: 0, expectedError: RowOutOfRangeError},
+	}
+	for _, testCase := range testCases {
+		actual, err := cellReference(testCase.columnIndex, testCase.rowNumber)
+		if err != testCase.expectedError {
+			t.Fatalf("Column %d row %d: expected error %v, got %v", testCase.columnIndex, testCase.rowNumber,
+				testCase.expectedError, err)
+		}
+		if actual != testCase.expected {
+			t.Fatalf("Column %d row %d: expected %q, got %q", testCase.columnIndex, testCase.rowNumber,
+				testCase.expected, actual)
+		}
+	}
+}
+
+func TestCellReferenceProperties(t *testing.T) {
+	// Every reference must either match the A1 pattern Excel accepts, or come with an error saying which part is out of
+	// range.
+	property := func(columnIndex, rowNumber int) bool {
+		reference, err := cellReference(columnIndex, rowNumber)
+		columnInRange := columnIndex >= 0 && columnIndex < maxColumns
+		rowInRange := rowNumber >= 1 && rowNumber <= maxRows
+		switch {
+		case !columnInRange:
+			return err == ColumnOutOfRangeError && reference == ""
+		case !rowInRange:
+			return err == RowOutOfRangeError && reference == ""
+		default:
+			return err == nil && cellReferencePattern.MatchString(reference)
+		}
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Fatal(err)
+	}
+	// Random ints are almost never in range, so also check values drawn from inside of it.
+	inRange := func(columnIndex uint16, rowNumber uint32) bool {
+		return property(int(columnIndex)%maxColumns, int(rowNumber)%maxRows+1)
+	}
+	if err := quick.Check(inRange, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestColumnNamesAreUnique(t *testing.T) {
+	seen := make(map[string]int, maxColumns)
+	for columnIndex := 0; columnIndex < maxColumns; columnIndex++ {
+		name := columnName(columnIndex)
+		if previous, ok := seen[name]; ok {
+			t.Fatalf("Columns %d and %d both have the name %s", previous, columnIndex, name)
+		}
+		seen[name] = columnIndex
+	}
+}
+
+func TestRangeReference(t *testing.T) {
+	testCases := []struct {
+		firstColumn, firstRow, lastColumn, lastRow int
+		expected                                   string
+		expectedError                              error
+	}{
+		{0, 2, 2, 10, "A2:C10", nil},
+		{1, 2, 1, 0, "B2:B1048576", nil},
+		{3, 5, 3, 5, "D5:D5", nil},
+		{2, 2, 1, 10, "", BackwardsRangeError},
+		{0, 10, 0, 2, "", BackwardsRangeError},
+		{0, 0, 1, 10, "", RowOutOfRangeError},
+		{0, 1, maxColumns, 10, "", ColumnOutOfRangeError},
+	}
+	for _, testCase := range testCases {
+		actual, err := rangeReference(testCase.firstColumn, testCase.firstRow, testCase.lastColumn, testCase.lastRow)
+		if actual != testCase.expected || err != testCase.expectedError {
+			t.Fatalf("Expected %q and %v for %v, got %q and %v", testCase.expected, testCase.expectedError, testCase,
+				actual, err)
+		}
+	}
+}
+
+func TestAddSheetTooManyColumns(t *testing.T) {
+	file := NewStreamFileBuilder(nil)
+	if err := file.AddSheet("Sheet1", make([]string, maxColumns+1)); err != ColumnOutOfRangeError {
+		t.Fatalf("Expected ColumnOutOfRangeError, got %v", err)
+	}
+}
+
+func TestStyleIDAttribute(t *testing.T) {
+	if attribute := styleIDAttribute(0); attribute != "" {
+		t.Fatalf("Expected no attribute for the default style, got %q", attribute)
+	}
+	if attribute := styleIDAttribute(3); attribute != ` s="3"` {
+		t.Fatalf(`Expected s="3", got %q`, attribute)
+	}
+}
+
+func TestNoEmptyStyleAttributes(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	columns := []ColumnDef{
+		{Name: "Name"},
+		{Name: "Count", Type: NumberColumn, Null: NullValue{Text: "0"}},
+		{Name: "Paid", Type: DateColumn},
+	}
+	if err := file.AddSheetWithColumns("Sheet1", columns); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco", "1", "2024-01-31"}); err != nil {
+		t.Fatal(err)
+	}
+	name, paid := "Burrito", ""
+	if err := excelStream.WriteNullableRow([]*string{&name, nil, &paid}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The header row is written by the xlsx library, so only the rows after it are checked.
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	rows := sheetXML[strings.Index(sheetXML, `<row r="2"`):]
+	if strings.Contains(rows, ` s=""`) || strings.Contains(rows, ` s="0"`) {
+		t.Fatalf("Expected no empty or default style attributes in %s", rows)
+	}
+}
@@ -0,0 +1,122 @@
+package excel_stream
+
+import (
+	"strconv"
+	"unicode"
+
+	"github.com/tealeg/xlsx"
+)
+
+const (
+	// minReportColumnWidth and maxReportColumnWidth limit the widths report sheets give columns that do not set one.
+	minReportColumnWidth = 10
+	maxReportColumnWidth = 50
+	// reportStripeColor is the fill of every other data row of report sheets.
+	reportStripeColor = "F2F2F2"
+)
+
+// AddReportSheet will add a sheet set up the way most reports want it. It works like AddSheetWithColumns, and also:
+// the header row is bold and stays in view while scrolling, the header has filter buttons covering every row that is
+// written, every other data row is shaded, and columns without a Width are made wide enough for their header.
+func (sb *StreamFileBuilder) AddReportSheet(name string, columns []ColumnDef) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	columns = append([]ColumnDef(nil), columns...)
+	for i := range columns {
+		if columns[i].Width == 0 {
+			columns[i].Width = reportColumnWidth(columns[i].Name)
+		}
+	}
+	if err := sb.AddSheetWithColumns(name, columns); err != nil {
+		return err
+	}
+	sheet := sb.xlsxFile.Sheets[len(sb.xlsxFile.Sheets)-1]
+	headerStyle := xlsx.NewStyle()
+	headerStyle.Font.Bold = true
+	headerStyle.ApplyFont = true
+	for _, cell := range sheet.Rows[0].Cells {
+		cell.SetStyle(headerStyle)
+	}
+	freezeHeader(sheet)
+	sb.reportSheets[len(sb.reportSheets)-1] = true
+	return nil
+}
+
+// wideRanges are the ranges of characters that East Asian fonts show twice as wide as Latin letters: Hangul, the CJK
+// ideographs, kana, their punctuation and the fullwidth forms.
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115F, Stride: 1},
+		{Lo: 0x2E80, Hi: 0x303E, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33FF, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4DBF, Stride: 1},
+		{Lo: 0x4E00, Hi: 0x9FFF, Stride: 1},
+		{Lo: 0xA000, Hi: 0xA4CF, Stride: 1},
+		{Lo: 0xAC00, Hi: 0xD7A3, Stride: 1},
+		{Lo: 0xF900, Hi: 0xFAFF, Stride: 1},
+		{Lo: 0xFE30, Hi: 0xFE4F, Stride: 1},
+		{Lo: 0xFF00, Hi: 0xFF60, Stride: 1},
+		{Lo: 0xFFE0, Hi: 0xFFE6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x20000, Hi: 0x3FFFD, Stride: 1},
+	},
+}
+
+// displayWidth returns how many characters wide the text is shown, counting East Asian wide characters twice and
+// combining marks not at all, so that columns of Japanese or Chinese text are not made too narrow.
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.Is(wideRanges, r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// reportColumnWidth returns the width for a report column with the header, which leaves room for the filter button.
+func reportColumnWidth(header string) float64 {
+	width := displayWidth(header) + 4
+	if width < minReportColumnWidth {
+		return minReportColumnWidth
+	}
+	if width > maxReportColumnWidth {
+		return maxReportColumnWidth
+	}
+	return float64(width)
+}
+
+// setUpReportSheet adds the differential format that shades the rows of a report sheet to the style sheet.
+func (sf *StreamFile) setUpReportSheet(sheetArrayIndex int) {
+	stripe := Highlight{FillColor: reportStripeColor}
+	dxf, _ := stripe.xml()
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	extras.report = true
+	extras.stripeDxfID = sf.styles.addDxf(dxf)
+}
+
+// renderReportElements adds the filter and the shading of a report sheet to the end of its XML, once the number of
+// rows is known. Sheets added with the AutoFilter flag only get the filter. The shading goes after the sheet's other
+// conditional formats, so that they take priority over it.
+// headerRow and lastRow are the Excel row numbers of the header and of the last data row.
+func renderReportElements(suffix string, extras *sheetExtras, columnCount, headerRow, lastRow int) string {
+	if (!extras.report && !extras.autoFilter) || columnCount < 1 || lastRow < headerRow {
+		return suffix
+	}
+	lastColumn := columnName(columnCount - 1)
+	suffix = insertSheetElement(suffix, "autoFilter", `<autoFilter ref="A`+strconv.Itoa(headerRow)+`:`+lastColumn+
+		strconv.Itoa(lastRow)+`"/>`)
+	if !extras.report || lastRow < headerRow+2 {
+		return suffix
+	}
+	return insertSheetElement(suffix, "conditionalFormatting", `<conditionalFormatting sqref="A`+
+		strconv.Itoa(headerRow+1)+`:`+lastColumn+strconv.Itoa(lastRow)+`"><cfRule type="expression" dxfId="`+
+		strconv.Itoa(extras.stripeDxfID)+`" priority="`+strconv.Itoa(extras.conditionalFormats+1)+
+		`"><formula>MOD(ROW(),2)=1</formula></cfRule></conditionalFormatting>`)
+}
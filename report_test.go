@@ -0,0 +1,109 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReportColumnWidth(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected float64
+	}{
+		{header: "", expected: minReportColumnWidth},
+		{header: "ID", expected: minReportColumnWidth},
+		{header: "Customer Name", expected: 17},
+		{header: "Ünïcödé Header", expected: 18},
+		{header: "顧客名と住所", expected: 16},
+		{header: "ｶﾅ ＡＢＣ", expected: 13},
+		{header: strings.Repeat("A", 100), expected: maxReportColumnWidth},
+	}
+	for _, testCase := range testCases {
+		if actual := reportColumnWidth(testCase.header); actual != testCase.expected {
+			t.Fatalf("Expected %q to get width %v, got %v", testCase.header, testCase.expected, actual)
+		}
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected int
+	}{
+		{text: "", expected: 0},
+		{text: "Name", expected: 4},
+		{text: "名前", expected: 4},
+		{text: "カタカナ", expected: 8},
+		{text: "ｶﾀｶﾅ", expected: 4},
+		{text: "ＡＢＣ", expected: 6},
+		{text: "한국어", expected: 6},
+		{text: "e\u0301", expected: 1},
+		{text: "𠮷野家", expected: 6},
+	}
+	for _, testCase := range testCases {
+		if actual := displayWidth(testCase.text); actual != testCase.expected {
+			t.Fatalf("Expected %q to be %d wide, got %d", testCase.text, testCase.expected, actual)
+		}
+	}
+}
+
+func TestRenderReportElements(t *testing.T) {
+	suffix := `<dataValidations count="1"></dataValidations><pageMargins/></worksheet>`
+	extras := sheetExtras{report: true, stripeDxfID: 4, conditionalFormats: 2}
+	expected := `<autoFilter ref="A1:C10"/><conditionalFormatting sqref="A2:C10"><cfRule type="expression" dxfId="4"` +
+		` priority="3"><formula>MOD(ROW(),2)=1</formula></cfRule></conditionalFormatting>` + suffix
+	if actual := renderReportElements(suffix, &extras, 3, 1, 10); actual != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+	expected = `<autoFilter ref="A1:C2"/>` + suffix
+	if actual := renderReportElements(suffix, &extras, 3, 1, 2); actual != expected {
+		t.Fatalf("Expected no shading for a single data row, got %s", actual)
+	}
+	if actual := renderReportElements(suffix, &sheetExtras{}, 3, 1, 10); actual != suffix {
+		t.Fatalf("Expected sheets that are not reports to be unchanged, got %s", actual)
+	}
+	expected = `<autoFilter ref="A3:C10"/><conditionalFormatting sqref="A4:C10"><cfRule type="expression" dxfId="4"` +
+		` priority="3"><formula>MOD(ROW(),2)=1</formula></cfRule></conditionalFormatting>` + suffix
+	if actual := renderReportElements(suffix, &extras, 3, 3, 10); actual != expected {
+		t.Fatalf("Expected the filter to start at the header below the preamble, got %s", actual)
+	}
+}
+
+func TestAddReportSheet(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	columns := []ColumnDef{{Name: "Name"}, {Name: "Amount", Type: NumberColumn, Width: 8}}
+	if err := file.AddReportSheet("Report", columns); err != nil {
+		t.Fatal(err)
+	}
+	if columns[0].Width != 0 {
+		t.Fatalf("Expected the caller's columns to be left unchanged, got width %v", columns[0].Width)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco", "1"}, {"Burrito", "2"}, {"Nacho", "3"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<autoFilter ref="A1:B4"/>`,
+		`<conditionalFormatting sqref="A2:B4">`,
+		`state="frozen"`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `<bgColor rgb="FF`+reportStripeColor+`"/>`) {
+		t.Fatalf("Expected the shading format in %s", stylesXML)
+	}
+}
@@ -0,0 +1,151 @@
+package excel_stream
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	richValuePath               = "xl/richData/rdrichvalue.xml"
+	richValueStructurePath      = "xl/richData/rdrichvaluestructure.xml"
+	richValueTypesPath          = "xl/richData/rdRichValueTypes.xml"
+	richDataNamespace           = "http://schemas.microsoft.com/office/spreadsheetml/2017/richdata"
+	richDataTypesNamespace      = "http://schemas.microsoft.com/office/spreadsheetml/2017/richdata2"
+	richDataRelationshipsPrefix = "http://schemas.microsoft.com/office/2017/06/relationships/"
+	// richValueFallback is the value of rich value cells for versions of Excel that can not read them.
+	richValueFallback = "#VALUE!"
+	// richValueMetadataExtension is the URI of the extension that links a future metadata block to a rich value.
+	richValueMetadataExtension = "{3e2802c4-a4d2-4d8b-9148-e3be6c30e623}"
+)
+
+var (
+	RichValuesDisabledError  = errors.New("Rich values must be enabled with SetRichValues before entities are written")
+	InvalidEntityError       = errors.New("Entity must have text, and properties with unique names that do not start with _")
+	InvalidEntityNumberError = errors.New("Entity number property must be a finite number")
+)
+
+// Entity is a record written to a cell with WriteRowCells as a rich value, such as the record a row was exported from.
+// Versions of Excel with data types show the cell's Text with a card icon, show the Properties on the card, and let
+// formulas read them, like =A2.Owner. Older versions show #VALUE! in the cell.
+// Entities are experimental, since the parts rich values are stored in are only partly documented.
+type Entity struct {
+	Text       string
+	Properties []EntityProperty
+}
+
+// EntityProperty is a named field of an Entity. Names must be unique within the entity and can not start with an
+// underscore, which Excel keeps for its own fields.
+type EntityProperty struct {
+	Name  string
+	Value string
+	// Number makes the property a number rather than text. Its Value must then be a number, like -12.5 or 1e3.
+	Number bool
+}
+
+// EntityValue returns a cell that is written as the entity. Entities need SetRichValues on the builder.
+func EntityValue(entity Entity) Cell {
+	return Cell{Value: entity.Text, Type: EntityCell, Entity: &entity}
+}
+
+// richValues holds the rich values written to cells, which are written to the rich data parts at Close.
+type richValues struct {
+	enabled bool
+	// structures holds the XML of each distinct set of keys the values have, and structureIndexes finds them by their
+	// XML.
+	structures       []string
+	structureIndexes map[string]int
+	// values holds the XML of each value, in the order of the cells' vm attributes.
+	values []string
+}
+
+// SetRichValues controls whether cells can be written as rich values, like an EntityCell. Rich values are experimental.
+// The workbook's relationships are held until Close when they are enabled, so that the rich data parts can be added to
+// them.
+func (sb *StreamFileBuilder) SetRichValues(enabled bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.richValues = enabled
+	return nil
+}
+
+// addEntity adds the entity to the file as a rich value, and returns the vm attribute of the cell that holds it, which
+// is its position in the value metadata, starting at 1. An entity of a row that is rejected after it is added is still
+// written, which Excel ignores since no cell refers to it.
+func (sf *StreamFile) addEntity(entity *Entity) (string, error) {
+	if !sf.richValues.enabled {
+		return "", RichValuesDisabledError
+	}
+	if entity == nil || entity.Text == "" {
+		return "", InvalidEntityError
+	}
+	// Entities with the same keys share a structure. The text of every entity is in its _DisplayString key.
+	structure := `<s t="_entity"><k n="_DisplayString" t="s"/>`
+	fields := `<v>` + escapeXML(entity.Text) + `</v>`
+	names := make(map[string]bool)
+	for _, property := range entity.Properties {
+		if property.Name == "" || strings.HasPrefix(property.Name, "_") || names[property.Name] {
+			return "", InvalidEntityError
+		}
+		names[property.Name] = true
+		text := property.Value
+		keyType := ` t="s"`
+		if property.Number {
+			number, err := strconv.ParseFloat(text, 64)
+			if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+				return "", InvalidEntityNumberError
+			}
+			text = strconv.FormatFloat(number, 'g', -1, 64)
+			// Keys without a type are numbers.
+			keyType = ""
+		}
+		structure += `<k` + xmlAttribute("n", property.Name) + keyType + `/>`
+		fields += `<v>` + escapeXML(text) + `</v>`
+	}
+	structure += `</s>`
+
+	values := &sf.richValues
+	index, ok := values.structureIndexes[structure]
+	if !ok {
+		if values.structureIndexes == nil {
+			values.structureIndexes = make(map[string]int)
+		}
+		index = len(values.structures)
+		values.structureIndexes[structure] = index
+		values.structures = append(values.structures, structure)
+	}
+	values.values = append(values.values, `<rv s="`+strconv.Itoa(index)+`">`+fields+`</rv>`)
+	return strconv.Itoa(len(values.values)), nil
+}
+
+// addRichValueParts adds the rich data parts for the rich values written to cells, and their relationships from the
+// workbook. The metadata that the cells' vm attributes refer to is added by addMetadataPart.
+func (sf *StreamFile) addRichValueParts() {
+	values := &sf.richValues
+	if len(values.values) == 0 {
+		return
+	}
+	count := strconv.Itoa(len(values.values))
+	sf.addWorkbookPart(richValuePath, richDataRelationshipsPrefix+"rdRichValue", "richData/rdrichvalue.xml",
+		"application/vnd.ms-excel.rdrichvalue+xml",
+		xmlHeader+`<rvData xmlns="`+richDataNamespace+`" count="`+count+`">`+strings.Join(values.values, "")+
+			`</rvData>`)
+	sf.addWorkbookPart(richValueStructurePath, richDataRelationshipsPrefix+"rdRichValueStructure",
+		"richData/rdrichvaluestructure.xml", "application/vnd.ms-excel.rdrichvaluestructure+xml",
+		xmlHeader+`<rvStructures xmlns="`+richDataNamespace+`" count="`+strconv.Itoa(len(values.structures))+`">`+
+			strings.Join(values.structures, "")+`</rvStructures>`)
+	// The key flags keep Excel's own keys out of comparisons between values, the same as in the files Excel writes.
+	var keyFlags strings.Builder
+	for _, key := range []string{"_DisplayString", "_Flags", "_Format", "_SubLabel", "_Attribution", "_Icon",
+		"_Display", "_CanonicalPropertyNames", "_ClassificationId"} {
+		keyFlags.WriteString(`<key` + xmlAttribute("name", key) +
+			`><flag name="ExcludeFromCalcComparison" value="1"/></key>`)
+	}
+	sf.addWorkbookPart(richValueTypesPath, richDataRelationshipsPrefix+"rdRichValueTypes",
+		"richData/rdRichValueTypes.xml", "application/vnd.ms-excel.rdrichvaluetypes+xml",
+		xmlHeader+`<rvTypesInfo xmlns="`+richDataTypesNamespace+`"><global><keyFlags><key name="_Self">`+
+			`<flag name="ExcludeFromFile" value="1"/><flag name="ExcludeFromCalcComparison" value="1"/></key>`+
+			keyFlags.String()+`</keyFlags></global></rvTypesInfo>`)
+}
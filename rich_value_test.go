@@ -0,0 +1,135 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAddEntity(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		entity        *Entity
+		expected      string
+		expectedError error
+	}{
+		{testName: "Nil", expectedError: InvalidEntityError},
+		{testName: "No Text", entity: &Entity{}, expectedError: InvalidEntityError},
+		{
+			testName:      "Reserved Name",
+			entity:        &Entity{Text: "Taco", Properties: []EntityProperty{{Name: "_Display", Value: "Taco"}}},
+			expectedError: InvalidEntityError,
+		},
+		{
+			testName: "Duplicate Name",
+			entity: &Entity{Text: "Taco", Properties: []EntityProperty{
+				{Name: "Owner", Value: "Ana"},
+				{Name: "Owner", Value: "Bo"},
+			}},
+			expectedError: InvalidEntityError,
+		},
+		{
+			testName:      "Not A Number",
+			entity:        &Entity{Text: "Taco", Properties: []EntityProperty{{Name: "Price", Value: "NaN", Number: true}}},
+			expectedError: InvalidEntityNumberError,
+		},
+		{
+			testName: "Properties",
+			entity: &Entity{Text: "Taco <3>", Properties: []EntityProperty{
+				{Name: "Owner", Value: "Ana & Bo"},
+				{Name: "Price", Value: "1_0", Number: true},
+			}},
+			expected: `<rv s="0"><v>Taco &lt;3&gt;</v><v>Ana &amp; Bo</v><v>10</v></rv>`,
+		},
+	}
+	for _, testCase := range testCases {
+		sf := &StreamFile{richValues: richValues{enabled: true}}
+		vm, err := sf.addEntity(testCase.entity)
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if err != nil {
+			continue
+		}
+		if vm != "1" || len(sf.richValues.values) != 1 || sf.richValues.values[0] != testCase.expected {
+			t.Fatalf("%s: Expected %s, got %s %v", testCase.testName, testCase.expected, vm, sf.richValues.values)
+		}
+	}
+}
+
+func TestEntityStructures(t *testing.T) {
+	sf := &StreamFile{richValues: richValues{enabled: true}}
+	entities := []Entity{
+		{Text: "Taco", Properties: []EntityProperty{{Name: "Price", Value: "3", Number: true}}},
+		{Text: "Burrito"},
+		{Text: "Nacho", Properties: []EntityProperty{{Name: "Price", Value: "4", Number: true}}},
+	}
+	for i := range entities {
+		if _, err := sf.addEntity(&entities[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []string{
+		`<s t="_entity"><k n="_DisplayString" t="s"/><k n="Price"/></s>`,
+		`<s t="_entity"><k n="_DisplayString" t="s"/></s>`,
+	}
+	if strings.Join(sf.richValues.structures, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected %v, got %v", expected, sf.richValues.structures)
+	}
+	if !strings.HasPrefix(sf.richValues.values[2], `<rv s="0">`) {
+		t.Fatalf("Expected the entities with the same keys to share a structure: %v", sf.richValues.values)
+	}
+}
+
+func TestWriteEntities(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetRichValues(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Record", "Note"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity := Entity{Text: "INV-1", Properties: []EntityProperty{{Name: "Owner", Value: "Ana"}}}
+	if err := excelStream.WriteRowCells([]Cell{EntityValue(entity), TextValue("Paid")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowCells([]Cell{{Type: EntityCell}, TextValue("Pending")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `<c r="A2" t="e" vm="1"><v>#VALUE!</v></c>`) || strings.Contains(sheetXML, `vm="2"`) {
+		t.Fatalf("Expected one entity cell: %s", sheetXML)
+	}
+	metadataXML := readZipPart(t, buffer.Bytes(), metadataPath)
+	if !strings.Contains(metadataXML, `<xlrd:rvb i="0"/>`) || !strings.Contains(metadataXML, `<rc t="1" v="0"/>`) {
+		t.Fatalf("Expected metadata for the entity: %s", metadataXML)
+	}
+	richValueXML := readZipPart(t, buffer.Bytes(), richValuePath)
+	if !strings.Contains(richValueXML, `<rv s="0"><v>INV-1</v><v>Ana</v></rv>`) {
+		t.Fatalf("Expected the entity's rich value: %s", richValueXML)
+	}
+	rels := readZipPart(t, buffer.Bytes(), workbookRelsPath)
+	for _, target := range []string{"metadata.xml", "richData/rdrichvalue.xml", "richData/rdrichvaluestructure.xml",
+		"richData/rdRichValueTypes.xml"} {
+		if !strings.Contains(rels, `Target="`+target+`"`) {
+			t.Fatalf("Expected a relationship to %s: %s", target, rels)
+		}
+	}
+	contentTypes := readZipPart(t, buffer.Bytes(), "[Content_Types].xml")
+	if !strings.Contains(contentTypes, `PartName="/xl/metadata.xml"`) {
+		t.Fatalf("Expected a content type for the metadata: %s", contentTypes)
+	}
+}
@@ -0,0 +1,92 @@
+package excel_stream
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+const (
+	// maxRowHeight is the tallest Excel allows a row to be, in points.
+	maxRowHeight = 409
+	// maxOutlineLevel is the deepest rows can be grouped in Excel.
+	maxOutlineLevel = 7
+)
+
+var (
+	InvalidRowHeightError    = errors.New("Row height must be between 0 and 409 points")
+	InvalidOutlineLevelError = errors.New("Row outline level must be between 0 and 7")
+	UnknownStyleIDError      = errors.New("Style ID was not returned by AddStyle")
+	PhoneticCountError       = errors.New("Row must have one phonetic reading per cell")
+	PhoneticCellError        = errors.New("Phonetic reading can only be given for a text cell")
+)
+
+// RowOptions are the settings of a single row written with WriteRowOpts. The zero RowOptions writes the row the same
+// way WriteRow does.
+type RowOptions struct {
+	// StyleID is the style of every cell of the row, in place of the styles of its columns and the sheet's default row
+	// style. If it is 0, the cells keep their usual styles.
+	StyleID StyleID
+	// Height is the height of the row in points. If it is 0, the row is as tall as its text needs.
+	Height float64
+	// OutlineLevel groups the row with the rows around it that have the same or a higher level, so that Excel can
+	// collapse them. 0 means the row is not grouped.
+	OutlineLevel int
+	Hidden       bool
+	// Phonetics are the phonetic readings (furigana) of the row's text cells, such as the kana of Japanese names, which
+	// Excel uses to sort the cells by their pronunciation. If it is not nil, it must have one entry for each cell, and
+	// cells that are not text must have an empty one. Readings of empty cells are ignored.
+	Phonetics []string
+	// Nulls marks the row's NULL cells, which are written as the NullValue of their column whatever the cell holds. If
+	// it is not nil, it must have one entry for each cell.
+	Nulls []bool
+	// cells are the cells of a row written with WriteRowCells, and cellStyles are the style attributes of those that
+	// have a number format of their own.
+	cells      []Cell
+	cellStyles []string
+}
+
+// WriteRowOpts will write a row of cells to the current sheet with the options. It works like WriteRow in every other
+// way.
+func (sf *StreamFile) WriteRowOpts(cells []string, options RowOptions) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	return sf.writeRow(cells, options)
+}
+
+// validate checks the options' settings.
+func (o *RowOptions) validate(styles *styleSheet) error {
+	if !styles.hasCellStyle(o.StyleID) {
+		return UnknownStyleIDError
+	}
+	if o.Height < 0 || o.Height > maxRowHeight || math.IsNaN(o.Height) {
+		return InvalidRowHeightError
+	}
+	if o.OutlineLevel < 0 || o.OutlineLevel > maxOutlineLevel {
+		return InvalidOutlineLevelError
+	}
+	return nil
+}
+
+// attributes returns the attributes of the row element for the options, in the order the schema lists them.
+func (o *RowOptions) attributes() string {
+	attributes := ""
+	if o.StyleID != 0 {
+		attributes += ` s="` + strconv.Itoa(int(o.StyleID)) + `" customFormat="1"`
+	}
+	if o.Height != 0 {
+		attributes += ` ht="` + strconv.FormatFloat(o.Height, 'f', -1, 64) + `"`
+	}
+	if o.Hidden {
+		attributes += ` hidden="1"`
+	}
+	if o.Height != 0 {
+		attributes += ` customHeight="1"`
+	}
+	if o.OutlineLevel != 0 {
+		attributes += ` outlineLevel="` + strconv.Itoa(o.OutlineLevel) + `"`
+	}
+	return attributes
+}
@@ -0,0 +1,96 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRowOptionsValidate(t *testing.T) {
+	var styles styleSheet
+	styles.setXML(`<styleSheet><cellXfs count="2"><xf/><xf/></cellXfs></styleSheet>`)
+	testCases := []struct {
+		testName      string
+		options       RowOptions
+		expectedError error
+	}{
+		{testName: "Default", options: RowOptions{}},
+		{testName: "Everything", options: RowOptions{StyleID: 1, Height: 30, OutlineLevel: 7, Hidden: true}},
+		{testName: "Unknown Style", options: RowOptions{StyleID: 2}, expectedError: UnknownStyleIDError},
+		{testName: "Negative Style", options: RowOptions{StyleID: -1}, expectedError: UnknownStyleIDError},
+		{testName: "Tall", options: RowOptions{Height: 410}, expectedError: InvalidRowHeightError},
+		{testName: "Negative Height", options: RowOptions{Height: -1}, expectedError: InvalidRowHeightError},
+		{testName: "Deep Outline", options: RowOptions{OutlineLevel: 8}, expectedError: InvalidOutlineLevelError},
+	}
+	for _, testCase := range testCases {
+		if err := testCase.options.validate(&styles); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestRowOptionsAttributes(t *testing.T) {
+	testCases := []struct {
+		options  RowOptions
+		expected string
+	}{
+		{options: RowOptions{}, expected: ``},
+		{options: RowOptions{StyleID: 3}, expected: ` s="3" customFormat="1"`},
+		{options: RowOptions{Height: 22.5}, expected: ` ht="22.5" customHeight="1"`},
+		{
+			options:  RowOptions{StyleID: 1, Height: 15, OutlineLevel: 2, Hidden: true},
+			expected: ` s="1" customFormat="1" ht="15" hidden="1" customHeight="1" outlineLevel="2"`,
+		},
+	}
+	for _, testCase := range testCases {
+		if actual := testCase.options.attributes(); actual != testCase.expected {
+			t.Fatalf("Expected %q, got %q", testCase.expected, actual)
+		}
+	}
+}
+
+func TestWriteRowOpts(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name", "Amount"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, err := excelStream.AddStyle(Style{Bold: true}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := excelStream.AddStyle(Style{Bold: true}, ""); err != nil || again != total {
+		t.Fatalf("Expected the same style to get ID %d, got %d, %v", total, again, err)
+	}
+	if err := excelStream.WriteRowOpts([]string{"Taco", "1"}, RowOptions{OutlineLevel: 1, Hidden: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowOpts([]string{"Total", "1"}, RowOptions{StyleID: total, Height: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowOpts([]string{"Bad", "1"}, RowOptions{StyleID: total + 1}); err != UnknownStyleIDError {
+		t.Fatalf("Expected %v, got %v", UnknownStyleIDError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	totalStyle := ` s="` + strconv.Itoa(int(total)) + `"`
+	for _, expected := range []string{
+		`<row r="2" hidden="1" outlineLevel="1">`,
+		`<row r="3"` + totalStyle + ` customFormat="1" ht="20" customHeight="1">`,
+		`<c r="A3"` + totalStyle + ` t="inlineStr">`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	if strings.Contains(sheetXML, `<row r="4"`) {
+		t.Fatalf("Expected the row with an unknown style to not be written: %s", sheetXML)
+	}
+}
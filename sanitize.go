@@ -0,0 +1,379 @@
+package excel_stream
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// InvalidCharacterPolicy controls what WriteRow does with characters that are not allowed in XML documents, such as
+// the vertical tab 0x0B or the other C0 control characters.
+type InvalidCharacterPolicy int
+
+const (
+	// ReplaceInvalidCharacters replaces each invalid character with the Unicode replacement character U+FFFD. This is
+	// the default policy.
+	ReplaceInvalidCharacters InvalidCharacterPolicy = iota
+	// StripInvalidCharacters removes invalid characters from the cell.
+	StripInvalidCharacters
+	// EscapeInvalidCharacters writes each invalid character with Excel's _xHHHH_ escape, which Excel turns back into
+	// the original character when the file is opened. Text that already looks like one of these escapes is protected
+	// with an escaped underscore so that Excel shows it as it was written.
+	EscapeInvalidCharacters
+)
+
+// CellLengthPolicy controls what WriteRow does with a cell that is longer than the 32,767 characters Excel allows.
+type CellLengthPolicy int
+
+const (
+	// RejectLongCells will make WriteRow return an error that wraps CellTooLongError. This is the default policy.
+	RejectLongCells CellLengthPolicy = iota
+	// TruncateLongCells will shorten the cell to the maximum length, ending it with the truncation marker.
+	TruncateLongCells
+	// SplitLongCells keeps the whole cell by writing the text that does not fit into the same column of continuation
+	// rows after the row, which are empty otherwise. Every part but the last ends with the marker, so that readers can
+	// tell that the text goes on in the next row. Only text cells written with WriteRow and its variants are split;
+	// rows encoded with a RowEncoder still reject long cells.
+	SplitLongCells
+)
+
+// InvalidUTF8Policy controls what WriteRow does with cells that are not valid UTF-8.
+type InvalidUTF8Policy int
+
+const (
+	// ReplaceInvalidUTF8 replaces each byte that is not part of a valid UTF-8 sequence with the Unicode replacement
+	// character U+FFFD. This is the default policy.
+	ReplaceInvalidUTF8 InvalidUTF8Policy = iota
+	// RejectInvalidUTF8 will make WriteRow return an error that wraps InvalidUTF8Error.
+	RejectInvalidUTF8
+	// PassThroughInvalidUTF8 writes the bytes exactly as they are given. The resulting sheet is not valid XML, so this
+	// should only be used when the output is going to be repaired by another program.
+	PassThroughInvalidUTF8
+)
+
+// FormulaInjectionPolicy controls what WriteRow does with cells that begin with a character that spreadsheet programs
+// treat as the start of a formula. Cells are always written as text, so Excel will not evaluate them when the file is
+// opened, but the formula can still run if a user edits the cell or the data is copied into another program, which
+// makes it dangerous to export untrusted input without neutralizing it.
+type FormulaInjectionPolicy int
+
+const (
+	// AllowFormulaPrefixes writes cells exactly as they are given. This is the default policy.
+	AllowFormulaPrefixes FormulaInjectionPolicy = iota
+	// EscapeFormulaPrefixes adds an apostrophe to the start of any cell that begins with =, +, -, @, a tab or a
+	// carriage return, so that the cell is always treated as text.
+	EscapeFormulaPrefixes
+)
+
+// NonFiniteNumberPolicy controls what WriteRow does with NaN and infinite values in number columns, which Excel has no
+// way to store as numbers. ParseFloat reads them from text like "NaN", "+Inf" and "1e400".
+type NonFiniteNumberPolicy int
+
+const (
+	// RejectNonFiniteNumbers will make WriteRow return an error that wraps NonFiniteNumberError. This is the default
+	// policy.
+	RejectNonFiniteNumbers NonFiniteNumberPolicy = iota
+	// ErrorForNonFiniteNumbers writes the cell as the #NUM! error, which Excel uses for results that are not valid
+	// numbers.
+	ErrorForNonFiniteNumbers
+	// EmptyForNonFiniteNumbers leaves the cell empty.
+	EmptyForNonFiniteNumbers
+	// TextForNonFiniteNumbers writes the cell as text, the same way cells in text columns are written.
+	TextForNonFiniteNumbers
+)
+
+// nonFiniteErrorValue is the error value written for NaN and infinite values by ErrorForNonFiniteNumbers.
+const nonFiniteErrorValue = "#NUM!"
+
+// formulaPrefixes are the characters that can start a formula. Tab and carriage return are included because some
+// programs strip them before checking for the other characters.
+const formulaPrefixes = "=+-@\t\r"
+
+// maxCellLength is the most characters Excel will store in a cell, counted in UTF-16 code units like Excel does.
+const maxCellLength = 32767
+
+// defaultTruncationMarker is added to the end of truncated cells when no other marker has been set.
+const defaultTruncationMarker = "...[truncated]"
+
+// SanitizeReason is the kind of change that was made to a cell's data before it was written.
+type SanitizeReason int
+
+const (
+	// InvalidXMLCharacters means characters that are not allowed in XML were replaced, removed or escaped.
+	InvalidXMLCharacters SanitizeReason = iota
+	// TruncatedCell means the cell was longer than Excel allows and was truncated.
+	TruncatedCell
+	// EscapedFormula means an apostrophe was added to the start of a cell that could have been run as a formula.
+	EscapedFormula
+	// InvalidUTF8 means bytes that were not valid UTF-8 were replaced.
+	InvalidUTF8
+	// NonFiniteNumber means a NaN or infinite value in a number column was written as an error, an empty cell or text.
+	NonFiniteNumber
+	// SplitCell means the cell was longer than Excel allows and was split across continuation rows.
+	SplitCell
+)
+
+func (r SanitizeReason) String() string {
+	switch r {
+	case InvalidXMLCharacters:
+		return "invalid XML characters"
+	case TruncatedCell:
+		return "truncated cell"
+	case EscapedFormula:
+		return "escaped formula"
+	case InvalidUTF8:
+		return "invalid UTF-8"
+	case NonFiniteNumber:
+		return "non-finite number"
+	case SplitCell:
+		return "split cell"
+	default:
+		return fmt.Sprintf("SanitizeReason(%d)", int(r))
+	}
+}
+
+// SanitizeWarning describes a change that was made to a cell's data before it was written.
+type SanitizeWarning struct {
+	// Sheet is the name of the sheet the cell was written to.
+	Sheet string
+	// Row is the Excel row number of the cell, which starts at 1.
+	Row int
+	// Column is the index of the cell in the slice passed to WriteRow, which starts at 0.
+	Column int
+	// Reason is the kind of change that was made.
+	Reason SanitizeReason
+}
+
+var (
+	UnknownInvalidCharacterPolicyError = errors.New("Unknown invalid character policy")
+	UnknownCellLengthPolicyError       = errors.New("Unknown cell length policy")
+	UnknownFormulaInjectionPolicyError = errors.New("Unknown formula injection policy")
+	UnknownInvalidUTF8PolicyError      = errors.New("Unknown invalid UTF-8 policy")
+	UnknownNonFiniteNumberPolicyError  = errors.New("Unknown non-finite number policy")
+	InvalidUTF8Error                   = errors.New("Cell is not valid UTF-8.")
+	CellTooLongError                   = errors.New("Cell is longer than the 32,767 characters Excel allows.")
+	TruncationMarkerTooLongError       = errors.New("Truncation marker is longer than the 32,767 characters Excel allows.")
+	NonFiniteNumberError               = errors.New("Cell in a number column is NaN or infinite.")
+)
+
+// SanitizePolicy holds all of the settings that control how WriteRow cleans up cell data before it is written. Start
+// from DefaultSanitizePolicy() and change the fields that matter, then pass it to StreamFileBuilder.SetSanitizePolicy.
+type SanitizePolicy struct {
+	InvalidUTF8       InvalidUTF8Policy
+	InvalidCharacters InvalidCharacterPolicy
+	CellLength        CellLengthPolicy
+	FormulaInjection  FormulaInjectionPolicy
+	NonFiniteNumbers  NonFiniteNumberPolicy
+	// TruncationMarker is added to the end of truncated cells by TruncateLongCells, and to the end of every part of a
+	// split cell but the last by SplitLongCells. An empty marker adds nothing.
+	TruncationMarker string
+	// OnWarning is called every time cell data is changed, describing which cell was changed and why. It may be nil.
+	OnWarning func(SanitizeWarning)
+}
+
+// DefaultSanitizePolicy returns the policy a new StreamFileBuilder starts with.
+func DefaultSanitizePolicy() SanitizePolicy {
+	return SanitizePolicy{TruncationMarker: defaultTruncationMarker}
+}
+
+// validate returns an error if any of the policy's settings are unknown or invalid.
+func (p *SanitizePolicy) validate() error {
+	if p.InvalidUTF8 < ReplaceInvalidUTF8 || p.InvalidUTF8 > PassThroughInvalidUTF8 {
+		return UnknownInvalidUTF8PolicyError
+	}
+	if p.InvalidCharacters < ReplaceInvalidCharacters || p.InvalidCharacters > EscapeInvalidCharacters {
+		return UnknownInvalidCharacterPolicyError
+	}
+	if err := validateCellLengthPolicy(p.CellLength, p.TruncationMarker); err != nil {
+		return err
+	}
+	if p.FormulaInjection != AllowFormulaPrefixes && p.FormulaInjection != EscapeFormulaPrefixes {
+		return UnknownFormulaInjectionPolicyError
+	}
+	if p.NonFiniteNumbers < RejectNonFiniteNumbers || p.NonFiniteNumbers > TextForNonFiniteNumbers {
+		return UnknownNonFiniteNumberPolicyError
+	}
+	return nil
+}
+
+// validateCellLengthPolicy returns an error if the cell length policy is unknown or its marker is too long. A split
+// cell's marker must leave room for at least one character of the text in every part, which can take two UTF-16 units.
+func validateCellLengthPolicy(policy CellLengthPolicy, marker string) error {
+	if policy < RejectLongCells || policy > SplitLongCells {
+		return UnknownCellLengthPolicyError
+	}
+	if excelLength(marker) > maxCellLength || policy == SplitLongCells && excelLength(marker) > maxCellLength-2 {
+		return TruncationMarkerTooLongError
+	}
+	return nil
+}
+
+// sanitizeCell applies all of the policy's settings to the cell's data. The sheet name, row and column are only
+// used to describe changes to the warning callback.
+func (p *SanitizePolicy) sanitizeCell(sheet string, row, column int, cellData string) (string, error) {
+	if !utf8.ValidString(cellData) {
+		switch p.InvalidUTF8 {
+		case RejectInvalidUTF8:
+			return "", InvalidUTF8Error
+		case ReplaceInvalidUTF8:
+			cellData = replaceInvalidUTF8(cellData)
+			p.warn(sheet, row, column, InvalidUTF8)
+		}
+	}
+	cellData, changed := sanitizeInvalidCharacters(cellData, p.InvalidCharacters)
+	if changed {
+		p.warn(sheet, row, column, InvalidXMLCharacters)
+	}
+	if p.FormulaInjection == EscapeFormulaPrefixes && cellData != "" && strings.ContainsRune(formulaPrefixes, rune(cellData[0])) {
+		cellData = "'" + cellData
+		p.warn(sheet, row, column, EscapedFormula)
+	}
+	// Check the length last, since the other changes can make the cell longer.
+	// Split cells are left whole here, since WriteRow splits them once the whole row has been sanitized.
+	if excelLength(cellData) > maxCellLength {
+		switch p.CellLength {
+		case TruncateLongCells:
+			cellData = truncateCell(cellData, p.TruncationMarker)
+			p.warn(sheet, row, column, TruncatedCell)
+		case SplitLongCells:
+			p.warn(sheet, row, column, SplitCell)
+		default:
+			return "", CellTooLongError
+		}
+	}
+	return cellData, nil
+}
+
+// sanitizeNumber checks the data of a cell in a number column, and returns the text to write for it and how to write
+// it. Empty cells stay empty.
+func (p *SanitizePolicy) sanitizeNumber(sheet string, row, column int, cellData string) (string, cellKind, error) {
+	if cellData == "" {
+		return "", numberCell, nil
+	}
+	number, err := strconv.ParseFloat(cellData, 64)
+	if err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) {
+		// Decimal numbers are written with all of their digits, so values with more precision than a float64 are kept.
+		if isDecimalLiteral(cellData) {
+			return cellData, numberCell, nil
+		}
+		return strconv.FormatFloat(number, 'g', -1, 64), numberCell, nil
+	}
+	// Numbers too large for a float64 are read as infinite, with a range error.
+	if err != nil && !math.IsInf(number, 0) {
+		return "", numberCell, NotANumberError
+	}
+	switch p.NonFiniteNumbers {
+	case ErrorForNonFiniteNumbers:
+		p.warn(sheet, row, column, NonFiniteNumber)
+		return nonFiniteErrorValue, errorCell, nil
+	case EmptyForNonFiniteNumbers:
+		p.warn(sheet, row, column, NonFiniteNumber)
+		return "", numberCell, nil
+	case TextForNonFiniteNumbers:
+		p.warn(sheet, row, column, NonFiniteNumber)
+		text, err := p.sanitizeCell(sheet, row, column, cellData)
+		return text, textCell, err
+	}
+	return "", numberCell, NonFiniteNumberError
+}
+
+func (p *SanitizePolicy) warn(sheet string, row, column int, reason SanitizeReason) {
+	if p.OnWarning == nil {
+		return
+	}
+	p.OnWarning(SanitizeWarning{Sheet: sheet, Row: row, Column: column, Reason: reason})
+}
+
+// sanitizeInvalidCharacters applies the policy to every character in the string that is not allowed in XML. It returns
+// the resulting string and whether any invalid characters were found.
+func sanitizeInvalidCharacters(s string, policy InvalidCharacterPolicy) (string, bool) {
+	hasInvalid := strings.IndexFunc(s, isInvalidXMLCharacter) != -1
+	if !hasInvalid && (policy != EscapeInvalidCharacters || !strings.Contains(s, "_x")) {
+		return s, false
+	}
+	var result strings.Builder
+	result.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && width == 1:
+			// Leave bytes that are not valid UTF-8 alone, they are handled by the InvalidUTF8Policy.
+			result.WriteByte(s[i])
+		case isInvalidXMLCharacter(r):
+			switch policy {
+			case StripInvalidCharacters:
+			case EscapeInvalidCharacters:
+				fmt.Fprintf(&result, "_x%04X_", r)
+			default:
+				result.WriteRune('\uFFFD')
+			}
+		case r == '_' && policy == EscapeInvalidCharacters && isExcelEscape(s[i:]):
+			// Escape the underscore so that Excel does not decode text that only looks like an escape.
+			result.WriteString("_x005F_")
+		default:
+			result.WriteString(s[i : i+width])
+		}
+		i += width
+	}
+	return result.String(), hasInvalid
+}
+
+// replaceInvalidUTF8 replaces every byte that is not part of a valid UTF-8 sequence with U+FFFD.
+func replaceInvalidUTF8(s string) string {
+	var result strings.Builder
+	result.Grow(len(s))
+	for _, r := range s {
+		// Ranging over a string turns each invalid byte into utf8.RuneError, which is U+FFFD.
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// truncateCell shortens the cell data to the maximum length Excel allows, including the marker at the end.
+func truncateCell(cellData, marker string) string {
+	return truncateToExcelLength(cellData, maxCellLength-excelLength(marker)) + marker
+}
+
+// splitCell splits the cell into parts that are no longer than the maximum length. Every part but the last ends with
+// the marker. The marker must leave room for at least one character in every part.
+func splitCell(cellData, marker string) []string {
+	var parts []string
+	for excelLength(cellData) > maxCellLength {
+		part := truncateToExcelLength(cellData, maxCellLength-excelLength(marker))
+		parts = append(parts, part+marker)
+		cellData = cellData[len(part):]
+	}
+	return append(parts, cellData)
+}
+
+// isInvalidXMLCharacter reports whether the rune is outside of the character range allowed by the XML 1.0
+// specification: #x9 | #xA | #xD | [#x20-#xD7FF] | [#xE000-#xFFFD] | [#x10000-#x10FFFF]
+func isInvalidXMLCharacter(r rune) bool {
+	switch {
+	case r == 0x09 || r == 0x0A || r == 0x0D:
+		return false
+	case r >= 0x20 && r <= 0xD7FF:
+		return false
+	case r >= 0xE000 && r <= 0xFFFD:
+		return false
+	case r >= 0x10000 && r <= 0x10FFFF:
+		return false
+	}
+	return true
+}
+
+// isExcelEscape reports whether the string begins with an escape in the form _xHHHH_ that Excel would decode.
+func isExcelEscape(s string) bool {
+	if len(s) < 7 || s[0] != '_' || s[1] != 'x' || s[6] != '_' {
+		return false
+	}
+	for _, c := range s[2:6] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
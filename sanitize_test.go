@@ -0,0 +1,324 @@
+package excel_stream
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeInvalidCharacters(t *testing.T) {
+	testCases := []struct {
+		testName        string
+		input           string
+		policy          InvalidCharacterPolicy
+		expected        string
+		expectedChanged bool
+	}{
+		{testName: "Valid text is unchanged", input: "Taco\tSalsa\r\n🍕", policy: EscapeInvalidCharacters, expected: "Taco\tSalsa\r\n🍕"},
+		{testName: "Replace", input: "a\x0bb\x00c", policy: ReplaceInvalidCharacters, expected: "a\uFFFDb\uFFFDc", expectedChanged: true},
+		{testName: "Strip", input: "a\x0bb\x00c", policy: StripInvalidCharacters, expected: "abc", expectedChanged: true},
+		{testName: "Escape", input: "a\x0bb\x1fc\uFFFE", policy: EscapeInvalidCharacters, expected: "a_x000B_b_x001F_c_xFFFE_", expectedChanged: true},
+		{testName: "Escape protects text that looks like an escape", input: "_x0041_ and _xZZ_", policy: EscapeInvalidCharacters, expected: "_x005F_x0041_ and _xZZ_"},
+		{testName: "Other policies leave text that looks like an escape", input: "_x0041_", policy: StripInvalidCharacters, expected: "_x0041_"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.testName, func(t *testing.T) {
+			actual, changed := sanitizeInvalidCharacters(testCase.input, testCase.policy)
+			if actual != testCase.expected {
+				t.Fatalf("Expected %q, got %q", testCase.expected, actual)
+			}
+			if changed != testCase.expectedChanged {
+				t.Fatalf("Expected changed to be %v", testCase.expectedChanged)
+			}
+		})
+	}
+}
+
+func TestWriteRowInvalidCharacterWarnings(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	var warnings []SanitizeWarning
+	if err := file.SetInvalidCharacterPolicy(EscapeInvalidCharacters); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetSanitizeWarningHandler(func(warning SanitizeWarning) {
+		warnings = append(warnings, warning)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123", "Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"456", "Sal\x0bsa"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expectedWarnings := []SanitizeWarning{{Sheet: "Sheet1", Row: 3, Column: 1, Reason: InvalidXMLCharacters}}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Fatalf("Expected warnings %v, got %v", expectedWarnings, warnings)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, "<t>Sal_x000B_sa</t>") {
+		t.Fatalf("Expected escaped character in sheet XML: %s", sheetXML)
+	}
+}
+
+func TestSanitizeCellLength(t *testing.T) {
+	longCell := strings.Repeat("a", maxCellLength+10)
+	rejecter := SanitizePolicy{TruncationMarker: defaultTruncationMarker}
+	if _, err := rejecter.sanitizeCell("Sheet1", 2, 0, longCell); err != CellTooLongError {
+		t.Fatalf("Expected CellTooLongError, got %v", err)
+	}
+	if cell, err := rejecter.sanitizeCell("Sheet1", 2, 0, longCell[:maxCellLength]); err != nil || cell != longCell[:maxCellLength] {
+		t.Fatalf("Expected a cell of exactly the maximum length to be unchanged, got error %v", err)
+	}
+
+	var warnings []SanitizeWarning
+	truncater := SanitizePolicy{
+		CellLength:       TruncateLongCells,
+		TruncationMarker: defaultTruncationMarker,
+		OnWarning: func(warning SanitizeWarning) {
+			warnings = append(warnings, warning)
+		},
+	}
+	cell, err := truncater.sanitizeCell("Sheet1", 2, 3, longCell)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if excelLength(cell) != maxCellLength || !strings.HasSuffix(cell, defaultTruncationMarker) {
+		t.Fatalf("Expected the cell to be truncated to the maximum length with the marker at the end")
+	}
+	expectedWarnings := []SanitizeWarning{{Sheet: "Sheet1", Row: 2, Column: 3, Reason: TruncatedCell}}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Fatalf("Expected warnings %v, got %v", expectedWarnings, warnings)
+	}
+}
+
+func TestWriteRowCellTooLong(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = excelStream.WriteRow([]string{"123", strings.Repeat("a", maxCellLength+1)})
+	cellErr, ok := err.(*CellError)
+	if !ok || cellErr.Err != CellTooLongError || cellErr.Row != 2 || cellErr.Column != 1 {
+		t.Fatalf("Expected a CellError for row 2 column 1 wrapping CellTooLongError, got %v", err)
+	}
+	// The rejected row should not have been written, so the next row should still be row 2.
+	if err := excelStream.WriteRow([]string{"123", "Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	bufReader := bytes.NewReader(buffer.Bytes())
+	_, workbookData := readXLSXFile(t, "", bufReader, bufReader.Size(), false)
+	expectedData := [][][]string{{{"Token", "Name"}, {"123", "Taco"}}}
+	if !reflect.DeepEqual(workbookData, expectedData) {
+		t.Fatalf("Expected workbook data %v, got %v", expectedData, workbookData)
+	}
+}
+
+func TestSanitizeFormulaInjection(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "=HYPERLINK(\"http://example.com\",\"Click\")", expected: "'=HYPERLINK(\"http://example.com\",\"Click\")"},
+		{input: "+1+1", expected: "'+1+1"},
+		{input: "-2+3", expected: "'-2+3"},
+		{input: "@SUM(A1:A2)", expected: "'@SUM(A1:A2)"},
+		{input: "\t=1+1", expected: "'\t=1+1"},
+		{input: "Taco", expected: "Taco"},
+		{input: "1=1", expected: "1=1"},
+		{input: "", expected: ""},
+	}
+	var warnings []SanitizeWarning
+	s := SanitizePolicy{
+		FormulaInjection: EscapeFormulaPrefixes,
+		OnWarning: func(warning SanitizeWarning) {
+			warnings = append(warnings, warning)
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			warnings = nil
+			actual, err := s.sanitizeCell("Sheet1", 2, 0, testCase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != testCase.expected {
+				t.Fatalf("Expected %q, got %q", testCase.expected, actual)
+			}
+			if escaped := actual != testCase.input; escaped != (len(warnings) == 1) {
+				t.Fatalf("Expected one warning for each escaped cell, got %v", warnings)
+			}
+		})
+	}
+	// The default policy leaves formulas alone.
+	actual, err := (&SanitizePolicy{}).sanitizeCell("Sheet1", 2, 0, "=1+1")
+	if err != nil || actual != "=1+1" {
+		t.Fatalf("Expected the cell to be unchanged, got %q %v", actual, err)
+	}
+}
+
+func TestSanitizeInvalidUTF8(t *testing.T) {
+	invalid := "Ta\xffco\xc3"
+	replacer := SanitizePolicy{}
+	if actual, err := replacer.sanitizeCell("Sheet1", 2, 0, invalid); err != nil || actual != "Ta\uFFFDco\uFFFD" {
+		t.Fatalf("Expected invalid bytes to be replaced, got %q %v", actual, err)
+	}
+	rejecter := SanitizePolicy{InvalidUTF8: RejectInvalidUTF8}
+	if _, err := rejecter.sanitizeCell("Sheet1", 2, 0, invalid); err != InvalidUTF8Error {
+		t.Fatalf("Expected InvalidUTF8Error, got %v", err)
+	}
+	passer := SanitizePolicy{InvalidUTF8: PassThroughInvalidUTF8, InvalidCharacters: StripInvalidCharacters}
+	if actual, err := passer.sanitizeCell("Sheet1", 2, 0, invalid+"\x0b"); err != nil || actual != invalid {
+		t.Fatalf("Expected invalid bytes to be passed through, got %q %v", actual, err)
+	}
+}
+
+func TestSetSanitizePolicy(t *testing.T) {
+	invalidPolicies := []struct {
+		change        func(*SanitizePolicy)
+		expectedError error
+	}{
+		{change: func(p *SanitizePolicy) { p.InvalidUTF8 = -1 }, expectedError: UnknownInvalidUTF8PolicyError},
+		{change: func(p *SanitizePolicy) { p.InvalidCharacters = 10 }, expectedError: UnknownInvalidCharacterPolicyError},
+		{change: func(p *SanitizePolicy) { p.CellLength = 10 }, expectedError: UnknownCellLengthPolicyError},
+		{change: func(p *SanitizePolicy) { p.FormulaInjection = 10 }, expectedError: UnknownFormulaInjectionPolicyError},
+		{
+			change:        func(p *SanitizePolicy) { p.TruncationMarker = strings.Repeat("a", maxCellLength+1) },
+			expectedError: TruncationMarkerTooLongError,
+		},
+		{
+			change: func(p *SanitizePolicy) {
+				p.CellLength = SplitLongCells
+				p.TruncationMarker = strings.Repeat("a", maxCellLength-1)
+			},
+			expectedError: TruncationMarkerTooLongError,
+		},
+	}
+	for _, invalidPolicy := range invalidPolicies {
+		policy := DefaultSanitizePolicy()
+		invalidPolicy.change(&policy)
+		if err := NewStreamFileBuilder(nil).SetSanitizePolicy(policy); err != invalidPolicy.expectedError {
+			t.Fatalf("Expected %v, got %v", invalidPolicy.expectedError, err)
+		}
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	var warnings []SanitizeWarning
+	policy := DefaultSanitizePolicy()
+	policy.InvalidCharacters = StripInvalidCharacters
+	policy.FormulaInjection = EscapeFormulaPrefixes
+	policy.OnWarning = func(warning SanitizeWarning) {
+		warnings = append(warnings, warning)
+	}
+	if err := file.SetSanitizePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"=1+1", "Sal\x0bsa"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expectedWarnings := []SanitizeWarning{
+		{Sheet: "Sheet1", Row: 2, Column: 0, Reason: EscapedFormula},
+		{Sheet: "Sheet1", Row: 2, Column: 1, Reason: InvalidXMLCharacters},
+	}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Fatalf("Expected warnings %v, got %v", expectedWarnings, warnings)
+	}
+}
+
+func TestSanitizeNumber(t *testing.T) {
+	testCases := []struct {
+		policy       NonFiniteNumberPolicy
+		input        string
+		expected     string
+		expectedKind cellKind
+		expectedErr  error
+		warns        bool
+	}{
+		{input: "42", expected: "42", expectedKind: numberCell},
+		{input: "-1.50", expected: "-1.50", expectedKind: numberCell},
+		{input: "1e21", expected: "1e21", expectedKind: numberCell},
+		{input: "12345678901234567890.123456789", expected: "12345678901234567890.123456789", expectedKind: numberCell},
+		{input: "0x1p-2", expected: "0.25", expectedKind: numberCell},
+		{input: "", expected: "", expectedKind: numberCell},
+		{input: "abc", expectedErr: NotANumberError},
+		{input: " 1", expectedErr: NotANumberError},
+		{input: "NaN", expectedErr: NonFiniteNumberError},
+		{input: "-Inf", expectedErr: NonFiniteNumberError},
+		{input: "1e400", expectedErr: NonFiniteNumberError},
+		{policy: ErrorForNonFiniteNumbers, input: "NaN", expected: "#NUM!", expectedKind: errorCell, warns: true},
+		{policy: ErrorForNonFiniteNumbers, input: "1e400", expected: "#NUM!", expectedKind: errorCell, warns: true},
+		{policy: ErrorForNonFiniteNumbers, input: "abc", expectedErr: NotANumberError},
+		{policy: EmptyForNonFiniteNumbers, input: "+Inf", expected: "", expectedKind: numberCell, warns: true},
+		{policy: TextForNonFiniteNumbers, input: "-Inf", expected: "-Inf", expectedKind: textCell, warns: true},
+		{policy: TextForNonFiniteNumbers, input: "12", expected: "12", expectedKind: numberCell},
+	}
+	for _, testCase := range testCases {
+		var warnings []SanitizeWarning
+		p := SanitizePolicy{NonFiniteNumbers: testCase.policy, OnWarning: func(warning SanitizeWarning) {
+			warnings = append(warnings, warning)
+		}}
+		actual, kind, err := p.sanitizeNumber("Sheet1", 2, 1, testCase.input)
+		if err != testCase.expectedErr {
+			t.Fatalf("%q: Expected error %v, got %v", testCase.input, testCase.expectedErr, err)
+		}
+		if err != nil {
+			continue
+		}
+		if actual != testCase.expected || kind != testCase.expectedKind {
+			t.Fatalf("%q: Expected %q of kind %d, got %q of kind %d", testCase.input, testCase.expected,
+				testCase.expectedKind, actual, kind)
+		}
+		if warned := len(warnings) == 1 && warnings[0].Reason == NonFiniteNumber; warned != testCase.warns {
+			t.Fatalf("%q: Expected a non-finite number warning to be %v, got %v", testCase.input, testCase.warns, warnings)
+		}
+	}
+}
+
+func TestSetNonFiniteNumberPolicy(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.SetNonFiniteNumberPolicy(NonFiniteNumberPolicy(9)); err != UnknownNonFiniteNumberPolicyError {
+		t.Fatalf("Expected %v, got %v", UnknownNonFiniteNumberPolicyError, err)
+	}
+	if err := file.SetNonFiniteNumberPolicy(ErrorForNonFiniteNumbers); err != nil {
+		t.Fatal(err)
+	}
+	if file.sanitizePolicy.NonFiniteNumbers != ErrorForNonFiniteNumbers {
+		t.Fatalf("Expected the policy to be set, got %v", file.sanitizePolicy.NonFiniteNumbers)
+	}
+	policy := DefaultSanitizePolicy()
+	policy.NonFiniteNumbers = NonFiniteNumberPolicy(-1)
+	if err := file.SetSanitizePolicy(policy); err != UnknownNonFiniteNumberPolicyError {
+		t.Fatalf("Expected %v, got %v", UnknownNonFiniteNumberPolicyError, err)
+	}
+}
@@ -0,0 +1,110 @@
+package excel_stream
+
+import (
+	"strings"
+)
+
+// Selection is the cell that is active and the range of cells that is selected when a sheet is opened.
+type Selection struct {
+	// Column and Row are the active cell. The column index starts at 0 and the Excel row number starts at 1.
+	Column int
+	Row    int
+	// LastColumn and LastRow are the corner of the selected range opposite the active cell. If both are 0, only the
+	// active cell is selected.
+	LastColumn int
+	LastRow    int
+}
+
+// SetSelection sets the active cell and the selected range of the named sheet, which must already have been added.
+// Sheets without a selection open with A1 selected, which is in the header row. Excel does not scroll to the
+// selection when the sheet is opened, so it should be near the top left of the sheet.
+func (sb *StreamFileBuilder) SetSelection(sheetName string, selection Selection) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	xml, err := selection.xml()
+	if err != nil {
+		return err
+	}
+	for i, sheet := range sb.xlsxFile.Sheets {
+		if sheet.Name == sheetName {
+			sb.selections[i] = xml
+			return nil
+		}
+	}
+	return UnknownSheetError
+}
+
+// xml returns the selection element for the selection, without the pane it is in.
+func (s *Selection) xml() (string, error) {
+	activeCell, err := cellReference(s.Column, s.Row)
+	if err != nil {
+		return "", err
+	}
+	sqref := activeCell
+	if s.LastColumn != 0 || s.LastRow != 0 {
+		if sqref, err = rangeReference(s.Column, s.Row, s.LastColumn, s.LastRow); err != nil {
+			return "", err
+		}
+	}
+	return `<selection activeCell="` + activeCell + `" sqref="` + sqref + `"/>`, nil
+}
+
+// replaceSelection replaces the selections in the sheet view at the start of a sheet's XML with the selection. The
+// selection is put in the sheet view's active pane, so that it is in the part of a frozen sheet that scrolls.
+func replaceSelection(prefix, selection string) string {
+	start := findElement(prefix, "sheetView")
+	if start == -1 {
+		return prefix
+	}
+	view := prefix[start:]
+	openEnd := strings.IndexByte(view, '>')
+	if openEnd == -1 {
+		return prefix
+	}
+	if view[openEnd-1] == '/' {
+		// The sheet view is empty, so it is opened up to hold the selection.
+		return prefix[:start] + view[:openEnd-1] + `>` + selection + `</sheetView>` + view[openEnd+1:]
+	}
+	end := strings.Index(view, `</sheetView>`)
+	if end == -1 {
+		return prefix
+	}
+	var content strings.Builder
+	children := view[openEnd+1 : end]
+	for {
+		selectionStart := findElement(children, "selection")
+		if selectionStart == -1 {
+			break
+		}
+		selectionEnd := strings.Index(children[selectionStart:], `/>`)
+		if selectionEnd == -1 {
+			return prefix
+		}
+		content.WriteString(children[:selectionStart])
+		children = children[selectionStart+selectionEnd+len(`/>`):]
+	}
+	content.WriteString(children)
+	if pane := activePane(content.String()); pane != "" {
+		selection = strings.Replace(selection, `<selection `, `<selection pane="`+pane+`" `, 1)
+	}
+	return prefix[:start] + view[:openEnd+1] + content.String() + selection + view[end:]
+}
+
+// activePane returns the activePane attribute of the pane element in the sheet view's children, or an empty string if
+// there is no pane.
+func activePane(children string) string {
+	paneStart := findElement(children, "pane")
+	if paneStart == -1 {
+		return ""
+	}
+	pane := children[paneStart:]
+	pane = pane[:strings.IndexByte(pane, '>')+1]
+	const attribute = ` activePane="`
+	valueStart := strings.Index(pane, attribute)
+	if valueStart == -1 {
+		return ""
+	}
+	value := pane[valueStart+len(attribute):]
+	return value[:strings.IndexByte(value, '"')]
+}
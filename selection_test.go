@@ -0,0 +1,90 @@
+package excel_stream
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSelectionXML(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		selection     Selection
+		expected      string
+		expectedError error
+	}{
+		{testName: "Cell", selection: Selection{Column: 0, Row: 2}, expected: `<selection activeCell="A2" sqref="A2"/>`},
+		{
+			testName:  "Range",
+			selection: Selection{Column: 1, Row: 2, LastColumn: 3, LastRow: 10},
+			expected:  `<selection activeCell="B2" sqref="B2:D10"/>`,
+		},
+		{testName: "No Row", selection: Selection{Column: 0}, expectedError: RowOutOfRangeError},
+		{testName: "Negative Column", selection: Selection{Column: -1, Row: 1}, expectedError: ColumnOutOfRangeError},
+		{
+			testName:      "Backwards",
+			selection:     Selection{Column: 3, Row: 5, LastColumn: 1, LastRow: 2},
+			expectedError: BackwardsRangeError,
+		},
+	}
+	for _, testCase := range testCases {
+		actual, err := testCase.selection.xml()
+		if err != testCase.expectedError || actual != testCase.expected {
+			t.Fatalf("%s: Expected %s, %v, got %s, %v", testCase.testName, testCase.expected, testCase.expectedError,
+				actual, err)
+		}
+	}
+}
+
+func TestReplaceSelection(t *testing.T) {
+	selection := `<selection activeCell="A2" sqref="A2"/>`
+	testCases := []struct {
+		testName string
+		prefix   string
+		expected string
+	}{
+		{
+			testName: "Default",
+			prefix: `<worksheet><sheetViews><sheetView workbookViewId="0"><selection pane="topLeft" activeCell="A1"` +
+				` activeCellId="0" sqref="A1"/></sheetView></sheetViews><sheetData>`,
+			expected: `<worksheet><sheetViews><sheetView workbookViewId="0"><selection activeCell="A2" sqref="A2"/>` +
+				`</sheetView></sheetViews><sheetData>`,
+		},
+		{
+			testName: "Frozen",
+			prefix: `<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft"` +
+				` state="frozen"/><selection pane="topLeft"/><selection pane="bottomLeft"/></sheetView></sheetViews>`,
+			expected: `<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft"` +
+				` state="frozen"/><selection pane="bottomLeft" activeCell="A2" sqref="A2"/></sheetView></sheetViews>`,
+		},
+		{
+			testName: "Empty View",
+			prefix:   `<sheetViews><sheetView workbookViewId="0"/></sheetViews>`,
+			expected: `<sheetViews><sheetView workbookViewId="0">` + selection + `</sheetView></sheetViews>`,
+		},
+		{testName: "No View", prefix: `<worksheet><sheetData>`, expected: `<worksheet><sheetData>`},
+	}
+	for _, testCase := range testCases {
+		if actual := replaceSelection(testCase.prefix, selection); actual != testCase.expected {
+			t.Fatalf("%s: Expected %s, got %s", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestSetSelection(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetSelection("Missing", Selection{Row: 2}); err != UnknownSheetError {
+		t.Fatalf("Expected %v, got %v", UnknownSheetError, err)
+	}
+	if err := file.SetSelection("Sheet1", Selection{}); err != RowOutOfRangeError {
+		t.Fatalf("Expected %v, got %v", RowOutOfRangeError, err)
+	}
+	if err := file.SetSelection("Sheet1", Selection{Row: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if file.selections[0] != `<selection activeCell="A2" sqref="A2"/>` {
+		t.Fatalf("Expected the selection to be kept, got %s", file.selections[0])
+	}
+}
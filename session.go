@@ -0,0 +1,240 @@
+package excel_stream
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// workbookFileMode is the permissions of the workbooks in tar archives.
+	workbookFileMode = 0644
+	// workbookSpoolPrefix starts the name of the temporary file that holds each workbook until it is finished.
+	workbookSpoolPrefix = "excel_stream_workbook"
+)
+
+var (
+	SessionClosedError         = errors.New("Session has already been closed, no more workbooks can be added")
+	WorkbookNotClosedError     = errors.New("The session's last workbook must be closed before another workbook is added or the session is closed")
+	WorkbookFinishedError      = errors.New("Workbook has already been finished by its session, nothing more can be written to it")
+	EmptyWorkbookNameError     = errors.New("Workbook name must not be empty")
+	DuplicateWorkbookNameError = errors.New("Session already has a workbook with the name")
+)
+
+// Session writes many independent workbooks, one after the other, into a single zip or tar archive, such as a nightly
+// export with a workbook for each customer. Each workbook is made with the StreamFileBuilder returned by AddWorkbook,
+// and must be closed before the next one is added. Workbooks are held in a temporary file until they are closed, and
+// only those whose StreamFile was closed without errors are copied into the archive, so a workbook that was aborted or
+// failed leaves nothing behind. A Session is not safe for concurrent use.
+type Session struct {
+	flushOutput func() error
+	zipWriter   *zip.Writer
+	tarWriter   *tar.Writer
+	// spool holds the workbook being written until it is finished, so that it can be left out if it fails, and since a
+	// tar header has to give the size of the file before it. The one temporary file in spoolDir is reused by every
+	// workbook.
+	spool    *os.File
+	spoolDir string
+	// deflater compresses the parts of every workbook, one part at a time, so that its buffers are only allocated once.
+	deflater *flate.Writer
+	// current is the workbook being written, and names are the names of all of the workbooks that were added.
+	current *sessionWorkbook
+	names   map[string]bool
+	closed  bool
+}
+
+// sessionWorkbook is the output of one of a Session's workbooks. The spool is only emptied for it when the workbook
+// writes to it.
+type sessionWorkbook struct {
+	session *Session
+	name    string
+	// file is set when the workbook's builder is built.
+	file   *StreamFile
+	writer io.Writer
+	// finished is set once the session has moved on to the next workbook or been closed.
+	finished bool
+}
+
+// NewZipSession creates a Session that writes a zip archive to the writer. The workbooks are stored in the archive
+// without being compressed again, since XLSX files are already compressed. Each workbook is held in a temporary file in
+// spoolDir until it is closed. If spoolDir is empty, the default directory for temporary files is used.
+func NewZipSession(writer io.Writer, spoolDir string) *Session {
+	return &Session{
+		flushOutput: writerFlusher(writer),
+		zipWriter:   zip.NewWriter(writer),
+		spoolDir:    spoolDir,
+		names:       make(map[string]bool),
+	}
+}
+
+// NewTarSession creates a Session that writes a tar archive to the writer. Each workbook is held in a temporary file in
+// spoolDir until it is closed. If spoolDir is empty, the default directory for temporary files is used.
+func NewTarSession(writer io.Writer, spoolDir string) *Session {
+	return &Session{
+		flushOutput: writerFlusher(writer),
+		tarWriter:   tar.NewWriter(writer),
+		spoolDir:    spoolDir,
+		names:       make(map[string]bool),
+	}
+}
+
+// AddWorkbook finishes the last workbook, whose StreamFile must have been closed, and returns a StreamFileBuilder for a
+// new workbook, which is stored in the archive under the name, such as "customers/1234.xlsx". It returns
+// WorkbookNotClosedError if the last workbook is still being written.
+func (s *Session) AddWorkbook(name string) (*StreamFileBuilder, error) {
+	if s.closed {
+		return nil, SessionClosedError
+	}
+	if name == "" {
+		return nil, EmptyWorkbookNameError
+	}
+	if s.names[name] {
+		return nil, fmt.Errorf("%w: %q", DuplicateWorkbookNameError, name)
+	}
+	if err := s.finishWorkbook(); err != nil {
+		return nil, err
+	}
+	s.names[name] = true
+	s.current = &sessionWorkbook{session: s, name: name}
+	sb := NewStreamFileBuilder(s.current)
+	sb.sessionWorkbook = s.current
+	sb.zipWriter.(*zip.Writer).RegisterCompressor(zip.Deflate, s.compressor)
+	return sb, nil
+}
+
+// Close finishes the last workbook, whose StreamFile must have been closed, and the archive. It does not close the
+// writer the Session was created with. If the last workbook is still being written, it is left out of the archive and
+// WorkbookNotClosedError is returned.
+func (s *Session) Close() error {
+	if s.closed {
+		return SessionClosedError
+	}
+	s.closed = true
+	errs := []error{s.finishWorkbook()}
+	if s.zipWriter != nil {
+		errs = append(errs, s.zipWriter.Close())
+	} else {
+		errs = append(errs, s.tarWriter.Close())
+	}
+	if s.spool != nil {
+		errs = append(errs, s.spool.Close(), os.Remove(s.spool.Name()))
+	}
+	if s.flushOutput != nil {
+		errs = append(errs, s.flushOutput())
+	}
+	return errors.Join(errs...)
+}
+
+// finishWorkbook ends the current workbook, and copies it into the archive from the spool if its StreamFile was closed
+// without errors.
+func (s *Session) finishWorkbook() error {
+	workbook := s.current
+	if workbook == nil {
+		return nil
+	}
+	if workbook.file != nil && !workbook.file.closed {
+		return WorkbookNotClosedError
+	}
+	workbook.finished = true
+	s.current = nil
+	// Workbooks that were never built, were aborted or failed are left out of the archive.
+	if workbook.writer == nil || workbook.file == nil || !workbook.file.complete {
+		return nil
+	}
+	size, err := s.spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	var archived io.Writer
+	if s.zipWriter != nil {
+		archived, err = s.zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     workbook.name,
+			Method:   zip.Store,
+			Modified: time.Now(),
+		})
+	} else {
+		archived, err = s.tarWriter, s.tarWriter.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     workbook.name,
+			Mode:     workbookFileMode,
+			Size:     size,
+			ModTime:  time.Now(),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := s.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(archived, s.spool, size); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// startWorkbook empties the spool for the current workbook, creating it for the first one, and returns it.
+func (s *Session) startWorkbook() (io.Writer, error) {
+	if s.spool == nil {
+		spool, err := os.CreateTemp(s.spoolDir, workbookSpoolPrefix)
+		if err != nil {
+			return nil, err
+		}
+		s.spool = spool
+		return spool, nil
+	}
+	if err := s.spool.Truncate(0); err != nil {
+		return nil, err
+	}
+	if _, err := s.spool.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.spool, nil
+}
+
+// compressor is registered with the zip writer of every workbook, and hands each of its compressed parts the
+// Session's deflater. Parts are written one after the other, so the deflater is never used by two parts at once.
+func (s *Session) compressor(writer io.Writer) (io.WriteCloser, error) {
+	if s.deflater == nil {
+		var err error
+		if s.deflater, err = flate.NewWriter(writer, flate.DefaultCompression); err != nil {
+			return nil, err
+		}
+		return s.deflater, nil
+	}
+	s.deflater.Reset(writer)
+	return s.deflater, nil
+}
+
+// flush sends everything written to the archive so far on to the Session's writer.
+func (s *Session) flush() error {
+	var err error
+	if s.zipWriter != nil {
+		err = s.zipWriter.Flush()
+	} else {
+		err = s.tarWriter.Flush()
+	}
+	if err != nil || s.flushOutput == nil {
+		return err
+	}
+	return s.flushOutput()
+}
+
+func (w *sessionWorkbook) Write(p []byte) (int, error) {
+	if w.finished {
+		return 0, WorkbookFinishedError
+	}
+	if w.writer == nil {
+		writer, err := w.session.startWorkbook()
+		if err != nil {
+			return 0, err
+		}
+		w.writer = writer
+	}
+	return w.writer.Write(p)
+}
@@ -0,0 +1,160 @@
+package excel_stream
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// writeSessionWorkbooks writes a workbook for each customer to the session and closes it.
+func writeSessionWorkbooks(t *testing.T, session *Session, customers []string) {
+	for _, customer := range customers {
+		file, err := session.AddWorkbook(customer + ".xlsx")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := file.AddSheet("Orders", []string{"Customer"}); err != nil {
+			t.Fatal(err)
+		}
+		excelStream, err := file.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := excelStream.WriteRow([]string{customer}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := session.AddWorkbook("Other.xlsx"); err != WorkbookNotClosedError {
+			t.Fatalf("Expected %v, got %v", WorkbookNotClosedError, err)
+		}
+		if err := excelStream.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Workbooks that are aborted, and builders that are never built, leave nothing in the archive.
+	file, err := session.AddWorkbook("Aborted.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Orders", []string{"Customer"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Aborted"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.AddWorkbook("Unused.xlsx"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.AddWorkbook(customers[0] + ".xlsx"); !errors.Is(err, DuplicateWorkbookNameError) {
+		t.Fatalf("Expected %v, got %v", DuplicateWorkbookNameError, err)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.AddWorkbook("Late.xlsx"); err != SessionClosedError {
+		t.Fatalf("Expected %v, got %v", SessionClosedError, err)
+	}
+}
+
+// checkSessionWorkbook checks that the workbook is a complete XLSX file with the customer's row.
+func checkSessionWorkbook(t *testing.T, data []byte, customer string) {
+	reader := bytes.NewReader(data)
+	_, workbookData := readXLSXFile(t, "", reader, reader.Size(), false)
+	expectedData := [][][]string{{{"Customer"}, {customer}}}
+	if !reflect.DeepEqual(workbookData, expectedData) {
+		t.Fatalf("Expected workbook data %v, got %v", expectedData, workbookData)
+	}
+}
+
+func TestZipSession(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	customers := []string{"Taco", "Burrito"}
+	spoolDir := t.TempDir()
+	writeSessionWorkbooks(t, NewZipSession(buffer, spoolDir), customers)
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.File) != len(customers) {
+		t.Fatalf("Expected %d workbooks, got %d", len(customers), len(archive.File))
+	}
+	for i, file := range archive.File {
+		if file.Name != customers[i]+".xlsx" || file.Method != zip.Store {
+			t.Fatalf("Expected %s.xlsx to be stored, got %s with method %d", customers[i], file.Name, file.Method)
+		}
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSessionWorkbook(t, data, customers[i])
+	}
+	if files, err := ioutil.ReadDir(spoolDir); err != nil || len(files) != 0 {
+		t.Fatalf("Expected the spool to be removed, got %v, %v", files, err)
+	}
+}
+
+func TestTarSession(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	customers := []string{"Taco", "Burrito"}
+	spoolDir := t.TempDir()
+	writeSessionWorkbooks(t, NewTarSession(buffer, spoolDir), customers)
+	archive := tar.NewReader(buffer)
+	for _, customer := range customers {
+		header, err := archive.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Name != customer+".xlsx" {
+			t.Fatalf("Expected %s.xlsx, got %s", customer, header.Name)
+		}
+		data, err := ioutil.ReadAll(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSessionWorkbook(t, data, customer)
+	}
+	if _, err := archive.Next(); err != io.EOF {
+		t.Fatalf("Expected only %d workbooks, got %v", len(customers), err)
+	}
+	if files, err := ioutil.ReadDir(spoolDir); err != nil || len(files) != 0 {
+		t.Fatalf("Expected the spool to be removed, got %v, %v", files, err)
+	}
+}
+
+func TestSessionWorkbookFinished(t *testing.T) {
+	session := NewZipSession(bytes.NewBuffer(nil), t.TempDir())
+	if _, err := session.AddWorkbook(""); err != EmptyWorkbookNameError {
+		t.Fatalf("Expected %v, got %v", EmptyWorkbookNameError, err)
+	}
+	if _, err := session.AddWorkbook("Taco.xlsx"); err != nil {
+		t.Fatal(err)
+	}
+	workbook := session.current
+	if _, err := session.AddWorkbook("Burrito.xlsx"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := workbook.Write([]byte("Taco")); err != WorkbookFinishedError {
+		t.Fatalf("Expected %v, got %v", WorkbookFinishedError, err)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Close(); err != SessionClosedError {
+		t.Fatalf("Expected %v, got %v", SessionClosedError, err)
+	}
+}
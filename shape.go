@@ -0,0 +1,157 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ShapeType is the kind of shape drawn by AddShape.
+type ShapeType int
+
+const (
+	// TextBoxShape is a rectangle that is marked as a text box, which Excel lets users type into directly.
+	TextBoxShape ShapeType = iota
+	RectangleShape
+	RoundedRectangleShape
+	EllipseShape
+)
+
+const (
+	// defaultShapeWidth and defaultShapeHeight are the size in pixels of shapes whose anchor has no size.
+	defaultShapeWidth  = 240
+	defaultShapeHeight = 60
+	// defaultShapeFontSize is the size in points of the text in shapes that do not set one.
+	defaultShapeFontSize = 11
+)
+
+var (
+	UnknownShapeTypeError = errors.New("Unknown shape type")
+	InvalidColorError     = errors.New("Colors must be six hexadecimal digits, such as FF0000 for red")
+	InvalidFontSizeError  = errors.New("Font size must be between 1 and 400 points")
+)
+
+// Shape describes a shape with text in it, like a "Generated by X on date" callout.
+type Shape struct {
+	Type ShapeType
+	// Text is shown inside of the shape. Each line of the text is a separate paragraph.
+	Text string
+	// FillColor and LineColor are six digit hexadecimal RGB colors, such as FFFF00 for yellow. If either is empty, the
+	// shape has no fill or no outline.
+	FillColor string
+	LineColor string
+	// FontSize is the size of the text in points. If it is 0, the text is 11 points.
+	FontSize int
+	Bold     bool
+}
+
+// AddShape draws a shape over the named sheet. The sheet must be the current sheet or one that has not been started
+// yet. If the anchor has no size, the shape is 240 by 60 pixels.
+func (sf *StreamFile) AddShape(sheetName string, anchor CellAnchor, shape Shape) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	if err := anchor.validate(); err != nil {
+		return err
+	}
+	if anchor.Width == 0 {
+		anchor.Width = defaultShapeWidth
+		anchor.Height = defaultShapeHeight
+	}
+	if err := shape.validate(); err != nil {
+		return err
+	}
+	drawing := sf.drawing(sheetArrayIndex)
+	objectID := strconv.Itoa(drawing.nextObjectID())
+	drawing.anchors = append(drawing.anchors, `<xdr:oneCellAnchor>`+anchor.fromXML()+anchor.extentXML()+
+		shape.xml(objectID, anchor)+`<xdr:clientData/></xdr:oneCellAnchor>`)
+	return nil
+}
+
+// validate checks the shape's settings.
+func (s *Shape) validate() error {
+	if s.Type < TextBoxShape || s.Type > EllipseShape {
+		return UnknownShapeTypeError
+	}
+	for _, color := range []string{s.FillColor, s.LineColor} {
+		if color != "" && !isHexColor(color) {
+			return InvalidColorError
+		}
+	}
+	if s.FontSize < 0 || s.FontSize > 400 {
+		return InvalidFontSizeError
+	}
+	return nil
+}
+
+// xml returns the XML for the shape, with the object ID it has in the drawing.
+func (s *Shape) xml(objectID string, anchor CellAnchor) string {
+	name := "Rectangle "
+	geometry := "rect"
+	textBox := ""
+	switch s.Type {
+	case TextBoxShape:
+		name = "TextBox "
+		textBox = ` txBox="1"`
+	case RoundedRectangleShape:
+		geometry = "roundRect"
+	case EllipseShape:
+		name = "Oval "
+		geometry = "ellipse"
+	}
+	fill := `<a:noFill/>`
+	if s.FillColor != "" {
+		fill = `<a:solidFill><a:srgbClr val="` + strings.ToUpper(s.FillColor) + `"/></a:solidFill>`
+	}
+	line := `<a:ln><a:noFill/></a:ln>`
+	if s.LineColor != "" {
+		line = `<a:ln w="9525"><a:solidFill><a:srgbClr val="` + strings.ToUpper(s.LineColor) + `"/></a:solidFill></a:ln>`
+	}
+	fontSize := s.FontSize
+	if fontSize == 0 {
+		fontSize = defaultShapeFontSize
+	}
+	// Font sizes in drawings are in hundredths of a point.
+	runProperties := `<a:rPr lang="en-US" sz="` + strconv.Itoa(fontSize*100) + `"`
+	if s.Bold {
+		runProperties += ` b="1"`
+	}
+	runProperties += `/>`
+
+	var builder strings.Builder
+	builder.WriteString(`<xdr:sp macro="" textlink=""><xdr:nvSpPr><xdr:cNvPr id="` + objectID + `" name="` + name +
+		objectID + `"/><xdr:cNvSpPr` + textBox + `/></xdr:nvSpPr><xdr:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="` +
+		strconv.Itoa(anchor.Width*emusPerPixel) + `" cy="` + strconv.Itoa(anchor.Height*emusPerPixel) + `"/></a:xfrm>` +
+		`<a:prstGeom prst="` + geometry + `"><a:avLst/></a:prstGeom>` + fill + line + `</xdr:spPr>` +
+		`<xdr:txBody><a:bodyPr wrap="square" rtlCol="0" anchor="t"/><a:lstStyle/>`)
+	for _, paragraph := range strings.Split(s.Text, "\n") {
+		builder.WriteString(`<a:p>`)
+		if paragraph != "" {
+			builder.WriteString(`<a:r>` + runProperties + `<a:t>` + escapeXML(paragraph) + `</a:t></a:r>`)
+		}
+		builder.WriteString(`</a:p>`)
+	}
+	builder.WriteString(`</xdr:txBody></xdr:sp>`)
+	return builder.String()
+}
+
+// isHexColor reports whether the string is a six digit hexadecimal RGB color.
+func isHexColor(color string) bool {
+	if len(color) != 6 {
+		return false
+	}
+	for i := 0; i < len(color); i++ {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", rune(color[i])) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestShapeValidate(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		shape         Shape
+		expectedError error
+	}{
+		{testName: "Valid", shape: Shape{Type: EllipseShape, FillColor: "ffcc00", LineColor: "000000", FontSize: 12}},
+		{testName: "Unknown Type", shape: Shape{Type: 10}, expectedError: UnknownShapeTypeError},
+		{testName: "Short Color", shape: Shape{FillColor: "FFF"}, expectedError: InvalidColorError},
+		{testName: "Not Hex", shape: Shape{LineColor: "GGGGGG"}, expectedError: InvalidColorError},
+		{testName: "Font Size", shape: Shape{FontSize: 401}, expectedError: InvalidFontSizeError},
+	}
+	for _, testCase := range testCases {
+		if err := testCase.shape.validate(); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestShapeXML(t *testing.T) {
+	shape := Shape{Type: TextBoxShape, Text: "Generated by <jobs>\n\non 2017-06-01", FillColor: "ffffcc", Bold: true}
+	shapeXML := shape.xml("3", CellAnchor{Width: 10, Height: 20})
+	expectedParts := []string{
+		`<xdr:cNvPr id="3" name="TextBox 3"/><xdr:cNvSpPr txBox="1"/>`,
+		`<a:ext cx="95250" cy="190500"/>`,
+		`<a:solidFill><a:srgbClr val="FFFFCC"/></a:solidFill><a:ln><a:noFill/></a:ln>`,
+		`<a:p><a:r><a:rPr lang="en-US" sz="1100" b="1"/><a:t>Generated by &lt;jobs&gt;</a:t></a:r></a:p><a:p></a:p><a:p>`,
+	}
+	for _, expectedPart := range expectedParts {
+		if !strings.Contains(shapeXML, expectedPart) {
+			t.Fatalf("Expected %s in %s", expectedPart, shapeXML)
+		}
+	}
+}
+
+func TestAddShape(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.AddShape("Sheet1", CellAnchor{Column: 3, Row: 1}, Shape{Type: RectangleShape, Text: "Note"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	drawingXML := readZipPart(t, buffer.Bytes(), "xl/drawings/drawing1.xml")
+	if !strings.Contains(drawingXML, `<a:prstGeom prst="rect">`) || !strings.Contains(drawingXML, `<xdr:ext cx="2286000" cy="571500"/>`) {
+		t.Fatalf("Expected a rectangle with the default size: %s", drawingXML)
+	}
+}
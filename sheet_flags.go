@@ -0,0 +1,48 @@
+package excel_stream
+
+import (
+	"errors"
+
+	"github.com/tealeg/xlsx"
+)
+
+// SheetFlag turns on a common sheet behavior when passed to AddSheet. Flags can be combined with |.
+type SheetFlag int
+
+const (
+	// FreezeHeader keeps the header row in view while scrolling.
+	FreezeHeader SheetFlag = 1 << iota
+	// AutoFilter adds filter buttons to the header, covering every row that is written.
+	AutoFilter
+)
+
+var UnknownSheetFlagError = errors.New("Unknown sheet flag")
+
+// combineSheetFlags returns all of the flags combined into one.
+func combineSheetFlags(flags []SheetFlag) (SheetFlag, error) {
+	var combined SheetFlag
+	for _, flag := range flags {
+		combined |= flag
+	}
+	if combined&^(FreezeHeader|AutoFilter) != 0 {
+		return 0, UnknownSheetFlagError
+	}
+	return combined, nil
+}
+
+// applySheetFlags sets up the sheet that was just added for the flags.
+func (sb *StreamFileBuilder) applySheetFlags(flags SheetFlag) {
+	sheetArrayIndex := len(sb.xlsxFile.Sheets) - 1
+	if flags&FreezeHeader != 0 {
+		freezeHeader(sb.xlsxFile.Sheets[sheetArrayIndex])
+	}
+	sb.autoFilters[sheetArrayIndex] = flags&AutoFilter != 0
+}
+
+// freezeHeader keeps the first row of the sheet in view while scrolling. Build moves the split down to the header if
+// the sheet has a preamble.
+func freezeHeader(sheet *xlsx.Sheet) {
+	sheet.SheetViews = []xlsx.SheetView{
+		{Pane: &xlsx.Pane{YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft", State: "frozen"}},
+	}
+}
@@ -0,0 +1,77 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCombineSheetFlags(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		flags         []SheetFlag
+		expected      SheetFlag
+		expectedError error
+	}{
+		{testName: "None"},
+		{testName: "One", flags: []SheetFlag{AutoFilter}, expected: AutoFilter},
+		{testName: "Both", flags: []SheetFlag{FreezeHeader | AutoFilter}, expected: FreezeHeader | AutoFilter},
+		{testName: "Separate", flags: []SheetFlag{FreezeHeader, AutoFilter}, expected: FreezeHeader | AutoFilter},
+		{testName: "Unknown", flags: []SheetFlag{AutoFilter << 1}, expectedError: UnknownSheetFlagError},
+	}
+	for _, testCase := range testCases {
+		actual, err := combineSheetFlags(testCase.flags)
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if actual != testCase.expected {
+			t.Fatalf("%s: Expected %d, got %d", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestRenderAutoFilter(t *testing.T) {
+	suffix := `<pageMargins/></worksheet>`
+	expected := `<autoFilter ref="A1:B10"/>` + suffix
+	if actual := renderReportElements(suffix, &sheetExtras{autoFilter: true}, 2, 1, 10); actual != expected {
+		t.Fatalf("Expected only a filter, got %s", actual)
+	}
+}
+
+func TestAddSheetWithFlags(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name", "Price"}, FreezeHeader|AutoFilter); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetPreamble("Sheet1", []PreambleRow{{Text: "Prices"}}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"Taco", "1.50"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{`ySplit="2"`, `topLeftCell="A3"`, `<autoFilter ref="A2:B3"/>`} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+	if strings.Contains(sheetXML, `<conditionalFormatting`) {
+		t.Fatalf("Expected no shading: %s", sheetXML)
+	}
+
+	file = NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Sheet1", []string{"Name"}, 8); err != UnknownSheetFlagError {
+		t.Fatalf("Expected %v, got %v", UnknownSheetFlagError, err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Name"}); err != BuiltExcelStreamBuilderError {
+		t.Fatalf("Expected %v, got %v", BuiltExcelStreamBuilderError, err)
+	}
+}
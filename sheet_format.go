@@ -0,0 +1,59 @@
+package excel_stream
+
+import (
+	"math"
+	"strings"
+)
+
+// SetDefaultSize sets the width of the named sheet's columns and the height of its rows, for the columns and rows that
+// do not set their own. The width is in characters and the height is in points, and 0 keeps Excel's default. Columns
+// added with a Width in their ColumnDef and rows written with a Height in their RowOptions keep their own sizes. This
+// lets dense sheets be made compact without giving every column a width.
+func (sb *StreamFileBuilder) SetDefaultSize(sheetName string, columnWidth, rowHeight float64) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if columnWidth < 0 || columnWidth > maxColumnWidth || math.IsNaN(columnWidth) {
+		return InvalidColumnWidthError
+	}
+	if rowHeight < 0 || rowHeight > maxRowHeight || math.IsNaN(rowHeight) {
+		return InvalidRowHeightError
+	}
+	for _, sheet := range sb.xlsxFile.Sheets {
+		if sheet.Name == sheetName {
+			sheet.SheetFormat.DefaultColWidth = columnWidth
+			sheet.SheetFormat.DefaultRowHeight = rowHeight
+			return nil
+		}
+	}
+	return UnknownSheetError
+}
+
+// applyDefaultColumnWidths gives the columns of each sheet that do not have a width the sheet's default width. The
+// XLSX library writes a width for every column of the header, which would hide the default.
+func (sb *StreamFileBuilder) applyDefaultColumnWidths() {
+	for _, sheet := range sb.xlsxFile.Sheets {
+		if sheet.SheetFormat.DefaultColWidth == 0 {
+			continue
+		}
+		for _, col := range sheet.Cols {
+			if col.Width == 0 {
+				col.Width = sheet.SheetFormat.DefaultColWidth
+			}
+		}
+	}
+}
+
+// setCustomRowHeight marks the default row height in the start of a sheet's XML as one that was chosen, since Excel
+// ignores it otherwise and works out the height from the default font.
+func setCustomRowHeight(prefix string) string {
+	start := findElement(prefix, "sheetFormatPr")
+	if start == -1 {
+		return prefix
+	}
+	tagEnd := start + len(`<sheetFormatPr`)
+	if strings.Contains(prefix[start:start+strings.IndexByte(prefix[start:], '>')], ` customHeight=`) {
+		return prefix
+	}
+	return prefix[:tagEnd] + ` customHeight="1"` + prefix[tagEnd:]
+}
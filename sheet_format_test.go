@@ -0,0 +1,66 @@
+package excel_stream
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestSetDefaultSize(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	err := file.AddSheetWithColumns("Sheet1", []ColumnDef{{Name: "Name", Width: 30}, {Name: "Code"}, {Name: "Note"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		testName      string
+		sheetName     string
+		columnWidth   float64
+		rowHeight     float64
+		expectedError error
+	}{
+		{testName: "Wide", sheetName: "Sheet1", columnWidth: 256, expectedError: InvalidColumnWidthError},
+		{testName: "NaN Width", sheetName: "Sheet1", columnWidth: math.NaN(), expectedError: InvalidColumnWidthError},
+		{testName: "Tall", sheetName: "Sheet1", rowHeight: 410, expectedError: InvalidRowHeightError},
+		{testName: "Negative Height", sheetName: "Sheet1", rowHeight: -1, expectedError: InvalidRowHeightError},
+		{testName: "Missing Sheet", sheetName: "Missing", columnWidth: 6, expectedError: UnknownSheetError},
+		{testName: "Compact", sheetName: "Sheet1", columnWidth: 6, rowHeight: 12},
+	}
+	for _, testCase := range testCases {
+		err := file.SetDefaultSize(testCase.sheetName, testCase.columnWidth, testCase.rowHeight)
+		if err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+	file.applyDefaultColumnWidths()
+	sheet := file.xlsxFile.Sheets[0]
+	if sheet.Cols[0].Width != 30 || sheet.Cols[1].Width != 6 || sheet.Cols[2].Width != 6 {
+		t.Fatalf("Expected columns without a width to get the default, got %v, %v, %v", sheet.Cols[0].Width,
+			sheet.Cols[1].Width, sheet.Cols[2].Width)
+	}
+	if sheet.SheetFormat.DefaultRowHeight != 12 {
+		t.Fatalf("Expected the default row height to be 12, got %v", sheet.SheetFormat.DefaultRowHeight)
+	}
+}
+
+func TestSetCustomRowHeight(t *testing.T) {
+	testCases := []struct {
+		prefix   string
+		expected string
+	}{
+		{
+			prefix:   `<sheetViews/><sheetFormatPr defaultRowHeight="12" defaultColWidth="6"/><cols>`,
+			expected: `<sheetViews/><sheetFormatPr customHeight="1" defaultRowHeight="12" defaultColWidth="6"/><cols>`,
+		},
+		{
+			prefix:   `<sheetFormatPr customHeight="1" defaultRowHeight="12"/>`,
+			expected: `<sheetFormatPr customHeight="1" defaultRowHeight="12"/>`,
+		},
+		{prefix: `<sheetData>`, expected: `<sheetData>`},
+	}
+	for _, testCase := range testCases {
+		if actual := setCustomRowHeight(testCase.prefix); actual != testCase.expected {
+			t.Fatalf("Expected %s, got %s", testCase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// SheetNamePolicy controls what AddSheet does with a sheet name that Excel would not accept.
+type SheetNamePolicy int
+
+const (
+	// RejectInvalidSheetNames will make AddSheet return an error for any name that breaks Excel's rules. This is the
+	// default policy.
+	RejectInvalidSheetNames SheetNamePolicy = iota
+	// NormalizeInvalidSheetNames will make AddSheet rewrite invalid names into valid ones. Invalid characters are
+	// replaced with underscores, leading and trailing apostrophes are removed, long names are truncated and blank names
+	// are replaced with "Sheet" followed by the sheet's index.
+	NormalizeInvalidSheetNames
+)
+
+// maxSheetNameLength is the longest sheet name Excel will accept, counted in UTF-16 code units like Excel does.
+const maxSheetNameLength = 31
+
+// invalidSheetNameCharacters are the characters that Excel does not allow anywhere in a sheet name.
+const invalidSheetNameCharacters = `[]/\?*:`
+
+var (
+	BlankSheetNameError            = errors.New("Sheet names cannot be blank.")
+	SheetNameTooLongError          = errors.New("Sheet names cannot be longer than 31 characters.")
+	InvalidSheetNameCharacterError = errors.New(`Sheet names cannot contain any of the characters []/\?*:`)
+	SheetNameApostropheError       = errors.New("Sheet names cannot begin or end with an apostrophe.")
+	UnknownSheetNamePolicyError    = errors.New("Unknown sheet name policy")
+	DuplicateSheetNameError        = errors.New("Sheet names must be unique. Excel does not consider case when comparing sheet names.")
+)
+
+// validateSheetName returns an error describing the first of Excel's sheet name rules that the name breaks, or nil if
+// the name is valid.
+func validateSheetName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return BlankSheetNameError
+	}
+	if excelLength(name) > maxSheetNameLength {
+		return SheetNameTooLongError
+	}
+	if strings.ContainsAny(name, invalidSheetNameCharacters) {
+		return InvalidSheetNameCharacterError
+	}
+	if strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'") {
+		return SheetNameApostropheError
+	}
+	return nil
+}
+
+// normalizeSheetName rewrites the name so that it passes validateSheetName. sheetIndex is the Excel sheet index, which
+// starts at 1, and is used to name sheets whose name is blank.
+func normalizeSheetName(name string, sheetIndex int) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidSheetNameCharacters, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, "'")
+	name = truncateToExcelLength(name, maxSheetNameLength)
+	// Truncating can expose a new trailing apostrophe.
+	name = strings.TrimRight(name, "'")
+	if strings.TrimSpace(name) == "" {
+		name = "Sheet" + strconv.Itoa(sheetIndex)
+	}
+	return name
+}
+
+// sheetNameExists reports whether the name is already used by one of the sheets. Excel compares sheet names without
+// considering case, so "Data" and "DATA" are the same sheet.
+func sheetNameExists(name string, sheets []*xlsx.Sheet) bool {
+	for _, sheet := range sheets {
+		if strings.EqualFold(sheet.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// deduplicateSheetName returns the name unchanged if no sheet is using it yet. Otherwise it returns the name with the
+// first free numbered suffix, such as "Data (2)", shortening the name if needed to make room for the suffix.
+func deduplicateSheetName(name string, sheets []*xlsx.Sheet) string {
+	if !sheetNameExists(name, sheets) {
+		return name
+	}
+	for i := 2; ; i++ {
+		suffix := " (" + strconv.Itoa(i) + ")"
+		candidate := truncateToExcelLength(name, maxSheetNameLength-len(suffix)) + suffix
+		if !sheetNameExists(candidate, sheets) {
+			return candidate
+		}
+	}
+}
+
+// excelLength returns the length of the string as Excel counts it, which is the number of UTF-16 code units.
+func excelLength(s string) int {
+	length := 0
+	for _, r := range s {
+		length += utf16RuneLen(r)
+	}
+	return length
+}
+
+// truncateToExcelLength shortens the string so that its excelLength is no more than max, without splitting a
+// character.
+func truncateToExcelLength(s string, max int) string {
+	length := 0
+	for i, r := range s {
+		length += utf16RuneLen(r)
+		if length > max {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// utf16RuneLen returns the number of UTF-16 code units needed to encode the rune.
+func utf16RuneLen(r rune) int {
+	// Runes outside of the Basic Multilingual Plane are encoded as a surrogate pair.
+	if r > 0xFFFF {
+		return 2
+	}
+	return 1
+}
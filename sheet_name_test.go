@@ -0,0 +1,161 @@
+package excel_stream
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestValidateSheetName(t *testing.T) {
+	testCases := []struct {
+		name          string
+		expectedError error
+	}{
+		{name: "Sheet1"},
+		{name: "Sales (2017) - Q1 & Q2"},
+		{name: strings.Repeat("a", 31)},
+		{name: "パーティーへ行かないか"},
+		{name: "It's fine"},
+		{name: "", expectedError: BlankSheetNameError},
+		{name: "   ", expectedError: BlankSheetNameError},
+		{name: strings.Repeat("a", 32), expectedError: SheetNameTooLongError},
+		// Emoji are two UTF-16 code units each, so 16 of them are too long for Excel.
+		{name: strings.Repeat("🍕", 16), expectedError: SheetNameTooLongError},
+		{name: "Q1/Q2", expectedError: InvalidSheetNameCharacterError},
+		{name: `C:\\data`, expectedError: InvalidSheetNameCharacterError},
+		{name: "[Draft]", expectedError: InvalidSheetNameCharacterError},
+		{name: "Why?", expectedError: InvalidSheetNameCharacterError},
+		{name: "*", expectedError: InvalidSheetNameCharacterError},
+		{name: "'Quoted", expectedError: SheetNameApostropheError},
+		{name: "Quoted'", expectedError: SheetNameApostropheError},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := validateSheetName(testCase.name); err != testCase.expectedError {
+				t.Fatalf("Error differs from expected error. Error: %v, Expected Error: %v ", err, testCase.expectedError)
+			}
+		})
+	}
+}
+
+func TestNormalizeSheetName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Sheet1", expected: "Sheet1"},
+		{name: "Q1/Q2 [Draft]", expected: "Q1_Q2 _Draft_"},
+		{name: "'Quoted'", expected: "Quoted"},
+		{name: "", expected: "Sheet3"},
+		{name: "''", expected: "Sheet3"},
+		{name: strings.Repeat("a", 40), expected: strings.Repeat("a", 31)},
+		{name: strings.Repeat("a", 30) + "'b", expected: strings.Repeat("a", 30)},
+		{name: strings.Repeat("🍕", 16), expected: strings.Repeat("🍕", 15)},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			normalized := normalizeSheetName(testCase.name, 3)
+			if normalized != testCase.expected {
+				t.Fatalf("Expected %q, got %q", testCase.expected, normalized)
+			}
+			if err := validateSheetName(normalized); err != nil {
+				t.Fatalf("Normalized name %q is not valid: %v", normalized, err)
+			}
+		})
+	}
+}
+
+func TestAddSheetInvalidName(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Q1/Q2", []string{"Token"}); err != InvalidSheetNameCharacterError {
+		t.Fatalf("Expected InvalidSheetNameCharacterError, got %v", err)
+	}
+	if _, err := file.Build(); err != BuiltExcelStreamBuilderError {
+		t.Fatalf("Expected BuiltExcelStreamBuilderError, got %v", err)
+	}
+}
+
+func TestAddSheetNormalizedName(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetSheetNamePolicy(NormalizeInvalidSheetNames); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("'Q1/Q2 Report: Sales and Returns by Region'", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	bufReader := bytes.NewReader(buffer.Bytes())
+	sheetNames, _ := readXLSXFile(t, "", bufReader, bufReader.Size(), false)
+	expectedName := "Q1_Q2 Report_ Sales and Returns"
+	if len(sheetNames) != 1 || sheetNames[0] != expectedName {
+		t.Fatalf("Expected sheet names [%s], got %v", expectedName, sheetNames)
+	}
+}
+
+func TestDeduplicateSheetName(t *testing.T) {
+	sheets := []*xlsx.Sheet{{Name: "Data"}, {Name: "data (2)"}, {Name: strings.Repeat("b", 31)}}
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Other", expected: "Other"},
+		{name: "Data", expected: "Data (3)"},
+		{name: "DATA", expected: "DATA (3)"},
+		{name: strings.Repeat("b", 31), expected: strings.Repeat("b", 27) + " (2)"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			deduplicated := deduplicateSheetName(testCase.name, sheets)
+			if deduplicated != testCase.expected {
+				t.Fatalf("Expected %q, got %q", testCase.expected, deduplicated)
+			}
+		})
+	}
+}
+
+func TestAddSheetDuplicateNames(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Data", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("DATA", []string{"Token"}); err != DuplicateSheetNameError {
+		t.Fatalf("Expected DuplicateSheetNameError, got %v", err)
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	file = NewStreamFileBuilder(buffer)
+	if err := file.SetDeduplicateSheetNames(true); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Data", "Data", "data", "Other"} {
+		if err := file.AddSheet(name, []string{"Token"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	bufReader := bytes.NewReader(buffer.Bytes())
+	sheetNames, _ := readXLSXFile(t, "", bufReader, bufReader.Size(), false)
+	expectedNames := []string{"Data", "Data (2)", "data (3)", "Other"}
+	if !reflect.DeepEqual(sheetNames, expectedNames) {
+		t.Fatalf("Expected sheet names %v, got %v", expectedNames, sheetNames)
+	}
+}
@@ -0,0 +1,70 @@
+package excel_stream
+
+import "strconv"
+
+// The sizes of the zip records around each part, without the part's name. Each part starts with a local file header
+// and ends with a data descriptor, and has a header in the central directory at the end of the zip, which is closed by
+// the end of central directory record.
+const (
+	zipLocalHeaderSize     = 30
+	zipDataDescriptorSize  = 16
+	zipDirectoryHeaderSize = 46
+	zipEndOfDirectorySize  = 22
+)
+
+// EstimatedSize returns an estimate of the size in bytes of the finished file, so that the total can be shown in a
+// progress bar or space reserved for an upload while rows are still being written. It is the number of bytes written
+// so far, plus the rows of the current sheet that are still being spooled, the start and end of every sheet that is
+// not finished, the parts written when the StreamFile is closed and the zip's directory. Those parts are counted before
+// they are compressed, and parts that are only made when the StreamFile is closed, such as charts, are not counted, so
+// the estimate is rough until then. It grows as rows are written, and is the size of the file once it has been closed.
+// Working it out renders the styles, so it should be called every so often rather than after every row. Once writing
+// to the output has failed, there is no final size to estimate, and the error from the output is returned.
+func (sf *StreamFile) EstimatedSize() (int64, error) {
+	if err := sf.acquire(); err != nil {
+		return 0, err
+	}
+	defer sf.release()
+	if err := sf.outputError(); err != nil {
+		return 0, err
+	}
+	if sf.closed {
+		return sf.output.count, nil
+	}
+	estimate := sf.output.count + sf.directorySize + zipEndOfDirectorySize
+	nextSheet := 0
+	if sf.currentSheet != nil {
+		sheetArrayIndex := sf.currentSheet.index - 1
+		nextSheet = sf.currentSheet.index
+		if sf.currentSheet.spooled != nil {
+			estimate += estimatedPartSize(sheetFilePathPrefix+strconv.Itoa(sf.currentSheet.index)+sheetFilePathSuffix,
+				len(sf.sheetXmlPrefix[sheetArrayIndex])) + sf.currentSheet.spooled.count
+		}
+		if !sf.currentSheet.finalized {
+			estimate += int64(len(sf.sheetXmlSuffix[sheetArrayIndex]))
+		}
+	}
+	for i := nextSheet; i < len(sf.sheetXmlPrefix); i++ {
+		sheetPath := sheetFilePathPrefix + strconv.Itoa(i+1) + sheetFilePathSuffix
+		estimate += estimatedPartSize(sheetPath, len(sf.sheetXmlPrefix[i])+len(sf.sheetXmlSuffix[i]))
+	}
+	for _, part := range sf.parts {
+		estimate += estimatedPartSize(part.name, len(part.data))
+	}
+	if sf.workbookXML != "" {
+		estimate += estimatedPartSize(workbookPath, len(sf.workbookXML))
+	}
+	if styles, err := sf.styles.render(); err == nil {
+		estimate += estimatedPartSize(stylesPath, len(styles))
+	}
+	if data, err := sf.contentTypes.render(); err == nil {
+		estimate += estimatedPartSize(contentTypesPath, len(data))
+	}
+	return estimate, nil
+}
+
+// estimatedPartSize returns the number of bytes a part that has not been created yet adds to the zip, if its data is
+// stored without compression.
+func estimatedPartSize(name string, dataSize int) int64 {
+	return int64(zipLocalHeaderSize + len(name) + dataSize + zipDataDescriptorSize + zipDirectoryHeaderSize + len(name))
+}
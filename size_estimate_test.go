@@ -0,0 +1,67 @@
+package excel_stream
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEstimatedSize(t *testing.T) {
+	for _, spool := range []bool{false, true} {
+		buffer := bytes.NewBuffer(nil)
+		file := NewStreamFileBuilder(buffer)
+		if err := file.SetSpoolSheets(spool, t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.AddSheet("Sheet2", []string{"Token", "Name"}); err != nil {
+			t.Fatal(err)
+		}
+		excelStream, err := file.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		before, err := excelStream.EstimatedSize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 100; i++ {
+			if err := excelStream.WriteRow([]string{"123", "Taco"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		estimate, err := excelStream.EstimatedSize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if estimate <= before {
+			t.Fatalf("Spool %v: Expected the estimate to grow as rows are written, got %d then %d", spool, before,
+				estimate)
+		}
+		if err := excelStream.Close(); err != nil {
+			t.Fatal(err)
+		}
+		size := int64(buffer.Len())
+		// The parts written at Close are counted before they are compressed, so the estimate is a little too large.
+		if estimate < size || estimate > size+16*1024 {
+			t.Fatalf("Spool %v: Expected an estimate a little over %d bytes, got %d", spool, size, estimate)
+		}
+		if closedSize, err := excelStream.EstimatedSize(); closedSize != size || err != nil {
+			t.Fatalf("Spool %v: Expected the size of the closed file, %d bytes, got %d, %v", spool, size, closedSize,
+				err)
+		}
+	}
+}
+
+func TestEstimatedSizeAfterOutputFailure(t *testing.T) {
+	sf := &StreamFile{output: &countingWriter{err: failedWriteError}}
+	if _, err := sf.EstimatedSize(); !errors.Is(err, OutputFailedError) {
+		t.Fatalf("Expected %v, got %v", OutputFailedError, err)
+	}
+	sf = &StreamFile{output: &countingWriter{}, inUse: 1}
+	if _, err := sf.EstimatedSize(); err != ConcurrentUseError {
+		t.Fatalf("Expected %v, got %v", ConcurrentUseError, err)
+	}
+}
@@ -0,0 +1,121 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// SparklineType is the kind of small chart drawn by a sparkline.
+type SparklineType int
+
+const (
+	LineSparkline SparklineType = iota
+	ColumnSparkline
+	WinLossSparkline
+)
+
+const (
+	sparklineExtensionURI = "{05C60535-1F16-4fd2-B633-F4F36F0B64E0}"
+	x14Namespace          = "http://schemas.microsoft.com/office/spreadsheetml/2009/9/main"
+	excelMainNamespace    = "http://schemas.microsoft.com/office/excel/2006/main"
+)
+
+var (
+	UnknownSparklineTypeError  = errors.New("Unknown sparkline type")
+	InvalidSparklineRangeError = errors.New("Sparkline data columns must not be backwards, and must not include the sparkline's own column")
+)
+
+// Sparkline describes the small chart drawn in every row of a column, showing the trend of that row's data.
+type Sparkline struct {
+	Type SparklineType
+	// FirstColumn and LastColumn are the indexes of the first and last columns of each row's data, which start at 0.
+	FirstColumn int
+	LastColumn  int
+	// ShowMarkers marks every point on line sparklines.
+	ShowMarkers bool
+	// ShowHighLow marks the highest and lowest points.
+	ShowHighLow bool
+}
+
+// sparklineColumn is a column of a sheet that has a sparkline in every row.
+type sparklineColumn struct {
+	column    int
+	sparkline Sparkline
+}
+
+// AddSparklines draws a sparkline in the column of every row written to the named sheet, after the header. Each
+// sparkline shows the data in the same row between the Sparkline's first and last columns. The column's cells should
+// be left empty, since the sparkline is drawn over them. The sheet must be the current sheet or one that has not been
+// started yet.
+// Sparklines are an extension added in Excel 2010, older programs ignore them.
+func (sf *StreamFile) AddSparklines(sheetName string, column int, sparkline Sparkline) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	if sf.closed {
+		return StreamFileClosedError
+	}
+	sheetArrayIndex, err := sf.editableSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	if sparkline.Type < LineSparkline || sparkline.Type > WinLossSparkline {
+		return UnknownSparklineTypeError
+	}
+	for _, columnIndex := range []int{column, sparkline.FirstColumn, sparkline.LastColumn} {
+		if _, err := cellReference(columnIndex, 1); err != nil {
+			return err
+		}
+	}
+	if sparkline.LastColumn < sparkline.FirstColumn || (column >= sparkline.FirstColumn && column <= sparkline.LastColumn) {
+		return InvalidSparklineRangeError
+	}
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	extras.sparklines = append(extras.sparklines, sparklineColumn{column: column, sparkline: sparkline})
+	return nil
+}
+
+// renderSparklines returns the extension holding the sparklines for the data rows of a sheet, which follow the header
+// row up to the last row, or an empty string if there are none.
+func renderSparklines(sheetName string, sparklines []sparklineColumn, headerRow, lastRow int) string {
+	if len(sparklines) == 0 || lastRow <= headerRow {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString(`<ext uri="` + sparklineExtensionURI + `" xmlns:x14="` + x14Namespace + `">` +
+		`<x14:sparklineGroups xmlns:xm="` + excelMainNamespace + `">`)
+	for _, sparklineColumn := range sparklines {
+		sparkline := sparklineColumn.sparkline
+		builder.WriteString(`<x14:sparklineGroup displayEmptyCellsAs="gap"`)
+		switch sparkline.Type {
+		case ColumnSparkline:
+			builder.WriteString(` type="column"`)
+		case WinLossSparkline:
+			builder.WriteString(` type="stacked"`)
+		}
+		if sparkline.ShowMarkers {
+			builder.WriteString(` markers="1"`)
+		}
+		if sparkline.ShowHighLow {
+			builder.WriteString(` high="1" low="1"`)
+		}
+		builder.WriteString(`><x14:colorSeries rgb="FF376092"/><x14:colorNegative rgb="FFD00000"/>` +
+			`<x14:colorAxis rgb="FF000000"/><x14:colorMarkers rgb="FFD00000"/><x14:colorFirst rgb="FFD00000"/>` +
+			`<x14:colorLast rgb="FFD00000"/><x14:colorHigh rgb="FFD00000"/><x14:colorLow rgb="FFD00000"/>` +
+			`<x14:sparklines>`)
+		firstColumn := columnName(sparkline.FirstColumn)
+		lastColumn := columnName(sparkline.LastColumn)
+		column := columnName(sparklineColumn.column)
+		for row := headerRow + 1; row <= lastRow; row++ {
+			rowNumber := strconv.Itoa(row)
+			data := escapeXML(sheetReference(sheetName, firstColumn+rowNumber+":"+lastColumn+rowNumber))
+			builder.WriteString(`<x14:sparkline><xm:f>` + data + `</xm:f><xm:sqref>` + column + rowNumber +
+				`</xm:sqref></x14:sparkline>`)
+		}
+		builder.WriteString(`</x14:sparklines></x14:sparklineGroup>`)
+	}
+	builder.WriteString(`</x14:sparklineGroups></ext>`)
+	return builder.String()
+}
@@ -0,0 +1,70 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderSparklines(t *testing.T) {
+	sparklines := []sparklineColumn{
+		{column: 4, sparkline: Sparkline{Type: ColumnSparkline, FirstColumn: 1, LastColumn: 3, ShowHighLow: true}},
+	}
+	if renderSparklines("Sheet1", sparklines, 1, 1) != "" {
+		t.Fatal("Expected no sparklines for a sheet with only a header")
+	}
+	if renderSparklines("Sheet1", nil, 1, 5) != "" {
+		t.Fatal("Expected nothing for a sheet without sparklines")
+	}
+	extension := renderSparklines("Sheet1", sparklines, 1, 3)
+	expectedParts := []string{
+		`<x14:sparklineGroup displayEmptyCellsAs="gap" type="column" high="1" low="1">`,
+		`<x14:sparkline><xm:f>&#39;Sheet1&#39;!B2:D2</xm:f><xm:sqref>E2</xm:sqref></x14:sparkline>`,
+		`<x14:sparkline><xm:f>&#39;Sheet1&#39;!B3:D3</xm:f><xm:sqref>E3</xm:sqref></x14:sparkline>`,
+	}
+	for _, expectedPart := range expectedParts {
+		if !strings.Contains(extension, expectedPart) {
+			t.Fatalf("Expected %s in %s", expectedPart, extension)
+		}
+	}
+	if strings.Count(extension, "<x14:sparkline>") != 2 {
+		t.Fatalf("Expected one sparkline per data row: %s", extension)
+	}
+}
+
+func TestAddSparklines(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Name", "Q1", "Q2", "Q3", "Trend"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.AddSparklines("Sheet1", 4, Sparkline{Type: 10, FirstColumn: 1, LastColumn: 3}); err != UnknownSparklineTypeError {
+		t.Fatalf("Expected UnknownSparklineTypeError, got %v", err)
+	}
+	if err := excelStream.AddSparklines("Sheet1", 2, Sparkline{FirstColumn: 1, LastColumn: 3}); err != InvalidSparklineRangeError {
+		t.Fatalf("Expected InvalidSparklineRangeError, got %v", err)
+	}
+	if err := excelStream.AddSparklines("Sheet1", 4, Sparkline{FirstColumn: 1, LastColumn: 3}); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco", "1", "2", "3", ""}, {"Salsa", "3", "2", "1", ""}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader := bytes.NewReader(buffer.Bytes())
+	if _, err := Validate(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, `<xm:sqref>E3</xm:sqref></x14:sparkline></x14:sparklines></x14:sparklineGroup></x14:sparklineGroups></ext></extLst></worksheet>`) {
+		t.Fatalf("Expected sparklines at the end of the sheet: %s", sheetXML)
+	}
+}
@@ -0,0 +1,43 @@
+package excel_stream
+
+// splitLongCells splits the row's text cells that are longer than Excel allows, when the cell length policy is
+// SplitLongCells. The first part of each is left in the row, and the rest are returned as the cells of the
+// continuation rows that follow it, which are empty apart from the split columns. It returns nil if no cell was split.
+func splitLongCells(sanitizedCells []string, kinds []cellKind, marker string) [][]string {
+	var continuations [][]string
+	for colIndex, cellData := range sanitizedCells {
+		if kinds[colIndex] != textCell || excelLength(cellData) <= maxCellLength {
+			continue
+		}
+		parts := splitCell(cellData, marker)
+		sanitizedCells[colIndex] = parts[0]
+		for i, part := range parts[1:] {
+			if i == len(continuations) {
+				continuations = append(continuations, make([]string, len(sanitizedCells)))
+			}
+			continuations[i][colIndex] = part
+		}
+	}
+	return continuations
+}
+
+// writeContinuationRows writes the continuation rows of a row with split cells. They are grouped and styled like the
+// row, but are not counted as rows of their own by the totals, groups or audit columns.
+func (sf *StreamFile) writeContinuationRows(sheetName string, columns *sheetColumns, continuations [][]string,
+	options RowOptions) error {
+	rowOptions := RowOptions{StyleID: options.StyleID, OutlineLevel: options.OutlineLevel, Hidden: options.Hidden}
+	for _, cells := range continuations {
+		kinds := make([]cellKind, len(cells))
+		for colIndex, cellData := range cells {
+			// Empty cells are written as empty number cells, which leaves them blank rather than holding empty text.
+			if cellData == "" {
+				kinds[colIndex] = numberCell
+			}
+		}
+		sf.currentSheet.rowCount++
+		if err := sf.writeRowXML(sheetName, columns, cells, kinds, rowOptions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
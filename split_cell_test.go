@@ -0,0 +1,133 @@
+package excel_stream
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const continuationMarker = "...[continued]"
+
+func TestSplitCell(t *testing.T) {
+	longCell := strings.Repeat("a", maxCellLength*2)
+	parts := splitCell(longCell, continuationMarker)
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+	var joined string
+	for i, part := range parts {
+		if excelLength(part) > maxCellLength {
+			t.Fatalf("Part %d is longer than the maximum length", i)
+		}
+		if i < len(parts)-1 {
+			if !strings.HasSuffix(part, continuationMarker) {
+				t.Fatalf("Expected part %d to end with the marker", i)
+			}
+			part = strings.TrimSuffix(part, continuationMarker)
+		}
+		joined += part
+	}
+	if joined != longCell {
+		t.Fatalf("Expected the parts to hold the whole cell")
+	}
+
+	// Characters outside of the Basic Multilingual Plane take two units and must not be split.
+	emojiCell := strings.Repeat("🍕", maxCellLength)
+	for i, part := range splitCell(emojiCell, "") {
+		if excelLength(part) > maxCellLength || !strings.HasPrefix(part, "🍕") {
+			t.Fatalf("Part %d was not split between characters", i)
+		}
+	}
+}
+
+func TestSplitLongCells(t *testing.T) {
+	cells := []string{"123", strings.Repeat("a", maxCellLength+10), "Taco", strings.Repeat("b", maxCellLength*2)}
+	kinds := []cellKind{numberCell, textCell, textCell, textCell}
+	continuations := splitLongCells(cells, kinds, "")
+	expectedCells := []string{"123", strings.Repeat("a", maxCellLength), "Taco", strings.Repeat("b", maxCellLength)}
+	if !reflect.DeepEqual(cells, expectedCells) {
+		t.Fatalf("Expected the first part of each long cell to be left in the row")
+	}
+	expectedContinuations := [][]string{
+		{"", strings.Repeat("a", 10), "", strings.Repeat("b", maxCellLength)},
+	}
+	if !reflect.DeepEqual(continuations, expectedContinuations) {
+		t.Fatalf("Expected one continuation row with the rest of each long cell")
+	}
+	if continuations := splitLongCells([]string{"Taco"}, []cellKind{textCell}, ""); continuations != nil {
+		t.Fatalf("Expected no continuation rows, got %v", continuations)
+	}
+}
+
+func TestWriteSplitLongCells(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Contract"}); err != nil {
+		t.Fatal(err)
+	}
+	var warnings []SanitizeWarning
+	policy := DefaultSanitizePolicy()
+	policy.CellLength = SplitLongCells
+	policy.TruncationMarker = continuationMarker
+	policy.OnWarning = func(warning SanitizeWarning) {
+		warnings = append(warnings, warning)
+	}
+	if err := file.SetSanitizePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := strings.Repeat("a", maxCellLength) + "Salsa"
+	if err := excelStream.WriteRow([]string{"123", contract}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"456", "Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expectedWarnings := []SanitizeWarning{{Sheet: "Sheet1", Row: 2, Column: 1, Reason: SplitCell}}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Fatalf("Expected warnings %v, got %v", expectedWarnings, warnings)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheetXML, continuationMarker+`</t>`) {
+		t.Fatalf("Expected the first part to end with the marker: %s", sheetXML)
+	}
+	if !strings.Contains(sheetXML, `<row r="3"><c r="B3" t="inlineStr"><is><t>`) {
+		t.Fatalf("Expected the rest of the cell in the continuation row: %s", sheetXML)
+	}
+	if strings.Contains(sheetXML, `r="A3"`) {
+		t.Fatalf("Expected the other cells of the continuation row to be blank: %s", sheetXML)
+	}
+	if !strings.Contains(sheetXML, `<c r="A4"`) {
+		t.Fatalf("Expected the next row to follow the continuation row: %s", sheetXML)
+	}
+}
+
+func TestEncodeSplitLongCells(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheet("Sheet1", []string{"Contract"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetCellLengthPolicy(SplitLongCells, continuationMarker); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder, err := excelStream.NewRowEncoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = encoder.EncodeRow([]string{strings.Repeat("a", maxCellLength+1)}, nil)
+	cellErr, ok := err.(*CellError)
+	if !ok || cellErr.Err != CellTooLongError {
+		t.Fatalf("Expected a CellError wrapping CellTooLongError, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package excel_stream
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+)
+
+// SpoolBackend is the way spooled sheets are held until they are finished.
+type SpoolBackend int
+
+const (
+	// FileSpoolBackend writes spooled rows to a temporary file through a buffer and reads them back when the sheet is
+	// finished. This is the default.
+	FileSpoolBackend SpoolBackend = iota
+	// MemoryMappedSpoolBackend maps a temporary file into memory and copies spooled rows straight into it, so that
+	// rows are not copied through a buffer and write calls, and are not read back through read calls when the sheet is
+	// finished. Rows that have not been written back to the disk by the time the sheet is finished are never written
+	// at all, since the file is deleted. It is only supported on Linux, macOS and the BSDs. If the disk fills up while
+	// rows are being spooled, the program is killed with SIGBUS instead of getting an error, so it suits spool
+	// directories with room to spare, like a tmpfs.
+	MemoryMappedSpoolBackend
+)
+
+var (
+	UnknownSpoolBackendError     = errors.New("Unknown spool backend")
+	UnsupportedSpoolBackendError = errors.New("Spool backend is not supported on this platform")
+)
+
+// sheetSpool holds the rows of a sheet until the sheet is finished. Spooling gives up streaming within a sheet, but it
+// allows parts of the sheet's XML that come before the rows, like the dimension tag, to be written once the rows are
+// known.
+type sheetSpool interface {
+	io.Writer
+	// copyTo writes everything that has been written to the spool so far to the writer.
+	copyTo(w io.Writer) error
+	// remove releases everything the spool holds.
+	remove() error
+}
+
+// newSheetSpool creates a spool of the backend backed by a new temporary file in dir. If dir is empty, the default
+// directory for temporary files is used.
+func newSheetSpool(dir string, backend SpoolBackend) (sheetSpool, error) {
+	if backend == MemoryMappedSpoolBackend {
+		return newMappedSpool(dir)
+	}
+	file, err := os.CreateTemp(dir, "excel_stream_sheet")
+	if err != nil {
+		return nil, err
+	}
+	return &fileSpool{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// fileSpool is the spool of FileSpoolBackend.
+type fileSpool struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func (s *fileSpool) Write(p []byte) (int, error) {
+	return s.writer.Write(p)
+}
+
+func (s *fileSpool) copyTo(w io.Writer) error {
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.Copy(w, s.file)
+	return err
+}
+
+// remove closes and deletes the spool's temporary file.
+func (s *fileSpool) remove() error {
+	closeErr := s.file.Close()
+	if err := os.Remove(s.file.Name()); err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,93 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package excel_stream
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+const (
+	memoryMappedSpoolSupported = true
+	// minMappedSpoolSize is the size of the first mapping of a memory mapped spool. The mapping doubles in size each
+	// time it fills up.
+	minMappedSpoolSize = 1 << 20
+)
+
+// mappedSpool is the spool of MemoryMappedSpoolBackend. The file is extended and mapped again each time the mapping
+// fills up, so only size bytes of it are rows.
+type mappedSpool struct {
+	file *os.File
+	data []byte
+	size int
+}
+
+func newMappedSpool(dir string) (sheetSpool, error) {
+	file, err := os.CreateTemp(dir, "excel_stream_sheet")
+	if err != nil {
+		return nil, err
+	}
+	return &mappedSpool{file: file}, nil
+}
+
+func (s *mappedSpool) Write(p []byte) (int, error) {
+	if len(p) > len(s.data)-s.size {
+		if err := s.grow(s.size + len(p)); err != nil {
+			return 0, err
+		}
+	}
+	copy(s.data[s.size:], p)
+	s.size += len(p)
+	return len(p), nil
+}
+
+// grow extends the file so that it holds at least minSize bytes and maps all of it.
+func (s *mappedSpool) grow(minSize int) error {
+	size := 2 * len(s.data)
+	if size < minMappedSpoolSize {
+		size = minMappedSpoolSize
+	}
+	for size < minSize {
+		size *= 2
+	}
+	if err := s.unmap(); err != nil {
+		return err
+	}
+	if err := s.file.Truncate(int64(size)); err != nil {
+		return err
+	}
+	data, err := syscall.Mmap(int(s.file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	s.data = data
+	return nil
+}
+
+func (s *mappedSpool) unmap() error {
+	if s.data == nil {
+		return nil
+	}
+	data := s.data
+	s.data = nil
+	return syscall.Munmap(data)
+}
+
+func (s *mappedSpool) copyTo(w io.Writer) error {
+	_, err := w.Write(s.data[:s.size])
+	return err
+}
+
+// remove unmaps, closes and deletes the spool's temporary file.
+func (s *mappedSpool) remove() error {
+	unmapErr := s.unmap()
+	closeErr := s.file.Close()
+	if err := os.Remove(s.file.Name()); err != nil {
+		return err
+	}
+	if unmapErr != nil {
+		return unmapErr
+	}
+	return closeErr
+}
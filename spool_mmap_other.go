@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package excel_stream
+
+const memoryMappedSpoolSupported = false
+
+func newMappedSpool(dir string) (sheetSpool, error) {
+	return nil, UnsupportedSpoolBackendError
+}
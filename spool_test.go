@@ -0,0 +1,133 @@
+package excel_stream
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSpoolSheetsWritesDimension(t *testing.T) {
+	spoolDir, err := ioutil.TempDir("", "excel_stream_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(spoolDir)
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetSpoolSheets(true, spoolDir); err != nil {
+		t.Fatal(err)
+	}
+	workbookData := [][][]string{
+		{
+			{"Token", "Name", "Price", "SKU"},
+			{"123", "Taco", "300", "0000000123"},
+			{"456", "Salsa", "200", "0346"},
+		},
+		{
+			{"Token", "Name"},
+		},
+		{
+			{},
+		},
+	}
+	for i, sheetData := range workbookData {
+		if err := file.AddSheet(fmt.Sprintf("Sheet%d", i+1), sheetData[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range workbookData[0][1:] {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedDimensions := []string{"A1:D3", "A1:B1", "A1"}
+	for i, expectedDimension := range expectedDimensions {
+		sheetXML := readZipPart(t, buffer.Bytes(), fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if !strings.Contains(sheetXML, fmt.Sprintf(dimensionTag, expectedDimension)) {
+			t.Fatalf("Expected dimension %s in sheet %d: %s", expectedDimension, i+1, sheetXML)
+		}
+	}
+	bufReader := bytes.NewReader(buffer.Bytes())
+	_, actualWorkbookData := readXLSXFile(t, "", bufReader, bufReader.Size(), false)
+	if !reflect.DeepEqual(actualWorkbookData, workbookData) {
+		t.Fatal("Expected workbook data to be equal")
+	}
+	// All of the temporary files should have been cleaned up.
+	spoolFiles, err := ioutil.ReadDir(spoolDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spoolFiles) != 0 {
+		t.Fatalf("Expected the spool directory to be empty, found %d files", len(spoolFiles))
+	}
+}
+
+func TestSheetSpoolBackends(t *testing.T) {
+	backends := []SpoolBackend{FileSpoolBackend}
+	if memoryMappedSpoolSupported {
+		backends = append(backends, MemoryMappedSpoolBackend)
+	}
+	for _, backend := range backends {
+		spoolDir, err := ioutil.TempDir("", "excel_stream_test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(spoolDir)
+		spool, err := newSheetSpool(spoolDir, backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Enough rows to make a memory mapped spool grow a few times.
+		expected := bytes.NewBuffer(nil)
+		row := []byte(strings.Repeat("<row><c><v>1</v></c></row>", 1000))
+		for i := 0; i < 200; i++ {
+			expected.Write(row)
+			if _, err := spool.Write(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		actual := bytes.NewBuffer(nil)
+		if err := spool.copyTo(actual); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(actual.Bytes(), expected.Bytes()) {
+			t.Fatalf("Backend %d: Expected %d bytes to be copied back, got %d", backend, expected.Len(), actual.Len())
+		}
+		if err := spool.remove(); err != nil {
+			t.Fatal(err)
+		}
+		spoolFiles, err := ioutil.ReadDir(spoolDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(spoolFiles) != 0 {
+			t.Fatalf("Backend %d: Expected the spool directory to be empty, found %d files", backend, len(spoolFiles))
+		}
+	}
+}
+
+func TestSetSpoolBackend(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.SetSpoolBackend(MemoryMappedSpoolBackend + 1); err != UnknownSpoolBackendError {
+		t.Fatalf("Expected %v, got %v", UnknownSpoolBackendError, err)
+	}
+	expectedError := error(nil)
+	if !memoryMappedSpoolSupported {
+		expectedError = UnsupportedSpoolBackendError
+	}
+	if err := file.SetSpoolBackend(MemoryMappedSpoolBackend); err != expectedError {
+		t.Fatalf("Expected %v, got %v", expectedError, err)
+	}
+}
@@ -0,0 +1,72 @@
+package excel_stream
+
+// WriteStats summarizes what a StreamFile wrote, so that it can be recorded with the export without the caller having
+// to count rows and warnings itself.
+type WriteStats struct {
+	// Sheets has the statistics of each sheet, in the order the sheets were added.
+	Sheets []SheetStats
+	// Bytes is the number of bytes written to the output.
+	Bytes int64
+	// Sanitizations is the number of cells that were changed before being written, counting truncated cells.
+	// Truncations is the number of cells that were truncated because they were too long.
+	Sanitizations int
+	Truncations   int
+}
+
+// SheetStats are the statistics of one sheet.
+type SheetStats struct {
+	Name string
+	// Rows is the number of rows in the sheet, including the header, like in SheetSummary.
+	Rows          int
+	Sanitizations int
+	Truncations   int
+}
+
+// Finalize closes the StreamFile and returns the statistics of everything it wrote. The statistics are returned even
+// if Close returns an error, in which case they describe the incomplete file.
+func (sf *StreamFile) Finalize() (*WriteStats, error) {
+	err := sf.Close()
+	if err == StreamFileClosedError || err == ConcurrentUseError {
+		return nil, err
+	}
+	stats, statsErr := sf.Stats()
+	if statsErr != nil {
+		return nil, statsErr
+	}
+	return stats, err
+}
+
+// Stats returns the statistics of everything the StreamFile has written so far.
+func (sf *StreamFile) Stats() (*WriteStats, error) {
+	if err := sf.acquire(); err != nil {
+		return nil, err
+	}
+	defer sf.release()
+	stats := &WriteStats{
+		Sheets: make([]SheetStats, len(sf.xlsxFile.Sheets)),
+		Bytes:  sf.output.count,
+	}
+	for i, summary := range sf.SheetSummaries() {
+		stats.Sheets[i] = sf.sheetStats[i]
+		stats.Sheets[i].Name = summary.Name
+		stats.Sheets[i].Rows = summary.Rows
+		stats.Sanitizations += sf.sheetStats[i].Sanitizations
+		stats.Truncations += sf.sheetStats[i].Truncations
+	}
+	return stats, nil
+}
+
+// countWarnings returns a warning callback that counts each warning in the statistics of the current sheet before
+// passing it on to the caller's callback, if there is one.
+func (sf *StreamFile) countWarnings(onWarning func(SanitizeWarning)) func(SanitizeWarning) {
+	return func(warning SanitizeWarning) {
+		sheetStats := &sf.sheetStats[sf.currentSheet.index-1]
+		sheetStats.Sanitizations++
+		if warning.Reason == TruncatedCell {
+			sheetStats.Truncations++
+		}
+		if onWarning != nil {
+			onWarning(warning)
+		}
+	}
+}
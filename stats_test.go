@@ -0,0 +1,109 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCountWarnings(t *testing.T) {
+	sf := &StreamFile{currentSheet: &streamSheet{index: 2}, sheetStats: make([]SheetStats, 2)}
+	var warnings []SanitizeWarning
+	onWarning := sf.countWarnings(func(warning SanitizeWarning) {
+		warnings = append(warnings, warning)
+	})
+	onWarning(SanitizeWarning{Sheet: "Sheet2", Row: 2, Reason: TruncatedCell})
+	onWarning(SanitizeWarning{Sheet: "Sheet2", Row: 3, Reason: EscapedFormula})
+	if len(warnings) != 2 {
+		t.Fatalf("Expected the warnings to be passed on, got %v", warnings)
+	}
+	if sf.sheetStats[0] != (SheetStats{}) || sf.sheetStats[1].Sanitizations != 2 || sf.sheetStats[1].Truncations != 1 {
+		t.Fatalf("Expected the warnings to be counted for the second sheet, got %v", sf.sheetStats)
+	}
+	sf.countWarnings(nil)(SanitizeWarning{Reason: InvalidUTF8})
+	if sf.sheetStats[1].Sanitizations != 3 {
+		t.Fatalf("Expected warnings to be counted without a callback, got %v", sf.sheetStats)
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.SetCellLengthPolicy(TruncateLongCells, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetFormulaInjectionPolicy(EscapeFormulaPrefixes); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet1", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Name"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"=1+1"}, {strings.Repeat("a", maxCellLength+1)}, {"Taco"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats, err := excelStream.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SheetStats{
+		{Name: "Sheet1", Rows: 4, Sanitizations: 2, Truncations: 1},
+		{Name: "Sheet2", Rows: 1},
+	}
+	if len(stats.Sheets) != len(expected) || stats.Sheets[0] != expected[0] || stats.Sheets[1] != expected[1] {
+		t.Fatalf("Expected %v, got %v", expected, stats.Sheets)
+	}
+	if stats.Sanitizations != 2 || stats.Truncations != 1 || stats.Bytes != int64(buffer.Len()) {
+		t.Fatalf("Unexpected totals: %+v", stats)
+	}
+	if _, err := excelStream.Finalize(); err != StreamFileClosedError {
+		t.Fatalf("Expected %v, got %v", StreamFileClosedError, err)
+	}
+}
+
+func TestStatsConcurrentUse(t *testing.T) {
+	sf := &StreamFile{output: &countingWriter{}, inUse: 1}
+	if _, err := sf.Stats(); err != ConcurrentUseError {
+		t.Fatalf("Expected %v, got %v", ConcurrentUseError, err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	builders := []*StreamFileBuilder{NewStreamFileBuilder(buffer), NewDryRunStreamFileBuilder()}
+	var sizes []int64
+	for _, file := range builders {
+		if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+			t.Fatal(err)
+		}
+		excelStream, err := file.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range [][]string{{"123", "Taco"}, {"456", "Salsa"}} {
+			if err := excelStream.WriteRow(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = excelStream.WriteRow([]string{"789", strings.Repeat("a", maxCellLength+1)})
+		if cellErr, ok := err.(*CellError); !ok || cellErr.Err != CellTooLongError {
+			t.Fatalf("Expected a CellError wrapping CellTooLongError, got %v", err)
+		}
+		stats, err := excelStream.Finalize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, stats.Bytes)
+	}
+	if sizes[0] != int64(buffer.Len()) || sizes[1] != sizes[0] {
+		t.Fatalf("Expected the dry run to report the size of the file, %d bytes, got %d", buffer.Len(), sizes[1])
+	}
+}
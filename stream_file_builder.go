@@ -10,12 +10,12 @@
 // to the io. All rows written to the same sheet must have the same number of cells as the header provided when the sheet
 // was created or an error will be returned.
 // 5. Call NextSheet() to proceed to the next sheet. Once NextSheet() is called, the previous sheet can not be edited.
-// 6. Call Close() to finish.
+// 6. Call Close() to finish, or Abort() to give up on the file.
 
 // Future work suggestions:
-// Currently the only supported cell type is string, since the main reason this library was written was to prevent
-// strings from being interpreted as numbers. It would be nice to have support for numbers and money so that the exported
-// files could better take advantage of Excel's features.
+// Cells are written as text unless their column is declared as a number, currency, percent, boolean or date column,
+// since the main reason this library was written was to prevent strings from being interpreted as numbers. Other types
+// could be added so that the exported files could better take advantage of Excel's features.
 // All text is written with the same text style. Support for additional text styles could be added to highlight certain
 // data in the file.
 // The current default style uses fonts that are not on Macs by default so opening the XLSX files in Numbers causes a
@@ -28,17 +28,79 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tealeg/xlsx"
 )
 
 type StreamFileBuilder struct {
-	built     bool
-	xlsxFile  *xlsx.File
-	zipWriter *zip.Writer
+	built           bool
+	xlsxFile        *xlsx.File
+	zipWriter       ArchiveWriter
+	sheetNamePolicy SheetNamePolicy
+	// deduplicateSheetNames makes AddSheet rename sheets whose name is already taken instead of returning an error.
+	deduplicateSheetNames bool
+	duplicateHeaderPolicy DuplicateHeaderPolicy
+	sanitizePolicy        SanitizePolicy
+	spoolSheets           bool
+	spoolDir              string
+	spoolBackend          SpoolBackend
+	finalizeLastSheet     bool
+	// ignoreUnknownMapKeys makes WriteRowFromMap skip keys that are not the name of a column.
+	ignoreUnknownMapKeys bool
+	// omitEmptyCells makes empty text cells get left out of the sheets.
+	omitEmptyCells bool
+	// output counts the bytes written to the writer the builder was created with, and flushOutput flushes the writer,
+	// or is nil if it does not need to be flushed.
+	output      *countingWriter
+	flushOutput func() error
+	quota       Quota
+	// countParts makes the bytes written to each part count as output, for archives whose output can not be counted.
+	countParts bool
+	// rateLimiter is consulted on every flush, if it is set.
+	rateLimiter     RateLimiter
+	onRateLimitWait func(bytes int64, wait time.Duration)
+	xmlHooks        SheetXMLHooks
+	// outputFile is set when the builder was created for a path.
+	outputFile      *atomicFile
+	computeManifest bool
+	// completionMarkers makes the file start with IncompleteMarkerPath and end with CompleteMarkerPath.
+	completionMarkers bool
+	// columnDefs holds the column definitions of each sheet, or nil for sheets added with AddSheet.
+	columnDefs [][]ColumnDef
+	// rowStyles holds the default style of the data rows of each sheet.
+	rowStyles []Style
+	// reportSheets is set for the sheets added with AddReportSheet.
+	reportSheets []bool
+	// autoFilters is set for the sheets added with the AutoFilter flag.
+	autoFilters []bool
+	plugins     []Plugin
+	audits      []*sheetAudit
+	// richValues is set by SetRichValues.
+	richValues bool
+	// groupKeys holds the index of the key column of each sheet with group subtotals, or -1.
+	groupKeys     []int
+	typeInference TypeInference
+	// strictText keeps type inference from being turned on.
+	strictText bool
+	// selections holds the selection element of each sheet, or an empty string for sheets that keep the default one.
+	selections []string
+	// preambles holds the rows written above the header of each sheet.
+	preambles [][]PreambleRow
+	// optionalColumns holds the number of trailing columns of each sheet that rows may leave out.
+	optionalColumns []int
+	// mergeRepeatedCells is set for the sheets whose runs of repeated cells are merged.
+	mergeRepeatedCells []bool
+	// structTypes holds the struct type of each sheet added with AddSheetForStruct, or nil for other sheets.
+	structTypes []reflect.Type
+	// definedNames holds the names of the lookup sheets' data.
+	definedNames   []definedName
+	xmlConformance XMLConformance
+	// sessionWorkbook is the workbook of the Session the file is written to, if the builder came from AddWorkbook.
+	sessionWorkbook *sessionWorkbook
 }
 
 const (
@@ -52,29 +114,273 @@ var BuiltExcelStreamBuilderError = errors.New("StreamFileBuilder has already bee
 
 // NewExcelBuilder creates an StreamFileBuilder that will write to the the provided io.writer
 func NewStreamFileBuilder(writer io.Writer) *StreamFileBuilder {
+	output := &countingWriter{writer: writer}
 	return &StreamFileBuilder{
-		zipWriter: zip.NewWriter(writer),
-		xlsxFile:  xlsx.NewFile(),
+		zipWriter:      zip.NewWriter(output),
+		output:         output,
+		flushOutput:    writerFlusher(writer),
+		xlsxFile:       xlsx.NewFile(),
+		sanitizePolicy: DefaultSanitizePolicy(),
 	}
 }
 
 // NewExcelBuilderForFile takes the name of an XLSX file and returns a builder for it.
-// The file will be created if it does not exist, or truncated if it does.
+// The file will be created if it does not exist, or replaced if it does. The data is written to a temporary file in
+// the same directory, which is synced to disk and renamed to the path when the StreamFile is successfully closed. If
+// anything fails, or Abort is called, the temporary file is removed and the file at the path is left untouched.
 func NewStreamFileBuilderForPath(path string) (*StreamFileBuilder, error) {
-	file, err := os.Create(path)
+	file, err := createAtomicFile(path)
 	if err != nil {
 		return nil, err
 	}
-	return NewStreamFileBuilder(file), nil
+	sb := NewStreamFileBuilder(file)
+	sb.outputFile = file
+	return sb, nil
+}
+
+// NewDryRunStreamFileBuilder returns a builder whose StreamFile checks, cleans up and encodes everything written to it
+// exactly as any other would, but throws the file away instead of writing it. This lets a large export be tried out
+// before it is made: errors show up as they would for the real file, and Stats or Finalize report how many bytes it
+// would take.
+func NewDryRunStreamFileBuilder() *StreamFileBuilder {
+	return NewStreamFileBuilder(io.Discard)
+}
+
+// Abort gives up on the file without building it. If the builder was created for a path, the temporary file is
+// removed. Once aborted, all functions on the builder will return an error.
+func (sb *StreamFileBuilder) Abort() error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.built = true
+	if sb.outputFile != nil {
+		return sb.outputFile.abort()
+	}
+	return nil
+}
+
+// SetSheetNamePolicy controls how AddSheet handles sheet names that do not follow Excel's rules. By default invalid
+// names are rejected with an error.
+func (sb *StreamFileBuilder) SetSheetNamePolicy(policy SheetNamePolicy) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if policy != RejectInvalidSheetNames && policy != NormalizeInvalidSheetNames {
+		return UnknownSheetNamePolicyError
+	}
+	sb.sheetNamePolicy = policy
+	return nil
+}
+
+// SetDeduplicateSheetNames controls what AddSheet does when a sheet name is already taken. When enabled, the new sheet
+// is given the first free numbered name, such as "Data (2)", instead of AddSheet returning an error. This is useful
+// when sheet names come from data that may repeat, like user provided categories.
+func (sb *StreamFileBuilder) SetDeduplicateSheetNames(deduplicate bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.deduplicateSheetNames = deduplicate
+	return nil
+}
+
+// SetSanitizePolicy replaces all of the settings that control how WriteRow cleans up cell data, which can otherwise
+// be changed one at a time with the other setters. An error is returned if any of the settings are invalid, in which
+// case none of them are changed.
+func (sb *StreamFileBuilder) SetSanitizePolicy(policy SanitizePolicy) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	sb.sanitizePolicy = policy
+	return nil
+}
+
+// SetInvalidUTF8Policy controls how WriteRow handles cells that are not valid UTF-8. By default each invalid byte is
+// replaced with the Unicode replacement character.
+func (sb *StreamFileBuilder) SetInvalidUTF8Policy(policy InvalidUTF8Policy) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if policy < ReplaceInvalidUTF8 || policy > PassThroughInvalidUTF8 {
+		return UnknownInvalidUTF8PolicyError
+	}
+	sb.sanitizePolicy.InvalidUTF8 = policy
+	return nil
+}
+
+// SetInvalidCharacterPolicy controls how WriteRow handles characters that are not allowed in XML, such as 0x0B. By
+// default they are replaced with the Unicode replacement character.
+func (sb *StreamFileBuilder) SetInvalidCharacterPolicy(policy InvalidCharacterPolicy) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if policy < ReplaceInvalidCharacters || policy > EscapeInvalidCharacters {
+		return UnknownInvalidCharacterPolicyError
+	}
+	sb.sanitizePolicy.InvalidCharacters = policy
+	return nil
+}
+
+// SetCellLengthPolicy controls how WriteRow handles cells that are longer than the 32,767 characters Excel allows. By
+// default these cells are rejected with an error. When cells are truncated, the marker is added to the end of the
+// truncated text so that readers can tell that data is missing. When cells are split, it is added to the end of every
+// part that goes on in the next row, such as "...[continued]". An empty marker adds nothing.
+func (sb *StreamFileBuilder) SetCellLengthPolicy(policy CellLengthPolicy, truncationMarker string) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if err := validateCellLengthPolicy(policy, truncationMarker); err != nil {
+		return err
+	}
+	sb.sanitizePolicy.CellLength = policy
+	sb.sanitizePolicy.TruncationMarker = truncationMarker
+	return nil
+}
+
+// SetFormulaInjectionPolicy controls whether WriteRow neutralizes cells that could be run as formulas, such as
+// "=HYPERLINK(...)" or "@SUM(...)". This should be enabled when exporting data that comes from untrusted users.
+func (sb *StreamFileBuilder) SetFormulaInjectionPolicy(policy FormulaInjectionPolicy) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if policy != AllowFormulaPrefixes && policy != EscapeFormulaPrefixes {
+		return UnknownFormulaInjectionPolicyError
+	}
+	sb.sanitizePolicy.FormulaInjection = policy
+	return nil
+}
+
+// SetNonFiniteNumberPolicy controls how WriteRow handles NaN and infinite values in number columns, which Excel can not
+// store as numbers. By default these cells are rejected with an error.
+func (sb *StreamFileBuilder) SetNonFiniteNumberPolicy(policy NonFiniteNumberPolicy) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	if policy < RejectNonFiniteNumbers || policy > TextForNonFiniteNumbers {
+		return UnknownNonFiniteNumberPolicyError
+	}
+	sb.sanitizePolicy.NonFiniteNumbers = policy
+	return nil
+}
+
+// SetSpoolSheets controls whether each sheet's rows are held in a temporary file in dir until the sheet is finished,
+// instead of being streamed straight to the output. If dir is empty the default directory for temporary files is used.
+// Spooling means that rows are not sent to the output as they are written, but it lets the sheet be written with an
+// accurate dimension tag, which some programs use to find the size of a sheet without reading all of its rows.
+// Without spooling the dimension tag is left out, since it has to come before the rows.
+func (sb *StreamFileBuilder) SetSpoolSheets(spool bool, dir string) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.spoolSheets = spool
+	sb.spoolDir = dir
+	return nil
+}
+
+// SetSpoolBackend sets how spooled sheets are held until they are finished. It only has an effect when sheets are
+// spooled. UnsupportedSpoolBackendError is returned if the backend can not be used on this platform.
+func (sb *StreamFileBuilder) SetSpoolBackend(backend SpoolBackend) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	switch {
+	case backend < FileSpoolBackend || backend > MemoryMappedSpoolBackend:
+		return UnknownSpoolBackendError
+	case backend == MemoryMappedSpoolBackend && !memoryMappedSpoolSupported:
+		return UnsupportedSpoolBackendError
+	}
+	sb.spoolBackend = backend
+	return nil
+}
+
+// SetFinalizeLastSheet changes what NextSheet does when it is called on the last sheet. Normally it returns
+// AlreadyOnLastSheetError. When enabled, the first such call finishes the last sheet instead, which suits loops that
+// call NextSheet after writing each sheet.
+func (sb *StreamFileBuilder) SetFinalizeLastSheet(finalize bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.finalizeLastSheet = finalize
+	return nil
+}
+
+// SetComputeManifest controls whether the StreamFile keeps a SHA-256 checksum of every part it writes. When enabled,
+// the checksums and the number of rows in each sheet can be read from StreamFile.Manifest() once the file is closed.
+func (sb *StreamFileBuilder) SetComputeManifest(compute bool) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.computeManifest = compute
+	return nil
+}
+
+// SetSanitizeWarningHandler registers a function that will be called every time WriteRow changes a cell's data before
+// writing it, describing which cell was changed and why.
+func (sb *StreamFileBuilder) SetSanitizeWarningHandler(onWarning func(SanitizeWarning)) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	sb.sanitizePolicy.OnWarning = onWarning
+	return nil
 }
 
 // AddSheet will add sheets with the given name with the provided headers. The headers cannot be edited later, and all
-// rows written to the sheet must contain the same number of cells as the header. Sheet names must be unique, or an
-// error will be thrown.
-func (sb *StreamFileBuilder) AddSheet(name string, headers []string) error {
+// rows written to the sheet must contain the same number of cells as the header. Sheet names must be unique, ignoring
+// case, or an error will be thrown unless SetDeduplicateSheetNames has been enabled. Sheet names must also follow
+// Excel's rules: they cannot be blank, cannot be longer than 31 characters, cannot contain any of the characters
+// []/\?*: and cannot begin or end with an apostrophe. Invalid names are handled according to the builder's
+// SheetNamePolicy.
+// A sheet added without any headers is an empty placeholder. It will be written to the file, but WriteRow will return
+// EmptySheetError if it is called while the sheet is selected.
+// Flags turn on the most common sheet behaviors without the column definitions of AddSheetWithColumns, such as
+// AddSheet(name, headers, FreezeHeader|AutoFilter).
+func (sb *StreamFileBuilder) AddSheet(name string, headers []string, flags ...SheetFlag) error {
 	if sb.built {
 		return BuiltExcelStreamBuilderError
 	}
+	combined, err := combineSheetFlags(flags)
+	if err != nil {
+		// Set built on error so that all subsequent calls to the builder will also fail.
+		sb.built = true
+		return err
+	}
+	if err := sb.addSheet(name, headers); err != nil {
+		return err
+	}
+	sb.applySheetFlags(combined)
+	return nil
+}
+
+// addSheet adds a sheet with the headers for AddSheet and AddSheetWithColumns.
+func (sb *StreamFileBuilder) addSheet(name string, headers []string) error {
+	if err := validateSheetName(name); err != nil {
+		if sb.sheetNamePolicy != NormalizeInvalidSheetNames {
+			// Set built on error so that all subsequent calls to the builder will also fail.
+			sb.built = true
+			return err
+		}
+		name = normalizeSheetName(name, len(sb.xlsxFile.Sheets)+1)
+	}
+	if sb.deduplicateSheetNames {
+		name = deduplicateSheetName(name, sb.xlsxFile.Sheets)
+	} else if sheetNameExists(name, sb.xlsxFile.Sheets) {
+		// Set built on error so that all subsequent calls to the builder will also fail.
+		sb.built = true
+		return DuplicateSheetNameError
+	}
+	if len(headers) > maxColumns {
+		// Set built on error so that all subsequent calls to the builder will also fail.
+		sb.built = true
+		return ColumnOutOfRangeError
+	}
+	headers, err := applyDuplicateHeaderPolicy(headers, sb.duplicateHeaderPolicy)
+	if err != nil {
+		// Set built on error so that all subsequent calls to the builder will also fail.
+		sb.built = true
+		return err
+	}
 	sheet, err := sb.xlsxFile.AddSheet(name)
 	if err != nil {
 		// Set built on error so that all subsequent calls to the builder will also fail.
@@ -87,6 +393,17 @@ func (sb *StreamFileBuilder) AddSheet(name string, headers []string) error {
 		sb.built = true
 		return errors.New("Failed to write headers")
 	}
+	sb.columnDefs = append(sb.columnDefs, nil)
+	sb.rowStyles = append(sb.rowStyles, Style{})
+	sb.reportSheets = append(sb.reportSheets, false)
+	sb.autoFilters = append(sb.autoFilters, false)
+	sb.audits = append(sb.audits, nil)
+	sb.groupKeys = append(sb.groupKeys, -1)
+	sb.selections = append(sb.selections, "")
+	sb.preambles = append(sb.preambles, nil)
+	sb.optionalColumns = append(sb.optionalColumns, 0)
+	sb.mergeRepeatedCells = append(sb.mergeRepeatedCells, false)
+	sb.structTypes = append(sb.structTypes, nil)
 	return nil
 }
 
@@ -97,15 +414,82 @@ func (sb *StreamFileBuilder) Build() (*StreamFile, error) {
 		return nil, BuiltExcelStreamBuilderError
 	}
 	sb.built = true
+	es, err := sb.build()
+	if err != nil && sb.outputFile != nil {
+		sb.outputFile.abort()
+	}
+	if err == nil && sb.sessionWorkbook != nil {
+		sb.sessionWorkbook.file = es
+	}
+	return es, err
+}
+
+func (sb *StreamFileBuilder) build() (*StreamFile, error) {
+	sb.applyDefaultColumnWidths()
+	for i, rows := range sb.preambles {
+		// The frozen header of report sheets and sheets added with FreezeHeader is moved down with the header.
+		for _, view := range sb.xlsxFile.Sheets[i].SheetViews {
+			if len(rows) > 0 && view.Pane != nil && view.Pane.State == "frozen" && view.Pane.YSplit == 1 {
+				view.Pane.YSplit = float64(len(rows) + 1)
+				view.Pane.TopLeftCell = "A" + strconv.Itoa(len(rows)+2)
+			}
+		}
+	}
 	parts, err := sb.xlsxFile.MarshallParts()
 	if err != nil {
 		return nil, err
 	}
 	es := &StreamFile{
-		zipWriter:      sb.zipWriter,
-		xlsxFile:       sb.xlsxFile,
-		sheetXmlPrefix: make([]string, len(sb.xlsxFile.Sheets)),
-		sheetXmlSuffix: make([]string, len(sb.xlsxFile.Sheets)),
+		zipWriter:         sb.zipWriter,
+		output:            sb.output,
+		countParts:        sb.countParts,
+		flushOutput:       sb.flushOutput,
+		quota:             sb.quota,
+		rateLimiter:       sb.rateLimiter,
+		onRateLimitWait:   sb.onRateLimitWait,
+		xmlHooks:          sb.xmlHooks,
+		xlsxFile:          sb.xlsxFile,
+		sheetXmlPrefix:    make([]string, len(sb.xlsxFile.Sheets)),
+		sheetXmlSuffix:    make([]string, len(sb.xlsxFile.Sheets)),
+		dimensionIndex:    make([]int, len(sb.xlsxFile.Sheets)),
+		rowCounts:         make([]int, len(sb.xlsxFile.Sheets)),
+		headerRows:        make([]int, len(sb.xlsxFile.Sheets)),
+		definedNames:      sb.definedNames,
+		xmlConformance:    sb.xmlConformance,
+		sheetStats:        make([]SheetStats, len(sb.xlsxFile.Sheets)),
+		sheetExtras:       make([]sheetExtras, len(sb.xlsxFile.Sheets)),
+		columns:           make([]sheetColumns, len(sb.xlsxFile.Sheets)),
+		sanitizePolicy:    sb.sanitizePolicy,
+		typeInference:     sb.typeInference,
+		spoolSheets:       sb.spoolSheets,
+		spoolDir:          sb.spoolDir,
+		spoolBackend:      sb.spoolBackend,
+		finalizeLastSheet: sb.finalizeLastSheet,
+		outputFile:        sb.outputFile,
+		computeManifest:   sb.computeManifest,
+		completionMarkers: sb.completionMarkers,
+	}
+	es.ignoreUnknownMapKeys = sb.ignoreUnknownMapKeys
+	es.omitEmptyCells = sb.omitEmptyCells
+	es.optionalColumns = sb.optionalColumns
+	es.mergeRepeatedCells = sb.mergeRepeatedCells
+	es.structTypes = sb.structTypes
+	es.plugins = sb.plugins
+	es.richValues.enabled = sb.richValues
+	es.audits = sb.audits
+	es.onWarning = sb.sanitizePolicy.OnWarning
+	es.sanitizePolicy.OnWarning = es.countWarnings(sb.sanitizePolicy.OnWarning)
+	for i, sheet := range sb.xlsxFile.Sheets {
+		es.headerRows[i] = len(sb.preambles[i]) + 1
+		es.rowCounts[i] = len(sheet.Rows) + len(sb.preambles[i])
+	}
+	// The incomplete marker comes before everything else, so that even the shortest truncated file has it.
+	if es.completionMarkers {
+		if err := es.writeMarker(IncompleteMarkerPath, "This export is incomplete unless "+CompleteMarkerPath+
+			" is present."); err != nil {
+			return nil, err
+		}
+		es.contentTypes.addDefault("marker", markerContentType)
 	}
 	for path, data := range parts {
 		// If the part is a sheet, don't write it yet. We only want to write the Excel metadata files, since at this
@@ -116,7 +500,33 @@ func (sb *StreamFileBuilder) Build() (*StreamFile, error) {
 			}
 			continue
 		}
-		metadataFile, err := sb.zipWriter.Create(path)
+		// [Content_Types].xml has to list every part, so it is written at Close after any parts added while streaming.
+		if path == contentTypesPath {
+			es.contentTypes.xml = data
+			continue
+		}
+		// The workbook of a file with lookup sheets is written at Close, since their defined names depend on how many
+		// rows they have.
+		if path == workbookPath && len(sb.definedNames) > 0 {
+			es.workbookXML = data
+			continue
+		}
+		// The relationships of the package and the workbook are written at Close when plugins can add to them. Rich
+		// values and dynamic array formulas add their parts to the workbook's.
+		if path == packageRelsPath && len(sb.plugins) > 0 {
+			es.pluginRelationships.packageRelsXML = data
+			continue
+		}
+		if path == workbookRelsPath && (len(sb.plugins) > 0 || sb.richValues || sb.hasDynamicArrays()) {
+			es.pluginRelationships.workbookRelsXML = data
+			continue
+		}
+		// The styles are also written at Close, since formats can be added to them while streaming.
+		if path == stylesPath {
+			es.styles.setXML(data)
+			continue
+		}
+		metadataFile, err := es.createPart(&zip.FileHeader{Name: path, Method: zip.Deflate})
 		if err != nil {
 			return nil, err
 		}
@@ -125,6 +535,51 @@ func (sb *StreamFileBuilder) Build() (*StreamFile, error) {
 			return nil, err
 		}
 	}
+	for i, rows := range sb.preambles {
+		if len(rows) == 0 {
+			continue
+		}
+		prefix, merges, err := es.styles.renderPreamble(es.sheetXmlPrefix[i], rows, len(sb.xlsxFile.Sheets[i].Cols))
+		if err != nil {
+			return nil, err
+		}
+		es.sheetXmlPrefix[i] = prefix
+		es.sheetExtras[i].mergeCells = merges
+	}
+	for i, autoFilter := range sb.autoFilters {
+		es.sheetExtras[i].autoFilter = autoFilter
+	}
+	for i, selection := range sb.selections {
+		if selection != "" {
+			es.sheetXmlPrefix[i] = replaceSelection(es.sheetXmlPrefix[i], selection)
+		}
+		if sb.xlsxFile.Sheets[i].SheetFormat.DefaultRowHeight != 0 {
+			es.sheetXmlPrefix[i] = setCustomRowHeight(es.sheetXmlPrefix[i])
+		}
+	}
+	// The column and row styles can only be added once the styles have been read.
+	for i, columns := range sb.columnDefs {
+		if es.columns[i], err = es.styles.resolveColumns(columns, sb.rowStyles[i]); err != nil {
+			return nil, err
+		}
+		if len(sb.typeInference.DateLayouts) > 0 {
+			err := es.styles.resolveDateStyles(&es.columns[i], columns, sb.rowStyles[i], sb.typeInference.dateFormat())
+			if err != nil {
+				return nil, err
+			}
+		}
+		if sb.reportSheets[i] {
+			es.setUpReportSheet(i)
+		}
+		es.addHeaderComments(i, columns)
+		es.columns[i].aggregates = make([]ColumnAggregate, len(sb.xlsxFile.Sheets[i].Cols))
+		if es.columns[i].totals != nil {
+			es.columns[i].totals.columns = es.columns[i].aggregates
+		}
+		if sb.groupKeys[i] != -1 {
+			es.columns[i].group = &sheetGroup{keyColumn: sb.groupKeys[i]}
+		}
+	}
 
 	if err := es.NextSheet(); err != nil {
 		return nil, err
@@ -143,17 +598,22 @@ func (sb *StreamFileBuilder) processEmptySheetXML(sf *StreamFile, path, data str
 
 	// Remove the Dimension tag. Since more rows are going to be written to the sheet, it will be wrong.
 	// It is valid to for a sheet to be missing a Dimension tag, but it is not valid for it to be wrong.
-	data, err = removeDimensionTag(data, sf.xlsxFile.Sheets[sheetIndex])
+	// When sheets are spooled, an accurate Dimension tag is put back in the same place once the sheet is finished.
+	data, dimensionIndex, err := removeDimensionTag(data, sf.xlsxFile.Sheets[sheetIndex])
 	if err != nil {
 		return err
 	}
+	sf.dimensionIndex[sheetIndex] = dimensionIndex
 
 	// Split the sheet at the end of its SheetData tag so that more rows can be added inside.
 	prefix, suffix, err := splitSheetIntoPrefixAndSuffix(data)
 	if err != nil {
 		return err
 	}
-	sf.sheetXmlPrefix[sheetIndex] = prefix
+	conformed := sb.xmlConformance.conformPrefix(prefix)
+	// The dimension tag goes after the declaration and the worksheet element, which may have changed length.
+	sf.dimensionIndex[sheetIndex] += len(conformed) - len(prefix)
+	sf.sheetXmlPrefix[sheetIndex] = conformed
 	sf.sheetXmlSuffix[sheetIndex] = suffix
 	return nil
 }
@@ -176,25 +636,25 @@ func getSheetIndex(sf *StreamFile, path string) (int, error) {
 	return sheetArrayIndex, nil
 }
 
-// removeDimensionTag will return the passed in Excel Spreadsheet XML with the dimension tag removed.
+// removeDimensionTag will return the passed in Excel Spreadsheet XML with the dimension tag removed, along with the
+// position in the XML that the tag was removed from.
 // data is the XML data for the sheet
 // sheet is the xlsx.Sheet struct that the XML was created from.
 // Can return an error if the XML's dimension tag does not match was is expected based on the provided Sheet
-func removeDimensionTag(data string, sheet *xlsx.Sheet) (string, error) {
-	x := len(sheet.Cols) - 1
-	y := len(sheet.Rows) - 1
-	var dimensionRef string
-	if x < 0 || y < 0 {
-		dimensionRef = "A1"
-	} else {
-		endCoordinate := xlsx.GetCellIDStringFromCoords(x, y)
-		dimensionRef = "A1:" + endCoordinate
-	}
-	dataParts := strings.Split(data, fmt.Sprintf(dimensionTag, dimensionRef))
+func removeDimensionTag(data string, sheet *xlsx.Sheet) (string, int, error) {
+	dataParts := strings.Split(data, fmt.Sprintf(dimensionTag, dimensionRef(len(sheet.Cols), len(sheet.Rows))))
 	if len(dataParts) != 2 {
-		return "", errors.New("Unexpected Sheet XML from XLSX library. Dimension tag not found.")
+		return "", -1, errors.New("Unexpected Sheet XML from XLSX library. Dimension tag not found.")
+	}
+	return dataParts[0] + dataParts[1], len(dataParts[0]), nil
+}
+
+// dimensionRef returns the reference used by a dimension tag for a sheet with the given number of columns and rows.
+func dimensionRef(columnCount, rowCount int) string {
+	if columnCount < 1 || rowCount < 1 {
+		return "A1"
 	}
-	return dataParts[0] + dataParts[1], nil
+	return "A1:" + columnName(columnCount-1) + strconv.Itoa(rowCount)
 }
 
 // splitSheetIntoPrefixAndSuffix will split the provided XML sheet into a prefix and a suffix so that
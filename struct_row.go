@@ -0,0 +1,238 @@
+package excel_stream
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// structTag is the key of the struct tags that describe the columns of a struct's fields.
+const structTag = "xlsx"
+
+var (
+	NotAStructError           = errors.New("Value must be a struct or a pointer to a struct")
+	WrongStructTypeError      = errors.New("Struct must have the type the current sheet was added with by AddSheetForStruct")
+	UnknownStructTagTypeError = errors.New("Struct tag has an unknown column type, must be text, number, currency, percent, bool or date")
+)
+
+// structColumnTypes are the column types that can be given in struct tags.
+var structColumnTypes = map[string]ColumnType{
+	"text":     TextColumn,
+	"number":   NumberColumn,
+	"currency": CurrencyColumn,
+	"percent":  PercentColumn,
+	"bool":     BoolColumn,
+	"date":     DateColumn,
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// AddSheetForStruct will add a sheet with a column for each exported field of the struct v, which can also be a
+// pointer to a struct, so that its rows can be written with WriteStruct. Each column is described by the field's xlsx
+// tag, in the form `xlsx:"Name,type"`, where the type is text, number, currency, percent, bool or date. Without a
+// name, the column is named after the field, and without a type, it is a date column for time.Time fields, a bool
+// column for booleans, a number column for numbers and a text column for anything else. Fields tagged `xlsx:"-"` are
+// left out. The fields of embedded structs are columns of their own, in their place among the fields, unless the
+// embedded field is given a name in its tag. It works like AddSheetWithColumns in every other way.
+func (sb *StreamFileBuilder) AddSheetForStruct(name string, v interface{}) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	structType, err := structTypeOf(v)
+	if err != nil {
+		// Set built on error so that all subsequent calls to the builder will also fail.
+		sb.built = true
+		return err
+	}
+	var columns []ColumnDef
+	for _, index := range structFieldIndexes(structType) {
+		def, err := structColumn(structType.FieldByIndex(index))
+		if err != nil {
+			sb.built = true
+			return err
+		}
+		columns = append(columns, def)
+	}
+	if err := sb.AddSheetWithColumns(name, columns); err != nil {
+		return err
+	}
+	sb.structTypes[len(sb.structTypes)-1] = structType
+	return nil
+}
+
+// WriteStruct will write a row to the current sheet from the exported fields of the struct v, which can also be a
+// pointer to a struct, in the order of the columns AddSheetForStruct made for its type. The struct must have the type
+// the current sheet was added with, or WrongStructTypeError is returned. The fields are written like the values of
+// WriteRowFromMap, and nil pointers are NULL, as are the fields of embedded structs behind nil pointers. It works like
+// WriteRow in every other way.
+func (sf *StreamFile) WriteStruct(v interface{}) error {
+	if err := sf.acquire(); err != nil {
+		return err
+	}
+	defer sf.release()
+	structType, err := structTypeOf(v)
+	if err != nil {
+		return err
+	}
+	if !sf.closed && sf.currentSheet != nil && structType != sf.structTypes[sf.currentSheet.index-1] {
+		return WrongStructTypeError
+	}
+	value := reflect.Indirect(reflect.ValueOf(v))
+	indexes := structFieldIndexes(structType)
+	values := make([]interface{}, len(indexes))
+	for i, index := range indexes {
+		field, ok := structField(value, index)
+		if !ok {
+			continue
+		}
+		values[i] = structFieldValue(field)
+	}
+	return sf.writeValues(values, mapValueCell)
+}
+
+// structTypeOf returns the type of the struct v, which can also be a pointer to a struct.
+func structTypeOf(v interface{}) (reflect.Type, error) {
+	structType := reflect.TypeOf(v)
+	if structType != nil && structType.Kind() == reflect.Ptr {
+		if reflect.ValueOf(v).IsNil() {
+			return nil, NotAStructError
+		}
+		structType = structType.Elem()
+	}
+	if structType == nil || structType.Kind() != reflect.Struct {
+		return nil, NotAStructError
+	}
+	return structType, nil
+}
+
+// structFieldIndexes returns the index sequences, as used by reflect.Type.FieldByIndex, of the fields of the struct
+// type that are written as columns. The fields of embedded structs are included in place of the embedded field.
+func structFieldIndexes(structType reflect.Type) [][]int {
+	return embeddedFieldIndexes(structType, map[reflect.Type]bool{structType: true})
+}
+
+// embeddedFieldIndexes is structFieldIndexes for a struct that may be embedded in others. outer holds the struct types
+// it is embedded in, whose fields are left out if they are embedded again, so that structs that embed pointers to
+// themselves do not have endless columns.
+func embeddedFieldIndexes(structType reflect.Type, outer map[reflect.Type]bool) [][]int {
+	var indexes [][]int
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get(structTag)
+		if tag == "-" {
+			continue
+		}
+		if embedded := embeddedStructType(field); embedded != nil && strings.SplitN(tag, ",", 2)[0] == "" {
+			if outer[embedded] {
+				continue
+			}
+			outer[embedded] = true
+			for _, index := range embeddedFieldIndexes(embedded, outer) {
+				indexes = append(indexes, append([]int{i}, index...))
+			}
+			delete(outer, embedded)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		indexes = append(indexes, []int{i})
+	}
+	return indexes
+}
+
+// embeddedStructType returns the struct type of an embedded field whose fields are written as columns of their own,
+// or nil if the field is not an embedded struct or a pointer to one. Embedded time.Times are a single date column.
+func embeddedStructType(field reflect.StructField) reflect.Type {
+	if !field.Anonymous {
+		return nil
+	}
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct || fieldType == timeType {
+		return nil
+	}
+	return fieldType
+}
+
+// structField returns the field of the struct value at the index sequence, following pointers to embedded structs and
+// to the field itself. It returns false if any of those pointers is nil.
+func structField(value reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, false
+			}
+			value = value.Elem()
+		}
+		value = value.Field(i)
+	}
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return reflect.Value{}, false
+		}
+		value = value.Elem()
+	}
+	return value, true
+}
+
+// structFieldValue returns the value of a struct field. Fields of named types, such as `type Cents int64`, are
+// converted to their underlying type, unless they are fmt.Stringers.
+func structFieldValue(field reflect.Value) interface{} {
+	value := field.Interface()
+	if _, ok := value.(fmt.Stringer); ok || field.Type().PkgPath() == "" {
+		return value
+	}
+	switch field.Kind() {
+	case reflect.String:
+		return field.String()
+	case reflect.Bool:
+		return field.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field.Uint()
+	case reflect.Float32, reflect.Float64:
+		return field.Float()
+	}
+	return value
+}
+
+// structColumn returns the column definition of a struct field from its tag and its type.
+func structColumn(field reflect.StructField) (ColumnDef, error) {
+	name, typeName := field.Tag.Get(structTag), ""
+	if comma := strings.Index(name, ","); comma != -1 {
+		name, typeName = name[:comma], name[comma+1:]
+	}
+	if name == "" {
+		name = field.Name
+	}
+	if typeName != "" {
+		columnType, ok := structColumnTypes[typeName]
+		if !ok {
+			return ColumnDef{}, UnknownStructTagTypeError
+		}
+		return ColumnDef{Name: name, Type: columnType}, nil
+	}
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	def := ColumnDef{Name: name}
+	switch fieldType.Kind() {
+	case reflect.Bool:
+		def.Type = BoolColumn
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		def.Type = NumberColumn
+	case reflect.Struct:
+		if fieldType == timeType {
+			def.Type = DateColumn
+		}
+	}
+	return def, nil
+}
@@ -0,0 +1,164 @@
+package excel_stream
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type cents int64
+
+type orderRow struct {
+	Token    string
+	Amount   float64 `xlsx:"Total,currency"`
+	Discount cents   `xlsx:",percent"`
+	Spicy    bool
+	Ordered  time.Time
+	Shipped  *time.Time
+	Code     int    `xlsx:"Code,text"`
+	Internal string `xlsx:"-"`
+	secret   string
+}
+
+type address struct {
+	City string
+}
+
+type contact struct {
+	Email string
+}
+
+type customerRow struct {
+	Name string
+	address
+	*contact
+	Billing address `xlsx:"Billing"`
+}
+
+type treeRow struct {
+	Name string
+	*treeRow
+}
+
+func TestStructFieldIndexes(t *testing.T) {
+	testCases := []struct {
+		testName string
+		value    interface{}
+		expected [][]int
+	}{
+		{testName: "Embedded structs", value: customerRow{}, expected: [][]int{{0}, {1, 0}, {2, 0}, {3}}},
+		{testName: "Embeds itself", value: treeRow{}, expected: [][]int{{0}}},
+	}
+	for _, testCase := range testCases {
+		actual := structFieldIndexes(reflect.TypeOf(testCase.value))
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestStructField(t *testing.T) {
+	row := reflect.ValueOf(customerRow{Name: "Taco", address: address{City: "Austin"}})
+	if field, ok := structField(row, []int{1, 0}); !ok || field.String() != "Austin" {
+		t.Fatalf("Expected the embedded field, got %v, %v", field, ok)
+	}
+	if _, ok := structField(row, []int{2, 0}); ok {
+		t.Fatal("Expected the field of a nil embedded pointer to be missing")
+	}
+}
+
+func TestStructColumns(t *testing.T) {
+	file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+	if err := file.AddSheetForStruct("Orders", &orderRow{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []ColumnDef{
+		{Name: "Token"},
+		{Name: "Total", Type: CurrencyColumn},
+		{Name: "Discount", Type: PercentColumn},
+		{Name: "Spicy", Type: BoolColumn},
+		{Name: "Ordered", Type: DateColumn},
+		{Name: "Shipped", Type: DateColumn},
+		{Name: "Code", Type: TextColumn},
+	}
+	if !reflect.DeepEqual(file.columnDefs[0], expected) {
+		t.Fatalf("Expected columns %v, got %v", expected, file.columnDefs[0])
+	}
+
+	testCases := []struct {
+		testName      string
+		value         interface{}
+		expectedError error
+	}{
+		{testName: "Not a struct", value: "Taco", expectedError: NotAStructError},
+		{testName: "Nil pointer", value: (*orderRow)(nil), expectedError: NotAStructError},
+		{testName: "Nil", value: nil, expectedError: NotAStructError},
+		{testName: "Unknown type", value: struct {
+			Name string `xlsx:"Name,money"`
+		}{}, expectedError: UnknownStructTagTypeError},
+	}
+	for _, testCase := range testCases {
+		file := NewStreamFileBuilder(bytes.NewBuffer(nil))
+		if err := file.AddSheetForStruct("Sheet1", testCase.value); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if err := file.AddSheet("Sheet2", []string{"Name"}); err != BuiltExcelStreamBuilderError {
+			t.Fatalf("%s: Expected the builder to fail after the error, got %v", testCase.testName, err)
+		}
+	}
+}
+
+func TestStructFieldValue(t *testing.T) {
+	date := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		testName string
+		value    interface{}
+		expected interface{}
+	}{
+		{testName: "Basic type", value: 12, expected: 12},
+		{testName: "Named type", value: cents(12), expected: int64(12)},
+		{testName: "Stringer", value: time.Second, expected: time.Second},
+		{testName: "Date", value: date, expected: date},
+	}
+	for _, testCase := range testCases {
+		actual := structFieldValue(reflect.ValueOf(testCase.value))
+		if actual != testCase.expected {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expected, actual)
+		}
+	}
+}
+
+func TestWriteStruct(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheetForStruct("Orders", orderRow{}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := orderRow{Token: "Taco", Amount: 12.5, Spicy: true, Code: 7, Internal: "hidden", secret: "hidden"}
+	if err := excelStream.WriteStruct(&row); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteStruct("Taco"); err != NotAStructError {
+		t.Fatalf("Expected %v, got %v", NotAStructError, err)
+	}
+	if err := excelStream.WriteStruct(customerRow{Name: "Taco"}); err != WrongStructTypeError {
+		t.Fatalf("Expected %v, got %v", WrongStructTypeError, err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	if strings.Contains(sheetXML, "hidden") {
+		t.Fatalf("Expected the left out fields not to be written: %s", sheetXML)
+	}
+	if !strings.Contains(sheetXML, `<t>Taco</t>`) || !strings.Contains(sheetXML, `<v>12.5</v>`) ||
+		!strings.Contains(sheetXML, `<t>7</t>`) {
+		t.Fatalf("Expected the fields in the row: %s", sheetXML)
+	}
+}
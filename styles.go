@@ -0,0 +1,421 @@
+package excel_stream
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	stylesPath       = "xl/styles.xml"
+	endStyleSheetTag = "</styleSheet>"
+	// firstCustomNumberFormatID is the first ID that is not used by one of Excel's built in number formats.
+	firstCustomNumberFormatID = 164
+	// defaultFontSize and defaultFontName are used for new fonts if the style sheet does not have a default font.
+	defaultFontSize = "11"
+	defaultFontName = "Calibri"
+)
+
+var (
+	InvalidStylesError    = errors.New("Invalid xl/styles.xml, the closing styleSheet tag was not found")
+	UnknownAlignmentError = errors.New("Unknown horizontal alignment")
+)
+
+// styleSheetElementOrder is the order that the elements of a style sheet must appear in, according to the schema.
+var styleSheetElementOrder = []string{
+	"numFmts",
+	"fonts",
+	"fills",
+	"borders",
+	"cellStyleXfs",
+	"cellXfs",
+	"cellStyles",
+	"dxfs",
+	"tableStyles",
+	"colors",
+	"extLst",
+}
+
+// builtInNumberFormats are the IDs of Excel's built in number formats that are commonly used. Their codes do not need
+// to be written to the style sheet.
+var builtInNumberFormats = map[string]int{
+	"General":  0,
+	"0":        1,
+	"0.00":     2,
+	"#,##0":    3,
+	"#,##0.00": 4,
+	"0%":       9,
+	"0.00%":    10,
+	"0.00E+00": 11,
+	"@":        49,
+}
+
+// HorizontalAlignment is where text is placed across the width of a cell.
+type HorizontalAlignment int
+
+const (
+	// GeneralAlignment puts text on the left and numbers on the right. This is Excel's default.
+	GeneralAlignment HorizontalAlignment = iota
+	LeftAlignment
+	CenterAlignment
+	RightAlignment
+)
+
+// StyleID identifies a cell style that was added with AddStyle. The zero StyleID is the default cell style.
+type StyleID int
+
+// Style is the look of a cell. The zero Style is Excel's default look.
+type Style struct {
+	Bold      bool
+	Italic    bool
+	Underline bool
+	// FontSize is the size of the text in points. If it is 0, the workbook's default size is used.
+	FontSize int
+	// FontColor and FillColor are six digit hexadecimal RGB colors, such as FFFF00 for yellow. If FillColor is empty,
+	// the cell has no fill.
+	FontColor string
+	FillColor string
+	Alignment HorizontalAlignment
+	// WrapText shows text that is too long for the cell on multiple lines.
+	WrapText bool
+}
+
+// styleList is a list in the style sheet, such as the list of fonts, that items can be added to.
+type styleList struct {
+	// element is the name of the list's element, and child is the name of the elements of its items.
+	element string
+	child   string
+	// existing is the number of items that were already in the XML.
+	existing int
+	added    []string
+}
+
+// add adds the item to the list, and returns its index. Identical items share an index.
+func (l *styleList) add(xml string) int {
+	for i, item := range l.added {
+		if item == xml {
+			return l.existing + i
+		}
+	}
+	l.added = append(l.added, xml)
+	return l.existing + len(l.added) - 1
+}
+
+// styleSheet holds xl/styles.xml, so that formats and styles added while streaming can be added to it before it is
+// written at Close.
+type styleSheet struct {
+	xml      string
+	numFmts  styleList
+	fonts    styleList
+	fills    styleList
+	cellXfs  styleList
+	dxfs     styleList
+	fontSize string
+	fontName string
+	// numFmtIDs maps the codes of the number formats that were added to their IDs.
+	numFmtIDs    map[string]int
+	nextNumFmtID int
+}
+
+// lists returns the style sheet's lists.
+func (s *styleSheet) lists() []*styleList {
+	return []*styleList{&s.numFmts, &s.fonts, &s.fills, &s.cellXfs, &s.dxfs}
+}
+
+// setXML sets the style sheet's XML, as generated by the XLSX library, and counts the items already in its lists.
+func (s *styleSheet) setXML(styleXML string) {
+	s.xml = styleXML
+	s.numFmts = styleList{element: "numFmts", child: "numFmt"}
+	s.fonts = styleList{element: "fonts", child: "font"}
+	s.fills = styleList{element: "fills", child: "fill"}
+	s.cellXfs = styleList{element: "cellXfs", child: "xf"}
+	s.dxfs = styleList{element: "dxfs", child: "dxf"}
+	for _, list := range s.lists() {
+		if _, contentStart, contentEnd, _, ok := elementBounds(styleXML, list.element); ok {
+			list.existing = countElements(styleXML[contentStart:contentEnd], list.child)
+		}
+	}
+	s.nextNumFmtID = firstCustomNumberFormatID
+	var styles struct {
+		NumFmts []struct {
+			ID int `xml:"numFmtId,attr"`
+		} `xml:"numFmts>numFmt"`
+		Fonts []struct {
+			Size struct {
+				Val string `xml:"val,attr"`
+			} `xml:"sz"`
+			Name struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+		} `xml:"fonts>font"`
+	}
+	// If the XML can not be read, new styles are still added with the defaults.
+	xml.Unmarshal([]byte(styleXML), &styles)
+	for _, numFmt := range styles.NumFmts {
+		if numFmt.ID >= s.nextNumFmtID {
+			s.nextNumFmtID = numFmt.ID + 1
+		}
+	}
+	s.fontSize = defaultFontSize
+	s.fontName = defaultFontName
+	if len(styles.Fonts) > 0 {
+		if styles.Fonts[0].Size.Val != "" {
+			s.fontSize = styles.Fonts[0].Size.Val
+		}
+		if styles.Fonts[0].Name.Val != "" {
+			s.fontName = styles.Fonts[0].Name.Val
+		}
+	}
+	s.numFmtIDs = make(map[string]int)
+}
+
+// addDxf adds a differential format to the style sheet and returns its ID. Identical formats share an ID.
+func (s *styleSheet) addDxf(xml string) int {
+	return s.dxfs.add(xml)
+}
+
+// numberFormatID returns the ID of the number format with the code, adding it to the style sheet if it is not one of
+// the built in formats.
+func (s *styleSheet) numberFormatID(code string) int {
+	if id, ok := builtInNumberFormats[code]; ok {
+		return id
+	}
+	if id, ok := s.numFmtIDs[code]; ok {
+		return id
+	}
+	if s.numFmtIDs == nil {
+		s.numFmtIDs = make(map[string]int)
+		s.nextNumFmtID = firstCustomNumberFormatID
+	}
+	id := s.nextNumFmtID
+	s.nextNumFmtID++
+	s.numFmtIDs[code] = id
+	s.numFmts.add(`<numFmt numFmtId="` + strconv.Itoa(id) + `"` + xmlAttribute("formatCode", code) + `/>`)
+	return id
+}
+
+// AddStyle adds a cell style with the style and number format to the file, and returns its ID for use with
+// WriteRowOpts. Adding the same style and format again returns the same ID. Format is an Excel number format code, like
+// "0.00%", or empty for the General format.
+func (sf *StreamFile) AddStyle(style Style, format string) (StyleID, error) {
+	if err := sf.acquire(); err != nil {
+		return 0, err
+	}
+	defer sf.release()
+	if sf.closed {
+		return 0, StreamFileClosedError
+	}
+	id, err := sf.styles.addCellStyle(style, format)
+	return StyleID(id), err
+}
+
+// hasCellStyle reports whether the ID is the default cell style or the ID of one of the style sheet's cell styles.
+func (s *styleSheet) hasCellStyle(id StyleID) bool {
+	return id == 0 || (id > 0 && int(id) < s.cellXfs.existing+len(s.cellXfs.added))
+}
+
+// addCellStyle adds a cell style with the style and number format to the style sheet, and returns its ID, which is
+// used as the s attribute of cells. The zero Style with an empty format is the default cell style, which has the ID 0.
+func (s *styleSheet) addCellStyle(style Style, format string) (int, error) {
+	if err := style.validate(); err != nil {
+		return 0, err
+	}
+	if style == (Style{}) && format == "" {
+		return 0, nil
+	}
+	xf := `<xf`
+	if format != "" {
+		xf += ` numFmtId="` + strconv.Itoa(s.numberFormatID(format)) + `"`
+	} else {
+		xf += ` numFmtId="0"`
+	}
+	fontID := 0
+	if style.Bold || style.Italic || style.Underline || style.FontSize != 0 || style.FontColor != "" {
+		fontID = s.fonts.add(style.fontXML(s.fontSize, s.fontName))
+	}
+	fillID := 0
+	if style.FillColor != "" {
+		fillID = s.fills.add(`<fill><patternFill patternType="solid"><fgColor rgb="FF` +
+			strings.ToUpper(style.FillColor) + `"/><bgColor indexed="64"/></patternFill></fill>`)
+	}
+	xf += ` fontId="` + strconv.Itoa(fontID) + `" fillId="` + strconv.Itoa(fillID) + `" borderId="0" xfId="0"`
+	if format != "" {
+		xf += ` applyNumberFormat="1"`
+	}
+	if fontID != 0 {
+		xf += ` applyFont="1"`
+	}
+	if fillID != 0 {
+		xf += ` applyFill="1"`
+	}
+	alignment := style.alignmentXML()
+	if alignment != "" {
+		xf += ` applyAlignment="1">` + alignment + `</xf>`
+	} else {
+		xf += `/>`
+	}
+	return s.cellXfs.add(xf), nil
+}
+
+// validate checks the style's settings.
+func (style *Style) validate() error {
+	for _, color := range []string{style.FontColor, style.FillColor} {
+		if color != "" && !isHexColor(color) {
+			return InvalidColorError
+		}
+	}
+	if style.FontSize < 0 || style.FontSize > 400 {
+		return InvalidFontSizeError
+	}
+	if style.Alignment < GeneralAlignment || style.Alignment > RightAlignment {
+		return UnknownAlignmentError
+	}
+	return nil
+}
+
+// over returns the style with the settings it leaves at their defaults taken from the base style.
+func (style *Style) over(base Style) Style {
+	base.Bold = base.Bold || style.Bold
+	base.Italic = base.Italic || style.Italic
+	base.Underline = base.Underline || style.Underline
+	base.WrapText = base.WrapText || style.WrapText
+	if style.FontSize != 0 {
+		base.FontSize = style.FontSize
+	}
+	if style.FontColor != "" {
+		base.FontColor = style.FontColor
+	}
+	if style.FillColor != "" {
+		base.FillColor = style.FillColor
+	}
+	if style.Alignment != GeneralAlignment {
+		base.Alignment = style.Alignment
+	}
+	return base
+}
+
+// fontXML returns the font for the style, based on the default font's size and name.
+func (style *Style) fontXML(defaultSize, name string) string {
+	font := `<font>`
+	if style.Bold {
+		font += `<b/>`
+	}
+	if style.Italic {
+		font += `<i/>`
+	}
+	if style.Underline {
+		font += `<u/>`
+	}
+	size := defaultSize
+	if style.FontSize != 0 {
+		size = strconv.Itoa(style.FontSize)
+	}
+	font += `<sz` + xmlAttribute("val", size) + `/>`
+	if style.FontColor != "" {
+		font += `<color rgb="FF` + strings.ToUpper(style.FontColor) + `"/>`
+	}
+	return font + `<name` + xmlAttribute("val", name) + `/></font>`
+}
+
+// alignmentXML returns the alignment element for the style, or an empty string if it has the default alignment.
+func (style *Style) alignmentXML() string {
+	if style.Alignment == GeneralAlignment && !style.WrapText {
+		return ""
+	}
+	alignment := `<alignment`
+	switch style.Alignment {
+	case LeftAlignment:
+		alignment += ` horizontal="left"`
+	case CenterAlignment:
+		alignment += ` horizontal="center"`
+	case RightAlignment:
+		alignment += ` horizontal="right"`
+	}
+	if style.WrapText {
+		alignment += ` wrapText="1"`
+	}
+	return alignment + `/>`
+}
+
+// render returns xl/styles.xml with all of the added formats and styles.
+func (s *styleSheet) render() (string, error) {
+	if strings.LastIndex(s.xml, endStyleSheetTag) == -1 {
+		return "", InvalidStylesError
+	}
+	styleXML := s.xml
+	for _, list := range s.lists() {
+		if len(list.added) == 0 {
+			continue
+		}
+		var err error
+		if styleXML, err = list.render(styleXML); err != nil {
+			return "", err
+		}
+	}
+	return styleXML, nil
+}
+
+// render returns the style sheet XML with the list's element replaced by one that has the items already in it
+// followed by the added items. If the list's element is missing, it is added where the schema requires.
+func (l *styleList) render(styleXML string) (string, error) {
+	existing := ""
+	start, contentStart, contentEnd, end, ok := elementBounds(styleXML, l.element)
+	if ok {
+		existing = styleXML[contentStart:contentEnd]
+		styleXML = styleXML[:start] + styleXML[end:]
+	}
+	position := start
+	if !ok {
+		position = strings.LastIndex(styleXML, endStyleSheetTag)
+		after := false
+		for _, name := range styleSheetElementOrder {
+			if name == l.element {
+				after = true
+				continue
+			}
+			if index := findElement(styleXML, name); after && index != -1 && index < position {
+				position = index
+			}
+		}
+	}
+	element := `<` + l.element + ` count="` + strconv.Itoa(l.existing+len(l.added)) + `">` + existing +
+		strings.Join(l.added, "") + `</` + l.element + `>`
+	return styleXML[:position] + element + styleXML[position:], nil
+}
+
+// elementBounds finds the first element with the name in the XML, and returns the positions of its start, the start
+// and end of its content, and its end. The content of an element that closes itself is empty.
+func elementBounds(data, name string) (start, contentStart, contentEnd, end int, ok bool) {
+	start = findElement(data, name)
+	if start == -1 {
+		return 0, 0, 0, 0, false
+	}
+	tagEnd := strings.IndexByte(data[start:], '>')
+	if tagEnd == -1 {
+		return 0, 0, 0, 0, false
+	}
+	tagEnd += start
+	if data[tagEnd-1] == '/' {
+		return start, tagEnd + 1, tagEnd + 1, tagEnd + 1, true
+	}
+	closeTag := "</" + name + ">"
+	closeIndex := strings.Index(data[tagEnd:], closeTag)
+	if closeIndex == -1 {
+		return 0, 0, 0, 0, false
+	}
+	return start, tagEnd + 1, tagEnd + closeIndex, tagEnd + closeIndex + len(closeTag), true
+}
+
+// countElements returns the number of elements with the name in the XML.
+func countElements(data, name string) int {
+	count := 0
+	for {
+		index := findElement(data, name)
+		if index == -1 {
+			return count
+		}
+		count++
+		data = data[index+len(name)+1:]
+	}
+}
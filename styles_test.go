@@ -0,0 +1,52 @@
+package excel_stream
+
+import (
+	"testing"
+)
+
+func TestStyleSheetRender(t *testing.T) {
+	testCases := []struct {
+		name     string
+		xml      string
+		expected string
+	}{
+		{
+			name:     "No dxfs",
+			xml:      `<styleSheet><cellXfs count="1"></cellXfs><extLst></extLst></styleSheet>`,
+			expected: `<styleSheet><cellXfs count="1"></cellXfs><dxfs count="2"><dxf>a</dxf><dxf>b</dxf></dxfs><extLst></extLst></styleSheet>`,
+		},
+		{
+			name:     "Empty dxfs",
+			xml:      `<styleSheet><dxfs count="0"/></styleSheet>`,
+			expected: `<styleSheet><dxfs count="2"><dxf>a</dxf><dxf>b</dxf></dxfs></styleSheet>`,
+		},
+		{
+			name:     "Existing dxfs",
+			xml:      `<styleSheet><dxfs count="1"><dxf>x</dxf></dxfs><colors></colors></styleSheet>`,
+			expected: `<styleSheet><dxfs count="3"><dxf>x</dxf><dxf>a</dxf><dxf>b</dxf></dxfs><colors></colors></styleSheet>`,
+		},
+	}
+	for _, testCase := range testCases {
+		var styles styleSheet
+		styles.setXML(testCase.xml)
+		first := styles.addDxf("<dxf>a</dxf>")
+		if second := styles.addDxf("<dxf>b</dxf>"); second != first+1 {
+			t.Fatalf("%s: Expected consecutive IDs, got %d and %d", testCase.name, first, second)
+		}
+		if again := styles.addDxf("<dxf>a</dxf>"); again != first {
+			t.Fatalf("%s: Expected identical formats to share ID %d, got %d", testCase.name, first, again)
+		}
+		actual, err := styles.render()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != testCase.expected {
+			t.Fatalf("%s: Expected %s, got %s", testCase.name, testCase.expected, actual)
+		}
+	}
+	var styles styleSheet
+	styles.setXML("<styleSheet>")
+	if _, err := styles.render(); err != InvalidStylesError {
+		t.Fatalf("Expected %v, got %v", InvalidStylesError, err)
+	}
+}
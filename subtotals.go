@@ -0,0 +1,84 @@
+package excel_stream
+
+import (
+	"errors"
+)
+
+// subtotalLabelSuffix is added to the group's key to label its subtotal row.
+const subtotalLabelSuffix = " Total"
+
+var SubtotalsNeedTotalsError = errors.New("Group subtotals need a sheet added with AddSheetWithColumns that has a column with a Total")
+
+// sheetGroup is the group of rows currently being written to a sheet with group subtotals.
+type sheetGroup struct {
+	keyColumn int
+	// key is the key of the group as it was given to WriteRow, and label is the key as it was written.
+	key   string
+	label string
+	// firstRow is the Excel row number of the group's first row, or 0 before any rows have been written.
+	firstRow int
+	totals   *sheetTotals
+}
+
+// SetGroupSubtotals makes the named sheet add a subtotal row whenever the value in the key column changes, for rows
+// that are written sorted by that column. The subtotals use the Total of each column, and the rows of each group are
+// outlined so that Excel can collapse them down to their subtotals. The totals row at the end of the sheet leaves the
+// subtotals out. The sheet must have been added with AddSheetWithColumns or AddReportSheet, with at least one column
+// that has a Total.
+func (sb *StreamFileBuilder) SetGroupSubtotals(sheetName string, keyColumn int) error {
+	if sb.built {
+		return BuiltExcelStreamBuilderError
+	}
+	for i, sheet := range sb.xlsxFile.Sheets {
+		if sheet.Name != sheetName {
+			continue
+		}
+		hasTotals := false
+		for _, def := range sb.columnDefs[i] {
+			hasTotals = hasTotals || def.Total != NoTotal
+		}
+		if !hasTotals {
+			return SubtotalsNeedTotalsError
+		}
+		if keyColumn < 0 || keyColumn >= len(sb.columnDefs[i]) {
+			return ColumnOutOfRangeError
+		}
+		sb.groupKeys[i] = keyColumn
+		return nil
+	}
+	return UnknownSheetError
+}
+
+// ends reports whether the row starts a new group, which means the current group's subtotal row has to be written
+// first.
+func (g *sheetGroup) ends(cells []string) bool {
+	return g.firstRow != 0 && cells[g.keyColumn] != g.key
+}
+
+// add adds a row that has been written to the group, starting a new group if needed.
+func (g *sheetGroup) add(cells, sanitizedCells []string, kinds []cellKind, rowNumber int, totals *sheetTotals) {
+	if g.firstRow == 0 {
+		g.key = cells[g.keyColumn]
+		g.label = sanitizedCells[g.keyColumn]
+		g.firstRow = rowNumber
+		g.totals = totals.reset()
+	}
+	for colIndex, cellData := range sanitizedCells {
+		g.totals.columns[colIndex].add(kinds[colIndex], cellData)
+	}
+}
+
+// writeSubtotalRow writes the subtotal row of the current group of the current sheet, and ends the group.
+func (sf *StreamFile) writeSubtotalRow(columns *sheetColumns) error {
+	group := columns.group
+	lastRow := sf.currentSheet.rowCount
+	labelColumn := group.keyColumn
+	if group.totals.aggregates[labelColumn] != NoTotal {
+		labelColumn = group.totals.labelColumn()
+	}
+	sf.currentSheet.rowCount++
+	firstRow := group.firstRow
+	group.firstRow = 0
+	return group.totals.writeRow(sf.currentSheet, columns, firstRow, lastRow, group.label+subtotalLabelSuffix,
+		labelColumn, true)
+}
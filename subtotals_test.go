@@ -0,0 +1,83 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSheetGroup(t *testing.T) {
+	totals := &sheetTotals{aggregates: []Aggregate{NoTotal, SumTotal}, styleIDs: []int{1, 1}}
+	group := &sheetGroup{keyColumn: 0}
+	if group.ends([]string{"East", "1"}) {
+		t.Fatal("Expected the first row to not end a group")
+	}
+	group.add([]string{"East", "1"}, []string{"East", "1"}, []cellKind{textCell, numberCell}, 2, totals)
+	group.add([]string{"East", "2"}, []string{"East", "2"}, []cellKind{textCell, numberCell}, 3, totals)
+	if group.ends([]string{"East", "3"}) || !group.ends([]string{"West", "3"}) {
+		t.Fatal("Expected only a different key to end the group")
+	}
+	if group.firstRow != 2 || group.totals.columns[1].Sum != 3 || group.totals.columns[0].Count != 2 {
+		t.Fatalf("Expected the group to start at row 2 and sum to 3, got %d and %v", group.firstRow, group.totals.columns)
+	}
+	if totals.columns != nil {
+		t.Fatal("Expected the sheet's totals to be left alone")
+	}
+}
+
+func TestGroupSubtotals(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Plain", []string{"Region", "Sales"}); err != nil {
+		t.Fatal(err)
+	}
+	err := file.AddSheetWithColumns("Sales", []ColumnDef{
+		{Name: "Region"},
+		{Name: "Sales", Type: NumberColumn, Total: SumTotal},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetGroupSubtotals("Plain", 0); err != SubtotalsNeedTotalsError {
+		t.Fatalf("Expected %v, got %v", SubtotalsNeedTotalsError, err)
+	}
+	if err := file.SetGroupSubtotals("Sales", 2); err != ColumnOutOfRangeError {
+		t.Fatalf("Expected %v, got %v", ColumnOutOfRangeError, err)
+	}
+	if err := file.SetGroupSubtotals("Missing", 0); err != UnknownSheetError {
+		t.Fatalf("Expected %v, got %v", UnknownSheetError, err)
+	}
+	if err := file.SetGroupSubtotals("Sales", 0); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.NextSheet(); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"East", "1"}, {"East", "2"}, {"West", "4"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet2.xml")
+	for _, expected := range []string{
+		`<row r="2" outlineLevel="1">`,
+		`<row r="4"><c r="A4" s="`,
+		`<t>East Total</t>`,
+		`<f>SUBTOTAL(9,B2:B3)</f><v>3</v>`,
+		`<row r="5" outlineLevel="1">`,
+		`<t>West Total</t>`,
+		`<f>SUBTOTAL(9,B5:B5)</f><v>4</v>`,
+		`<f>SUBTOTAL(9,B2:B6)</f><v>7</v>`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package excel_stream
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Aggregate is a function that sums up a column in the totals row.
+type Aggregate int
+
+const (
+	// NoTotal leaves the column's cell in the totals row empty. This is the default.
+	NoTotal Aggregate = iota
+	SumTotal
+	AverageTotal
+	// CountTotal counts the cells of the column that are not empty.
+	CountTotal
+)
+
+// totalsLabel is written in the totals row, in the first column that has no total.
+const totalsLabel = "Total"
+
+// aggregateFunctions and subtotalFunctions are the start of the formulas for each aggregate, up to the cell range.
+var (
+	aggregateFunctions = map[Aggregate]string{SumTotal: "SUM(", AverageTotal: "AVERAGE(", CountTotal: "COUNTA("}
+	subtotalFunctions  = map[Aggregate]string{
+		SumTotal:     "SUBTOTAL(9,",
+		AverageTotal: "SUBTOTAL(1,",
+		CountTotal:   "SUBTOTAL(3,",
+	}
+)
+
+var (
+	UnknownAggregateError  = errors.New("Unknown aggregate")
+	TotalNeedsNumbersError = errors.New("Sum and average totals can only be used on number and formula columns")
+)
+
+// sheetTotals holds the totals of a sheet's columns. The columns are summed up as rows are written, so that the totals
+// row can hold their values as well as their formulas.
+type sheetTotals struct {
+	aggregates []Aggregate
+	// styleIDs are the IDs of the cell styles of the totals row, which are bold versions of the columns' styles.
+	styleIDs []int
+	columns  []ColumnAggregate
+	// written is set once the totals row has been written.
+	written bool
+}
+
+// validateTotal checks that the column's type can be summed up by its total.
+func (def *ColumnDef) validateTotal() error {
+	if def.Total < NoTotal || def.Total > CountTotal {
+		return UnknownAggregateError
+	}
+	if (def.Total == SumTotal || def.Total == AverageTotal) && def.Type == TextColumn {
+		return TotalNeedsNumbersError
+	}
+	return nil
+}
+
+// resolveTotals adds the styles of the totals row to the style sheet. It returns nil if none of the columns have a
+// total.
+func (s *styleSheet) resolveTotals(columns []ColumnDef, rowStyle Style) (*sheetTotals, error) {
+	hasTotals := false
+	for _, def := range columns {
+		hasTotals = hasTotals || def.Total != NoTotal
+	}
+	if !hasTotals {
+		return nil, nil
+	}
+	totals := &sheetTotals{
+		aggregates: make([]Aggregate, len(columns)),
+		styleIDs:   make([]int, len(columns)),
+		columns:    make([]ColumnAggregate, len(columns)),
+	}
+	for i, def := range columns {
+		style := def.Style.over(rowStyle)
+		style.Bold = true
+		format := def.numberFormat()
+		if def.Total == CountTotal {
+			format = ""
+		}
+		styleID, err := s.addCellStyle(style, format)
+		if err != nil {
+			return nil, err
+		}
+		totals.aggregates[i] = def.Total
+		totals.styleIDs[i] = styleID
+	}
+	return totals, nil
+}
+
+// cellXML returns the cell of the totals row for the column at the index, which sums up the rows from firstRow to
+// lastRow. Subtotals use SUBTOTAL, which leaves out the other subtotals in the range. The values of formula columns
+// are not known, so their totals are left for Excel to calculate when the file is opened.
+func (t *sheetTotals) cellXML(colIndex, firstRow, lastRow int, columnType ColumnType, label string,
+	subtotal bool) string {
+	column := columnName(colIndex)
+	cellOpen := `<c r="` + column + strconv.Itoa(lastRow+1) + `" s="` + strconv.Itoa(t.styleIDs[colIndex]) + `"`
+	cells := column + strconv.Itoa(firstRow) + ":" + column + strconv.Itoa(lastRow)
+	function := aggregateFunctions[t.aggregates[colIndex]]
+	if subtotal {
+		function = subtotalFunctions[t.aggregates[colIndex]]
+	}
+	aggregate := &t.columns[colIndex]
+	switch t.aggregates[colIndex] {
+	case SumTotal:
+		return cellOpen + `><f>` + function + cells + `)</f>` + cachedValue(aggregate, columnType, aggregate.Sum) + `</c>`
+	case AverageTotal:
+		if aggregate.Numbers == 0 && columnType == NumberColumn && aggregate.Errors == 0 {
+			return cellOpen + ` t="e"><f>` + function + cells + `)</f><v>#DIV/0!</v></c>`
+		}
+		average := aggregate.Sum / float64(aggregate.Numbers)
+		return cellOpen + `><f>` + function + cells + `)</f>` + cachedValue(aggregate, columnType, average) + `</c>`
+	case CountTotal:
+		return cellOpen + `><f>` + function + cells + `)</f><v>` + strconv.Itoa(aggregate.Count) + `</v></c>`
+	}
+	if label != "" {
+		textOpen := `<t>`
+		if needsSpacePreserved(label) {
+			textOpen = `<t xml:space="preserve">`
+		}
+		return cellOpen + ` t="inlineStr"><is>` + textOpen + escapeXML(label) + `</t></is></c>`
+	}
+	return cellOpen + `/>`
+}
+
+// reset returns empty totals for the same columns.
+func (t *sheetTotals) reset() *sheetTotals {
+	return &sheetTotals{
+		aggregates: t.aggregates,
+		styleIDs:   t.styleIDs,
+		columns:    make([]ColumnAggregate, len(t.aggregates)),
+	}
+}
+
+// labelColumn returns the index of the first column without a total, where the label of the totals row goes, or -1
+// if every column has one.
+func (t *sheetTotals) labelColumn() int {
+	for colIndex, aggregate := range t.aggregates {
+		if aggregate == NoTotal {
+			return colIndex
+		}
+	}
+	return -1
+}
+
+// writeRow writes a totals row after the rows from firstRow to lastRow, with the label in the label column.
+func (t *sheetTotals) writeRow(sheet *streamSheet, columns *sheetColumns, firstRow, lastRow int, label string,
+	labelColumn int, subtotal bool) error {
+	if err := sheet.write(`<row r="` + strconv.Itoa(lastRow+1) + `">`); err != nil {
+		return err
+	}
+	for colIndex := range t.aggregates {
+		cellLabel := ""
+		if colIndex == labelColumn {
+			cellLabel = label
+		}
+		cellXML := t.cellXML(colIndex, firstRow, lastRow, columns.columnType(colIndex), cellLabel, subtotal)
+		if err := sheet.write(cellXML); err != nil {
+			return err
+		}
+	}
+	return sheet.write(`</row>`)
+}
+
+// cachedValue returns the value element holding the result of a total of the column, if it is known. Errors in the
+// column make the total an error too.
+func cachedValue(aggregate *ColumnAggregate, columnType ColumnType, value float64) string {
+	if columnType != NumberColumn || aggregate.Errors != 0 {
+		return ""
+	}
+	return `<v>` + strconv.FormatFloat(value, 'g', -1, 64) + `</v>`
+}
+
+// writeTotalsRow writes the totals row after the rows of the current sheet, if its columns have totals and rows were
+// written to it. If the sheet's rows are grouped, the subtotal row of the last group is written first.
+func (sf *StreamFile) writeTotalsRow() error {
+	columns := &sf.columns[sf.currentSheet.index-1]
+	totals := columns.totals
+	if totals == nil {
+		return nil
+	}
+	if columns.group != nil && columns.group.firstRow != 0 {
+		if err := sf.writeSubtotalRow(columns); err != nil {
+			return err
+		}
+	}
+	firstRow := sf.headerRows[sf.currentSheet.index-1] + 1
+	lastRow := sf.currentSheet.rowCount
+	if lastRow < firstRow || lastRow >= maxRows {
+		return nil
+	}
+	sf.currentSheet.rowCount++
+	totals.written = true
+	return totals.writeRow(sf.currentSheet, columns, firstRow, lastRow, totalsLabel, totals.labelColumn(),
+		columns.group != nil)
+}
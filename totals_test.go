@@ -0,0 +1,115 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValidateTotal(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		column        ColumnDef
+		expectedError error
+	}{
+		{testName: "No Total", column: ColumnDef{}},
+		{testName: "Count Text", column: ColumnDef{Total: CountTotal}},
+		{testName: "Sum Numbers", column: ColumnDef{Type: NumberColumn, Total: SumTotal}},
+		{testName: "Average Formulas", column: ColumnDef{Type: FormulaColumn, Formula: "A{row}", Total: AverageTotal}},
+		{testName: "Sum Text", column: ColumnDef{Total: SumTotal}, expectedError: TotalNeedsNumbersError},
+		{testName: "Unknown", column: ColumnDef{Total: Aggregate(7)}, expectedError: UnknownAggregateError},
+	}
+	for _, testCase := range testCases {
+		if err := testCase.column.validate(); err != testCase.expectedError {
+			t.Fatalf("%s: Expected %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+	}
+}
+
+func TestTotalsCellXML(t *testing.T) {
+	var styles styleSheet
+	styles.setXML(`<styleSheet><cellXfs count="1"><xf/></cellXfs></styleSheet>`)
+	totals, err := styles.resolveTotals([]ColumnDef{
+		{Name: "Name"},
+		{Name: "Amount", Type: NumberColumn, Total: SumTotal},
+		{Name: "Rate", Type: NumberColumn, Total: AverageTotal},
+		{Name: "Note", Total: CountTotal},
+		{Name: "Bad", Type: NumberColumn, Total: SumTotal},
+	}, Style{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]string{{"Taco", "1.5", "2", "Spicy", "1"}, {"Burrito", "2", "", "", "#NUM!"}}
+	for _, row := range rows {
+		for colIndex, cellData := range row {
+			kind := numberCell
+			if colIndex == 0 || colIndex == 3 {
+				kind = textCell
+			} else if cellData == "#NUM!" {
+				kind = errorCell
+			}
+			totals.columns[colIndex].add(kind, cellData)
+		}
+	}
+	expected := []string{
+		`<c r="A4" s="1" t="inlineStr"><is><t>Total</t></is></c>`,
+		`<c r="B4" s="1"><f>SUM(B2:B3)</f><v>3.5</v></c>`,
+		`<c r="C4" s="1"><f>AVERAGE(C2:C3)</f><v>2</v></c>`,
+		`<c r="D4" s="1"><f>COUNTA(D2:D3)</f><v>1</v></c>`,
+		`<c r="E4" s="1"><f>SUM(E2:E3)</f></c>`,
+	}
+	types := []ColumnType{TextColumn, NumberColumn, NumberColumn, TextColumn, NumberColumn}
+	for colIndex, expectedXML := range expected {
+		label := ""
+		if colIndex == 0 {
+			label = totalsLabel
+		}
+		if actual := totals.cellXML(colIndex, 2, 3, types[colIndex], label, false); actual != expectedXML {
+			t.Fatalf("Expected %s, got %s", expectedXML, actual)
+		}
+	}
+	expectedXML := `<c r="B4" s="1"><f>SUBTOTAL(9,B2:B3)</f><v>3.5</v></c>`
+	if actual := totals.cellXML(1, 2, 3, NumberColumn, "", true); actual != expectedXML {
+		t.Fatalf("Expected %s, got %s", expectedXML, actual)
+	}
+	if actual := totals.reset().cellXML(3, 5, 9, TextColumn, "", true); actual != `<c r="D10" s="1"><f>SUBTOTAL(3,D5:D9)</f><v>0</v></c>` {
+		t.Fatalf("Expected reset totals to start over, got %s", actual)
+	}
+	if totals, err := styles.resolveTotals([]ColumnDef{{Name: "Name"}}, Style{}); totals != nil || err != nil {
+		t.Fatalf("Expected no totals, got %v, %v", totals, err)
+	}
+}
+
+func TestTotalsRow(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	err := file.AddReportSheet("Report", []ColumnDef{
+		{Name: "Name"},
+		{Name: "Amount", Type: NumberColumn, Total: SumTotal},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"Taco", "1"}, {"Burrito", "2"}} {
+		if err := excelStream.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sheetXML := readZipPart(t, buffer.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<row r="4"><c r="A4" s="`,
+		`<f>SUM(B2:B3)</f><v>3</v></c></row>`,
+		`<autoFilter ref="A1:B3"/>`,
+	} {
+		if !strings.Contains(sheetXML, expected) {
+			t.Fatalf("Expected %s in %s", expected, sheetXML)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package excel_stream
+
+// Unit is the unit of the values of a column, like ms or USD. A column with a Unit has it added to its header in
+// parentheses, such as "Latency (ms)", which is also the name WriteRowFromMap matches. Other units than the ones below
+// can be used, but only these set the column's number format.
+type Unit string
+
+const (
+	// MillisecondsUnit and BytesUnit are whole numbers with thousands separators.
+	MillisecondsUnit Unit = "ms"
+	BytesUnit        Unit = "bytes"
+	// PercentUnit values are fractions, so 0.25 is shown as 25.00%.
+	PercentUnit Unit = "%"
+	// USDUnit values are amounts in US dollars, shown with two decimal places.
+	USDUnit Unit = "USD"
+)
+
+// unitFormats are the number formats of the units that have one.
+var unitFormats = map[Unit]string{
+	MillisecondsUnit: localeFormats["en-US"].Integer,
+	BytesUnit:        localeFormats["en-US"].Integer,
+	PercentUnit:      localeFormats["en-US"].Percent,
+	USDUnit:          localeFormats["en-US"].Currency,
+}
+
+// header returns the text of the column's header cell.
+func (def *ColumnDef) header() string {
+	if def.Unit == "" {
+		return def.Name
+	}
+	return def.Name + " (" + string(def.Unit) + ")"
+}
+
+// numberFormat returns the number format of the column's cells, which is its Format if it has one.
+func (def *ColumnDef) numberFormat() string {
+	if def.Format != "" {
+		return def.Format
+	}
+	switch def.Type {
+	case DateColumn:
+		return defaultInferredDateFormat
+	case CurrencyColumn:
+		return defaultCurrencyFormat
+	case PercentColumn:
+		return defaultPercentFormat
+	}
+	return unitFormats[def.Unit]
+}
@@ -0,0 +1,85 @@
+package excel_stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestColumnUnit(t *testing.T) {
+	testCases := []struct {
+		testName       string
+		def            ColumnDef
+		expectedHeader string
+		expectedFormat string
+	}{
+		{testName: "No unit", def: ColumnDef{Name: "Count", Type: NumberColumn}, expectedHeader: "Count"},
+		{
+			testName:       "Milliseconds",
+			def:            ColumnDef{Name: "Latency", Type: NumberColumn, Unit: MillisecondsUnit},
+			expectedHeader: "Latency (ms)",
+			expectedFormat: "#,##0",
+		},
+		{
+			testName:       "Percent",
+			def:            ColumnDef{Name: "Error Rate", Type: NumberColumn, Unit: PercentUnit},
+			expectedHeader: "Error Rate (%)",
+			expectedFormat: "0.00%",
+		},
+		{
+			testName:       "Format over unit",
+			def:            ColumnDef{Name: "Cost", Type: NumberColumn, Unit: USDUnit, Format: "0.0000"},
+			expectedHeader: "Cost (USD)",
+			expectedFormat: "0.0000",
+		},
+		{
+			testName:       "Percent column",
+			def:            ColumnDef{Name: "Conversion", Type: PercentColumn},
+			expectedHeader: "Conversion",
+			expectedFormat: "0.0%",
+		},
+		{
+			testName:       "Other unit",
+			def:            ColumnDef{Name: "Distance", Type: NumberColumn, Unit: "km"},
+			expectedHeader: "Distance (km)",
+		},
+	}
+	for _, testCase := range testCases {
+		if header := testCase.def.header(); header != testCase.expectedHeader {
+			t.Fatalf("%s: Expected %q, got %q", testCase.testName, testCase.expectedHeader, header)
+		}
+		if format := testCase.def.numberFormat(); format != testCase.expectedFormat {
+			t.Fatalf("%s: Expected %q, got %q", testCase.testName, testCase.expectedFormat, format)
+		}
+	}
+}
+
+func TestWriteUnitColumns(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	columns := []ColumnDef{
+		{Name: "Size", Type: NumberColumn, Unit: BytesUnit},
+		{Name: "Cost", Type: NumberColumn, Unit: USDUnit},
+	}
+	if err := file.AddSheetWithColumns("Sheet1", columns); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRowFromMap(map[string]interface{}{"Size (bytes)": 2048, "Cost (USD)": 1.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sharedStrings := readZipPart(t, buffer.Bytes(), "xl/sharedStrings.xml")
+	if !strings.Contains(sharedStrings, `<t>Size (bytes)</t>`) || !strings.Contains(sharedStrings, `<t>Cost (USD)</t>`) {
+		t.Fatalf("Expected the units in the headers: %s", sharedStrings)
+	}
+	stylesXML := readZipPart(t, buffer.Bytes(), "xl/styles.xml")
+	if !strings.Contains(stylesXML, `formatCode="#,##0"`) || !strings.Contains(stylesXML, `formatCode="[$$-409]#,##0.00"`) {
+		t.Fatalf("Expected the unit formats in %s", stylesXML)
+	}
+}
@@ -0,0 +1,222 @@
+package excel_stream
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// requiredParts are the parts that every XLSX file must contain.
+var requiredParts = []string{
+	"[Content_Types].xml",
+	"_rels/.rels",
+	"xl/workbook.xml",
+	"xl/_rels/workbook.xml.rels",
+}
+
+var (
+	MissingPartError      = errors.New("Part missing from XLSX file")
+	MalformedPartError    = errors.New("Part of XLSX file is not well formed XML")
+	RowCountMismatchError = errors.New("Number of rows in XLSX file differs from the number of rows written")
+)
+
+// SheetSummary describes a sheet in an XLSX file.
+type SheetSummary struct {
+	Name string
+	// Rows is the number of rows in the sheet, including the header.
+	Rows int
+}
+
+// Validate reads back a finished XLSX file and checks that it can be opened. It checks that the zip file is intact,
+// that all of the required parts are present, that every XML part is well formed, and that every sheet listed in the
+// workbook exists. It returns a summary of each sheet in the order they appear in the workbook, which can be compared
+// to what was written.
+func Validate(r io.ReaderAt, size int64) ([]SheetSummary, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	parts := make(map[string]*zip.File, len(zipReader.File))
+	for _, file := range zipReader.File {
+		parts[file.Name] = file
+	}
+	for _, name := range requiredParts {
+		if parts[name] == nil {
+			return nil, fmt.Errorf("%w: %s", MissingPartError, name)
+		}
+	}
+	// Reading every part to the end makes the zip reader check its checksum.
+	for _, file := range zipReader.File {
+		if err := validatePart(file); err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(parts["xl/workbook.xml"], &workbook); err != nil {
+		return nil, err
+	}
+	var relationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(parts["xl/_rels/workbook.xml.rels"], &relationships); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(relationships.Relationships))
+	for _, relationship := range relationships.Relationships {
+		targets[relationship.ID] = relationship.Target
+	}
+
+	summaries := make([]SheetSummary, 0, len(workbook.Sheets))
+	for _, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.ID]
+		if !ok {
+			return nil, fmt.Errorf("%w: relationship %s for sheet %q", MissingPartError, sheet.ID, sheet.Name)
+		}
+		partName := resolvePartName("xl", target)
+		part := parts[partName]
+		if part == nil {
+			return nil, fmt.Errorf("%w: %s for sheet %q", MissingPartError, partName, sheet.Name)
+		}
+		rows, err := countRows(part)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", partName, err)
+		}
+		summaries = append(summaries, SheetSummary{Name: sheet.Name, Rows: rows})
+	}
+	return summaries, nil
+}
+
+// ValidateFile runs Validate on the XLSX file at the path.
+func ValidateFile(path string) ([]SheetSummary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Validate(file, info.Size())
+}
+
+// Verify runs Validate on the finished XLSX file, and checks that every sheet has the number of rows that were
+// written to it.
+func (sf *StreamFile) Verify(r io.ReaderAt, size int64) error {
+	summaries, err := Validate(r, size)
+	if err != nil {
+		return err
+	}
+	expected := sf.SheetSummaries()
+	if len(summaries) != len(expected) {
+		return fmt.Errorf("%w: expected %d sheets, found %d", RowCountMismatchError, len(expected), len(summaries))
+	}
+	for i := range expected {
+		if summaries[i] != expected[i] {
+			return fmt.Errorf("%w: expected sheet %q to have %d rows, found sheet %q with %d rows",
+				RowCountMismatchError, expected[i].Name, expected[i].Rows, summaries[i].Name, summaries[i].Rows)
+		}
+	}
+	return nil
+}
+
+// SheetSummaries returns a summary of every sheet in the file, with the number of rows that have been written to each
+// one so far, including the header.
+func (sf *StreamFile) SheetSummaries() []SheetSummary {
+	summaries := make([]SheetSummary, len(sf.xlsxFile.Sheets))
+	for i, sheet := range sf.xlsxFile.Sheets {
+		summaries[i] = SheetSummary{Name: sheet.Name, Rows: sf.rowCounts[i]}
+	}
+	if sf.currentSheet != nil && !sf.currentSheet.finalized {
+		summaries[sf.currentSheet.index-1].Rows = sf.currentSheet.rowCount
+	}
+	return summaries
+}
+
+// validatePart reads the part to the end, checking that XML parts are well formed.
+func validatePart(file *zip.File) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if !isXMLPart(file.Name) {
+		_, err := io.Copy(ioutil.Discard, reader)
+		return err
+	}
+	decoder := xml.NewDecoder(reader)
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", MalformedPartError, err)
+		}
+	}
+}
+
+// decodePart unmarshals the XML part into v.
+func decodePart(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err := xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w: %v", file.Name, MalformedPartError, err)
+	}
+	return nil
+}
+
+// countRows returns the number of row elements in the sheet part.
+func countRows(file *zip.File) (int, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	decoder := xml.NewDecoder(reader)
+	rows := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", MalformedPartError, err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "row" {
+			rows++
+		}
+	}
+}
+
+// isXMLPart reports whether the part holds XML, based on its extension.
+func isXMLPart(name string) bool {
+	return strings.HasSuffix(name, ".xml") || strings.HasSuffix(name, ".rels") || strings.HasSuffix(name, ".vml")
+}
+
+// resolvePartName turns the target of a relationship into the name of the part in the zip file. Targets are relative to
+// the directory of the part that owns the relationship, unless they start with a slash.
+func resolvePartName(sourceDir, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(sourceDir, target)
+}
@@ -0,0 +1,139 @@
+package excel_stream
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	sheetNames := []string{"Sheet1", "Sheet2"}
+	workbookData := [][][]string{
+		{
+			{"Token", "Name", "Price", "SKU"},
+			{"123", "Taco", "300", "0000000123"},
+			{"456", "Salsa", "200", "0346"},
+		},
+		{
+			{"Token", "Name"},
+		},
+	}
+	if err := writeStreamFile("", buffer, sheetNames, workbookData, false); err != nil {
+		t.Fatal(err)
+	}
+	reader := bytes.NewReader(buffer.Bytes())
+	summaries, err := Validate(reader, reader.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SheetSummary{{Name: "Sheet1", Rows: 3}, {Name: "Sheet2", Rows: 1}}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Fatalf("Expected %v, got %v", expected, summaries)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	file := NewStreamFileBuilder(buffer)
+	if err := file.AddSheet("Sheet1", []string{"Token", "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.AddSheet("Sheet2", []string{"Token"}); err != nil {
+		t.Fatal(err)
+	}
+	excelStream, err := file.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.WriteRow([]string{"123", "Taco"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := excelStream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader := bytes.NewReader(buffer.Bytes())
+	if err := excelStream.Verify(reader, reader.Size()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend a row went missing on the way to the file.
+	excelStream.rowCounts[0]++
+	if err := excelStream.Verify(reader, reader.Size()); !errors.Is(err, RowCountMismatchError) {
+		t.Fatalf("Expected RowCountMismatchError, got %v", err)
+	}
+}
+
+func TestValidateBrokenFiles(t *testing.T) {
+	validParts := map[string]string{
+		"[Content_Types].xml":        `<Types></Types>`,
+		"_rels/.rels":                `<Relationships></Relationships>`,
+		"xl/workbook.xml":            `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"></sheet></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"></Relationship></Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="1"></row><row r="2"></row></sheetData></worksheet>`,
+	}
+	testCases := []struct {
+		testName      string
+		changes       map[string]string
+		expectedError error
+	}{
+		{
+			testName: "Valid",
+		},
+		{
+			testName:      "Missing Workbook",
+			changes:       map[string]string{"xl/workbook.xml": ""},
+			expectedError: MissingPartError,
+		},
+		{
+			testName:      "Missing Sheet",
+			changes:       map[string]string{"xl/worksheets/sheet1.xml": ""},
+			expectedError: MissingPartError,
+		},
+		{
+			testName:      "Malformed Sheet",
+			changes:       map[string]string{"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"></sheetData></worksheet>`},
+			expectedError: MalformedPartError,
+		},
+		{
+			testName:      "Malformed Content Types",
+			changes:       map[string]string{"[Content_Types].xml": `<Types>`},
+			expectedError: MalformedPartError,
+		},
+	}
+	for _, testCase := range testCases {
+		buffer := bytes.NewBuffer(nil)
+		zipWriter := zip.NewWriter(buffer)
+		for name, data := range validParts {
+			if change, ok := testCase.changes[name]; ok {
+				if change == "" {
+					continue
+				}
+				data = change
+			}
+			writer, err := zipWriter.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := writer.Write([]byte(data)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zipWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+		reader := bytes.NewReader(buffer.Bytes())
+		summaries, err := Validate(reader, reader.Size())
+		if !errors.Is(err, testCase.expectedError) {
+			t.Fatalf("%s: Expected error %v, got %v", testCase.testName, testCase.expectedError, err)
+		}
+		if testCase.expectedError == nil && !reflect.DeepEqual(summaries, []SheetSummary{{Name: "Sheet1", Rows: 2}}) {
+			t.Fatalf("%s: Unexpected summaries %v", testCase.testName, summaries)
+		}
+	}
+	if _, err := Validate(bytes.NewReader([]byte("not a zip file")), 14); err == nil {
+		t.Fatal("Expected an error for a file that is not a zip file")
+	}
+}
@@ -0,0 +1,149 @@
+package excel_stream
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	drawingsDirectory     = "xl/drawings/"
+	drawingsRelsDirectory = "xl/drawings/_rels/"
+	vmlContentType        = "application/vnd.openxmlformats-officedocument.vmlDrawing"
+	vmlRelationshipType   = relationshipsNamespace + "/vmlDrawing"
+	// vmlShapeIDBlockSize is the size of the blocks of shape IDs that VML drawings reserve in their idmap.
+	vmlShapeIDBlockSize = 1024
+)
+
+// sheetVML holds the shapes of a legacy VML drawing, which Excel still uses for form controls and for the images in
+// headers and footers. Each sheet has at most one VML drawing of each kind, which is written when the StreamFile is
+// closed.
+type sheetVML struct {
+	// name is the name of the drawing's part in the drawings directory, without the extension.
+	name string
+	// shapeTypes holds the XML of the shape types used by the shapes, by ID.
+	shapeTypes     map[string]string
+	shapeTypeOrder []string
+	// Shape IDs are only given out once all of the shapes in the file are known, when it is closed.
+	shapes []vmlShape
+	// relationships link the drawing to the parts holding its images.
+	relationships []relationship
+}
+
+// vmlShape is a shape in a VML drawing.
+type vmlShape struct {
+	// name is used as the shape's ID if it is set, in which case the shape's unique ID goes in the o:spid attribute.
+	name string
+	// xml is the XML of the shape after its ID attributes.
+	xml string
+}
+
+// vml returns the VML drawing for the sheet's cells, creating it and linking it to the sheet if it does not exist yet.
+func (sf *StreamFile) vml(sheetArrayIndex int) *sheetVML {
+	extras := &sf.sheetExtras[sheetArrayIndex]
+	if extras.vml == nil {
+		extras.vml = sf.newVML(sheetArrayIndex, "vmlDrawing"+strconv.Itoa(sheetArrayIndex+1), "legacyDrawing")
+	}
+	return extras.vml
+}
+
+// newVML creates a VML drawing with the name, and links it to the sheet with an element of the given kind.
+func (sf *StreamFile) newVML(sheetArrayIndex int, name, elementName string) *sheetVML {
+	id := sf.addSheetRelationship(sheetArrayIndex, vmlRelationshipType, "../drawings/"+name+".vml")
+	sf.addSheetElement(sheetArrayIndex, elementName,
+		`<`+elementName+` xmlns:r="`+relationshipsNamespace+`" r:id="`+id+`"></`+elementName+`>`)
+	sf.contentTypes.addDefault("vml", vmlContentType)
+	return &sheetVML{name: name, shapeTypes: make(map[string]string)}
+}
+
+// addShapeType adds the shape type to the drawing, unless it is already there.
+func (v *sheetVML) addShapeType(id, xml string) {
+	if _, ok := v.shapeTypes[id]; ok {
+		return
+	}
+	v.shapeTypes[id] = xml
+	v.shapeTypeOrder = append(v.shapeTypeOrder, id)
+}
+
+// addShape adds a shape to the drawing. The XML must start with the shape's attributes, without its ID. The name may
+// be empty.
+func (v *sheetVML) addShape(name, xml string) {
+	v.shapes = append(v.shapes, vmlShape{name: name, xml: xml})
+}
+
+// addRelationship adds a relationship from the drawing to the target, which is relative to the drawings directory, and
+// returns its ID.
+func (v *sheetVML) addRelationship(relType, target string) string {
+	id := "rId" + strconv.Itoa(len(v.relationships)+1)
+	v.relationships = append(v.relationships, relationship{id: id, relType: relType, target: target})
+	return id
+}
+
+// addVMLParts adds the parts for the VML drawings of every sheet. Shape IDs must be unique across the file, so each
+// drawing reserves as many blocks of IDs as it needs, after the blocks of the drawings before it.
+func (sf *StreamFile) addVMLParts() {
+	nextBlock := 1
+	for i := range sf.sheetExtras {
+		for _, vml := range sf.sheetExtras[i].vmlDrawings() {
+			sf.addVMLPart(vml, nextBlock)
+			nextBlock += vml.blocks()
+		}
+	}
+}
+
+// firstVMLShapeID returns the ID that addVMLParts gives the first shape of the VML drawing for the sheet's cells. It
+// only depends on the drawings of the sheets before it, so it is known once the sheets before it are finished.
+func (sf *StreamFile) firstVMLShapeID(sheetArrayIndex int) int {
+	firstBlock := 1
+	for i := 0; i < sheetArrayIndex; i++ {
+		for _, vml := range sf.sheetExtras[i].vmlDrawings() {
+			firstBlock += vml.blocks()
+		}
+	}
+	return firstBlock*vmlShapeIDBlockSize + 1
+}
+
+// vmlDrawings returns the sheet's VML drawings, in the order their shape IDs are given out.
+func (e *sheetExtras) vmlDrawings() []*sheetVML {
+	var drawings []*sheetVML
+	for _, vml := range []*sheetVML{e.vml, e.headerFooterVML} {
+		if vml != nil {
+			drawings = append(drawings, vml)
+		}
+	}
+	return drawings
+}
+
+// blocks returns the number of blocks of shape IDs the drawing reserves.
+func (v *sheetVML) blocks() int {
+	return len(v.shapes)/vmlShapeIDBlockSize + 1
+}
+
+// addVMLPart adds the parts for a VML drawing, with shape IDs from the blocks starting at firstBlock.
+func (sf *StreamFile) addVMLPart(vml *sheetVML, firstBlock int) {
+	blocks := make([]string, vml.blocks())
+	for i := range blocks {
+		blocks[i] = strconv.Itoa(firstBlock + i)
+	}
+	firstShapeID := firstBlock*vmlShapeIDBlockSize + 1
+
+	var builder strings.Builder
+	builder.WriteString(`<xml xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office"` +
+		` xmlns:x="urn:schemas-microsoft-com:office:excel"><o:shapelayout v:ext="edit"><o:idmap v:ext="edit" data="` +
+		strings.Join(blocks, ",") + `"/></o:shapelayout>`)
+	for _, id := range vml.shapeTypeOrder {
+		builder.WriteString(vml.shapeTypes[id])
+	}
+	for i, shape := range vml.shapes {
+		shapeID := "_x0000_s" + strconv.Itoa(firstShapeID+i)
+		if shape.name != "" {
+			builder.WriteString(`<v:shape id="` + shape.name + `" o:spid="` + shapeID + `"` + shape.xml)
+		} else {
+			builder.WriteString(`<v:shape id="` + shapeID + `"` + shape.xml)
+		}
+	}
+	builder.WriteString(`</xml>`)
+	sf.addPart(drawingsDirectory+vml.name+".vml", []byte(builder.String()))
+	if len(vml.relationships) > 0 {
+		sf.addPart(drawingsRelsDirectory+vml.name+".vml.rels", []byte(renderRelationships(vml.relationships)))
+	}
+}